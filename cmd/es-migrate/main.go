@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/es"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+// 按 conf/es 下的定义创建或迁移 Elasticsearch 索引
+//
+//	go run ./cmd/es-migrate -dry-run
+//	go run ./cmd/es-migrate -sync-articles
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only print the actions to be performed")
+	syncArticles := flag.Bool("sync-articles", false, "index all articles from the database after migrating")
+	flag.Parse()
+
+	setting.Setup()
+	if err := es.Setup(); err != nil {
+		log.Fatalf("es.Setup err: %v", err)
+	}
+
+	results, err := es.Migrate(*dryRun)
+	for _, r := range results {
+		fmt.Printf("%-20s %-20s %-16s %v\n", r.Name, r.Index, r.Action, r.Detail)
+	}
+	if err != nil {
+		log.Fatalf("es.Migrate err: %v", err)
+	}
+
+	if *syncArticles && !*dryRun {
+		models.Setup()
+		result, err := article_service.SyncAllToIndex()
+		if err != nil {
+			log.Fatalf("article_service.SyncAllToIndex err: %v", err)
+		}
+		fmt.Printf("articles indexed: %d, failed: %d\n", result.Indexed, result.Failed)
+	}
+}
@@ -0,0 +1,18 @@
+-- 订单号唯一，重复提交的订单由唯一索引拒绝，Redis 锁只让并发提交尽早返回
+ALTER TABLE `blog_order`
+  ADD UNIQUE KEY `uk_order_sn` (`order_sn`);
+
+-- 嵌套事务创建的订单号，与扣减库存在同一个事务中写入，重试的订单不会重复扣减库存
+CREATE TABLE `blog_stock_order` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `order_sn` varchar(64) NOT NULL DEFAULT '' COMMENT '订单号',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_order_sn` (`order_sn`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='已扣减库存的订单';
+
+-- 库存转移写入明细时记录锁的栅栏值，拒绝锁过期后仍在执行的旧请求
+ALTER TABLE `blog_stock_product_details`
+  ADD COLUMN `lock_fence` bigint(20) NOT NULL DEFAULT '0' COMMENT '最后一次加锁写入的栅栏值';
@@ -0,0 +1,14 @@
+-- 文章浏览量，由 Redis 中缓冲的计数定期累加
+ALTER TABLE `blog_article`
+  ADD COLUMN `views` bigint unsigned NOT NULL DEFAULT '0' COMMENT '浏览量' AFTER `state`,
+  ADD KEY `idx_views` (`views`);
+
+-- 已写入的浏览量批次，与浏览量在同一个事务中写入，同一批次不会重复累加；保留一天
+CREATE TABLE `blog_article_views_batch` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `batch` varchar(32) NOT NULL DEFAULT '' COMMENT '批次号',
+  `created_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_batch` (`batch`),
+  KEY `idx_created_on` (`created_on`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='浏览量批次';
@@ -0,0 +1,31 @@
+-- 文章发布流程：draft → in_review → published → archived
+-- state 仍表示是否对外可见，只有 published 且 publish_at 已到的文章 state 为 1
+ALTER TABLE `blog_article`
+  ADD COLUMN `status` varchar(20) NOT NULL DEFAULT 'draft' COMMENT '发布流程状态' AFTER `state`,
+  ADD COLUMN `publish_at` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '定时发布时间' AFTER `status`,
+  ADD KEY `idx_status_publish_at` (`status`, `publish_at`);
+
+UPDATE `blog_article` SET `status` = 'published', `publish_at` = `created_on` WHERE `state` = 1;
+
+-- 文章修订记录，每次新建、编辑、恢复都保存一份完整内容
+CREATE TABLE `blog_article_revision` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `article_id` int(10) unsigned NOT NULL COMMENT '文章ID',
+  `revision` int(10) unsigned NOT NULL COMMENT '版本号，从 1 开始',
+  `tag_id` int(10) unsigned DEFAULT '0' COMMENT '标签ID',
+  `title` varchar(100) DEFAULT '' COMMENT '文章标题',
+  `desc` varchar(255) DEFAULT '' COMMENT '简述',
+  `content` text COMMENT '内容',
+  `cover_image_url` varchar(255) DEFAULT '' COMMENT '封面图片地址',
+  `created_by` varchar(100) DEFAULT '' COMMENT '修改人',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_article_revision` (`article_id`, `revision`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='文章修订记录';
+
+-- 存量文章的第一个版本
+INSERT INTO `blog_article_revision` (`article_id`, `revision`, `tag_id`, `title`, `desc`, `content`, `cover_image_url`, `created_by`, `created_on`)
+SELECT `id`, 1, `tag_id`, `title`, `desc`, `content`, `cover_image_url`, IF(`modified_by` = '', `created_by`, `modified_by`), UNIX_TIMESTAMP()
+FROM `blog_article` WHERE `deleted_on` = 0;
@@ -0,0 +1,19 @@
+-- 文章评论，回复通过 parent_id 指向被回复的评论，root_id 为所在楼层的顶层评论（顶层评论为 0）
+-- status：pending 待审核，approved 已通过（读者可见），spam 垃圾评论
+CREATE TABLE `blog_comment` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `article_id` int(10) unsigned NOT NULL COMMENT '文章ID',
+  `parent_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '回复的评论ID',
+  `root_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '顶层评论ID',
+  `content` text COMMENT '内容',
+  `created_by` varchar(100) DEFAULT '' COMMENT '评论人',
+  `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+  `moderated_by` varchar(100) DEFAULT '' COMMENT '审核人',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_article_status` (`article_id`, `status`, `root_id`),
+  KEY `idx_root_id` (`root_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章评论';
@@ -0,0 +1,37 @@
+-- 文章与标签多对多，blog_article.tag_id 保留为主标签（第一个标签），兼容只支持单个标签的调用方
+CREATE TABLE `blog_article_tag` (
+  `article_id` int(10) unsigned NOT NULL COMMENT '文章ID',
+  `tag_id` int(10) unsigned NOT NULL COMMENT '标签ID',
+  PRIMARY KEY (`article_id`, `tag_id`),
+  KEY `idx_tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='文章标签关联';
+
+-- 迁移已有的 tag_id
+INSERT IGNORE INTO `blog_article_tag` (`article_id`, `tag_id`)
+SELECT `id`, `tag_id` FROM `blog_article` WHERE `tag_id` > 0;
+
+-- 分类树，parent_id 为 0 的是顶层分类
+CREATE TABLE `blog_category` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `parent_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '上级分类ID',
+  `name` varchar(100) DEFAULT '' COMMENT '分类名称',
+  `sort` int(10) NOT NULL DEFAULT '0' COMMENT '同级排序，从小到大',
+  `created_by` varchar(100) DEFAULT '' COMMENT '创建人',
+  `modified_by` varchar(100) DEFAULT '' COMMENT '修改人',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='文章分类';
+
+ALTER TABLE `blog_article`
+  ADD COLUMN `category_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '分类ID' AFTER `tag_id`,
+  ADD KEY `idx_category_id` (`category_id`);
+
+-- 修订记录同时保存标签和分类，blog_article_revision 由 003_article_workflow.sql 创建
+ALTER TABLE `blog_article_revision`
+  ADD COLUMN `tag_ids` varchar(255) NOT NULL DEFAULT '' COMMENT '标签ID，逗号分隔' AFTER `tag_id`,
+  ADD COLUMN `category_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '分类ID' AFTER `tag_ids`;
+
+UPDATE `blog_article_revision` SET `tag_ids` = `tag_id` WHERE `tag_id` > 0;
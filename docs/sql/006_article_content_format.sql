@@ -0,0 +1,7 @@
+-- 正文格式：markdown 或 html，已有文章按 markdown 渲染
+ALTER TABLE `blog_article`
+  ADD COLUMN `content_format` varchar(10) NOT NULL DEFAULT 'markdown' COMMENT '正文格式 markdown / html' AFTER `content`;
+
+-- blog_article_revision 由 003_article_workflow.sql 创建
+ALTER TABLE `blog_article_revision`
+  ADD COLUMN `content_format` varchar(10) NOT NULL DEFAULT 'markdown' COMMENT '正文格式 markdown / html' AFTER `content`;
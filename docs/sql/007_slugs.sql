@@ -0,0 +1,20 @@
+-- 文章和标签的 slug，已有数据的 slug 在服务启动时由标题/名称补全
+-- 删除的文章不占用 slug，唯一性由服务检查，这里只建普通索引
+ALTER TABLE `blog_article`
+  ADD COLUMN `slug` varchar(120) NOT NULL DEFAULT '' COMMENT 'slug' AFTER `title`,
+  ADD KEY `idx_slug` (`slug`);
+
+ALTER TABLE `blog_tag`
+  ADD COLUMN `slug` varchar(120) NOT NULL DEFAULT '' COMMENT 'slug' AFTER `name`,
+  ADD KEY `idx_slug` (`slug`);
+
+-- 文章改名前使用过的 slug，访问旧 slug 时 301 重定向到当前 slug
+CREATE TABLE `blog_article_slug` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `article_id` int(10) unsigned NOT NULL COMMENT '文章ID',
+  `slug` varchar(120) NOT NULL COMMENT '旧 slug',
+  `created_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_slug` (`slug`),
+  KEY `idx_article_id` (`article_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='文章历史 slug';
@@ -0,0 +1,52 @@
+-- 分片上传，全部分片上传并校验 SHA-256 后合并为附件
+-- status：uploading 上传中，completing 合并中，completed 已完成，aborted 已取消或过期
+CREATE TABLE `blog_upload` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `upload_id` varchar(32) NOT NULL COMMENT '上传ID',
+  `file_name` varchar(255) NOT NULL DEFAULT '' COMMENT '文件名',
+  `size` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '文件大小',
+  `chunk_size` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '分片大小',
+  `sha256` char(64) NOT NULL DEFAULT '' COMMENT '文件 SHA-256',
+  `owner_type` varchar(20) NOT NULL DEFAULT '' COMMENT '所属对象类型',
+  `owner_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '所属对象ID',
+  `status` varchar(20) NOT NULL DEFAULT 'uploading' COMMENT '状态',
+  `created_by` varchar(100) DEFAULT '' COMMENT '创建人',
+  `attachment_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '完成后的附件ID',
+  `expires_on` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '过期时间',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_upload_id` (`upload_id`),
+  KEY `idx_status_expires` (`status`, `expires_on`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='分片上传';
+
+-- 已收到的分片，分片内容保存在存储中，上传完成或取消后删除
+CREATE TABLE `blog_upload_chunk` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `upload_id` int(10) unsigned NOT NULL COMMENT '上传',
+  `chunk_index` int(10) unsigned NOT NULL COMMENT '分片序号，从 0 开始',
+  `size` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '分片大小',
+  `sha256` char(64) NOT NULL DEFAULT '' COMMENT '分片 SHA-256',
+  `created_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_upload_chunk` (`upload_id`, `chunk_index`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='上传分片';
+
+-- 文章、库存产品、订单的附件，内容相同的附件共用一个文件
+CREATE TABLE `blog_attachment` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `owner_type` varchar(20) NOT NULL DEFAULT '' COMMENT '所属对象类型：article、stock_product、order',
+  `owner_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '所属对象ID',
+  `file_name` varchar(255) NOT NULL DEFAULT '' COMMENT '文件名',
+  `storage_key` varchar(255) NOT NULL DEFAULT '' COMMENT '存储路径',
+  `size` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '文件大小',
+  `content_type` varchar(100) NOT NULL DEFAULT '' COMMENT 'MIME 类型',
+  `sha256` char(64) NOT NULL DEFAULT '' COMMENT '文件 SHA-256',
+  `created_by` varchar(100) DEFAULT '' COMMENT '上传人',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_owner` (`owner_type`, `owner_id`),
+  KEY `idx_storage_key` (`storage_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='附件';
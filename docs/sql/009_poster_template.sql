@@ -0,0 +1,14 @@
+-- 海报模板，definition 为模板定义的 JSON：尺寸、背景图、输出格式和图层
+-- 没有名为 default 的模板时使用内置的默认模板
+CREATE TABLE `blog_poster_template` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL DEFAULT '' COMMENT '模板名称',
+  `definition` text COMMENT '模板定义',
+  `created_by` varchar(100) DEFAULT '' COMMENT '创建人',
+  `modified_by` varchar(255) DEFAULT '' COMMENT '修改人',
+  `created_on` int(10) unsigned DEFAULT '0',
+  `modified_on` int(10) unsigned DEFAULT '0',
+  `deleted_on` int(10) unsigned DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `idx_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='海报模板';
@@ -6,6 +6,7 @@ toolchain go1.24.5
 
 require (
 	github.com/360EntSecGroup-Skylar/excelize v1.3.1-0.20180527032555-9e463b461434
+	github.com/alicebob/miniredis/v2 v2.37.0
 	github.com/astaxie/beego v1.9.3-0.20171218111859-f16688817aa4
 	github.com/boombuler/barcode v1.0.1-0.20180315051053-3c06908149f7
 	github.com/casbin/casbin/v2 v2.135.0
@@ -21,6 +22,7 @@ require (
 	github.com/swaggo/swag v1.16.4
 	github.com/tealeg/xlsx v1.0.4-0.20180419195153-f36fa3be8893
 	github.com/unknwon/com v1.0.1
+	golang.org/x/image v0.0.0-20180628062038-cc896f830ced
 	golang.org/x/time v0.11.0
 )
 
@@ -58,10 +60,10 @@ require (
 	github.com/smartystreets/goconvey v0.0.0-20190731233626-505e41936337 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/ugorji/go/codec v1.1.5-pre // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	go.opentelemetry.io/otel v1.28.0 // indirect
 	go.opentelemetry.io/otel/metric v1.28.0 // indirect
 	go.opentelemetry.io/otel/trace v1.28.0 // indirect
-	golang.org/x/image v0.0.0-20180628062038-cc896f830ced // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/tools v0.40.0 // indirect
@@ -8,24 +8,55 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/cache"
 	casbinPkg "github.com/EDDYCJY/go-gin-example/pkg/casbin"
 	"github.com/EDDYCJY/go-gin-example/pkg/es"
 	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/rabbitmq"
+	"github.com/EDDYCJY/go-gin-example/pkg/ratelimit"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 	"github.com/EDDYCJY/go-gin-example/routers"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+	"github.com/EDDYCJY/go-gin-example/service/comment_service"
+	"github.com/EDDYCJY/go-gin-example/service/image_service"
+	"github.com/EDDYCJY/go-gin-example/service/label_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
 )
 
 func init() {
 	setting.Setup()
 	models.Setup()
 	logging.Setup()
-	gredis.Setup()
+	if err := gredis.Setup(); err != nil {
+		log.Fatalf("gredis.Setup err: %v", err)
+	}
+	cache.Setup()
+	ratelimit.Setup()
+	article_service.StartViewFlusher()
+	article_service.StartPublisher()
+	article_service.StartSitemapGenerator()
+	article_service.StartSlugBackfill()
+	tag_service.StartSlugBackfill()
+	comment_service.Setup()
+	image_service.Setup()
+	label_service.Setup()
 	rabbitmq.Setup()
-	es.Setup()
+	if err := es.Setup(); err != nil {
+		log.Printf("[warn] es.Setup err: %v", err)
+	} else if setting.ElasticSearchSetting.AutoMigrate {
+		if _, err := es.Migrate(false); err != nil {
+			log.Printf("[warn] es.Migrate err: %v", err)
+		}
+	}
 	util.Setup()
+	if err := storage.Setup(); err != nil {
+		log.Fatalf("storage.Setup err: %v", err)
+	}
+	attachment_service.StartUploadCleaner()
 
 	// 初始化 Casbin
 	if err := casbinPkg.Setup(); err != nil {
@@ -3,7 +3,6 @@ package casbin
 import (
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -95,31 +94,23 @@ func getUserIDFromToken(c *gin.Context) (int, error) {
 		}
 	}
 
-	// 从 claims 中获取用户ID
-	// 注意：这里假设你的 JWT claims 中有 user_id 字段
-	// 你可能需要根据实际情况调整
-	if userID, ok := claims["user_id"].(float64); ok {
-		return int(userID), nil
-	}
-
-	// 如果没有 user_id，尝试使用 name 作为用户标识
-	if name, ok := claims["name"].(string); ok {
-		// 这里简单处理，实际应该从数据库查询用户ID
-		// 或者你的 JWT token 中应该包含用户ID
-		return parseUserIDFromName(name), nil
+	// 用户ID 在登录时写入 token，角色由 Casbin 中 user:<ID> 的角色分配决定；
+	// 旧版本签发的 token 没有用户ID，需要重新登录
+	if claims.UserID > 0 {
+		return claims.UserID, nil
 	}
 
 	return 0, fmt.Errorf("无法从 token 中获取用户标识")
 }
 
-// parseUserIDFromName 从用户名解析用户ID（示例实现）
-func parseUserIDFromName(name string) int {
-	// 这只是一个示例，实际应该从数据库查询
-	// 或者在生成 JWT 时就包含用户ID
-	if strings.Contains(name, "admin") {
-		return 1
+// Subject 返回当前请求用户的 Casbin 主体，例如 user:1，供业务中按操作检查权限
+func Subject(c *gin.Context) (string, error) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		return "", err
 	}
-	return 2
+
+	return fmt.Sprintf("user:%d", userID), nil
 }
 
 // CasbinWithRoles 带角色检查的中间件（可选）
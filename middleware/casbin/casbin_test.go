@@ -0,0 +1,51 @@
+package casbin
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+)
+
+func TestSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	util.Setup()
+
+	tests := []struct {
+		name     string
+		userID   int
+		username string
+		want     string
+		wantErr  bool
+	}{
+		{"user id from token", 3, "viewer", "user:3", false},
+		{"name does not grant admin", 7, "notadmin", "user:7", false},
+		{"token without user id", 0, "admin", "", true},
+	}
+	for _, tt := range tests {
+		token, err := util.GenerateToken(tt.userID, tt.username, "password")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/v1/articles", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+
+		got, err := Subject(c)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: Subject() = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSubjectWithoutToken(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/articles", nil)
+
+	if _, err := Subject(c); err == nil {
+		t.Error("Subject() without token should fail")
+	}
+}
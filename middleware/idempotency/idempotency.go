@@ -0,0 +1,214 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+const (
+	// HeaderKey 客户端为每次业务操作生成的唯一标识，重试时保持不变
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed 响应来自保存的结果时设置
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// record 保存的首次响应
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// bodyWriter 在写给客户端的同时保留一份响应内容
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 对配置中的 POST / PUT 接口，相同 Idempotency-Key 的重复请求直接返回首次的响应
+//
+// 首次请求处理期间的重复请求返回 409；同一个 key 用于不同的请求体时返回 422。
+// 5xx 响应不保存，客户端可以用原来的 key 重试
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" || !enabled(c.Request) {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			abort(c, http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			abort(c, http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := scopeOf(c, key)
+		fingerprint := fingerprintOf(c.Request, body)
+
+		if replayed(c, scope, fingerprint) {
+			return
+		}
+
+		lock, err := gredis.Obtain(ctx, scope, setting.IdempotencySetting.LockTTL)
+		if err == gredis.ErrLockNotObtained {
+			abort(c, http.StatusConflict, e.ERROR_IDEMPOTENCY_IN_PROGRESS)
+			return
+		}
+		if err != nil {
+			// Redis 不可用时不阻塞业务，按普通请求处理
+			logging.Warn("idempotency obtain lock err:", err)
+			c.Next()
+			return
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				logging.Warn("idempotency release lock err:", err)
+			}
+		}()
+
+		// 拿到锁之前首次请求可能刚好完成
+		if replayed(c, scope, fingerprint) {
+			return
+		}
+
+		w := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		save(scope, &record{
+			Fingerprint: fingerprint,
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		})
+	}
+}
+
+// enabled 判断请求是否在配置的接口列表中
+func enabled(r *http.Request) bool {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return false
+	}
+
+	for _, route := range setting.IdempotencySetting.Routes {
+		fields := strings.Fields(route)
+		if len(fields) != 2 || fields[0] != r.Method {
+			continue
+		}
+		path := fields[1]
+		if strings.HasSuffix(path, "*") {
+			if strings.HasPrefix(r.URL.Path, strings.TrimSuffix(path, "*")) {
+				return true
+			}
+		} else if r.URL.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scopeOf 按用户和接口隔离 key，避免不同用户的 key 冲突
+func scopeOf(c *gin.Context, key string) string {
+	username := ""
+	if claims := jwt.GetClaims(c); claims != nil {
+		username = claims.Username
+	}
+
+	return "IDEMPOTENCY_" + username + "_" + c.Request.Method + "_" + c.Request.URL.Path + "_" + key
+}
+
+// fingerprintOf 请求内容的摘要，用于识别 key 被用于不同的请求
+func fingerprintOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayed 存在保存的响应时直接返回它
+func replayed(c *gin.Context, scope, fingerprint string) bool {
+	data, err := gredis.GetBytes(c.Request.Context(), scope)
+	if err != nil {
+		if err != gredis.ErrNil {
+			logging.Warn("idempotency get record err:", err)
+		}
+		return false
+	}
+
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		logging.Warn("idempotency unmarshal record err:", err)
+		return false
+	}
+
+	if r.Fingerprint != fingerprint {
+		abort(c, http.StatusUnprocessableEntity, e.ERROR_IDEMPOTENCY_KEY_REUSED)
+		return true
+	}
+
+	c.Header(HeaderReplayed, "true")
+	c.Data(r.Status, r.ContentType, r.Body)
+	c.Abort()
+
+	return true
+}
+
+func save(scope string, r *record) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		logging.Warn("idempotency marshal record err:", err)
+		return
+	}
+
+	if err := gredis.SetEX(context.Background(), scope, data, setting.IdempotencySetting.TTL); err != nil {
+		logging.Warn("idempotency save record err:", err)
+	}
+}
+
+func abort(c *gin.Context, httpCode, code int) {
+	c.AbortWithStatusJSON(httpCode, gin.H{
+		"code": code,
+		"msg":  e.GetMsg(code),
+		"data": nil,
+	})
+}
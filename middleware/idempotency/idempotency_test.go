@@ -0,0 +1,201 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+)
+
+// setup 连接内存 Redis 并启用 /orders 接口，测试结束后恢复配置
+func setup(t *testing.T) *miniredis.Miniredis {
+	gin.SetMode(gin.TestMode)
+	s := miniredis.RunT(t)
+
+	savedRedis, saved := *setting.RedisSetting, *setting.IdempotencySetting
+	t.Cleanup(func() { *setting.RedisSetting, *setting.IdempotencySetting = savedRedis, saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = s.Addr()
+	if err := gredis.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	*setting.IdempotencySetting = setting.Idempotency{
+		TTL:     time.Hour,
+		LockTTL: time.Minute,
+		Routes:  []string{"POST /orders", "PUT /orders/*"},
+	}
+
+	return s
+}
+
+// newRouter handler 以 status 返回调用序号，用户名取自 X-User
+func newRouter(calls *int, status int) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(jwt.ClaimsKey, &util.Claims{Username: c.GetHeader("X-User")})
+	})
+	r.Use(Idempotency())
+	r.Any("/*path", func(c *gin.Context) {
+		*calls++
+		c.String(status, "call %d", *calls)
+	})
+	return r
+}
+
+func do(r http.Handler, method, path, key, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	req.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestEnabled(t *testing.T) {
+	saved := *setting.IdempotencySetting
+	t.Cleanup(func() { *setting.IdempotencySetting = saved })
+	setting.IdempotencySetting.Routes = []string{"POST /orders", "PUT /orders/*", "bad"}
+
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodPost, "/orders", true},
+		{http.MethodPost, "/orders/1", false},
+		{http.MethodPut, "/orders/1", true},
+		{http.MethodPut, "/orders", false},
+		{http.MethodGet, "/orders", false},
+		{http.MethodDelete, "/orders/1", false},
+		{http.MethodPost, "/tags", false},
+	}
+	for _, tt := range tests {
+		if got := enabled(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("enabled(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprintOf(t *testing.T) {
+	fp := func(target, body string) string {
+		return fingerprintOf(httptest.NewRequest(http.MethodPost, target, nil), []byte(body))
+	}
+
+	if fp("/orders?a=1", "x") != fp("/orders?a=1", "x") {
+		t.Error("fingerprintOf is not stable")
+	}
+	// 查询参数和请求体之间有分隔，不能互相拼接出相同的摘要
+	for _, other := range []string{fp("/orders?a=2", "x"), fp("/orders?a=1", "y"), fp("/orders?a=1x", "")} {
+		if other == fp("/orders?a=1", "x") {
+			t.Errorf("fingerprintOf collision: %s", other)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	s := setup(t)
+	calls := 0
+	r := newRouter(&calls, http.StatusCreated)
+
+	first := do(r, http.MethodPost, "/orders", "k1", "alice", `{"n":1}`)
+	if first.Code != http.StatusCreated || first.Body.String() != "call 1" || first.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("first = %d %q %v", first.Code, first.Body, first.Header())
+	}
+
+	again := do(r, http.MethodPost, "/orders", "k1", "alice", `{"n":1}`)
+	if again.Code != http.StatusCreated || again.Body.String() != "call 1" || again.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("replay = %d %q %v", again.Code, again.Body, again.Header())
+	}
+	if ct := again.Header().Get("Content-Type"); ct != first.Header().Get("Content-Type") {
+		t.Errorf("replay Content-Type = %q, want %q", ct, first.Header().Get("Content-Type"))
+	}
+
+	// 同一个 key 用于不同的请求体
+	if w := do(r, http.MethodPost, "/orders", "k1", "alice", `{"n":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key = %d, want 422", w.Code)
+	}
+	// key 按用户隔离
+	if w := do(r, http.MethodPost, "/orders", "k1", "bob", `{"n":1}`); w.Body.String() != "call 2" {
+		t.Errorf("other user = %q, want call 2", w.Body)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+
+	// 请求结束后锁已释放，保存的记录带过期时间
+	if s.Exists("LOCK_{IDEMPOTENCY_alice_POST_/orders_k1}") {
+		t.Error("lock should be released")
+	}
+	if ttl := s.TTL("IDEMPOTENCY_alice_POST_/orders_k1"); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("record TTL = %v", ttl)
+	}
+}
+
+func TestInProgress(t *testing.T) {
+	s := setup(t)
+	calls := 0
+	r := newRouter(&calls, http.StatusOK)
+
+	// 首次请求仍持有锁
+	s.Set("LOCK_{IDEMPOTENCY_alice_POST_/orders_k1}", "other")
+	if w := do(r, http.MethodPost, "/orders", "k1", "alice", ""); w.Code != http.StatusConflict {
+		t.Errorf("locked = %d, want 409", w.Code)
+	}
+	if calls != 0 {
+		t.Errorf("handler calls = %d, want 0", calls)
+	}
+}
+
+func TestServerErrorNotSaved(t *testing.T) {
+	setup(t)
+	calls := 0
+	r := newRouter(&calls, http.StatusInternalServerError)
+
+	for i := 1; i <= 2; i++ {
+		if w := do(r, http.MethodPost, "/orders", "k1", "alice", ""); w.Header().Get(HeaderReplayed) != "" {
+			t.Errorf("request %d replayed a 5xx response", i)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestPassThrough(t *testing.T) {
+	setup(t)
+	calls := 0
+	r := newRouter(&calls, http.StatusOK)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		code   int
+	}{
+		{"no key", http.MethodPost, "/orders", "", http.StatusOK},
+		{"route not enabled", http.MethodPost, "/tags", "k1", http.StatusOK},
+		{"key too long", http.MethodPost, "/orders", strings.Repeat("k", maxKeyLength+1), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ {
+			if w := do(r, tt.method, tt.path, tt.key, "alice", ""); w.Code != tt.code || w.Header().Get(HeaderReplayed) != "" {
+				t.Errorf("%s: request %d = %d %v, want %d", tt.name, i, w.Code, w.Header(), tt.code)
+			}
+		}
+	}
+	if calls != 4 {
+		t.Errorf("handler calls = %d, want 4", calls)
+	}
+}
@@ -10,6 +10,19 @@ import (
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 )
 
+// ClaimsKey 校验通过后 token 中的 Claims 保存在 gin.Context 中的 key
+const ClaimsKey = "jwt_claims"
+
+// GetClaims 返回 JWT 中间件解析出的 Claims，未经过中间件时返回 nil
+func GetClaims(c *gin.Context) *util.Claims {
+	if v, ok := c.Get(ClaimsKey); ok {
+		claims, _ := v.(*util.Claims)
+		return claims
+	}
+
+	return nil
+}
+
 // JWT is jwt middleware
 func JWT() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -33,7 +46,7 @@ func JWT() gin.HandlerFunc {
 		if token == "" {
 			code = e.INVALID_PARAMS
 		} else {
-			_, err := util.ParseToken(token)
+			claims, err := util.ParseToken(token)
 			if err != nil {
 				switch err.(*jwt.ValidationError).Errors {
 				case jwt.ValidationErrorExpired:
@@ -41,6 +54,8 @@ func JWT() gin.HandlerFunc {
 				default:
 					code = e.ERROR_AUTH_CHECK_TOKEN_FAIL
 				}
+			} else {
+				c.Set(ClaimsKey, claims)
 			}
 		}
 
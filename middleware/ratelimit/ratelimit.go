@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	pkgratelimit "github.com/EDDYCJY/go-gin-example/pkg/ratelimit"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+
+	// remainingKey 同一请求经过多个限流器时，响应头只保留剩余次数最少的一个
+	remainingKey = "ratelimit_remaining"
+)
+
+// KeyFunc 返回调用方标识，返回空串时不限流
+type KeyFunc func(c *gin.Context) string
+
+// IPKey 按客户端 IP 计数
+func IPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// UserKey 按登录用户计数，需放在 JWT 中间件之后；未登录时退回按 IP 计数
+func UserKey(c *gin.Context) string {
+	if claims := jwt.GetClaims(c); claims != nil && claims.Username != "" {
+		return "user:" + claims.Username
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// New 创建限流中间件，name 用于区分不同规则的计数
+func New(name string, rate pkgratelimit.Rate, key KeyFunc, code int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allow(c, name, rate, key, code) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IP 按 [ratelimit] IP 配置限制每个 IP 的请求数
+func IP() gin.HandlerFunc {
+	return New("ip", mustParse(setting.RateLimitSetting.IP), IPKey, e.ERROR_TOO_MANY_REQUESTS)
+}
+
+// User 按 [ratelimit] User 配置限制每个用户的请求数
+func User() gin.HandlerFunc {
+	return New("user", mustParse(setting.RateLimitSetting.User), UserKey, e.ERROR_TOO_MANY_REQUESTS)
+}
+
+type groupRule struct {
+	prefix string
+	rate   pkgratelimit.Rate
+}
+
+// Groups 按 [ratelimit] Groups 配置对路由组单独限流，请求路径匹配多个前缀时取最长的一个
+func Groups() gin.HandlerFunc {
+	var rules []groupRule
+	for _, rule := range setting.RateLimitSetting.Groups {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("ratelimit: invalid group rule %q, want <path prefix>=<limit>/<period>", rule)
+		}
+		rules = append(rules, groupRule{prefix: strings.TrimSpace(parts[0]), rate: mustParse(parts[1])})
+	}
+
+	return func(c *gin.Context) {
+		var matched *groupRule
+		for i := range rules {
+			if strings.HasPrefix(c.Request.URL.Path, rules[i].prefix) &&
+				(matched == nil || len(rules[i].prefix) > len(matched.prefix)) {
+				matched = &rules[i]
+			}
+		}
+		if matched != nil && !allow(c, "group:"+matched.prefix, matched.rate, UserKey, e.ERROR_TOO_MANY_REQUESTS) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Login 登录限流，按 IP 和用户名分别计数，防止暴力破解
+func Login() gin.HandlerFunc {
+	ipRate := mustParse(setting.RateLimitSetting.LoginIP)
+	userRate := mustParse(setting.RateLimitSetting.LoginUser)
+	usernameKey := func(c *gin.Context) string {
+		if username := c.PostForm("username"); username != "" {
+			return "username:" + username
+		}
+		return ""
+	}
+
+	return func(c *gin.Context) {
+		if !allow(c, "login:ip", ipRate, IPKey, e.ERROR_TOO_MANY_LOGIN_ATTEMPTS) {
+			return
+		}
+		if !allow(c, "login:user", userRate, usernameKey, e.ERROR_TOO_MANY_LOGIN_ATTEMPTS) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Comment 按 [ratelimit] Comment 配置限制每个用户发表评论的频率
+func Comment() gin.HandlerFunc {
+	return New("comment", mustParse(setting.RateLimitSetting.Comment), UserKey, e.ERROR_TOO_MANY_COMMENTS)
+}
+
+// allow 判断是否放行并设置响应头，被拒绝时以 429 结束请求
+func allow(c *gin.Context, name string, rate pkgratelimit.Rate, key KeyFunc, code int) bool {
+	if !setting.RateLimitSetting.Enabled || rate.Zero() || pkgratelimit.Default == nil {
+		return true
+	}
+	id := key(c)
+	if id == "" {
+		return true
+	}
+
+	result, err := pkgratelimit.Default.Allow(c.Request.Context(), name+":"+id, rate)
+	if err != nil {
+		// 限流存储不可用时放行，不影响业务
+		logging.Warn("ratelimit allow err:", name, err)
+		return true
+	}
+
+	setHeaders(c, result)
+	if result.Allowed {
+		return true
+	}
+
+	c.Header(HeaderRetryAfter, strconv.Itoa(seconds(result.RetryAfter)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, app.Response{
+		Code: code,
+		Msg:  e.GetMsg(code),
+		Data: nil,
+	})
+
+	return false
+}
+
+func setHeaders(c *gin.Context, result *pkgratelimit.Result) {
+	if v, ok := c.Get(remainingKey); ok && v.(int) <= result.Remaining && result.Allowed {
+		return
+	}
+	c.Set(remainingKey, result.Remaining)
+
+	c.Header(HeaderLimit, strconv.Itoa(result.Limit))
+	c.Header(HeaderRemaining, strconv.Itoa(result.Remaining))
+	c.Header(HeaderReset, strconv.Itoa(seconds(result.ResetAfter)))
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(d.Seconds()))
+}
+
+// mustParse 解析配置中的限制，留空表示不限制
+func mustParse(s string) pkgratelimit.Rate {
+	if strings.TrimSpace(s) == "" {
+		return pkgratelimit.Rate{}
+	}
+
+	rate, err := pkgratelimit.ParseRate(s)
+	if err != nil {
+		log.Fatalf("ratelimit: %v", err)
+	}
+
+	return rate
+}
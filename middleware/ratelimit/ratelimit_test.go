@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	pkgratelimit "github.com/EDDYCJY/go-gin-example/pkg/ratelimit"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// setup 启用限流并使用进程内限流器，测试结束后恢复配置
+func setup(t *testing.T, s setting.RateLimit) {
+	gin.SetMode(gin.TestMode)
+	saved, savedDefault := *setting.RateLimitSetting, pkgratelimit.Default
+	t.Cleanup(func() { *setting.RateLimitSetting, pkgratelimit.Default = saved, savedDefault })
+
+	s.Enabled = true
+	*setting.RateLimitSetting = s
+	pkgratelimit.Default = pkgratelimit.NewMemoryLimiter()
+}
+
+func newRouter(handler gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(handler)
+	r.Any("/*path", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return r
+}
+
+func do(r http.Handler, method, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIP(t *testing.T) {
+	setup(t, setting.RateLimit{IP: "2/1m"})
+	r := newRouter(IP())
+
+	for i := 0; i < 2; i++ {
+		w := do(r, http.MethodGet, "/", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d", i, w.Code)
+		}
+		if w.Header().Get(HeaderLimit) != "2" || w.Header().Get(HeaderRemaining) != []string{"1", "0"}[i] {
+			t.Errorf("request %d headers = %v", i, w.Header())
+		}
+	}
+
+	w := do(r, http.MethodGet, "/", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("3rd request = %d, want 429", w.Code)
+	}
+	if w.Header().Get(HeaderRetryAfter) != "30" || w.Header().Get(HeaderRemaining) != "0" {
+		t.Errorf("429 headers = %v", w.Header())
+	}
+	if !strings.Contains(w.Body.String(), `"code":`) {
+		t.Errorf("429 body = %s", w.Body)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	setup(t, setting.RateLimit{IP: "1/1m"})
+	setting.RateLimitSetting.Enabled = false
+	r := newRouter(IP())
+
+	for i := 0; i < 3; i++ {
+		if w := do(r, http.MethodGet, "/", nil); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "" {
+			t.Fatalf("request %d = %d, %v", i, w.Code, w.Header())
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	setup(t, setting.RateLimit{LoginIP: "10/1m", LoginUser: "2/1m"})
+	r := newRouter(Login())
+
+	for i := 0; i < 2; i++ {
+		if w := do(r, http.MethodPost, "/auth", url.Values{"username": {"admin"}}); w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d", i, w.Code)
+		}
+	}
+	if w := do(r, http.MethodPost, "/auth", url.Values{"username": {"admin"}}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("3rd attempt for admin = %d, want 429", w.Code)
+	}
+
+	// 其他用户名单独计数，响应头取剩余次数最少的规则
+	w := do(r, http.MethodPost, "/auth", url.Values{"username": {"guest"}})
+	if w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "2" || w.Header().Get(HeaderRemaining) != "1" {
+		t.Errorf("guest = %d, %v", w.Code, w.Header())
+	}
+}
+
+func TestGroups(t *testing.T) {
+	setup(t, setting.RateLimit{Groups: []string{"/api=5/1m", "/api/v1/articles=1/1m"}})
+	r := newRouter(Groups())
+
+	if w := do(r, http.MethodGet, "/api/v1/articles", nil); w.Code != http.StatusOK {
+		t.Fatalf("first request = %d", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/api/v1/articles/1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("longest prefix was not applied: %d", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/api/v1/tags", nil); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "5" {
+		t.Errorf("shorter prefix = %d, %v", w.Code, w.Header())
+	}
+	if w := do(r, http.MethodGet, "/upload", nil); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "" {
+		t.Errorf("unmatched path = %d, %v", w.Code, w.Header())
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{-time.Second, 0},
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		if got := seconds(tt.d); got != tt.want {
+			t.Errorf("seconds(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
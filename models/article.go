@@ -1,22 +1,57 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/markdown"
+)
+
+// Article workflow statuses, State is 1 only when the article is published and publish_at has passed
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusInReview  = "in_review"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+// Article content formats
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
 )
 
 type Article struct {
 	Model
 
-	TagID int `json:"tag_id" gorm:"index"`
-	Tag   Tag `json:"tag"`
+	// TagID 主标签，即 Tags 中的第一个，保留用于兼容只支持单个标签的调用方
+	TagID      int   `json:"tag_id" gorm:"index"`
+	Tag        Tag   `json:"tag"`
+	Tags       []Tag `json:"tags" gorm:"-"`
+	CategoryID int   `json:"category_id" gorm:"index"`
 
 	Title         string `json:"title"`
+	Slug          string `json:"slug" gorm:"index"`
 	Desc          string `json:"desc"`
 	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
 	CoverImageUrl string `json:"cover_image_url"`
 	CreatedBy     string `json:"created_by"`
 	ModifiedBy    string `json:"modified_by"`
 	State         int    `json:"state"`
+	Status        string `json:"status"`
+	PublishAt     int    `json:"publish_at"`
+	Views         int64  `json:"views" gorm:"default:0"`
+	Visitors      int64  `json:"visitors,omitempty" gorm:"-"`
+	Comments      int    `json:"comments" gorm:"-"`
+
+	// 渲染结果，由 article_service 生成并随文章缓存
+	ContentHTML string              `json:"content_html" gorm:"-"`
+	Toc         []*markdown.Heading `json:"toc" gorm:"-"`
+	ReadingTime int                 `json:"reading_time" gorm:"-"`
 }
 
 // ExistArticleByID checks if an article exists based on ID
@@ -35,9 +70,9 @@ func ExistArticleByID(id int) (bool, error) {
 }
 
 // GetArticleTotal gets the total number of articles based on the constraints
-func GetArticleTotal(maps interface{}) (int, error) {
+func GetArticleTotal(maps interface{}, scopes ...func(*gorm.DB) *gorm.DB) (int, error) {
 	var count int
-	if err := db.Model(&Article{}).Where(maps).Count(&count).Error; err != nil {
+	if err := db.Model(&Article{}).Where(maps).Scopes(scopes...).Count(&count).Error; err != nil {
 		return 0, err
 	}
 
@@ -45,14 +80,14 @@ func GetArticleTotal(maps interface{}) (int, error) {
 }
 
 // GetArticles gets a list of articles based on paging constraints
-func GetArticles(pageNum int, pageSize int, maps interface{}) ([]*Article, error) {
+func GetArticles(pageNum int, pageSize int, maps interface{}, scopes ...func(*gorm.DB) *gorm.DB) ([]*Article, error) {
 	var articles []*Article
-	err := db.Preload("Tag").Where(maps).Offset(pageNum).Limit(pageSize).Find(&articles).Error
+	err := db.Preload("Tag").Where(maps).Scopes(scopes...).Offset(pageNum).Limit(pageSize).Find(&articles).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, err
 	}
 
-	return articles, nil
+	return articles, loadArticleTags(articles...)
 }
 
 // GetArticle Get a single article based on ID
@@ -67,10 +102,193 @@ func GetArticle(id int) (*Article, error) {
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, err
 	}
+	if article.ID > 0 {
+		if err := loadArticleTags(&article); err != nil {
+			return nil, err
+		}
+	}
 
 	return &article, nil
 }
 
+// GetArticlesByIDs gets the undeleted articles with the given IDs, keeping the order of ids
+func GetArticlesByIDs(ids []int, maps interface{}, scopes ...func(*gorm.DB) *gorm.DB) ([]*Article, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var articles []*Article
+	err := db.Preload("Tag").Where("id IN (?)", ids).Where(maps).Scopes(scopes...).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err := loadArticleTags(articles...); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*Article, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+	}
+	ordered := make([]*Article, 0, len(articles))
+	for _, id := range ids {
+		if article, ok := byID[id]; ok {
+			ordered = append(ordered, article)
+		}
+	}
+
+	return ordered, nil
+}
+
+// GetPopularArticles gets articles ordered by total views
+func GetPopularArticles(limit int, maps interface{}, scopes ...func(*gorm.DB) *gorm.DB) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Scopes(scopes...).Order("views DESC, id DESC").Limit(limit).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, loadArticleTags(articles...)
+}
+
+// GetLatestArticles gets the most recently published articles,
+// articles published before the workflow existed have no publish_at and fall back to created_on
+func GetLatestArticles(limit int, maps interface{}, scopes ...func(*gorm.DB) *gorm.DB) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Scopes(scopes...).
+		Order("IF(publish_at > 0, publish_at, created_on) DESC, id DESC").Limit(limit).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, loadArticleTags(articles...)
+}
+
+// GetArticleStamps gets the id and timestamps of up to limit articles with id greater than afterID, ordered by id
+func GetArticleStamps(afterID, limit int, maps interface{}) ([]*Article, error) {
+	var articles []*Article
+	err := db.Select("id, slug, created_on, modified_on, publish_at").Where(maps).Where("id > ?", afterID).
+		Order("id").Limit(limit).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// ArticleViewsBatch records a batch of buffered views that has been added to the articles
+type ArticleViewsBatch struct {
+	ID        int    `gorm:"primary_key" json:"id"`
+	Batch     string `json:"batch"`
+	CreatedOn int    `json:"created_on"`
+}
+
+// viewsBatchRetention how long applied batches are kept, a batch is retried within seconds
+const viewsBatchRetention = 24 * time.Hour
+
+// AddArticleViews adds buffered view counts (article id => views) in one transaction
+// without touching modified_on. The batch is recorded in the same transaction,
+// returns false without adding anything if the batch has been added before
+func AddArticleViews(batch string, views map[int]int64) (bool, error) {
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return false, err
+	}
+
+	var count int
+	if err := tx.Model(&ArticleViewsBatch{}).Where("batch = ?", batch).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if count > 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := tx.Create(&ArticleViewsBatch{Batch: batch}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	for id, n := range views {
+		err := tx.Model(&Article{}).Where("id = ?", id).UpdateColumn("views", gorm.Expr("views + ?", n)).Error
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	expired := time.Now().Add(-viewsBatchRetention).Unix()
+	if err := tx.Where("created_on < ?", expired).Delete(&ArticleViewsBatch{}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit().Error
+}
+
+// EditArticleWithRevision modifies a single article and its tags, and keeps the result as a new revision
+func EditArticleWithRevision(id int, data map[string]interface{}, tagIDs []int, author string) error {
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if len(tagIDs) > 0 {
+		data["tag_id"] = tagIDs[0]
+	}
+	if slug, ok := data["slug"].(string); ok {
+		if err := changeArticleSlug(tx, id, slug); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Model(&Article{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := setArticleTags(tx, id, tagIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := addArticleRevision(tx, id, author); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// TransitionArticle updates an article only if it is still in status from,
+// returns false when the status has been changed by someone else
+func TransitionArticle(id int, from string, data map[string]interface{}) (bool, error) {
+	result := db.Model(&Article{}).Where("id = ? AND status = ? AND deleted_on = ? ", id, from, 0).Updates(data)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// PublishDueArticles makes scheduled articles visible once publish_at has passed, returns their IDs
+func PublishDueArticles(now int) ([]int, error) {
+	var ids []int
+	err := db.Model(&Article{}).
+		Where("status = ? AND state = ? AND publish_at <= ? AND deleted_on = ? ", ArticleStatusPublished, 0, now, 0).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	err = db.Model(&Article{}).
+		Where("id IN (?) AND status = ? AND state = ? ", ids, ArticleStatusPublished, 0).
+		Update("state", 1).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 // EditArticle modify a single article
 func EditArticle(id int, data interface{}) error {
 	if err := db.Model(&Article{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error; err != nil {
@@ -80,22 +298,46 @@ func EditArticle(id int, data interface{}) error {
 	return nil
 }
 
-// AddArticle add a single article
-func AddArticle(data map[string]interface{}) error {
+// AddArticle add a single article as a draft together with its tags and first revision,
+// data["tag_ids"] must not be empty and its first tag becomes the primary tag
+func AddArticle(data map[string]interface{}) (int, error) {
+	tagIDs := data["tag_ids"].([]int)
 	article := Article{
-		TagID:         data["tag_id"].(int),
+		TagID:         tagIDs[0],
+		CategoryID:    data["category_id"].(int),
 		Title:         data["title"].(string),
+		Slug:          data["slug"].(string),
 		Desc:          data["desc"].(string),
 		Content:       data["content"].(string),
+		ContentFormat: data["content_format"].(string),
 		CreatedBy:     data["created_by"].(string),
-		State:         data["state"].(int),
+		State:         0,
+		Status:        ArticleStatusDraft,
 		CoverImageUrl: data["cover_image_url"].(string),
 	}
-	if err := db.Create(&article).Error; err != nil {
-		return err
+
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Create(&article).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := setArticleTags(tx, article.ID, tagIDs); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := addArticleRevision(tx, article.ID, article.CreatedBy); err != nil {
+		tx.Rollback()
+		return 0, err
 	}
 
-	return nil
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+
+	return article.ID, nil
 }
 
 // DeleteArticle delete a single article
@@ -115,3 +357,84 @@ func CleanAllArticle() error {
 
 	return nil
 }
+
+// ArticleFacet is the number of articles grouped by a column
+type ArticleFacet struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// likeEscaper escapes the wildcards of LIKE, used together with ESCAPE '\\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// containsPattern is a LIKE pattern matching s literally anywhere in a column
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// searchArticles builds the query of SearchArticles, matching the keyword against title, desc and content
+func searchArticles(keyword string, maps interface{}, startTime, endTime int, scopes []func(*gorm.DB) *gorm.DB) *gorm.DB {
+	query := db.Model(&Article{}).Where(maps).Scopes(scopes...)
+	if keyword != "" {
+		like := containsPattern(keyword)
+		query = query.Where("title LIKE ? ESCAPE '\\\\' OR `desc` LIKE ? ESCAPE '\\\\' OR content LIKE ? ESCAPE '\\\\'", like, like, like)
+	}
+	if startTime > 0 {
+		query = query.Where("created_on >= ?", startTime)
+	}
+	if endTime > 0 {
+		query = query.Where("created_on <= ?", endTime)
+	}
+
+	return query
+}
+
+// SearchArticles searches articles by keyword with LIKE, used when Elasticsearch is not available
+func SearchArticles(keyword string, maps interface{}, startTime, endTime, pageNum, pageSize int, scopes ...func(*gorm.DB) *gorm.DB) ([]*Article, error) {
+	var articles []*Article
+	err := searchArticles(keyword, maps, startTime, endTime, scopes).Preload("Tag").
+		Order("id desc").Offset(pageNum).Limit(pageSize).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, loadArticleTags(articles...)
+}
+
+// SearchArticleTotal counts the articles matched by SearchArticles
+func SearchArticleTotal(keyword string, maps interface{}, startTime, endTime int, scopes ...func(*gorm.DB) *gorm.DB) (int, error) {
+	var count int
+	if err := searchArticles(keyword, maps, startTime, endTime, scopes).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SearchArticleFacets groups the articles matched by SearchArticles by column
+func SearchArticleFacets(column, keyword string, maps interface{}, startTime, endTime, size int, scopes ...func(*gorm.DB) *gorm.DB) ([]*ArticleFacet, error) {
+	var facets []*ArticleFacet
+	err := searchArticles(keyword, maps, startTime, endTime, scopes).
+		Select(column + " AS `key`, COUNT(*) AS `count`").
+		Group(column).Order("`count` desc").Limit(size).Scan(&facets).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// SearchArticleTagFacets groups the articles matched by SearchArticles by each of their tags
+func SearchArticleTagFacets(keyword string, maps interface{}, startTime, endTime, size int, scopes ...func(*gorm.DB) *gorm.DB) ([]*ArticleFacet, error) {
+	var facets []*ArticleFacet
+	at, article := tableName(&ArticleTag{}), tableName(&Article{})
+	err := searchArticles(keyword, maps, startTime, endTime, scopes).
+		Joins(fmt.Sprintf("JOIN %s ON %s.article_id = %s.id", at, at, article)).
+		Select(fmt.Sprintf("%s.tag_id AS `key`, COUNT(*) AS `count`", at)).
+		Group(fmt.Sprintf("%s.tag_id", at)).Order("`count` desc").Limit(size).Scan(&facets).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ArticleRevision a full snapshot of an article after each edit
+type ArticleRevision struct {
+	Model
+
+	ArticleID int `json:"article_id" gorm:"index"`
+	Revision  int `json:"revision"`
+
+	TagID         int    `json:"tag_id"`
+	TagIDs        string `json:"tag_ids"`
+	CategoryID    int    `json:"category_id"`
+	Title         string `json:"title"`
+	Desc          string `json:"desc"`
+	Content       string `json:"content,omitempty"`
+	ContentFormat string `json:"content_format"`
+	CoverImageUrl string `json:"cover_image_url"`
+	CreatedBy     string `json:"created_by"`
+}
+
+// addArticleRevision snapshots the current content of an article as its next revision
+func addArticleRevision(tx *gorm.DB, articleID int, author string) error {
+	var article Article
+	if err := tx.Where("id = ?", articleID).First(&article).Error; err != nil {
+		return err
+	}
+
+	tagIDs, err := getArticleTagIDs(tx, articleID)
+	if err != nil {
+		return err
+	}
+	// 主标签放在第一个，恢复时保持不变
+	for i, id := range tagIDs {
+		if id == article.TagID {
+			copy(tagIDs[1:i+1], tagIDs[:i])
+			tagIDs[0] = id
+			break
+		}
+	}
+
+	// 在事务中锁住已有的修订记录，避免并发编辑得到相同的版本号
+	var last ArticleRevision
+	err = tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("article_id = ?", articleID).Order("revision DESC").First(&last).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	return tx.Create(&ArticleRevision{
+		ArticleID:     articleID,
+		Revision:      last.Revision + 1,
+		TagID:         article.TagID,
+		TagIDs:        joinIDs(tagIDs),
+		CategoryID:    article.CategoryID,
+		Title:         article.Title,
+		Desc:          article.Desc,
+		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
+		CoverImageUrl: article.CoverImageUrl,
+		CreatedBy:     author,
+	}).Error
+}
+
+// GetArticleRevisions gets the revisions of an article without content, newest first
+func GetArticleRevisions(articleID int) ([]*ArticleRevision, error) {
+	var revisions []*ArticleRevision
+	err := db.Select("id, created_on, modified_on, deleted_on, article_id, revision, tag_id, tag_ids, category_id, title, `desc`, content_format, cover_image_url, created_by").
+		Where("article_id = ?", articleID).Order("revision DESC").Find(&revisions).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetArticleRevision gets a single revision, returns nil if it does not exist
+func GetArticleRevision(articleID, revision int) (*ArticleRevision, error) {
+	var r ArticleRevision
+	err := db.Where("article_id = ? AND revision = ?", articleID, revision).First(&r).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// TagIDList the tag IDs of the revision, revisions saved before articles had multiple tags only have TagID
+func (r *ArticleRevision) TagIDList() []int {
+	ids := splitIDs(r.TagIDs)
+	if len(ids) == 0 && r.TagID > 0 {
+		ids = []int{r.TagID}
+	}
+
+	return ids
+}
+
+func joinIDs(ids []int) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.Itoa(id))
+	}
+
+	return strings.Join(s, ",")
+}
+
+func splitIDs(s string) []int {
+	var ids []int
+	for _, v := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// ArticleSlug a slug an article used before being renamed, old links are redirected to the current slug
+type ArticleSlug struct {
+	ID        int    `gorm:"primary_key" json:"id"`
+	ArticleID int    `json:"article_id" gorm:"index"`
+	Slug      string `json:"slug" gorm:"unique_index"`
+	CreatedOn int    `json:"created_on"`
+}
+
+// GetArticleIDBySlug gets the ID of the undeleted article whose current slug is slug, returns 0 if none
+func GetArticleIDBySlug(slug string) (int, error) {
+	var article Article
+	err := db.Select("id").Where("slug = ? AND deleted_on = ? ", slug, 0).First(&article).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	return article.ID, nil
+}
+
+// GetArticleIDByOldSlug gets the ID of the undeleted article that used slug before, returns 0 if none
+func GetArticleIDByOldSlug(slug string) (int, error) {
+	var old ArticleSlug
+	err := db.Where("slug = ?", slug).First(&old).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return GetArticleIDIfExists(old.ArticleID)
+}
+
+// GetArticleIDIfExists returns id if the article exists and is not deleted, otherwise 0
+func GetArticleIDIfExists(id int) (int, error) {
+	exists, err := ExistArticleByID(id)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ExistArticleSlug checks if slug is the current slug of an undeleted article or an old slug of any article,
+// the article excludeID itself is not counted
+func ExistArticleSlug(slug string, excludeID int) (bool, error) {
+	var count int
+	err := db.Model(&Article{}).Where("slug = ? AND id <> ? AND deleted_on = ? ", slug, excludeID, 0).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = db.Model(&ArticleSlug{}).Where("slug = ? AND article_id <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+// changeArticleSlug keeps the current slug of the article as an old slug when it changes,
+// an old slug taken back by the same article is removed from the history
+func changeArticleSlug(tx *gorm.DB, id int, slug string) error {
+	var article Article
+	if err := tx.Select("id, slug").Where("id = ?", id).First(&article).Error; err != nil {
+		return err
+	}
+	if article.Slug == slug {
+		return nil
+	}
+
+	if err := tx.Where("article_id = ? AND slug = ?", id, slug).Delete(&ArticleSlug{}).Error; err != nil {
+		return err
+	}
+	if article.Slug == "" {
+		return nil
+	}
+
+	return tx.Create(&ArticleSlug{ArticleID: id, Slug: article.Slug}).Error
+}
+
+// GetArticlesWithoutSlug gets the id and title of up to limit undeleted articles that have no slug yet
+func GetArticlesWithoutSlug(limit int) ([]*Article, error) {
+	var articles []*Article
+	err := db.Select("id, title").Where("slug = ? AND deleted_on = ? ", "", 0).Order("id").Limit(limit).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// SetArticleSlug sets the slug of an article without keeping history or touching modified_on
+func SetArticleSlug(id int, slug string) error {
+	return db.Model(&Article{}).Where("id = ?", id).UpdateColumn("slug", slug).Error
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ArticleTag the many-to-many relation between articles and tags
+type ArticleTag struct {
+	ArticleID int `json:"article_id" gorm:"primary_key;auto_increment:false"`
+	TagID     int `json:"tag_id" gorm:"primary_key;auto_increment:false;index"`
+}
+
+// ArticleTagRelation an article tag relation with names, used when exporting tags
+type ArticleTagRelation struct {
+	ArticleID    int    `json:"article_id"`
+	ArticleTitle string `json:"article_title"`
+	TagID        int    `json:"tag_id"`
+	TagName      string `json:"tag_name"`
+}
+
+func tableName(value interface{}) string {
+	return db.NewScope(value).TableName()
+}
+
+// WithAnyTags filters articles having at least one of the tags
+func WithAnyTags(tagIDs []int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(fmt.Sprintf("id IN (SELECT article_id FROM %s WHERE tag_id IN (?))", tableName(&ArticleTag{})), tagIDs)
+	}
+}
+
+// WithAllTags filters articles having all of the tags
+func WithAllTags(tagIDs []int) func(*gorm.DB) *gorm.DB {
+	unique := make(map[int]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		unique[id] = true
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(fmt.Sprintf("id IN (SELECT article_id FROM %s WHERE tag_id IN (?) GROUP BY article_id HAVING COUNT(DISTINCT tag_id) = ?)",
+			tableName(&ArticleTag{})), tagIDs, len(unique))
+	}
+}
+
+// setArticleTags replaces the tags of an article
+func setArticleTags(tx *gorm.DB, articleID int, tagIDs []int) error {
+	if err := tx.Where("article_id = ?", articleID).Delete(&ArticleTag{}).Error; err != nil {
+		return err
+	}
+
+	for _, tagID := range tagIDs {
+		if err := tx.Create(&ArticleTag{ArticleID: articleID, TagID: tagID}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getArticleTagIDs gets the tag IDs of an article in ascending order
+func getArticleTagIDs(tx *gorm.DB, articleID int) ([]int, error) {
+	var ids []int
+	err := tx.Model(&ArticleTag{}).Where("article_id = ?", articleID).Order("tag_id").Pluck("tag_id", &ids).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// loadArticleTags fills the Tags of the articles, deleted tags are skipped
+func loadArticleTags(articles ...*Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	articleIDs := make([]int, 0, len(articles))
+	for _, article := range articles {
+		articleIDs = append(articleIDs, article.ID)
+	}
+
+	var relations []ArticleTag
+	err := db.Where("article_id IN (?)", articleIDs).Order("tag_id").Find(&relations).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+
+	tagIDs := make([]int, 0, len(relations))
+	for _, r := range relations {
+		tagIDs = append(tagIDs, r.TagID)
+	}
+	var tags []Tag
+	err = db.Where("id IN (?) AND deleted_on = ? ", tagIDs, 0).Find(&tags).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	tagsByID := make(map[int]Tag, len(tags))
+	for _, tag := range tags {
+		tagsByID[tag.ID] = tag
+	}
+
+	byID := make(map[int]*Article, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+	}
+	for _, r := range relations {
+		if tag, ok := tagsByID[r.TagID]; ok {
+			byID[r.ArticleID].Tags = append(byID[r.ArticleID].Tags, tag)
+		}
+	}
+
+	return nil
+}
+
+// AddArticleTag adds an article tag relation if it does not exist
+func AddArticleTag(articleID, tagID int) error {
+	relation := ArticleTag{ArticleID: articleID, TagID: tagID}
+	return db.Where(relation).FirstOrCreate(&relation).Error
+}
+
+// GetArticleTagRelations gets the relations between undeleted articles and the given tags
+func GetArticleTagRelations(tagIDs []int) ([]*ArticleTagRelation, error) {
+	var relations []*ArticleTagRelation
+	if len(tagIDs) == 0 {
+		return relations, nil
+	}
+
+	at, article, tag := tableName(&ArticleTag{}), tableName(&Article{}), tableName(&Tag{})
+	err := db.Table(at).
+		Select(fmt.Sprintf("%s.article_id, %s.title AS article_title, %s.tag_id, %s.name AS tag_name", at, article, at, tag)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.article_id AND %s.deleted_on = 0", article, article, at, article)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.tag_id", tag, tag, at)).
+		Where(fmt.Sprintf("%s.tag_id IN (?)", at), tagIDs).
+		Order(fmt.Sprintf("%s.article_id, %s.tag_id", at, at)).
+		Scan(&relations).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+// GetTagArticleCounts counts the undeleted articles of each tag
+func GetTagArticleCounts(tagIDs []int) (map[int]int, error) {
+	counts := make(map[int]int, len(tagIDs))
+	if len(tagIDs) == 0 {
+		return counts, nil
+	}
+
+	at, article := tableName(&ArticleTag{}), tableName(&Article{})
+	rows, err := db.Table(at).
+		Select(fmt.Sprintf("%s.tag_id, COUNT(*)", at)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.article_id AND %s.deleted_on = 0", article, article, at, article)).
+		Where(fmt.Sprintf("%s.tag_id IN (?)", at), tagIDs).
+		Group(fmt.Sprintf("%s.tag_id", at)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,20 @@
+package models
+
+import "testing"
+
+func TestContainsPattern(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"gin", `%gin%`},
+		{"100%", `%100\%%`},
+		{"go_gin", `%go\_gin%`},
+		{`C:\go`, `%C:\\go%`},
+	}
+	for _, tt := range tests {
+		if got := containsPattern(tt.keyword); got != tt.want {
+			t.Errorf("containsPattern(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
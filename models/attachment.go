@@ -0,0 +1,68 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Attachment owner types
+const (
+	AttachmentOwnerArticle      = "article"
+	AttachmentOwnerStockProduct = "stock_product"
+	AttachmentOwnerOrder        = "order"
+)
+
+// Attachment a file attached to an article, a stock product or an order
+type Attachment struct {
+	Model
+
+	OwnerType   string `json:"owner_type" gorm:"index:idx_owner"`
+	OwnerID     int    `json:"owner_id" gorm:"index:idx_owner"`
+	FileName    string `json:"file_name"`
+	Key         string `json:"key" gorm:"column:storage_key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256" gorm:"column:sha256"`
+	CreatedBy   string `json:"created_by"`
+}
+
+// AddAttachment adds an attachment
+func AddAttachment(attachment *Attachment) error {
+	return db.Create(attachment).Error
+}
+
+// GetAttachment gets an undeleted attachment, returns nil if it does not exist
+func GetAttachment(id int) (*Attachment, error) {
+	var attachment Attachment
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&attachment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// GetAttachments gets the undeleted attachments of an owner
+func GetAttachments(ownerType string, ownerID int) ([]*Attachment, error) {
+	var attachments []*Attachment
+	err := db.Where("owner_type = ? AND owner_id = ? AND deleted_on = ? ", ownerType, ownerID, 0).Order("id").Find(&attachments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// CountAttachmentsByKey counts the undeleted attachments stored at key, files with the same content share a key
+func CountAttachmentsByKey(key string) (int, error) {
+	var count int
+	err := db.Model(&Attachment{}).Where("storage_key = ? AND deleted_on = ? ", key, 0).Count(&count).Error
+	return count, err
+}
+
+// DeleteAttachment deletes an attachment
+func DeleteAttachment(id int) error {
+	return db.Where("id = ?", id).Delete(&Attachment{}).Error
+}
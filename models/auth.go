@@ -10,15 +10,32 @@ type Auth struct {
 
 // CheckAuth checks if authentication information exists
 func CheckAuth(username, password string) (bool, error) {
+	id, err := GetAuthID(username, password)
+	if err != nil {
+		return false, err
+	}
+
+	return id > 0, nil
+}
+
+// GetAuthID returns the id of the matching user, 0 if none matches
+func GetAuthID(username, password string) (int, error) {
 	var auth Auth
 	err := db.Select("id").Where(Auth{Username: username, Password: password}).First(&auth).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return false, err
+		return 0, err
 	}
 
-	if auth.ID > 0 {
-		return true, nil
+	return auth.ID, nil
+}
+
+// GetAuthUsername returns the username of the user, "" if the user does not exist
+func GetAuthUsername(id int) (string, error) {
+	var auth Auth
+	err := db.Select("username").Where("id = ?", id).First(&auth).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", err
 	}
 
-	return false, nil
+	return auth.Username, nil
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Category a node of the article category tree, top-level categories have ParentID 0
+type Category struct {
+	Model
+
+	ParentID   int    `json:"parent_id" gorm:"index"`
+	Name       string `json:"name"`
+	Sort       int    `json:"sort"`
+	CreatedBy  string `json:"created_by"`
+	ModifiedBy string `json:"modified_by"`
+
+	Children []*Category `json:"children,omitempty" gorm:"-"`
+}
+
+// GetAllCategories gets all undeleted categories ordered by sort
+func GetAllCategories() ([]*Category, error) {
+	var categories []*Category
+	err := db.Where("deleted_on = ?", 0).Order("sort, id").Find(&categories).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// ExistCategoryByID checks if a category exists based on ID
+func ExistCategoryByID(id int) (bool, error) {
+	var category Category
+	err := db.Select("id").Where("id = ? AND deleted_on = ? ", id, 0).First(&category).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	return category.ID > 0, nil
+}
+
+// ExistCategoryByName checks if the parent already has a child category with the same name
+func ExistCategoryByName(parentID int, name string) (bool, error) {
+	var category Category
+	err := db.Select("id").Where("parent_id = ? AND name = ? AND deleted_on = ? ", parentID, name, 0).First(&category).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	return category.ID > 0, nil
+}
+
+// AddCategory add a single category
+func AddCategory(category *Category) error {
+	return db.Create(category).Error
+}
+
+// EditCategory modify a single category
+func EditCategory(id int, data interface{}) error {
+	return db.Model(&Category{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error
+}
+
+// DeleteCategory deletes a category and moves its articles to the parent category
+func DeleteCategory(id, parentID int) error {
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("id = ?", id).Delete(&Category{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	err := tx.Model(&Article{}).Where("category_id = ?", id).UpdateColumn("category_id", parentID).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Comment moderation statuses, only approved comments are visible to readers
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusSpam     = "spam"
+)
+
+// Comment a reader comment on an article, replies keep the top-level comment of their thread in RootID
+type Comment struct {
+	Model
+
+	ArticleID   int    `json:"article_id" gorm:"index"`
+	ParentID    int    `json:"parent_id"`
+	RootID      int    `json:"root_id" gorm:"index"`
+	Content     string `json:"content"`
+	CreatedBy   string `json:"created_by"`
+	Status      string `json:"status"`
+	ModeratedBy string `json:"moderated_by"`
+
+	Replies []*Comment `json:"replies,omitempty" gorm:"-"`
+}
+
+// AddComment add a single comment
+func AddComment(comment *Comment) error {
+	return db.Create(comment).Error
+}
+
+// GetComment gets a single comment, returns nil if it does not exist
+func GetComment(id int) (*Comment, error) {
+	var comment Comment
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&comment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// GetComments gets a list of comments based on paging and constraints, newest first
+func GetComments(pageNum int, pageSize int, maps interface{}) ([]*Comment, error) {
+	var comments []*Comment
+	err := db.Where(maps).Order("id DESC").Offset(pageNum).Limit(pageSize).Find(&comments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentTotal counts the total number of comments based on the constraint
+func GetCommentTotal(maps interface{}) (int, error) {
+	var count int
+	if err := db.Model(&Comment{}).Where(maps).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetCommentReplies gets the approved replies of the given threads, oldest first
+func GetCommentReplies(rootIDs []int) ([]*Comment, error) {
+	var comments []*Comment
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+
+	err := db.Where("root_id IN (?) AND status = ? AND deleted_on = ? ", rootIDs, CommentStatusApproved, 0).
+		Order("id ASC").Find(&comments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentsByIDs gets the comments with the given IDs
+func GetCommentsByIDs(ids []int) ([]*Comment, error) {
+	var comments []*Comment
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	err := db.Where("id IN (?) AND deleted_on = ? ", ids, 0).Find(&comments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// ModerateComments sets the status of the given comments
+func ModerateComments(ids []int, status, moderatedBy string) error {
+	return db.Model(&Comment{}).Where("id IN (?) AND deleted_on = ? ", ids, 0).Updates(map[string]interface{}{
+		"status":       status,
+		"moderated_by": moderatedBy,
+	}).Error
+}
+
+// DeleteComment delete a single comment, deleting a top-level comment also deletes its replies
+func DeleteComment(id int) error {
+	return db.Where("id = ? OR root_id = ?", id, id).Delete(&Comment{}).Error
+}
+
+// GetArticleCommentCounts counts the approved comments of each article
+func GetArticleCommentCounts(articleIDs []int) (map[int]int, error) {
+	counts := make(map[int]int, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := db.Model(&Comment{}).Select("article_id, COUNT(*)").
+		Where("article_id IN (?) AND status = ? AND deleted_on = ? ", articleIDs, CommentStatusApproved, 0).
+		Group("article_id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+
+	return counts, rows.Err()
+}
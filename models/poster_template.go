@@ -0,0 +1,75 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// PosterTemplate a poster layout, Definition is the JSON encoded poster.Template
+type PosterTemplate struct {
+	Model
+
+	Name       string `json:"name" gorm:"index"`
+	Definition string `json:"-" gorm:"type:text"`
+	CreatedBy  string `json:"created_by"`
+	ModifiedBy string `json:"modified_by"`
+}
+
+// GetPosterTemplates gets all undeleted poster templates
+func GetPosterTemplates() ([]*PosterTemplate, error) {
+	var templates []*PosterTemplate
+	err := db.Where("deleted_on = ?", 0).Order("id").Find(&templates).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// GetPosterTemplate gets an undeleted poster template by ID, returns nil if it does not exist
+func GetPosterTemplate(id int) (*PosterTemplate, error) {
+	return getPosterTemplate("id = ? AND deleted_on = ? ", id, 0)
+}
+
+// GetPosterTemplateByName gets an undeleted poster template by name, returns nil if it does not exist
+func GetPosterTemplateByName(name string) (*PosterTemplate, error) {
+	return getPosterTemplate("name = ? AND deleted_on = ? ", name, 0)
+}
+
+func getPosterTemplate(query string, args ...interface{}) (*PosterTemplate, error) {
+	var template PosterTemplate
+	err := db.Where(query, args...).First(&template).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// ExistPosterTemplateByName checks if another undeleted poster template uses the name
+func ExistPosterTemplateByName(name string, excludeID int) (bool, error) {
+	var template PosterTemplate
+	err := db.Select("id").Where("name = ? AND id <> ? AND deleted_on = ? ", name, excludeID, 0).First(&template).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	return template.ID > 0, nil
+}
+
+// AddPosterTemplate add a single poster template
+func AddPosterTemplate(template *PosterTemplate) error {
+	return db.Create(template).Error
+}
+
+// EditPosterTemplate modify a single poster template
+func EditPosterTemplate(id int, data interface{}) error {
+	return db.Model(&PosterTemplate{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error
+}
+
+// DeletePosterTemplate delete a single poster template
+func DeletePosterTemplate(id int) error {
+	return db.Where("id = ?", id).Delete(&PosterTemplate{}).Error
+}
@@ -0,0 +1,23 @@
+package stock
+
+import (
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/jinzhu/gorm"
+)
+
+// StockOrder 已扣减库存的订单号，与扣减库存在同一个事务中写入
+type StockOrder struct {
+	models.Model
+
+	OrderSN string `json:"order_sn" gorm:"type:varchar(64);not null;default:'';unique_index:uk_order_sn" comment:"订单号"`
+}
+
+// ExistStockOrderInTx 在事务中检查订单号是否已经扣减过库存
+func ExistStockOrderInTx(tx *gorm.DB, orderSN string) (bool, error) {
+	var count int
+	if err := tx.Model(&StockOrder{}).Where("order_sn = ?", orderSN).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
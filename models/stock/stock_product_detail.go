@@ -17,6 +17,7 @@ type StockProductDetail struct {
 	HiddenCode     string  `json:"hidden_code" gorm:"type:varchar(64);not null;default:'';unique_index:uk_spd_company_batch_hidden_code;index:idx_hidden_code" comment:"唯一编码（系统使用）"`
 	Status         int     `json:"status" gorm:"type:tinyint;not null;default:0;index:idx_status" comment:"状态 0=正常 1=丢失 2=报废"`
 	Note           string  `json:"note" gorm:"type:varchar(255);not null;default:''"`
+	LockFence      int64   `json:"-" gorm:"not null;default:0" comment:"最后一次加锁写入的栅栏值"`
 
 	// 关联关系 - 属于某个产品
 	StockProduct StockProduct `json:"stock_product" gorm:"foreignkey:StockProductID"`
@@ -115,6 +116,31 @@ func GetStockProductDetailsByProductID(productID int, pageNum int, pageSize int)
 	return details, nil
 }
 
+// GetStockProductDetailsWithProductByIDs 根据ID批量获取明细及关联的产品信息
+func GetStockProductDetailsWithProductByIDs(ids []int) ([]*StockProductDetail, error) {
+	var details []*StockProductDetail
+	err := models.Db.Preload("StockProduct").Where("id IN (?) AND deleted_on = ?", ids, 0).Find(&details).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// GetStockProductDetailsWithProductByProductID 获取产品的前 limit 条明细及关联的产品信息
+func GetStockProductDetailsWithProductByProductID(productID int, limit int) ([]*StockProductDetail, error) {
+	var details []*StockProductDetail
+	err := models.Db.Preload("StockProduct").Where("stock_product_id = ? AND deleted_on = ?", productID, 0).
+		Order("id").
+		Limit(limit).
+		Find(&details).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return details, nil
+}
+
 // GetStockProductDetailsByOrderID 根据订单ID获取明细列表
 func GetStockProductDetailsByOrderID(orderID int) ([]*StockProductDetail, error) {
 	var details []*StockProductDetail
@@ -8,9 +8,12 @@ type Tag struct {
 	Model
 
 	Name       string `json:"name"`
+	Slug       string `json:"slug" gorm:"index"`
 	CreatedBy  string `json:"created_by"`
 	ModifiedBy string `json:"modified_by"`
 	State      int    `json:"state"`
+
+	ArticleCount int `json:"article_count" gorm:"-"`
 }
 
 // ExistTagByName checks if there is a tag with the same name
@@ -28,10 +31,82 @@ func ExistTagByName(name string) (bool, error) {
 	return false, nil
 }
 
+// GetTagByName gets an undeleted tag by name, returns nil if it does not exist
+func GetTagByName(name string) (*Tag, error) {
+	var tag Tag
+	err := db.Where("name = ? AND deleted_on = ? ", name, 0).First(&tag).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// ExistTagBySlug checks if an undeleted tag other than excludeID uses slug
+func ExistTagBySlug(slug string, excludeID int) (bool, error) {
+	var tag Tag
+	err := db.Select("id").Where("slug = ? AND id <> ? AND deleted_on = ? ", slug, excludeID, 0).First(&tag).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	return tag.ID > 0, nil
+}
+
+// GetTagBySlug gets an undeleted tag by slug, returns nil if it does not exist
+func GetTagBySlug(slug string) (*Tag, error) {
+	var tag Tag
+	err := db.Where("slug = ? AND deleted_on = ? ", slug, 0).First(&tag).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// GetTagsWithoutSlug gets the id and name of up to limit undeleted tags that have no slug yet
+func GetTagsWithoutSlug(limit int) ([]*Tag, error) {
+	var tags []*Tag
+	err := db.Select("id, name").Where("slug = ? AND deleted_on = ? ", "", 0).Order("id").Limit(limit).Find(&tags).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// SetTagSlug sets the slug of a tag without touching modified_on
+func SetTagSlug(id int, slug string) error {
+	return db.Model(&Tag{}).Where("id = ?", id).UpdateColumn("slug", slug).Error
+}
+
+// ExistTagsByIDs determines whether all the tags exist
+func ExistTagsByIDs(ids []int) (bool, error) {
+	unique := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+
+	var count int
+	err := db.Model(&Tag{}).Where("id IN (?) AND deleted_on = ? ", ids, 0).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count == len(unique), nil
+}
+
 // AddTag Add a Tag
-func AddTag(name string, state int, createdBy string) error {
+func AddTag(name, slug string, state int, createdBy string) error {
 	tag := Tag{
 		Name:      name,
+		Slug:      slug,
 		State:     state,
 		CreatedBy: createdBy,
 	}
@@ -86,6 +161,20 @@ func ExistTagByID(id int) (bool, error) {
 	return false, nil
 }
 
+// GetTag gets a single undeleted tag, returns nil if it does not exist
+func GetTag(id int) (*Tag, error) {
+	var tag Tag
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&tag).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
 // DeleteTag delete a tag
 func DeleteTag(id int) error {
 	if err := db.Where("id = ?", id).Delete(&Tag{}).Error; err != nil {
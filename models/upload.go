@@ -0,0 +1,118 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Upload statuses
+const (
+	UploadStatusUploading  = "uploading"
+	UploadStatusCompleting = "completing"
+	UploadStatusCompleted  = "completed"
+	UploadStatusAborted    = "aborted"
+)
+
+// Upload a resumable upload session, the chunks are stored separately until the upload is completed
+type Upload struct {
+	ID        int    `gorm:"primary_key" json:"-"`
+	UploadID  string `json:"upload_id" gorm:"unique_index"`
+	FileName  string `json:"file_name"`
+	Size      int64  `json:"size"`
+	ChunkSize int    `json:"chunk_size"`
+	SHA256    string `json:"sha256" gorm:"column:sha256"`
+	OwnerType string `json:"owner_type"`
+	OwnerID   int    `json:"owner_id"`
+	Status    string `json:"status" gorm:"index"`
+	CreatedBy string `json:"created_by"`
+
+	AttachmentID int `json:"attachment_id"`
+	ExpiresOn    int `json:"expires_on" gorm:"index"`
+	CreatedOn    int `json:"created_on"`
+	ModifiedOn   int `json:"modified_on"`
+}
+
+// UploadChunk a received chunk of an upload, index starts from 0
+type UploadChunk struct {
+	ID        int    `gorm:"primary_key" json:"-"`
+	UploadID  int    `json:"-" gorm:"unique_index:uk_upload_chunk"`
+	Index     int    `json:"index" gorm:"column:chunk_index;unique_index:uk_upload_chunk"`
+	Size      int    `json:"size"`
+	SHA256    string `json:"sha256" gorm:"column:sha256"`
+	CreatedOn int    `json:"created_on"`
+}
+
+// AddUpload creates an upload session
+func AddUpload(upload *Upload) error {
+	return db.Create(upload).Error
+}
+
+// GetUpload gets an upload session by its upload id, returns nil if it does not exist
+func GetUpload(uploadID string) (*Upload, error) {
+	var upload Upload
+	err := db.Where("upload_id = ?", uploadID).First(&upload).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// SetUploadStatus changes the status of an upload from one of the statuses in from,
+// returns false if the upload is not in any of them, so that only one caller wins
+func SetUploadStatus(id int, from []string, to string, data map[string]interface{}) (bool, error) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["status"] = to
+
+	res := db.Model(&Upload{}).Where("id = ? AND status IN (?)", id, from).Updates(data)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetExpiredUploads gets up to limit uploading uploads expired before now,
+// and completing uploads expired before completingBefore
+func GetExpiredUploads(now, completingBefore, limit int) ([]*Upload, error) {
+	var uploads []*Upload
+	err := db.Where("(status = ? AND expires_on < ?) OR (status = ? AND expires_on < ?)",
+		UploadStatusUploading, now, UploadStatusCompleting, completingBefore).
+		Order("id").Limit(limit).Find(&uploads).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return uploads, nil
+}
+
+// SaveUploadChunk records a received chunk, a chunk uploaded again replaces the previous one
+func SaveUploadChunk(chunk *UploadChunk) error {
+	tx := db.Begin()
+	if err := tx.Where("upload_id = ? AND chunk_index = ?", chunk.UploadID, chunk.Index).Delete(&UploadChunk{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Create(chunk).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetUploadChunks gets the received chunks of an upload ordered by index
+func GetUploadChunks(uploadID int) ([]*UploadChunk, error) {
+	var chunks []*UploadChunk
+	err := db.Where("upload_id = ?", uploadID).Order("chunk_index").Find(&chunks).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+// DeleteUploadChunks deletes the chunk records of an upload
+func DeleteUploadChunks(uploadID int) error {
+	return db.Where("upload_id = ?", uploadID).Delete(&UploadChunk{}).Error
+}
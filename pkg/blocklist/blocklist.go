@@ -0,0 +1,91 @@
+package blocklist
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Filter 关键词过滤，忽略大小写，并忽略关键词中间插入的空白和标点（例如 "s p a m"、"s.p.a.m"）
+type Filter struct {
+	words []string
+}
+
+// New 创建过滤器，空关键词会被忽略
+func New(words []string) *Filter {
+	f := &Filter{}
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		w = normalize(w)
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		f.words = append(f.words, w)
+	}
+
+	return f
+}
+
+// Load 从文件读取关键词，每行一个，# 开头的行为注释；文件不存在时返回空的过滤器
+func Load(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return New(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return New(words), nil
+}
+
+// Len 关键词数量
+func (f *Filter) Len() int {
+	if f == nil {
+		return 0
+	}
+
+	return len(f.words)
+}
+
+// Match 返回文本中命中的第一个关键词
+func (f *Filter) Match(text string) (string, bool) {
+	if f.Len() == 0 {
+		return "", false
+	}
+
+	text = normalize(text)
+	for _, w := range f.words {
+		if strings.Contains(text, w) {
+			return w, true
+		}
+	}
+
+	return "", false
+}
+
+// normalize 转为小写并去掉空白、标点和符号
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
@@ -0,0 +1,67 @@
+package blocklist
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	f := New([]string{"Spam", "刷 单", "", "!!", "spam"})
+	if f.Len() != 2 {
+		t.Errorf("Len() = %d, want 2: empty and duplicate words are ignored", f.Len())
+	}
+
+	tests := []struct {
+		text string
+		word string
+		ok   bool
+	}{
+		{"this is SPAM", "spam", true},
+		{"s p a m", "spam", true},
+		{"s.p-a_m!", "spam", true},
+		{"专业刷单", "刷单", true},
+		{"刷，单", "刷单", true},
+		{"span", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		word, ok := f.Match(tt.text)
+		if word != tt.word || ok != tt.ok {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.text, word, ok, tt.word, tt.ok)
+		}
+	}
+
+	var empty *Filter
+	if _, ok := empty.Match("spam"); ok || empty.Len() != 0 {
+		t.Error("nil filter matched")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := ioutil.WriteFile(path, []byte("# comment\n\n  casino  \r\nviagra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Load(path)
+	if err != nil || f.Len() != 2 {
+		t.Fatalf("Load = %v, %v", f.Len(), err)
+	}
+	if w, ok := f.Match("online Casino"); !ok || w != "casino" {
+		t.Errorf("Match = %q, %v", w, ok)
+	}
+	if _, ok := f.Match("comment"); ok {
+		t.Error("comment line was loaded as a word")
+	}
+
+	// 文件不存在时不过滤
+	f, err = Load(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil || f.Len() != 0 {
+		t.Errorf("Load missing = %d, %v", f.Len(), err)
+	}
+
+	if f, err := Load("../../conf/comment_blocklist.txt"); err != nil || f.Len() == 0 {
+		t.Errorf("Load conf = %v, %v", f, err)
+	}
+}
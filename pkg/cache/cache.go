@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// negativeValue 标记“数据不存在”的缓存值，合法的 JSON 不会与它相同
+var negativeValue = []byte("\x00nil")
+
+// Options 缓存参数
+type Options struct {
+	TTL         time.Duration // 默认过期时间
+	Jitter      float64       // 过期时间随机增加的比例，避免大量 key 同时失效
+	NegativeTTL time.Duration // 不存在的记录的缓存时间，0 表示不缓存
+}
+
+// Cache 读穿缓存：依次查询各级存储，都未命中时调用 loader 并回填
+type Cache struct {
+	stores []Store
+	opts   Options
+	group  group
+}
+
+// Default 由 Setup 按配置创建的全局缓存
+var Default *Cache
+
+// New 创建缓存，stores 按从近到远的顺序排列，例如 LRU、Redis
+func New(opts Options, stores ...Store) *Cache {
+	return &Cache{stores: stores, opts: opts}
+}
+
+// Setup 按配置初始化 Default
+func Setup() {
+	var stores []Store
+	if setting.CacheSetting.LocalSize > 0 {
+		stores = append(stores, NewLRUStore(setting.CacheSetting.LocalSize, setting.CacheSetting.LocalTTL))
+	}
+	stores = append(stores, NewRedisStore(setting.RedisSetting.ReadTimeout))
+
+	Default = New(Options{
+		TTL:         setting.CacheSetting.TTL,
+		Jitter:      setting.CacheSetting.Jitter,
+		NegativeTTL: setting.CacheSetting.NegativeTTL,
+	}, stores...)
+}
+
+// Get 读取 key 并解码为 T，未命中时调用 loader 加载并按 tags 写入缓存
+// loader 返回 ErrNotFound 时会缓存“不存在”，在 NegativeTTL 内直接返回 ErrNotFound
+func Get[T any](c *Cache, key string, loader func() (T, error), tags ...string) (T, error) {
+	var v T
+
+	data, err := c.get(key, tags)
+	if err == nil {
+		if bytes.Equal(data, negativeValue) {
+			return v, ErrNotFound
+		}
+		if err := json.Unmarshal(data, &v); err == nil {
+			return v, nil
+		}
+		// 数据结构变化等原因导致解码失败，当作未命中重新加载
+		logging.Warn("cache.Get unmarshal err:", key, err)
+		c.Delete(key)
+	}
+
+	data, err = c.group.Do(key, func() ([]byte, error) {
+		v, err := loader()
+		if errors.Is(err, ErrNotFound) {
+			if c.opts.NegativeTTL > 0 {
+				c.set(key, negativeValue, c.opts.NegativeTTL, tags)
+			}
+			return negativeValue, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, data, c.jitter(c.opts.TTL), tags)
+
+		return data, nil
+	})
+	if err != nil {
+		return v, err
+	}
+	if bytes.Equal(data, negativeValue) {
+		return v, ErrNotFound
+	}
+
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// Set 直接写入缓存
+func (c *Cache) Set(key string, v interface{}, tags ...string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.set(key, data, c.jitter(c.opts.TTL), tags)
+}
+
+// Delete 从各级存储删除 keys
+func (c *Cache) Delete(keys ...string) error {
+	var firstErr error
+	for _, s := range c.stores {
+		if err := s.Delete(keys...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Invalidate 删除属于任一 tag 的缓存，写操作之后调用
+func (c *Cache) Invalidate(tags ...string) error {
+	var firstErr error
+	for _, s := range c.stores {
+		if err := s.InvalidateTags(tags...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// get 依次查询各级存储，远端命中时回填近端
+func (c *Cache) get(key string, tags []string) ([]byte, error) {
+	for i, s := range c.stores {
+		data, err := s.Get(key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			logging.Warn("cache.get err:", key, err)
+			continue
+		}
+
+		ttl := c.opts.TTL
+		if bytes.Equal(data, negativeValue) {
+			ttl = c.opts.NegativeTTL
+		}
+		for _, near := range c.stores[:i] {
+			near.Set(key, data, ttl, tags...)
+		}
+		return data, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// set 写入各级存储，写缓存失败不影响业务，只记录日志
+func (c *Cache) set(key string, data []byte, ttl time.Duration, tags []string) error {
+	var firstErr error
+	for _, s := range c.stores {
+		if err := s.Set(key, data, ttl, tags...); err != nil {
+			logging.Warn("cache.set err:", key, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// jitter 在 ttl 上随机增加 [0, ttl*Jitter) 的时间
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	if c.opts.Jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+
+	return ttl + time.Duration(rand.Int63n(int64(float64(ttl)*c.opts.Jitter)+1))
+}
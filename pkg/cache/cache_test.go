@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memStore 记录写入参数的内存存储
+type memStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+	tags map[string][]string
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: map[string][]byte{}, ttls: map[string]time.Duration{}, tags: map[string][]string{}}
+}
+
+func (s *memStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key], s.ttls[key] = value, ttl
+	for _, tag := range tags {
+		s.tags[tag] = append(s.tags[tag], key)
+	}
+	return nil
+}
+
+func (s *memStore) Delete(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.data, key)
+	}
+	return nil
+}
+
+func (s *memStore) InvalidateTags(tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for _, key := range s.tags[tag] {
+			delete(s.data, key)
+		}
+		delete(s.tags, tag)
+	}
+	return nil
+}
+
+type article struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestGet(t *testing.T) {
+	near, far := newMemStore(), newMemStore()
+	c := New(Options{TTL: time.Minute}, near, far)
+
+	loads := 0
+	loader := func() (*article, error) {
+		loads++
+		return &article{ID: 1, Title: "gin"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		a, err := Get(c, "article_1", loader, "article:1")
+		if err != nil || a.Title != "gin" {
+			t.Fatalf("Get() = %+v, %v", a, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loader called %d times, want 1", loads)
+	}
+	if string(far.data["article_1"]) != `{"id":1,"title":"gin"}` || far.ttls["article_1"] != time.Minute {
+		t.Errorf("far store = %q, ttl %v", far.data["article_1"], far.ttls["article_1"])
+	}
+
+	// 只有远端命中时回填近端
+	near.Delete("article_1")
+	if _, err := Get(c, "article_1", loader, "article:1"); err != nil || loads != 1 {
+		t.Fatalf("Get() from far store err = %v, loads = %d", err, loads)
+	}
+	if _, ok := near.data["article_1"]; !ok || len(near.tags["article:1"]) == 0 {
+		t.Error("far store hit was not copied to the near store with its tags")
+	}
+
+	if err := c.Invalidate("article:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(c, "article_1", loader, "article:1"); err != nil || loads != 2 {
+		t.Errorf("Get() after Invalidate err = %v, loads = %d, want 2", err, loads)
+	}
+
+	failed := errors.New("db down")
+	if _, err := Get(c, "article_2", func() (*article, error) { return nil, failed }); err != failed {
+		t.Errorf("Get() with loader error = %v, want %v", err, failed)
+	}
+	if _, ok := far.data["article_2"]; ok {
+		t.Error("loader error was cached")
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	near, far := newMemStore(), newMemStore()
+	c := New(Options{TTL: time.Minute, NegativeTTL: 5 * time.Second}, near, far)
+
+	loads := 0
+	loader := func() (*article, error) {
+		loads++
+		return nil, ErrNotFound
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Get(c, "article_9", loader); err != ErrNotFound {
+			t.Fatalf("Get() err = %v, want ErrNotFound", err)
+		}
+	}
+	if loads != 1 || far.ttls["article_9"] != 5*time.Second {
+		t.Errorf("loads = %d, negative ttl = %v", loads, far.ttls["article_9"])
+	}
+
+	// 近端回填时也使用 NegativeTTL
+	near.Delete("article_9")
+	if _, err := Get(c, "article_9", loader); err != ErrNotFound || loads != 1 {
+		t.Fatalf("Get() from far store err = %v, loads = %d", err, loads)
+	}
+	if near.ttls["article_9"] != 5*time.Second {
+		t.Errorf("near negative ttl = %v, want 5s", near.ttls["article_9"])
+	}
+
+	// NegativeTTL 为 0 时不缓存
+	c = New(Options{TTL: time.Minute}, newMemStore())
+	Get(c, "article_9", loader)
+	Get(c, "article_9", loader)
+	if loads != 3 {
+		t.Errorf("loads without negative cache = %d, want 3", loads)
+	}
+}
+
+func TestGetSingleflight(t *testing.T) {
+	c := New(Options{TTL: time.Minute}, newMemStore())
+
+	var loads int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = Get(c, "answer", loader)
+		}(i)
+	}
+	// 加载完成前到达的请求共享结果，之后到达的请求命中缓存
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("loader called %d times, want 1", n)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Errorf("results[%d] = %d", i, v)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	c := New(Options{Jitter: 0.2})
+	for i := 0; i < 100; i++ {
+		if d := c.jitter(time.Minute); d < time.Minute || d > time.Minute+12*time.Second {
+			t.Fatalf("jitter(1m) = %v", d)
+		}
+	}
+	if d := New(Options{}).jitter(time.Minute); d != time.Minute {
+		t.Errorf("jitter without Jitter = %v", d)
+	}
+}
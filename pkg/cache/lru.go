@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry LRU 中的一项
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// LRUStore 进程内的 LRU 存储，作为 Redis 之前的一级缓存
+// 其他实例的失效通知不会到达这里，因此 ttl 应该设置得比较短
+type LRUStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	ll      *list.List
+	items   map[string]*list.Element
+	tagKeys map[string]map[string]struct{}
+}
+
+// NewLRUStore 创建最多保存 size 项的 LRU，ttl 为单项的最长存活时间
+func NewLRUStore(size int, ttl time.Duration) *LRUStore {
+	return &LRUStore{
+		size:    size,
+		ttl:     ttl,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		tagKeys: make(map[string]map[string]struct{}),
+	}
+}
+
+// Get 读取 key，过期的项顺便删除
+func (s *LRUStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		s.removeElement(el)
+		return nil, ErrNotFound
+	}
+	s.ll.MoveToFront(el)
+
+	return entry.value, nil
+}
+
+// Set 写入 key，超出容量时淘汰最久未使用的项
+func (s *LRUStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	if s.ttl > 0 && (ttl <= 0 || ttl > s.ttl) {
+		ttl = s.ttl
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.removeElement(el)
+	}
+
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(ttl), tags: tags}
+	s.items[key] = s.ll.PushFront(entry)
+	for _, tag := range tags {
+		if s.tagKeys[tag] == nil {
+			s.tagKeys[tag] = make(map[string]struct{})
+		}
+		s.tagKeys[tag][key] = struct{}{}
+	}
+
+	for s.size > 0 && s.ll.Len() > s.size {
+		s.removeElement(s.ll.Back())
+	}
+
+	return nil
+}
+
+// Delete 删除 keys
+func (s *LRUStore) Delete(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.removeElement(el)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateTags 删除属于任一 tag 的全部 key
+func (s *LRUStore) InvalidateTags(tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tagKeys[tag] {
+			if el, ok := s.items[key]; ok {
+				s.removeElement(el)
+			}
+		}
+		delete(s.tagKeys, tag)
+	}
+
+	return nil
+}
+
+func (s *LRUStore) removeElement(el *list.Element) {
+	entry := s.ll.Remove(el).(*lruEntry)
+	delete(s.items, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := s.tagKeys[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(s.tagKeys, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUStore(t *testing.T) {
+	s := NewLRUStore(2, time.Minute)
+	s.Set("a", []byte("1"), time.Hour, "t1")
+	s.Set("b", []byte("2"), time.Hour, "t1", "t2")
+
+	// 访问 a 后 b 成为最久未使用的项
+	if data, err := s.Get("a"); err != nil || string(data) != "1" {
+		t.Fatalf("Get(a) = %q, %v", data, err)
+	}
+	s.Set("c", []byte("3"), time.Hour, "t2")
+	if _, err := s.Get("b"); err != ErrNotFound {
+		t.Errorf("Get(b) after eviction err = %v, want ErrNotFound", err)
+	}
+	if _, ok := s.tagKeys["t2"]["b"]; ok {
+		t.Error("evicted key is still in its tag")
+	}
+
+	if err := s.InvalidateTags("t2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("c"); err != ErrNotFound {
+		t.Errorf("Get(c) after InvalidateTags err = %v", err)
+	}
+	if _, err := s.Get("a"); err != nil {
+		t.Errorf("Get(a) of another tag err = %v", err)
+	}
+
+	s.Delete("a")
+	if s.ll.Len() != 0 || len(s.items) != 0 || len(s.tagKeys) != 0 {
+		t.Errorf("store not empty after Delete: %d items, tags %v", s.ll.Len(), s.tagKeys)
+	}
+}
+
+func TestLRUStoreTTL(t *testing.T) {
+	s := NewLRUStore(10, 30*time.Millisecond)
+
+	// 超过 LRU 自身 ttl 的过期时间被截断
+	s.Set("a", []byte("1"), time.Hour)
+	s.Set("b", []byte("2"), 10*time.Millisecond)
+	time.Sleep(15 * time.Millisecond)
+	if _, err := s.Get("b"); err != ErrNotFound {
+		t.Errorf("Get(b) after its ttl err = %v", err)
+	}
+	if _, err := s.Get("a"); err != nil {
+		t.Errorf("Get(a) err = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := s.Get("a"); err != ErrNotFound {
+		t.Errorf("Get(a) after the store ttl err = %v", err)
+	}
+	if s.ll.Len() != 0 {
+		t.Errorf("expired items kept: %d", s.ll.Len())
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+)
+
+// tag 集合的 key 前缀，集合中保存属于该 tag 的缓存 key
+const tagKeyPrefix = "CACHE_TAG_"
+
+// RedisStore 基于 gredis 的存储，支持单机、哨兵和集群模式
+type RedisStore struct {
+	timeout time.Duration
+}
+
+// NewRedisStore 创建 Redis 存储，timeout 为单次操作的超时时间，0 表示不限
+func NewRedisStore(timeout time.Duration) *RedisStore {
+	return &RedisStore{timeout: timeout}
+}
+
+func (s *RedisStore) context() (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.Background(), func() {}
+	}
+
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+// Get 读取 key
+func (s *RedisStore) Get(key string) ([]byte, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	data, err := gredis.GetBytes(ctx, key)
+	if err == gredis.ErrNil {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// tagScriptSrc 把 ARGV[1] 加入 tag 集合 KEYS[1]，集合的过期时间只延长不缩短，
+// 否则 ttl 较短的 key 会让集合先于其他 key 过期，按 tag 失效时漏删
+const tagScriptSrc = `
+redis.call("SADD", KEYS[1], ARGV[1])
+if redis.call("PTTL", KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 1
+`
+
+var tagScript = gredis.NewScript(1, tagScriptSrc)
+
+// Set 写入 key，并把 key 加入各 tag 的集合
+// 集群模式下 key 与 tag 集合不在同一槽位，因此分别执行而不是用事务
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	if _, err := gredis.Do(ctx, key, "SET", key, value, "PX", ttl.Milliseconds()); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		// tag 集合比其中的 key 活得久即可，过期的成员在失效时 DEL 一下也无妨
+		if _, err := tagScript.Do(ctx, tagKeyPrefix+tag, key, (2 * ttl).Milliseconds()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete 删除 keys
+func (s *RedisStore) Delete(keys ...string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	_, err := gredis.Del(ctx, keys...)
+	return err
+}
+
+// InvalidateTags 删除 tag 集合中的全部 key 以及集合本身
+func (s *RedisStore) InvalidateTags(tags ...string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	for _, tag := range tags {
+		tagKey := tagKeyPrefix + tag
+		keys, err := gredis.SMembers(ctx, tagKey)
+		if err != nil {
+			return err
+		}
+
+		if _, err := gredis.Del(ctx, append(keys, tagKey)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
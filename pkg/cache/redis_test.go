@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func newTestStore(t *testing.T) (*miniredis.Miniredis, *RedisStore) {
+	mr := miniredis.RunT(t)
+
+	saved := *setting.RedisSetting
+	t.Cleanup(func() { *setting.RedisSetting = saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = mr.Addr()
+	if err := gredis.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	return mr, NewRedisStore(time.Second)
+}
+
+func TestRedisStore(t *testing.T) {
+	mr, s := newTestStore(t)
+
+	if _, err := s.Get("a"); err != ErrNotFound {
+		t.Fatalf("Get(missing) err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Set("a", []byte("1"), time.Minute, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("b", []byte("2"), 10*time.Millisecond, "t1", "t2"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.Get("a"); err != nil || string(data) != "1" {
+		t.Errorf("Get(a) = %q, %v", data, err)
+	}
+
+	// 过期时间至少 1 秒；tag 集合的过期时间只延长，不会被 ttl 较短的 b 缩短
+	if ttl := mr.TTL("b"); ttl != time.Second {
+		t.Errorf("TTL b = %v, want 1s", ttl)
+	}
+	if ttl := mr.TTL(tagKeyPrefix + "t1"); ttl != 2*time.Minute {
+		t.Errorf("TTL of tag t1 = %v, want 2m", ttl)
+	}
+	if ttl := mr.TTL(tagKeyPrefix + "t2"); ttl != 2*time.Second {
+		t.Errorf("TTL of tag t2 = %v, want 2s", ttl)
+	}
+
+	if err := s.InvalidateTags("t2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("b"); err != ErrNotFound {
+		t.Errorf("Get(b) after InvalidateTags err = %v", err)
+	}
+	if _, err := s.Get("a"); err != nil {
+		t.Errorf("Get(a) of another tag err = %v", err)
+	}
+	if mr.Exists(tagKeyPrefix + "t2") {
+		t.Error("tag set t2 not removed")
+	}
+
+	if err := s.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("a"); err != ErrNotFound {
+		t.Errorf("Get(a) after Delete err = %v", err)
+	}
+}
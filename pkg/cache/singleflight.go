@@ -0,0 +1,44 @@
+package cache
+
+import "sync"
+
+// call 一次正在进行的加载
+type call struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// group 合并同一 key 的并发加载，只有第一个请求真正执行 fn
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do 执行 fn，同一时间相同 key 的其他调用等待并共享结果
+func (g *group) Do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
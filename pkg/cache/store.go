@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound 缓存中不存在，或 loader 确认数据不存在
+var ErrNotFound = errors.New("cache: not found")
+
+// Store 缓存存储
+type Store interface {
+	// Get 读取 key，不存在时返回 ErrNotFound
+	Get(key string) ([]byte, error)
+	// Set 写入 key，并记录其所属的 tags，便于按 tag 批量失效
+	Set(key string, value []byte, ttl time.Duration, tags ...string) error
+	// Delete 删除 keys
+	Delete(keys ...string) error
+	// InvalidateTags 删除属于任一 tag 的全部 key
+	InvalidateTags(tags ...string) error
+}
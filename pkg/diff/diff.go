@@ -0,0 +1,294 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 行的变化类型
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// maxEdits 编辑距离超过该值时不再计算最短编辑序列，直接视为全部删除后全部插入，限制内存占用
+const maxEdits = 2000
+
+// Line diff 结果中的一行，OldLine / NewLine 从 1 开始，0 表示该侧没有这一行
+type Line struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Hunk 一段连续的变化及其上下文
+type Hunk struct {
+	OldStart int     `json:"old_start"`
+	OldLines int     `json:"old_lines"`
+	NewStart int     `json:"new_start"`
+	NewLines int     `json:"new_lines"`
+	Lines    []*Line `json:"lines"`
+}
+
+// Stats 增删行数
+type Stats struct {
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// SplitLines 按行切分文本，统一换行符
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Strings 逐行比较两段文本
+func Strings(a, b string) []*Line {
+	return Lines(SplitLines(a), SplitLines(b))
+}
+
+// Lines 用 Myers 算法计算 a 到 b 的最短编辑序列
+func Lines(a, b []string) []*Line {
+	// 去掉相同的首尾，缩小需要比较的范围
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []*Line
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, &Line{Type: Equal, Text: a[i]})
+	}
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := len(a) - suffix; i < len(a); i++ {
+		lines = append(lines, &Line{Type: Equal, Text: a[i]})
+	}
+
+	number(lines)
+	return lines
+}
+
+func myers(a, b []string) []*Line {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	if max > maxEdits*2 {
+		return replaceAll(a, b)
+	}
+
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= max && d <= maxEdits && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return replaceAll(a, b)
+	}
+
+	// 从终点沿 trace 回溯，得到逆序的编辑序列
+	var reversed []*Line
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, &Line{Type: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, &Line{Type: Insert, Text: b[y-1]})
+		} else {
+			reversed = append(reversed, &Line{Type: Delete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, &Line{Type: Equal, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	lines := make([]*Line, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		lines = append(lines, reversed[i])
+	}
+
+	return lines
+}
+
+func replaceAll(a, b []string) []*Line {
+	lines := make([]*Line, 0, len(a)+len(b))
+	for _, s := range a {
+		lines = append(lines, &Line{Type: Delete, Text: s})
+	}
+	for _, s := range b {
+		lines = append(lines, &Line{Type: Insert, Text: s})
+	}
+
+	return lines
+}
+
+// number 填写每一行在新旧文本中的行号
+func number(lines []*Line) {
+	oldLine, newLine := 0, 0
+	for _, l := range lines {
+		switch l.Type {
+		case Equal:
+			oldLine++
+			newLine++
+			l.OldLine, l.NewLine = oldLine, newLine
+		case Delete:
+			oldLine++
+			l.OldLine = oldLine
+		case Insert:
+			newLine++
+			l.NewLine = newLine
+		}
+	}
+}
+
+// Stat 统计增删行数
+func Stat(lines []*Line) Stats {
+	var s Stats
+	for _, l := range lines {
+		switch l.Type {
+		case Insert:
+			s.Insertions++
+		case Delete:
+			s.Deletions++
+		}
+	}
+
+	return s
+}
+
+// Hunks 把变化按 context 行上下文分组，上下文重叠的变化合并为一段，没有变化时返回空
+func Hunks(lines []*Line, context int) []*Hunk {
+	var ranges [][2]int
+	for i, l := range lines {
+		if l.Type == Equal {
+			continue
+		}
+
+		start, end := i-context, i+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+
+	hunks := make([]*Hunk, 0, len(ranges))
+	oldBefore, newBefore, pos := 0, 0, 0
+	for _, r := range ranges {
+		// 统计这一段之前新旧文本各有多少行
+		for ; pos < r[0]; pos++ {
+			oldBefore, newBefore = advance(lines[pos], oldBefore, newBefore)
+		}
+
+		h := &Hunk{Lines: lines[r[0]:r[1]]}
+		for _, l := range h.Lines {
+			h.OldLines, h.NewLines = advance(l, h.OldLines, h.NewLines)
+		}
+		// 与 unified diff 一致：某一侧为空时，起始行号是它前面的一行
+		h.OldStart, h.NewStart = oldBefore+1, newBefore+1
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+		hunks = append(hunks, h)
+	}
+
+	return hunks
+}
+
+func advance(l *Line, oldLines, newLines int) (int, int) {
+	switch l.Type {
+	case Equal:
+		return oldLines + 1, newLines + 1
+	case Delete:
+		return oldLines + 1, newLines
+	default:
+		return oldLines, newLines + 1
+	}
+}
+
+// Unified 以 unified diff 格式输出
+func Unified(oldName, newName string, lines []*Line, context int) string {
+	hunks := Hunks(lines, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+		for _, l := range h.Lines {
+			switch l.Type {
+			case Equal:
+				sb.WriteString(" ")
+			case Delete:
+				sb.WriteString("-")
+			case Insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(l.Text)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
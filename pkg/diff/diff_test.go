@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// render 把 diff 结果写成 " a" "-b" "+c" 的形式，便于比较
+func render(lines []*Line) string {
+	var s []string
+	for _, l := range lines {
+		s = append(s, map[string]string{Equal: " ", Delete: "-", Insert: "+"}[l.Type]+l.Text)
+	}
+
+	return strings.Join(s, "|")
+}
+
+// apply 用 diff 结果从 a 还原出 b，并检查行号
+func apply(t *testing.T, lines []*Line) (string, string) {
+	var a, b []string
+	for _, l := range lines {
+		if l.Type != Insert {
+			a = append(a, l.Text)
+			if l.OldLine != len(a) {
+				t.Errorf("%q OldLine = %d, want %d", l.Text, l.OldLine, len(a))
+			}
+		}
+		if l.Type != Delete {
+			b = append(b, l.Text)
+			if l.NewLine != len(b) {
+				t.Errorf("%q NewLine = %d, want %d", l.Text, l.NewLine, len(b))
+			}
+		}
+	}
+
+	return strings.Join(a, "\n"), strings.Join(b, "\n")
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\n\nb", []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		if got := SplitLines(tt.s); fmt.Sprint(got) != fmt.Sprint(tt.want) || len(got) != len(tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"a\nb", "a\nb", " a| b"},
+		{"", "a\nb", "+a|+b"},
+		{"a\nb", "", "-a|-b"},
+		{"a\nb\nc", "a\nc", " a|-b| c"},
+		{"a\nc", "a\nb\nc", " a|+b| c"},
+		{"a\nb\nc", "a\nx\nc", " a|-b|+x| c"},
+		{"a\r\nb\r\n", "a\nb", " a| b"},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", "-a|-b| c|+b| a| b|-b| a|+c"},
+	}
+	for _, tt := range tests {
+		lines := Strings(tt.a, tt.b)
+		if got := render(lines); got != tt.want {
+			t.Errorf("Strings(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+		a, b := apply(t, lines)
+		if a != strings.Join(SplitLines(tt.a), "\n") || b != strings.Join(SplitLines(tt.b), "\n") {
+			t.Errorf("Strings(%q, %q) does not reproduce the input: %q, %q", tt.a, tt.b, a, b)
+		}
+	}
+}
+
+func TestLinesReplaceAll(t *testing.T) {
+	// 编辑距离超过 maxEdits 时退化为全部删除后全部插入
+	a, b := make([]string, maxEdits+1), make([]string, maxEdits+1)
+	for i := range a {
+		a[i], b[i] = fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i)
+	}
+	a = append([]string{"same"}, a...)
+	b = append([]string{"same"}, b...)
+
+	lines := Lines(a, b)
+	if s := Stat(lines); s.Insertions != maxEdits+1 || s.Deletions != maxEdits+1 {
+		t.Errorf("Stat = %+v", s)
+	}
+	if lines[0].Type != Equal || lines[1].Type != Delete || lines[len(lines)-1].Type != Insert {
+		t.Errorf("lines = %v %v ... %v", lines[0], lines[1], lines[len(lines)-1])
+	}
+	apply(t, lines)
+}
+
+func TestStat(t *testing.T) {
+	s := Stat(Strings("a\nb\nc", "a\nx\ny\nc"))
+	if s.Insertions != 2 || s.Deletions != 1 {
+		t.Errorf("Stat = %+v, want 2 insertions 1 deletion", s)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprint(i))
+	}
+	b := append([]string(nil), a...)
+	b[1] = "two"                  // 第 2 行
+	b = append(b[:15], b[16:]...) // 删除第 16 行
+	b = append(b, "21")           // 末尾新增
+
+	hunks := Hunks(Lines(a, b), 2)
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+	tests := []struct {
+		oldStart, oldLines, newStart, newLines int
+	}{
+		{1, 4, 1, 4},
+		{14, 7, 14, 7},
+	}
+	for i, tt := range tests {
+		h := hunks[i]
+		if h.OldStart != tt.oldStart || h.OldLines != tt.oldLines || h.NewStart != tt.newStart || h.NewLines != tt.newLines {
+			t.Errorf("hunk %d = -%d,%d +%d,%d, want -%d,%d +%d,%d", i,
+				h.OldStart, h.OldLines, h.NewStart, h.NewLines, tt.oldStart, tt.oldLines, tt.newStart, tt.newLines)
+		}
+	}
+
+	if hunks := Hunks(Strings("a\nb", "a\nb"), 3); len(hunks) != 0 {
+		t.Errorf("Hunks without changes = %d hunks", len(hunks))
+	}
+
+	// 某一侧为空时，起始行号是它前面的一行
+	h := Hunks(Strings("a\nb", "a\nb\nc"), 0)[0]
+	if h.OldStart != 2 || h.OldLines != 0 || h.NewStart != 3 || h.NewLines != 1 {
+		t.Errorf("insert-only hunk = -%d,%d +%d,%d, want -2,0 +3,1", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	got := Unified("a.md", "b.md", Strings("a\nb\nc\nd\n", "a\nB\nc\nd\ne\n"), 1)
+	want := "--- a.md\n+++ b.md\n" +
+		"@@ -1,4 +1,5 @@\n" +
+		" a\n-b\n+B\n c\n d\n+e\n"
+	if got != want {
+		t.Errorf("Unified = %q, want %q", got, want)
+	}
+
+	if got := Unified("a", "b", Strings("x", "x"), 3); got != "" {
+		t.Errorf("Unified without changes = %q", got)
+	}
+}
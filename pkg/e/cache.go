@@ -1,6 +1,8 @@
 package e
 
 const (
-	CACHE_ARTICLE = "ARTICLE"
-	CACHE_TAG     = "TAG"
+	CACHE_ARTICLE  = "ARTICLE"
+	CACHE_TAG      = "TAG"
+	CACHE_CATEGORY = "CATEGORY"
+	CACHE_FEED     = "FEED"
 )
@@ -25,6 +25,75 @@ const (
 	ERROR_GET_ARTICLES_FAIL        = 10017
 	ERROR_GET_ARTICLE_FAIL         = 10018
 	ERROR_GEN_ARTICLE_POSTER_FAIL  = 10019
+	ERROR_SEARCH_ARTICLES_FAIL     = 10025
+	ERROR_GET_TRENDING_FAIL        = 10030
+
+	ERROR_ARTICLE_FORBIDDEN          = 10031
+	ERROR_ARTICLE_INVALID_TRANSITION = 10032
+	ERROR_ARTICLE_TRANSITION_FAIL    = 10033
+	ERROR_NOT_EXIST_REVISION         = 10034
+	ERROR_GET_REVISIONS_FAIL         = 10035
+	ERROR_RESTORE_REVISION_FAIL      = 10036
+
+	ERROR_NOT_EXIST_COMMENT        = 10037
+	ERROR_ADD_COMMENT_FAIL         = 10038
+	ERROR_GET_COMMENTS_FAIL        = 10039
+	ERROR_COMMENT_FORBIDDEN        = 10040
+	ERROR_MODERATE_COMMENT_FAIL    = 10041
+	ERROR_DELETE_COMMENT_FAIL      = 10042
+	ERROR_COMMENT_ARTICLE_NOT_OPEN = 10043
+	ERROR_TOO_MANY_COMMENTS        = 10044
+
+	ERROR_NOT_EXIST_CATEGORY        = 10045
+	ERROR_CHECK_EXIST_CATEGORY_FAIL = 10046
+	ERROR_EXIST_CATEGORY            = 10047
+	ERROR_GET_CATEGORIES_FAIL       = 10048
+	ERROR_ADD_CATEGORY_FAIL         = 10049
+	ERROR_EDIT_CATEGORY_FAIL        = 10050
+	ERROR_DELETE_CATEGORY_FAIL      = 10051
+	ERROR_CATEGORY_INVALID_PARENT   = 10052
+	ERROR_CATEGORY_HAS_CHILDREN     = 10053
+
+	ERROR_GET_FEED_FAIL    = 10054
+	ERROR_GET_SITEMAP_FAIL = 10055
+
+	ERROR_EXIST_ARTICLE_SLUG = 10056
+	ERROR_EXIST_TAG_SLUG     = 10057
+	ERROR_GET_TAG_FAIL       = 10058
+
+	ERROR_NOT_EXIST_UPLOAD       = 10059
+	ERROR_INIT_UPLOAD_FAIL       = 10060
+	ERROR_GET_UPLOAD_FAIL        = 10061
+	ERROR_UPLOAD_CLOSED          = 10062
+	ERROR_UPLOAD_CHUNK_INVALID   = 10063
+	ERROR_UPLOAD_CHUNK_CHECKSUM  = 10064
+	ERROR_SAVE_UPLOAD_CHUNK_FAIL = 10065
+	ERROR_UPLOAD_INCOMPLETE      = 10066
+	ERROR_UPLOAD_CHECKSUM        = 10067
+	ERROR_COMPLETE_UPLOAD_FAIL   = 10068
+	ERROR_ABORT_UPLOAD_FAIL      = 10069
+	ERROR_ATTACHMENT_INVALID     = 10070
+	ERROR_NOT_EXIST_ATTACHMENT   = 10071
+	ERROR_GET_ATTACHMENTS_FAIL   = 10072
+	ERROR_DELETE_ATTACHMENT_FAIL = 10073
+
+	ERROR_NOT_EXIST_POSTER_TEMPLATE   = 10074
+	ERROR_EXIST_POSTER_TEMPLATE       = 10075
+	ERROR_POSTER_TEMPLATE_INVALID     = 10076
+	ERROR_GET_POSTER_TEMPLATES_FAIL   = 10077
+	ERROR_ADD_POSTER_TEMPLATE_FAIL    = 10078
+	ERROR_EDIT_POSTER_TEMPLATE_FAIL   = 10079
+	ERROR_DELETE_POSTER_TEMPLATE_FAIL = 10080
+	ERROR_PREVIEW_ARTICLE_POSTER_FAIL = 10081
+
+	ERROR_CODE_INVALID  = 10082
+	ERROR_GEN_CODE_FAIL = 10083
+
+	ERROR_LABEL_INVALID    = 10084
+	ERROR_LABEL_TOO_SMALL  = 10085
+	ERROR_LABEL_NO_DETAILS = 10086
+	ERROR_LABEL_TOO_MANY   = 10087
+	ERROR_GEN_LABEL_FAIL   = 10088
 
 	ERROR_COUNT_ORDER_FAIL = 10020
 	ERROR_EDIT_ORDER_FAIL  = 10022
@@ -32,6 +101,11 @@ const (
 	ERROR_EXIST     = 10023
 	ERROR_NOT_EXIST = 10024
 
+	ERROR_IDEMPOTENCY_IN_PROGRESS = 10026
+	ERROR_IDEMPOTENCY_KEY_REUSED  = 10027
+	ERROR_TOO_MANY_REQUESTS       = 10028
+	ERROR_TOO_MANY_LOGIN_ATTEMPTS = 10029
+
 	ERROR_AUTH_CHECK_TOKEN_FAIL    = 20001
 	ERROR_AUTH_CHECK_TOKEN_TIMEOUT = 20002
 	ERROR_AUTH_TOKEN               = 20003
@@ -40,4 +114,9 @@ const (
 	ERROR_UPLOAD_SAVE_IMAGE_FAIL    = 30001
 	ERROR_UPLOAD_CHECK_IMAGE_FAIL   = 30002
 	ERROR_UPLOAD_CHECK_IMAGE_FORMAT = 30003
+	ERROR_UPLOAD_IMAGE_TOO_LARGE    = 30004
+	ERROR_UPLOAD_IMAGE_DIMENSIONS   = 30005
+	ERROR_IMAGE_SIGNATURE           = 30006
+	ERROR_NOT_EXIST_IMAGE           = 30007
+	ERROR_PROCESS_IMAGE_FAIL        = 30008
 )
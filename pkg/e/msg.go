@@ -1,39 +1,108 @@
 package e
 
 var MsgFlags = map[int]string{
-	SUCCESS:                         "ok",
-	ERROR:                           "fail",
-	INVALID_PARAMS:                  "请求参数错误",
-	ERROR_EXIST_TAG:                 "已存在该标签名称",
-	ERROR_EXIST_TAG_FAIL:            "获取已存在标签失败",
-	ERROR_NOT_EXIST_TAG:             "该标签不存在",
-	ERROR_GET_TAGS_FAIL:             "获取所有标签失败",
-	ERROR_COUNT_TAG_FAIL:            "统计标签失败",
-	ERROR_COUNT_ORDER_FAIL:          "统计订单失败",
-	ERROR_ADD_TAG_FAIL:              "新增标签失败",
-	ERROR_EDIT_TAG_FAIL:             "修改标签失败",
-	ERROR_DELETE_TAG_FAIL:           "删除标签失败",
-	ERROR_EXPORT_TAG_FAIL:           "导出标签失败",
-	ERROR_IMPORT_TAG_FAIL:           "导入标签失败",
-	ERROR_NOT_EXIST_ARTICLE:         "该文章不存在",
-	ERROR_ADD_ARTICLE_FAIL:          "新增文章失败",
-	ERROR_DELETE_ARTICLE_FAIL:       "删除文章失败",
-	ERROR_CHECK_EXIST_ARTICLE_FAIL:  "检查文章是否存在失败",
-	ERROR_EDIT_ARTICLE_FAIL:         "修改文章失败",
-	ERROR_COUNT_ARTICLE_FAIL:        "统计文章失败",
-	ERROR_GET_ARTICLES_FAIL:         "获取多个文章失败",
-	ERROR_GET_ARTICLE_FAIL:          "获取单个文章失败",
-	ERROR_GEN_ARTICLE_POSTER_FAIL:   "生成文章海报失败",
-	ERROR_AUTH_CHECK_TOKEN_FAIL:     "Token鉴权失败",
-	ERROR_AUTH_CHECK_TOKEN_TIMEOUT:  "Token已超时",
-	ERROR_AUTH_TOKEN:                "Token生成失败",
-	ERROR_AUTH:                      "Token错误",
-	ERROR_UPLOAD_SAVE_IMAGE_FAIL:    "保存图片失败",
-	ERROR_UPLOAD_CHECK_IMAGE_FAIL:   "检查图片失败",
-	ERROR_EDIT_ORDER_FAIL:           "更新订单失败",
-	ERROR_UPLOAD_CHECK_IMAGE_FORMAT: "校验图片错误，图片格式或大小有问题",
-	ERROR_EXIST:                     "该记录已存在",
-	ERROR_NOT_EXIST:                 "该记录不存在",
+	SUCCESS:                           "ok",
+	ERROR:                             "fail",
+	INVALID_PARAMS:                    "请求参数错误",
+	ERROR_EXIST_TAG:                   "已存在该标签名称",
+	ERROR_EXIST_TAG_FAIL:              "获取已存在标签失败",
+	ERROR_NOT_EXIST_TAG:               "该标签不存在",
+	ERROR_GET_TAGS_FAIL:               "获取所有标签失败",
+	ERROR_COUNT_TAG_FAIL:              "统计标签失败",
+	ERROR_COUNT_ORDER_FAIL:            "统计订单失败",
+	ERROR_ADD_TAG_FAIL:                "新增标签失败",
+	ERROR_EDIT_TAG_FAIL:               "修改标签失败",
+	ERROR_DELETE_TAG_FAIL:             "删除标签失败",
+	ERROR_EXPORT_TAG_FAIL:             "导出标签失败",
+	ERROR_IMPORT_TAG_FAIL:             "导入标签失败",
+	ERROR_NOT_EXIST_ARTICLE:           "该文章不存在",
+	ERROR_ADD_ARTICLE_FAIL:            "新增文章失败",
+	ERROR_DELETE_ARTICLE_FAIL:         "删除文章失败",
+	ERROR_CHECK_EXIST_ARTICLE_FAIL:    "检查文章是否存在失败",
+	ERROR_EDIT_ARTICLE_FAIL:           "修改文章失败",
+	ERROR_COUNT_ARTICLE_FAIL:          "统计文章失败",
+	ERROR_GET_ARTICLES_FAIL:           "获取多个文章失败",
+	ERROR_GET_ARTICLE_FAIL:            "获取单个文章失败",
+	ERROR_GEN_ARTICLE_POSTER_FAIL:     "生成文章海报失败",
+	ERROR_SEARCH_ARTICLES_FAIL:        "搜索文章失败",
+	ERROR_AUTH_CHECK_TOKEN_FAIL:       "Token鉴权失败",
+	ERROR_AUTH_CHECK_TOKEN_TIMEOUT:    "Token已超时",
+	ERROR_AUTH_TOKEN:                  "Token生成失败",
+	ERROR_AUTH:                        "Token错误",
+	ERROR_UPLOAD_SAVE_IMAGE_FAIL:      "保存图片失败",
+	ERROR_UPLOAD_CHECK_IMAGE_FAIL:     "检查图片失败",
+	ERROR_EDIT_ORDER_FAIL:             "更新订单失败",
+	ERROR_UPLOAD_CHECK_IMAGE_FORMAT:   "校验图片错误，图片格式或大小有问题",
+	ERROR_UPLOAD_IMAGE_TOO_LARGE:      "图片文件太大",
+	ERROR_UPLOAD_IMAGE_DIMENSIONS:     "图片尺寸超出限制",
+	ERROR_IMAGE_SIGNATURE:             "图片地址签名无效",
+	ERROR_NOT_EXIST_IMAGE:             "图片不存在",
+	ERROR_PROCESS_IMAGE_FAIL:          "处理图片失败",
+	ERROR_EXIST:                       "该记录已存在",
+	ERROR_NOT_EXIST:                   "该记录不存在",
+	ERROR_IDEMPOTENCY_IN_PROGRESS:     "相同的请求正在处理中",
+	ERROR_IDEMPOTENCY_KEY_REUSED:      "Idempotency-Key 已用于其他请求",
+	ERROR_TOO_MANY_REQUESTS:           "请求过于频繁，请稍后再试",
+	ERROR_TOO_MANY_LOGIN_ATTEMPTS:     "登录尝试次数过多，请稍后再试",
+	ERROR_GET_TRENDING_FAIL:           "获取热门文章失败",
+	ERROR_ARTICLE_FORBIDDEN:           "没有权限执行该操作",
+	ERROR_ARTICLE_INVALID_TRANSITION:  "文章当前状态不允许该操作",
+	ERROR_ARTICLE_TRANSITION_FAIL:     "变更文章状态失败",
+	ERROR_NOT_EXIST_REVISION:          "修订版本不存在",
+	ERROR_GET_REVISIONS_FAIL:          "获取修订记录失败",
+	ERROR_RESTORE_REVISION_FAIL:       "恢复修订版本失败",
+	ERROR_NOT_EXIST_COMMENT:           "评论不存在",
+	ERROR_ADD_COMMENT_FAIL:            "发表评论失败",
+	ERROR_GET_COMMENTS_FAIL:           "获取评论失败",
+	ERROR_COMMENT_FORBIDDEN:           "没有审核评论的权限",
+	ERROR_MODERATE_COMMENT_FAIL:       "审核评论失败",
+	ERROR_DELETE_COMMENT_FAIL:         "删除评论失败",
+	ERROR_COMMENT_ARTICLE_NOT_OPEN:    "文章未发布，不能评论",
+	ERROR_TOO_MANY_COMMENTS:           "评论太频繁，请稍后再试",
+	ERROR_NOT_EXIST_CATEGORY:          "该分类不存在",
+	ERROR_CHECK_EXIST_CATEGORY_FAIL:   "检查分类是否存在失败",
+	ERROR_EXIST_CATEGORY:              "同级已存在该分类名称",
+	ERROR_GET_CATEGORIES_FAIL:         "获取分类失败",
+	ERROR_ADD_CATEGORY_FAIL:           "新增分类失败",
+	ERROR_EDIT_CATEGORY_FAIL:          "修改分类失败",
+	ERROR_DELETE_CATEGORY_FAIL:        "删除分类失败",
+	ERROR_CATEGORY_INVALID_PARENT:     "上级分类不存在或不能是自己的下级",
+	ERROR_CATEGORY_HAS_CHILDREN:       "分类下还有子分类，不能删除",
+	ERROR_GET_FEED_FAIL:               "生成订阅源失败",
+	ERROR_GET_SITEMAP_FAIL:            "生成 sitemap 失败",
+	ERROR_EXIST_ARTICLE_SLUG:          "已存在该文章 slug",
+	ERROR_EXIST_TAG_SLUG:              "已存在该标签 slug",
+	ERROR_GET_TAG_FAIL:                "获取标签失败",
+	ERROR_NOT_EXIST_UPLOAD:            "上传不存在",
+	ERROR_INIT_UPLOAD_FAIL:            "创建上传失败",
+	ERROR_GET_UPLOAD_FAIL:             "获取上传进度失败",
+	ERROR_UPLOAD_CLOSED:               "上传已完成、已取消或已过期",
+	ERROR_UPLOAD_CHUNK_INVALID:        "分片序号或大小错误",
+	ERROR_UPLOAD_CHUNK_CHECKSUM:       "分片校验和不一致",
+	ERROR_SAVE_UPLOAD_CHUNK_FAIL:      "保存分片失败",
+	ERROR_UPLOAD_INCOMPLETE:           "还有分片没有上传",
+	ERROR_UPLOAD_CHECKSUM:             "文件 SHA-256 不一致",
+	ERROR_COMPLETE_UPLOAD_FAIL:        "完成上传失败",
+	ERROR_ABORT_UPLOAD_FAIL:           "取消上传失败",
+	ERROR_ATTACHMENT_INVALID:          "附件所属对象不存在，或文件类型、大小不允许",
+	ERROR_NOT_EXIST_ATTACHMENT:        "附件不存在",
+	ERROR_GET_ATTACHMENTS_FAIL:        "获取附件失败",
+	ERROR_DELETE_ATTACHMENT_FAIL:      "删除附件失败",
+	ERROR_NOT_EXIST_POSTER_TEMPLATE:   "海报模板不存在",
+	ERROR_EXIST_POSTER_TEMPLATE:       "已存在该海报模板名称",
+	ERROR_POSTER_TEMPLATE_INVALID:     "海报模板的尺寸、格式或图层配置错误",
+	ERROR_GET_POSTER_TEMPLATES_FAIL:   "获取海报模板失败",
+	ERROR_ADD_POSTER_TEMPLATE_FAIL:    "新增海报模板失败",
+	ERROR_EDIT_POSTER_TEMPLATE_FAIL:   "修改海报模板失败",
+	ERROR_DELETE_POSTER_TEMPLATE_FAIL: "删除海报模板失败",
+	ERROR_PREVIEW_ARTICLE_POSTER_FAIL: "预览文章海报失败",
+	ERROR_CODE_INVALID:                "条码类型、格式或尺寸错误，或内容不能用该类型编码",
+	ERROR_GEN_CODE_FAIL:               "生成条码失败",
+	ERROR_LABEL_INVALID:               "标签纸、条码类型或字段错误，或明细内容不能用该条码类型编码",
+	ERROR_LABEL_TOO_SMALL:             "标签太小，放不下条码",
+	ERROR_LABEL_NO_DETAILS:            "没有可以打印的产品明细",
+	ERROR_LABEL_TOO_MANY:              "标签数量超过上限",
+	ERROR_GEN_LABEL_FAIL:              "生成标签失败",
 }
 
 // GetMsg get error information based on Code
@@ -0,0 +1,367 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Aggregation 聚合定义
+type Aggregation interface {
+	Source() map[string]interface{}
+}
+
+// bucketAggregation 可挂载子聚合的桶聚合
+type bucketAggregation struct {
+	typ    string
+	params map[string]interface{}
+	subs   map[string]Aggregation
+}
+
+func newBucketAggregation(typ string) *bucketAggregation {
+	return &bucketAggregation{typ: typ, params: map[string]interface{}{}}
+}
+
+// Source 返回聚合 DSL
+func (a *bucketAggregation) Source() map[string]interface{} {
+	source := map[string]interface{}{a.typ: a.params}
+	if len(a.subs) > 0 {
+		source["aggs"] = aggregationSources(a.subs)
+	}
+
+	return source
+}
+
+func (a *bucketAggregation) addSub(name string, agg Aggregation) {
+	if a.subs == nil {
+		a.subs = map[string]Aggregation{}
+	}
+	a.subs[name] = agg
+}
+
+// TermsAggregation 按字段值分桶
+type TermsAggregation struct {
+	*bucketAggregation
+}
+
+// TermsAgg 创建 terms 聚合，size 为返回的桶数量
+func TermsAgg(field string, size int) *TermsAggregation {
+	a := &TermsAggregation{newBucketAggregation("terms")}
+	a.params["field"] = field
+	if size > 0 {
+		a.params["size"] = size
+	}
+
+	return a
+}
+
+// OrderBy 设置桶排序，例如 ("_count", "desc")
+func (a *TermsAggregation) OrderBy(key, order string) *TermsAggregation {
+	a.params["order"] = map[string]string{key: order}
+	return a
+}
+
+// SubAgg 添加子聚合
+func (a *TermsAggregation) SubAgg(name string, agg Aggregation) *TermsAggregation {
+	a.addSub(name, agg)
+	return a
+}
+
+// HistogramAggregation 数值区间直方图
+type HistogramAggregation struct {
+	*bucketAggregation
+}
+
+// HistogramAgg 创建 histogram 聚合
+func HistogramAgg(field string, interval float64) *HistogramAggregation {
+	a := &HistogramAggregation{newBucketAggregation("histogram")}
+	a.params["field"] = field
+	a.params["interval"] = interval
+
+	return a
+}
+
+// MinDocCount 设置桶的最小文档数，0 表示返回空桶
+func (a *HistogramAggregation) MinDocCount(n int) *HistogramAggregation {
+	a.params["min_doc_count"] = n
+	return a
+}
+
+// SubAgg 添加子聚合
+func (a *HistogramAggregation) SubAgg(name string, agg Aggregation) *HistogramAggregation {
+	a.addSub(name, agg)
+	return a
+}
+
+// DateHistogramAggregation 日期直方图
+type DateHistogramAggregation struct {
+	*bucketAggregation
+}
+
+// DateHistogramAgg 创建 date_histogram 聚合，interval 例如 day / month
+func DateHistogramAgg(field, interval string) *DateHistogramAggregation {
+	a := &DateHistogramAggregation{newBucketAggregation("date_histogram")}
+	a.params["field"] = field
+	a.params["calendar_interval"] = interval
+
+	return a
+}
+
+// Format 设置 key_as_string 的日期格式
+func (a *DateHistogramAggregation) Format(format string) *DateHistogramAggregation {
+	a.params["format"] = format
+	return a
+}
+
+// SubAgg 添加子聚合
+func (a *DateHistogramAggregation) SubAgg(name string, agg Aggregation) *DateHistogramAggregation {
+	a.addSub(name, agg)
+	return a
+}
+
+// RangeAggregation 自定义区间分桶
+type RangeAggregation struct {
+	*bucketAggregation
+	ranges []map[string]interface{}
+}
+
+// RangeAgg 创建 range 聚合
+func RangeAgg(field string) *RangeAggregation {
+	a := &RangeAggregation{bucketAggregation: newBucketAggregation("range")}
+	a.params["field"] = field
+
+	return a
+}
+
+// AddRange 添加区间 [from, to)，nil 表示不限
+func (a *RangeAggregation) AddRange(key string, from, to interface{}) *RangeAggregation {
+	r := map[string]interface{}{}
+	if key != "" {
+		r["key"] = key
+	}
+	if from != nil {
+		r["from"] = from
+	}
+	if to != nil {
+		r["to"] = to
+	}
+	a.ranges = append(a.ranges, r)
+	a.params["ranges"] = a.ranges
+
+	return a
+}
+
+// SubAgg 添加子聚合
+func (a *RangeAggregation) SubAgg(name string, agg Aggregation) *RangeAggregation {
+	a.addSub(name, agg)
+	return a
+}
+
+// NestedAggregation 进入 nested 对象后再聚合
+type NestedAggregation struct {
+	*bucketAggregation
+}
+
+// NestedAgg 创建 nested 聚合
+func NestedAgg(path string) *NestedAggregation {
+	a := &NestedAggregation{newBucketAggregation("nested")}
+	a.params["path"] = path
+
+	return a
+}
+
+// SubAgg 添加子聚合
+func (a *NestedAggregation) SubAgg(name string, agg Aggregation) *NestedAggregation {
+	a.addSub(name, agg)
+	return a
+}
+
+// FilterAggregation 只对满足条件的文档聚合
+type FilterAggregation struct {
+	filter Query
+	subs   map[string]Aggregation
+}
+
+// FilterAgg 创建 filter 聚合
+func FilterAgg(filter Query) *FilterAggregation {
+	return &FilterAggregation{filter: filter}
+}
+
+// SubAgg 添加子聚合
+func (a *FilterAggregation) SubAgg(name string, agg Aggregation) *FilterAggregation {
+	if a.subs == nil {
+		a.subs = map[string]Aggregation{}
+	}
+	a.subs[name] = agg
+	return a
+}
+
+// Source 返回 filter 聚合 DSL
+func (a *FilterAggregation) Source() map[string]interface{} {
+	source := map[string]interface{}{"filter": a.filter.Source()}
+	if len(a.subs) > 0 {
+		source["aggs"] = aggregationSources(a.subs)
+	}
+
+	return source
+}
+
+// metricAggregation 指标聚合
+type metricAggregation struct {
+	typ   string
+	field string
+}
+
+// Source 返回指标聚合 DSL
+func (a *metricAggregation) Source() map[string]interface{} {
+	return map[string]interface{}{a.typ: map[string]interface{}{"field": a.field}}
+}
+
+// MinAgg 最小值
+func MinAgg(field string) Aggregation { return &metricAggregation{typ: "min", field: field} }
+
+// MaxAgg 最大值
+func MaxAgg(field string) Aggregation { return &metricAggregation{typ: "max", field: field} }
+
+// AvgAgg 平均值
+func AvgAgg(field string) Aggregation { return &metricAggregation{typ: "avg", field: field} }
+
+// SumAgg 求和
+func SumAgg(field string) Aggregation { return &metricAggregation{typ: "sum", field: field} }
+
+// CardinalityAgg 去重计数
+func CardinalityAgg(field string) Aggregation {
+	return &metricAggregation{typ: "cardinality", field: field}
+}
+
+func aggregationSources(aggs map[string]Aggregation) map[string]interface{} {
+	sources := make(map[string]interface{}, len(aggs))
+	for name, agg := range aggs {
+		sources[name] = agg.Source()
+	}
+
+	return sources
+}
+
+// Aggregations 搜索结果中的聚合部分，按名称延迟解析
+type Aggregations map[string]json.RawMessage
+
+// Bucket 桶聚合结果中的一个桶
+type Bucket struct {
+	Key          interface{}  `json:"key"`
+	KeyAsString  string       `json:"key_as_string,omitempty"`
+	DocCount     int64        `json:"doc_count"`
+	From         *float64     `json:"from,omitempty"`
+	To           *float64     `json:"to,omitempty"`
+	Aggregations Aggregations `json:"aggregations,omitempty"`
+}
+
+var bucketReservedKeys = map[string]bool{
+	"key":            true,
+	"key_as_string":  true,
+	"doc_count":      true,
+	"from":           true,
+	"from_as_string": true,
+	"to":             true,
+	"to_as_string":   true,
+}
+
+// UnmarshalJSON 解析桶，非保留字段视为子聚合
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	type bucket Bucket
+	var v bucket
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	v.Aggregations = nil
+
+	for k, msg := range raw {
+		if bucketReservedKeys[k] {
+			continue
+		}
+		if v.Aggregations == nil {
+			v.Aggregations = Aggregations{}
+		}
+		v.Aggregations[k] = msg
+	}
+
+	*b = Bucket(v)
+	return nil
+}
+
+// Buckets 解析 terms / histogram / range 等桶聚合
+func (a Aggregations) Buckets(name string) ([]Bucket, error) {
+	msg, ok := a[name]
+	if !ok {
+		return nil, fmt.Errorf("aggregation %s not found", name)
+	}
+
+	var r struct {
+		Buckets json.RawMessage `json:"buckets"`
+	}
+	if err := json.Unmarshal(msg, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode aggregation %s: %w", name, err)
+	}
+	if len(r.Buckets) == 0 {
+		return []Bucket{}, nil
+	}
+
+	// keyed 聚合返回对象而不是数组
+	if r.Buckets[0] == '{' {
+		var keyed map[string]Bucket
+		if err := json.Unmarshal(r.Buckets, &keyed); err != nil {
+			return nil, fmt.Errorf("failed to decode buckets of %s: %w", name, err)
+		}
+		buckets := make([]Bucket, 0, len(keyed))
+		for k, b := range keyed {
+			if b.Key == nil {
+				b.Key = k
+			}
+			buckets = append(buckets, b)
+		}
+		return buckets, nil
+	}
+
+	var buckets []Bucket
+	if err := json.Unmarshal(r.Buckets, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode buckets of %s: %w", name, err)
+	}
+
+	return buckets, nil
+}
+
+// Single 解析 nested / filter 等单桶聚合，返回文档数及其子聚合
+func (a Aggregations) Single(name string) (*Bucket, error) {
+	msg, ok := a[name]
+	if !ok {
+		return nil, fmt.Errorf("aggregation %s not found", name)
+	}
+
+	var b Bucket
+	if err := json.Unmarshal(msg, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode aggregation %s: %w", name, err)
+	}
+
+	return &b, nil
+}
+
+// Value 解析 min / max / avg / sum / cardinality 等指标聚合，无数据时返回 false
+func (a Aggregations) Value(name string) (float64, bool) {
+	msg, ok := a[name]
+	if !ok {
+		return 0, false
+	}
+
+	var r struct {
+		Value *float64 `json:"value"`
+	}
+	if err := json.Unmarshal(msg, &r); err != nil || r.Value == nil {
+		return 0, false
+	}
+
+	return *r.Value, true
+}
@@ -0,0 +1,124 @@
+package es
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+func TestAggregationSource(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"terms", TermsAgg("brand", 0), `{"terms":{"field":"brand"}}`},
+		{
+			"terms with sub",
+			TermsAgg("brand", 10).OrderBy("_count", "desc").SubAgg("avg_price", AvgAgg("price")),
+			`{"aggs":{"avg_price":{"avg":{"field":"price"}}},"terms":{"field":"brand","order":{"_count":"desc"},"size":10}}`,
+		},
+		{"histogram", HistogramAgg("price", 100).MinDocCount(0), `{"histogram":{"field":"price","interval":100,"min_doc_count":0}}`},
+		{
+			"date_histogram",
+			DateHistogramAgg("created_on", "month").Format("yyyy-MM"),
+			`{"date_histogram":{"calendar_interval":"month","field":"created_on","format":"yyyy-MM"}}`,
+		},
+		{
+			"range",
+			RangeAgg("price").AddRange("cheap", nil, 100).AddRange("", 100, nil),
+			`{"range":{"field":"price","ranges":[{"key":"cheap","to":100},{"from":100}]}}`,
+		},
+		{
+			"nested",
+			NestedAgg("attrs").SubAgg("names", TermsAgg("attrs.name", 5)),
+			`{"aggs":{"names":{"terms":{"field":"attrs.name","size":5}}},"nested":{"path":"attrs"}}`,
+		},
+		{
+			"filter",
+			FilterAgg(Term("state", 1)).SubAgg("max", MaxAgg("price")),
+			`{"aggs":{"max":{"max":{"field":"price"}}},"filter":{"term":{"state":1}}}`,
+		},
+		{"cardinality", CardinalityAgg("user_id"), `{"cardinality":{"field":"user_id"}}`},
+	}
+	for _, tt := range tests {
+		if got := compact(tt.agg.Source()); got != tt.want {
+			t.Errorf("%s: Source() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAggregationsDecode(t *testing.T) {
+	var aggs Aggregations
+	err := json.Unmarshal([]byte(`{
+		"brands": {"buckets": [
+			{"key": "apple", "doc_count": 3, "avg_price": {"value": 5.5}},
+			{"key": "pear", "doc_count": 1, "avg_price": {"value": null}}
+		]},
+		"prices": {"buckets": {
+			"cheap": {"to": 100, "doc_count": 2},
+			"*-100.0": {"key": "custom", "doc_count": 1}
+		}},
+		"empty": {"buckets": []},
+		"attrs": {"doc_count": 7, "names": {"buckets": [{"key": "color", "doc_count": 4}]}},
+		"max_price": {"value": 99},
+		"min_price": {"value": null}
+	}`), &aggs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	brands, err := aggs.Buckets("brands")
+	if err != nil || len(brands) != 2 {
+		t.Fatalf("Buckets(brands) = %v, %v", brands, err)
+	}
+	if brands[0].Key != "apple" || brands[0].DocCount != 3 {
+		t.Errorf("brands[0] = %+v", brands[0])
+	}
+	if v, ok := brands[0].Aggregations.Value("avg_price"); !ok || v != 5.5 {
+		t.Errorf("brands[0] avg_price = %v, %v", v, ok)
+	}
+	if _, ok := brands[1].Aggregations.Value("avg_price"); ok {
+		t.Error("null avg_price reported as a value")
+	}
+	if _, ok := brands[0].Aggregations["doc_count"]; ok {
+		t.Error("doc_count decoded as a sub aggregation")
+	}
+
+	// keyed 桶使用对象的键作为 key，桶自带 key 时保留
+	prices, err := aggs.Buckets("prices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, b := range prices {
+		keys = append(keys, b.Key.(string))
+	}
+	sort.Strings(keys)
+	if compact(keys) != `["cheap","custom"]` {
+		t.Errorf("keyed bucket keys = %v", keys)
+	}
+
+	if empty, err := aggs.Buckets("empty"); err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("Buckets(empty) = %v, %v", empty, err)
+	}
+	if _, err := aggs.Buckets("missing"); err == nil {
+		t.Error("Buckets(missing) err = nil")
+	}
+
+	attrs, err := aggs.Single("attrs")
+	if err != nil || attrs.DocCount != 7 {
+		t.Fatalf("Single(attrs) = %+v, %v", attrs, err)
+	}
+	names, err := attrs.Aggregations.Buckets("names")
+	if err != nil || len(names) != 1 || names[0].Key != "color" {
+		t.Errorf("attrs names = %+v, %v", names, err)
+	}
+
+	if v, ok := aggs.Value("max_price"); !ok || v != 99 {
+		t.Errorf("Value(max_price) = %v, %v", v, ok)
+	}
+	if _, ok := aggs.Value("min_price"); ok {
+		t.Error("Value(min_price) with null ok = true")
+	}
+}
@@ -0,0 +1,228 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// IndexExists 判断索引（或别名）是否存在
+func IndexExists(index string) (bool, error) {
+	req := esapi.IndicesExistsRequest{
+		Index: []string{index},
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return false, fmt.Errorf("indices exists request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case 200:
+		return true, nil
+	case 404:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(res.Body)
+		return false, fmt.Errorf("error checking index=%s: %s", index, string(body))
+	}
+}
+
+// CreateIndex 创建索引，body 为 settings/mappings/aliases 定义
+func CreateIndex(index string, body map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode index body: %w", err)
+	}
+
+	req := esapi.IndicesCreateRequest{
+		Index: index,
+		Body:  &buf,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("create index request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error creating index=%s: %s", index, string(body))
+	}
+
+	return nil
+}
+
+// DeleteIndex 删除索引
+func DeleteIndex(index string) error {
+	req := esapi.IndicesDeleteRequest{
+		Index: []string{index},
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("delete index request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error deleting index=%s: %s", index, string(body))
+	}
+
+	return nil
+}
+
+// GetAliasIndices 获取别名当前指向的索引，别名不存在时返回空
+func GetAliasIndices(alias string) ([]string, error) {
+	req := esapi.IndicesGetAliasRequest{
+		Name: []string{alias},
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return nil, fmt.Errorf("get alias request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error getting alias=%s: %s", alias, string(body))
+	}
+
+	var r map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode alias response: %w", err)
+	}
+
+	indices := make([]string, 0, len(r))
+	for index := range r {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+
+	return indices, nil
+}
+
+// SwapAlias 原子地把别名从旧索引切换到 newIndex，并设为写索引
+func SwapAlias(alias, newIndex string) error {
+	oldIndices, err := GetAliasIndices(alias)
+	if err != nil {
+		return err
+	}
+
+	actions := make([]map[string]interface{}, 0, len(oldIndices)+1)
+	for _, index := range oldIndices {
+		if index == newIndex {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": index, "alias": alias},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": newIndex, "alias": alias, "is_write_index": true},
+	})
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"actions": actions}); err != nil {
+		return fmt.Errorf("failed to encode alias actions: %w", err)
+	}
+
+	req := esapi.IndicesUpdateAliasesRequest{
+		Body: &buf,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("update aliases request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error swapping alias=%s to index=%s: %s", alias, newIndex, string(body))
+	}
+
+	return nil
+}
+
+// Reindex 把 source 的全部文档复制到 dest，同步等待完成并返回复制条数
+func Reindex(source, dest string) (int64, error) {
+	body := map[string]interface{}{
+		"source": map[string]interface{}{"index": source},
+		"dest":   map[string]interface{}{"index": dest},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return 0, fmt.Errorf("failed to encode reindex body: %w", err)
+	}
+
+	waitForCompletion := true
+	refresh := true
+	req := esapi.ReindexRequest{
+		Body:              &buf,
+		WaitForCompletion: &waitForCompletion,
+		Refresh:           &refresh,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return 0, fmt.Errorf("reindex request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return 0, fmt.Errorf("error reindexing %s to %s: %s", source, dest, string(body))
+	}
+
+	var r struct {
+		Total    int64             `json:"total"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, fmt.Errorf("failed to decode reindex response: %w", err)
+	}
+	if len(r.Failures) > 0 {
+		return r.Total, fmt.Errorf("reindex %s to %s finished with %d failures: %s", source, dest, len(r.Failures), string(r.Failures[0]))
+	}
+
+	return r.Total, nil
+}
+
+// ReindexWithAlias 零停机重建索引：创建 newIndex，从别名当前索引复制数据，再切换别名
+// 别名尚不存在时，若存在同名的旧索引则会报错，需要先手工迁移
+func ReindexWithAlias(alias, newIndex string, body map[string]interface{}) error {
+	oldIndices, err := GetAliasIndices(alias)
+	if err != nil {
+		return err
+	}
+
+	exists, err := IndexExists(newIndex)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := CreateIndex(newIndex, body); err != nil {
+			return err
+		}
+	}
+
+	for _, index := range oldIndices {
+		if index == newIndex {
+			continue
+		}
+		if _, err := Reindex(index, newIndex); err != nil {
+			return err
+		}
+	}
+
+	return SwapAlias(alias, newIndex)
+}
@@ -0,0 +1,128 @@
+package es
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeIndices 模拟索引、映射和别名相关的接口
+type fakeIndices struct {
+	indices  map[string]bool
+	aliases  map[string][]string
+	mappings map[string]interface{} // 索引 => 映射
+	reindex  []string
+	actions  []string
+	puts     []string // PutMapping 的索引
+	deleted  []string
+	failures []interface{}
+}
+
+func (f *fakeIndices) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "_alias/"):
+		indices, ok := f.aliases[strings.TrimPrefix(path, "_alias/")]
+		if !ok {
+			writeJSON(w, 404, map[string]interface{}{"error": "alias missing"})
+			return
+		}
+		body := map[string]interface{}{}
+		for _, index := range indices {
+			body[index] = map[string]interface{}{"aliases": map[string]interface{}{}}
+		}
+		writeJSON(w, 200, body)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/_mapping"):
+		index := strings.TrimSuffix(path, "/_mapping")
+		writeJSON(w, 200, map[string]interface{}{index: map[string]interface{}{"mappings": f.mappings[index]}})
+	case r.Method == http.MethodPut && strings.HasSuffix(path, "/_mapping"):
+		index := strings.TrimSuffix(path, "/_mapping")
+		f.puts = append(f.puts, index)
+		f.mappings[index] = mergeMaps(f.mappings[index].(map[string]interface{}), readJSON(r))
+		writeJSON(w, 200, map[string]interface{}{"acknowledged": true})
+	case r.Method == http.MethodHead:
+		if f.indices[path] {
+			w.WriteHeader(200)
+		} else {
+			w.WriteHeader(404)
+		}
+	case r.Method == http.MethodPut:
+		f.indices[path] = true
+		if f.mappings != nil {
+			f.mappings[path] = readJSON(r)["mappings"]
+		}
+		writeJSON(w, 200, map[string]interface{}{"acknowledged": true})
+	case r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, path)
+		delete(f.indices, path)
+		writeJSON(w, 200, map[string]interface{}{"acknowledged": true})
+	case r.Method == http.MethodPost && path == "_reindex":
+		body := readJSON(r)
+		f.reindex = append(f.reindex, compact(body["source"])+"->"+compact(body["dest"]))
+		writeJSON(w, 200, map[string]interface{}{"total": 3, "failures": f.failures})
+	case r.Method == http.MethodPost && path == "_aliases":
+		for _, action := range readJSON(r)["actions"].([]interface{}) {
+			f.actions = append(f.actions, compact(action))
+		}
+		writeJSON(w, 200, map[string]interface{}{"acknowledged": true})
+	default:
+		http.Error(w, r.Method+" "+r.URL.Path, 400)
+	}
+}
+
+func TestReindexWithAlias(t *testing.T) {
+	f := &fakeIndices{
+		indices: map[string]bool{"products_v1": true},
+		aliases: map[string][]string{"products": {"products_v1"}},
+	}
+	newTestClient(t, f.ServeHTTP)
+
+	if err := ReindexWithAlias("products", "products_v2", map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.indices["products_v2"] {
+		t.Error("products_v2 was not created")
+	}
+	if want := []string{`{"index":"products_v1"}->{"index":"products_v2"}`}; compact(f.reindex) != compact(want) {
+		t.Errorf("reindex = %v, want %v", f.reindex, want)
+	}
+	want := []string{
+		`{"remove":{"alias":"products","index":"products_v1"}}`,
+		`{"add":{"alias":"products","index":"products_v2","is_write_index":true}}`,
+	}
+	if compact(f.actions) != compact(want) {
+		t.Errorf("alias actions = %v, want %v", f.actions, want)
+	}
+}
+
+func TestReindexWithAliasFirstRun(t *testing.T) {
+	f := &fakeIndices{indices: map[string]bool{}, aliases: map[string][]string{}}
+	newTestClient(t, f.ServeHTTP)
+
+	if err := ReindexWithAlias("products", "products_v1", map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.reindex) != 0 {
+		t.Errorf("reindex = %v, want none", f.reindex)
+	}
+	want := []string{`{"add":{"alias":"products","index":"products_v1","is_write_index":true}}`}
+	if compact(f.actions) != compact(want) {
+		t.Errorf("alias actions = %v, want %v", f.actions, want)
+	}
+}
+
+func TestReindexFailures(t *testing.T) {
+	f := &fakeIndices{
+		indices:  map[string]bool{"products_v1": true},
+		aliases:  map[string][]string{"products": {"products_v1"}},
+		failures: []interface{}{map[string]interface{}{"id": "1", "cause": "mapper_parsing_exception"}},
+	}
+	newTestClient(t, f.ServeHTTP)
+
+	if err := ReindexWithAlias("products", "products_v2", map[string]interface{}{}); err == nil {
+		t.Fatal("ReindexWithAlias() with reindex failures err = nil")
+	}
+	if len(f.actions) != 0 {
+		t.Errorf("alias swapped after failed reindex: %v", f.actions)
+	}
+}
@@ -0,0 +1,118 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// 分词器配置方案
+const (
+	AnalyzerAuto     = "auto"
+	AnalyzerIK       = "ik"
+	AnalyzerSmartCN  = "smartcn"
+	AnalyzerStandard = "standard"
+)
+
+// 映射文件中引用的中文分词器名称，实际定义由 AnalysisSettings 按方案生成
+const (
+	ChineseIndexAnalyzer  = "chinese_index"
+	ChineseSearchAnalyzer = "chinese_search"
+)
+
+// AnalysisSettings 返回指定方案下的 analysis 配置
+func AnalysisSettings(profile string) map[string]interface{} {
+	var indexAnalyzer, searchAnalyzer map[string]interface{}
+
+	switch profile {
+	case AnalyzerIK:
+		// ik_max_word 建索引时细粒度切分，ik_smart 查询时粗粒度切分
+		indexAnalyzer = map[string]interface{}{"type": "custom", "tokenizer": "ik_max_word"}
+		searchAnalyzer = map[string]interface{}{"type": "custom", "tokenizer": "ik_smart"}
+	case AnalyzerSmartCN:
+		indexAnalyzer = map[string]interface{}{"type": "custom", "tokenizer": "smartcn_tokenizer"}
+		searchAnalyzer = indexAnalyzer
+	default:
+		indexAnalyzer = map[string]interface{}{"type": "standard"}
+		searchAnalyzer = indexAnalyzer
+	}
+
+	return map[string]interface{}{
+		"analyzer": map[string]interface{}{
+			ChineseIndexAnalyzer:  indexAnalyzer,
+			ChineseSearchAnalyzer: searchAnalyzer,
+		},
+	}
+}
+
+// ResolveAnalyzer 确定实际使用的分词方案，auto 时根据集群已安装的插件选择
+func ResolveAnalyzer(profile string) (string, error) {
+	switch profile {
+	case AnalyzerIK, AnalyzerSmartCN, AnalyzerStandard:
+		return profile, nil
+	case "", AnalyzerAuto:
+	default:
+		return "", fmt.Errorf("unknown analyzer profile: %s", profile)
+	}
+
+	plugins, err := installedPlugins()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case plugins["analysis-ik"]:
+		return AnalyzerIK, nil
+	case plugins["analysis-smartcn"]:
+		return AnalyzerSmartCN, nil
+	default:
+		return AnalyzerStandard, nil
+	}
+}
+
+// installedPlugins 返回所有节点都安装了的插件
+func installedPlugins() (map[string]bool, error) {
+	req := esapi.NodesInfoRequest{
+		Metric: []string{"plugins"},
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return nil, fmt.Errorf("nodes info request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error getting nodes plugins: %s", string(body))
+	}
+
+	var r struct {
+		Nodes map[string]struct {
+			Plugins []struct {
+				Name string `json:"name"`
+			} `json:"plugins"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode nodes info response: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, node := range r.Nodes {
+		for _, plugin := range node.Plugins {
+			counts[plugin.Name]++
+		}
+	}
+
+	plugins := make(map[string]bool)
+	for name, n := range counts {
+		if n == len(r.Nodes) {
+			plugins[name] = true
+		}
+	}
+
+	return plugins, nil
+}
@@ -0,0 +1,170 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+)
+
+// BulkOptions 批量写入配置
+type BulkOptions struct {
+	Index         string        // 默认索引，可被单条文档覆盖
+	NumWorkers    int           // 并发 worker 数，默认 runtime.NumCPU()
+	FlushBytes    int           // 缓冲区达到多少字节时刷新，默认 5MB
+	FlushInterval time.Duration // 间隔多久强制刷新一次，默认 30s
+	Refresh       string        // 整批完成后的 refresh 策略：""、"true"、"wait_for"
+}
+
+// BulkFailure 单条文档的写入失败信息
+type BulkFailure struct {
+	Index      string `json:"index"`
+	DocumentID string `json:"document_id"`
+	Action     string `json:"action"`
+	Status     int    `json:"status"`
+	Type       string `json:"type"`
+	Reason     string `json:"reason"`
+}
+
+// BulkResult 批量写入结果
+type BulkResult struct {
+	Added    uint64        `json:"added"`
+	Indexed  uint64        `json:"indexed"`
+	Created  uint64        `json:"created"`
+	Updated  uint64        `json:"updated"`
+	Deleted  uint64        `json:"deleted"`
+	Failed   uint64        `json:"failed"`
+	Requests uint64        `json:"requests"`
+	Failures []BulkFailure `json:"failures"`
+}
+
+// BulkIndexer 对 esutil.BulkIndexer 的封装，收集每条文档的失败信息
+type BulkIndexer struct {
+	indexer esutil.BulkIndexer
+
+	mu       sync.Mutex
+	failures []BulkFailure
+	errs     []error
+}
+
+// NewBulkIndexer 创建批量写入器，使用完必须调用 Close
+func NewBulkIndexer(opts BulkOptions) (*BulkIndexer, error) {
+	b := &BulkIndexer{}
+
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client:        ESClient,
+		Index:         opts.Index,
+		NumWorkers:    opts.NumWorkers,
+		FlushBytes:    opts.FlushBytes,
+		FlushInterval: opts.FlushInterval,
+		Refresh:       opts.Refresh,
+		OnError: func(ctx context.Context, err error) {
+			b.mu.Lock()
+			b.errs = append(b.errs, err)
+			b.mu.Unlock()
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bulk indexer: %w", err)
+	}
+
+	b.indexer = indexer
+	return b, nil
+}
+
+// Index 添加一条 index 操作，id 为空则自动生成
+func (b *BulkIndexer) Index(index, id string, body interface{}) error {
+	return b.add("index", index, id, body)
+}
+
+// Create 添加一条 create 操作，文档已存在时记为失败
+func (b *BulkIndexer) Create(index, id string, body interface{}) error {
+	return b.add("create", index, id, body)
+}
+
+// Update 添加一条局部更新操作
+func (b *BulkIndexer) Update(index, id string, doc map[string]interface{}) error {
+	return b.add("update", index, id, map[string]interface{}{"doc": doc})
+}
+
+// Delete 添加一条删除操作
+func (b *BulkIndexer) Delete(index, id string) error {
+	return b.add("delete", index, id, nil)
+}
+
+func (b *BulkIndexer) add(action, index, id string, body interface{}) error {
+	item := esutil.BulkIndexerItem{
+		Index:      index,
+		Action:     action,
+		DocumentID: id,
+		OnFailure:  b.onFailure,
+	}
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal document: %w", err)
+		}
+		item.Body = bytes.NewReader(data)
+	}
+
+	if err := b.indexer.Add(context.Background(), item); err != nil {
+		return fmt.Errorf("failed to add bulk item: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BulkIndexer) onFailure(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+	failure := BulkFailure{
+		Index:      res.Index,
+		DocumentID: item.DocumentID,
+		Action:     item.Action,
+		Status:     res.Status,
+		Type:       res.Error.Type,
+		Reason:     res.Error.Reason,
+	}
+	if failure.Index == "" {
+		failure.Index = item.Index
+	}
+	if err != nil {
+		failure.Reason = err.Error()
+	}
+
+	b.mu.Lock()
+	b.failures = append(b.failures, failure)
+	b.mu.Unlock()
+}
+
+// Close 刷新剩余的缓冲并返回统计结果
+func (b *BulkIndexer) Close() (*BulkResult, error) {
+	if err := b.indexer.Close(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to close bulk indexer: %w", err)
+	}
+
+	stats := b.indexer.Stats()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := &BulkResult{
+		Added:    stats.NumAdded,
+		Indexed:  stats.NumIndexed,
+		Created:  stats.NumCreated,
+		Updated:  stats.NumUpdated,
+		Deleted:  stats.NumDeleted,
+		Failed:   stats.NumFailed,
+		Requests: stats.NumRequests,
+		Failures: b.failures,
+	}
+
+	if len(b.errs) > 0 {
+		return result, fmt.Errorf("bulk indexer error: %w", b.errs[0])
+	}
+
+	return result, nil
+}
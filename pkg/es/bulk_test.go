@@ -0,0 +1,109 @@
+package es
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBulkIndexer(t *testing.T) {
+	var lines []string
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/products/_bulk" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("refresh"); got != "wait_for" {
+			t.Errorf("refresh = %q, want wait_for", got)
+		}
+
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"errors": true,
+			"items": []interface{}{
+				map[string]interface{}{"index": map[string]interface{}{"_index": "products", "_id": "1", "status": 201}},
+				map[string]interface{}{"create": map[string]interface{}{
+					"_index": "products", "_id": "2", "status": 409,
+					"error": map[string]interface{}{"type": "version_conflict_engine_exception", "reason": "document already exists"},
+				}},
+				map[string]interface{}{"update": map[string]interface{}{"_index": "other", "_id": "3", "status": 200}},
+				map[string]interface{}{"delete": map[string]interface{}{"_index": "products", "_id": "4", "status": 200}},
+			},
+		})
+	})
+
+	b, err := NewBulkIndexer(BulkOptions{Index: "products", NumWorkers: 1, Refresh: "wait_for"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Index("", "1", map[string]string{"name": "a"})
+	b.Create("", "2", map[string]string{"name": "b"})
+	b.Update("other", "3", map[string]interface{}{"price": 10})
+	b.Delete("", "4")
+
+	result, err := b.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"index":{"_id":"1"}}`, `{"name":"a"}`,
+		`{"create":{"_id":"2"}}`, `{"name":"b"}`,
+		`{"update":{"_id":"3","_index":"other"}}`, `{"doc":{"price":10}}`,
+		`{"delete":{"_id":"4"}}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("bulk body:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	if result.Added != 4 || result.Indexed != 1 || result.Updated != 1 || result.Deleted != 1 || result.Failed != 1 || result.Requests != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	failure := BulkFailure{
+		Index: "products", DocumentID: "2", Action: "create", Status: 409,
+		Type: "version_conflict_engine_exception", Reason: "document already exists",
+	}
+	if len(result.Failures) != 1 || result.Failures[0] != failure {
+		t.Errorf("failures = %+v, want [%+v]", result.Failures, failure)
+	}
+}
+
+func TestBulkIndexerRequestError(t *testing.T) {
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, 500, map[string]interface{}{"error": "boom"})
+	})
+
+	b, err := NewBulkIndexer(BulkOptions{Index: "products", NumWorkers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Index("", "1", map[string]string{"name": "a"})
+
+	result, err := b.Close()
+	if err == nil {
+		t.Fatal("Close() err = nil, want the request error")
+	}
+	if result == nil || result.Failed != 1 {
+		t.Errorf("result = %+v, want 1 failed", result)
+	}
+}
+
+func TestBulkIndexerMarshalError(t *testing.T) {
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+
+	b, err := NewBulkIndexer(BulkOptions{Index: "products", NumWorkers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Index("", "1", map[string]interface{}{"bad": make(chan int)}); err == nil {
+		t.Error("Index() with unsupported value err = nil")
+	}
+	if _, err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
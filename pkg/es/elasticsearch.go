@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -45,6 +44,11 @@ func Setup() error {
 	return nil
 }
 
+// Enabled 客户端是否已成功初始化
+func Enabled() bool {
+	return ESClient != nil
+}
+
 // Index 索引文档，如果 id 为空则自动生成
 func Index(index string, id string, body interface{}) (string, error) {
 	data, err := json.Marshal(body)
@@ -56,7 +60,7 @@ func Index(index string, id string, body interface{}) (string, error) {
 		Index:      index,
 		Body:       bytes.NewReader(data),
 		DocumentID: id, // 留空则自动生成
-		Refresh:    "true",
+		Refresh:    setting.ElasticSearchSetting.Refresh,
 	}
 
 	res, err := req.Do(context.Background(), ESClient)
@@ -124,6 +128,7 @@ func Delete(index, id string) error {
 	req := esapi.DeleteRequest{
 		Index:      index,
 		DocumentID: id,
+		Refresh:    setting.ElasticSearchSetting.Refresh,
 	}
 
 	res, err := req.Do(context.Background(), ESClient)
@@ -140,53 +145,6 @@ func Delete(index, id string) error {
 	return nil
 }
 
-// Search 执行带分页的查询
-func Search(index string, query map[string]interface{}, from, size int) ([]map[string]interface{}, error) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		return nil, fmt.Errorf("failed to encode query: %w", err)
-	}
-
-	res, err := ESClient.Search(
-		ESClient.Search.WithContext(context.Background()),
-		ESClient.Search.WithIndex(index),
-		ESClient.Search.WithBody(&buf),
-		ESClient.Search.WithFrom(from),
-		ESClient.Search.WithSize(size),
-		ESClient.Search.WithTrackTotalHits(true),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("search request failed: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.IsError() {
-		body, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("error searching index=%s: %s", index, string(body))
-	}
-
-	var r map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return nil, fmt.Errorf("failed to decode search response: %w", err)
-	}
-
-	hitsData, ok := r["hits"].(map[string]interface{})["hits"].([]interface{})
-	if !ok {
-		log.Printf("unexpected structure in search result: %+v", r["hits"])
-		return nil, errors.New("invalid hits structure")
-	}
-
-	results := make([]map[string]interface{}, len(hitsData))
-	for i, hit := range hitsData {
-		source, ok := hit.(map[string]interface{})["_source"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		results[i] = source
-	}
-	return results, nil
-}
-
 // UpdateReplica 设置索引副本数
 func UpdateReplica(index string, numReplicas int) error {
 	settings := map[string]interface{}{
@@ -232,7 +190,7 @@ func Update(index, id string, doc map[string]interface{}) error {
 		Index:      index,
 		DocumentID: id,
 		Body:       &buf,
-		Refresh:    "true",
+		Refresh:    setting.ElasticSearchSetting.Refresh,
 	}
 
 	res, err := req.Do(context.Background(), ESClient)
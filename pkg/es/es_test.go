@@ -0,0 +1,62 @@
+package es
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// newTestClient 把 ESClient 指向 handler，测试结束后恢复
+func newTestClient(t *testing.T, handler http.HandlerFunc) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 客户端要求响应带有该头部，否则认为不是 Elasticsearch
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:    []string{srv.URL},
+		DisableRetry: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := ESClient
+	ESClient = client
+	t.Cleanup(func() { ESClient = saved })
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readJSON 解析请求体，失败时返回 nil
+func readJSON(r *http.Request) map[string]interface{} {
+	data, _ := io.ReadAll(r.Body)
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil
+	}
+
+	return body
+}
+
+// compact 把 v 编码为 JSON，用于比较请求体
+func compact(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,297 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// IndexDefinition 版本化的索引定义，对外通过别名 Name 访问，实际索引为 Name_v{Version}
+type IndexDefinition struct {
+	Name     string                 `json:"name"`
+	Version  int                    `json:"version"`
+	Settings map[string]interface{} `json:"settings"`
+	Mappings map[string]interface{} `json:"mappings"`
+}
+
+// TemplateDefinition 索引模板定义，body 原样提交给 _index_template
+type TemplateDefinition struct {
+	Name string                 `json:"name"`
+	Body map[string]interface{} `json:"body"`
+}
+
+// IndexName 返回当前版本的实际索引名
+func (d *IndexDefinition) IndexName() string {
+	return fmt.Sprintf("%s_v%d", d.Name, d.Version)
+}
+
+// Body 返回创建索引的请求体，注入分词器配置并在 _meta 中记录版本和校验和
+func (d *IndexDefinition) Body(analyzer string) map[string]interface{} {
+	settings := make(map[string]interface{}, len(d.Settings)+1)
+	for k, v := range d.Settings {
+		settings[k] = v
+	}
+	analysis, _ := settings["analysis"].(map[string]interface{})
+	settings["analysis"] = mergeMaps(analysis, AnalysisSettings(analyzer))
+
+	mappings := make(map[string]interface{}, len(d.Mappings)+1)
+	for k, v := range d.Mappings {
+		mappings[k] = v
+	}
+	mappings["_meta"] = d.meta(analyzer)
+
+	return map[string]interface{}{
+		"settings": settings,
+		"mappings": mappings,
+	}
+}
+
+// meta 写入映射 _meta 的版本信息
+func (d *IndexDefinition) meta(analyzer string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":           d.Version,
+		"checksum":          checksum(d.Mappings),
+		"settings_checksum": checksum(d.Settings),
+		"analyzer":          analyzer,
+	}
+}
+
+// checksum 计算定义内容的校验和，encoding/json 对 map 的 key 排序，结果是稳定的
+func checksum(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := md5.Sum(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadDefinitions 读取目录下的索引定义（*.json）和 templates 子目录下的模板定义
+func LoadDefinitions(dir string) ([]*IndexDefinition, []*TemplateDefinition, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+
+	var defs []*IndexDefinition
+	for _, f := range files {
+		var def IndexDefinition
+		if err := readJSONFile(f, &def); err != nil {
+			return nil, nil, err
+		}
+		if def.Name == "" || def.Version < 1 {
+			return nil, nil, fmt.Errorf("invalid index definition %s: name and version are required", f)
+		}
+		defs = append(defs, &def)
+	}
+
+	files, err = filepath.Glob(filepath.Join(dir, "templates", "*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+
+	var templates []*TemplateDefinition
+	for _, f := range files {
+		var tpl TemplateDefinition
+		if err := readJSONFile(f, &tpl); err != nil {
+			return nil, nil, err
+		}
+		if tpl.Name == "" {
+			tpl.Name = strings.TrimSuffix(filepath.Base(f), ".json")
+		}
+		templates = append(templates, &tpl)
+	}
+
+	return defs, templates, nil
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// mappingMeta 已创建索引中记录的 _meta
+type mappingMeta struct {
+	Version          int    `json:"version"`
+	Checksum         string `json:"checksum"`
+	SettingsChecksum string `json:"settings_checksum"`
+	Analyzer         string `json:"analyzer"`
+}
+
+// GetMapping 获取索引（或别名）的映射
+func GetMapping(index string) (map[string]interface{}, error) {
+	req := esapi.IndicesGetMappingRequest{
+		Index: []string{index},
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return nil, fmt.Errorf("get mapping request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error getting mapping index=%s: %s", index, string(body))
+	}
+
+	var r map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode mapping response: %w", err)
+	}
+
+	for _, v := range r {
+		return v.Mappings, nil
+	}
+
+	return nil, fmt.Errorf("mapping of index=%s not found", index)
+}
+
+// PutMapping 为已有索引追加字段映射
+func PutMapping(index string, mappings map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(mappings); err != nil {
+		return fmt.Errorf("failed to encode mapping: %w", err)
+	}
+
+	req := esapi.IndicesPutMappingRequest{
+		Index: []string{index},
+		Body:  &buf,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("put mapping request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error putting mapping index=%s: %s", index, string(body))
+	}
+
+	return nil
+}
+
+// PutIndexTemplate 创建或更新索引模板
+func PutIndexTemplate(name string, body map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode index template: %w", err)
+	}
+
+	req := esapi.IndicesPutIndexTemplateRequest{
+		Name: name,
+		Body: &buf,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("put index template request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error putting index template=%s: %s", name, string(body))
+	}
+
+	return nil
+}
+
+// 字段定义中不能在线修改的属性
+var immutableFieldKeys = []string{"type", "analyzer", "search_analyzer", "normalizer", "format", "index"}
+
+// MappingDiff 定义与线上映射的差异
+type MappingDiff struct {
+	Added     []string `json:"added,omitempty"`     // 线上不存在、可以直接 PutMapping 的字段
+	Conflicts []string `json:"conflicts,omitempty"` // 已存在但定义不同、必须重建索引的字段
+}
+
+// DiffMapping 比较期望映射与线上映射，线上多出的动态字段忽略
+func DiffMapping(expected, actual map[string]interface{}) MappingDiff {
+	var diff MappingDiff
+	diffProperties("", properties(expected), properties(actual), &diff)
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Conflicts)
+
+	return diff
+}
+
+func diffProperties(prefix string, expected, actual map[string]interface{}, diff *MappingDiff) {
+	for name, e := range expected {
+		path := prefix + name
+		eField, _ := e.(map[string]interface{})
+		aField, ok := actual[name].(map[string]interface{})
+		if !ok {
+			diff.Added = append(diff.Added, path)
+			continue
+		}
+
+		for _, key := range immutableFieldKeys {
+			ev, eok := eField[key]
+			av, aok := aField[key]
+			if key == "type" && !eok && !aok {
+				continue
+			}
+			// object 类型在线上映射中通常省略 type
+			if key == "type" && (ev == "object" && !aok || av == "object" && !eok) {
+				continue
+			}
+			if eok != aok || !reflect.DeepEqual(ev, av) {
+				diff.Conflicts = append(diff.Conflicts, fmt.Sprintf("%s.%s", path, key))
+			}
+		}
+
+		diffProperties(path+".", properties(eField), properties(aField), diff)
+		if fields, ok := eField["fields"].(map[string]interface{}); ok {
+			aFields, _ := aField["fields"].(map[string]interface{})
+			diffProperties(path+".", fields, aFields, diff)
+		}
+	}
+}
+
+func properties(mapping map[string]interface{}) map[string]interface{} {
+	props, _ := mapping["properties"].(map[string]interface{})
+	if props == nil {
+		return map[string]interface{}{}
+	}
+
+	return props
+}
+
+// mergeMaps 深度合并，src 覆盖 dst 中的同名键
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, v := range src {
+		if sv, ok := v.(map[string]interface{}); ok {
+			if dv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeMaps(dv, sv)
+				continue
+			}
+		}
+		out[k] = v
+	}
+
+	return out
+}
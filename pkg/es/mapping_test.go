@@ -0,0 +1,172 @@
+package es
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mapping 解析 JSON 形式的映射
+func mapping(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestDiffMapping(t *testing.T) {
+	actual := `{"properties": {
+		"title": {"type": "text", "analyzer": "chinese_index", "fields": {"raw": {"type": "keyword"}}},
+		"price": {"type": "float"},
+		"attrs": {"properties": {"name": {"type": "keyword"}}},
+		"dynamic_field": {"type": "long"}
+	}}`
+	tests := []struct {
+		name     string
+		expected string
+		added    string
+		conflict string
+	}{
+		{
+			"same",
+			`{"properties": {"title": {"type": "text", "analyzer": "chinese_index", "fields": {"raw": {"type": "keyword"}}}, "price": {"type": "float"}}}`,
+			`null`, `null`,
+		},
+		{
+			"added",
+			`{"properties": {"price": {"type": "float"}, "stock": {"type": "integer"}, "title": {"type": "text", "analyzer": "chinese_index", "fields": {"raw": {"type": "keyword"}, "pinyin": {"type": "text"}}}}}`,
+			`["stock","title.pinyin"]`, `null`,
+		},
+		{
+			"changed type and analyzer",
+			`{"properties": {"price": {"type": "scaled_float"}, "title": {"type": "text", "analyzer": "standard"}}}`,
+			`null`, `["price.type","title.analyzer"]`,
+		},
+		{
+			"object without type",
+			`{"properties": {"attrs": {"type": "object", "properties": {"name": {"type": "keyword"}, "value": {"type": "keyword"}}}}}`,
+			`["attrs.value"]`, `null`,
+		},
+		{
+			"nested sub field changed",
+			`{"properties": {"attrs": {"properties": {"name": {"type": "text"}}}}}`,
+			`null`, `["attrs.name.type"]`,
+		},
+	}
+	for _, tt := range tests {
+		diff := DiffMapping(mapping(t, tt.expected), mapping(t, actual))
+		if got := compact(diff.Added); got != tt.added {
+			t.Errorf("%s: Added = %s, want %s", tt.name, got, tt.added)
+		}
+		if got := compact(diff.Conflicts); got != tt.conflict {
+			t.Errorf("%s: Conflicts = %s, want %s", tt.name, got, tt.conflict)
+		}
+	}
+}
+
+func TestIndexDefinitionBody(t *testing.T) {
+	def := &IndexDefinition{
+		Name:     "products",
+		Version:  2,
+		Settings: mapping(t, `{"number_of_shards": 1, "analysis": {"analyzer": {"shingle": {"type": "custom"}}}}`),
+		Mappings: mapping(t, `{"properties": {"name": {"type": "text"}}}`),
+	}
+	if def.IndexName() != "products_v2" {
+		t.Errorf("IndexName() = %s", def.IndexName())
+	}
+
+	body := def.Body(AnalyzerIK)
+	settings := body["settings"].(map[string]interface{})
+	analyzers := settings["analysis"].(map[string]interface{})["analyzer"].(map[string]interface{})
+	for _, name := range []string{"shingle", ChineseIndexAnalyzer, ChineseSearchAnalyzer} {
+		if _, ok := analyzers[name]; !ok {
+			t.Errorf("analyzer %s missing in %s", name, compact(analyzers))
+		}
+	}
+	if compact(analyzers[ChineseSearchAnalyzer]) != `{"tokenizer":"ik_smart","type":"custom"}` {
+		t.Errorf("search analyzer = %s", compact(analyzers[ChineseSearchAnalyzer]))
+	}
+
+	meta := body["mappings"].(map[string]interface{})["_meta"].(map[string]interface{})
+	if meta["version"] != 2 || meta["analyzer"] != AnalyzerIK || meta["checksum"] != checksum(def.Mappings) {
+		t.Errorf("_meta = %s", compact(meta))
+	}
+
+	// 定义本身不被修改
+	if _, ok := def.Mappings["_meta"]; ok {
+		t.Error("Body() modified the definition mappings")
+	}
+	if _, ok := def.Settings["analysis"].(map[string]interface{})["analyzer"].(map[string]interface{})[ChineseIndexAnalyzer]; ok {
+		t.Error("Body() modified the definition settings")
+	}
+}
+
+func TestChecksumIsStable(t *testing.T) {
+	a := mapping(t, `{"properties": {"a": {"type": "text"}, "b": {"type": "long"}}}`)
+	b := mapping(t, `{"properties": {"b": {"type": "long"}, "a": {"type": "text"}}}`)
+	if checksum(a) != checksum(b) {
+		t.Error("checksum depends on key order")
+	}
+	if checksum(a) == checksum(mapping(t, `{"properties": {"a": {"type": "keyword"}}}`)) {
+		t.Error("different mappings have the same checksum")
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"name": "b", "version": 1}`), 0644)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"name": "a", "version": 2, "mappings": {"properties": {}}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "logs.json"), []byte(`{"body": {"index_patterns": ["logs-*"]}}`), 0644)
+
+	defs, templates, err := LoadDefinitions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 || defs[0].Name != "a" || defs[0].Version != 2 || defs[1].Name != "b" {
+		t.Errorf("defs = %s", compact(defs))
+	}
+	if len(templates) != 1 || templates[0].Name != "logs" {
+		t.Errorf("templates = %s", compact(templates))
+	}
+
+	os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"name": "c"}`), 0644)
+	if _, _, err := LoadDefinitions(dir); err == nil {
+		t.Error("LoadDefinitions() without version err = nil")
+	}
+	os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"name": `), 0644)
+	if _, _, err := LoadDefinitions(dir); err == nil {
+		t.Error("LoadDefinitions() with invalid JSON err = nil")
+	}
+}
+
+// TestConfDefinitions 仓库中的索引定义都可以加载
+func TestConfDefinitions(t *testing.T) {
+	defs, _, err := LoadDefinitions("../../conf/es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) == 0 {
+		t.Fatal("no index definitions in conf/es")
+	}
+	for _, def := range defs {
+		if len(properties(def.Mappings)) == 0 {
+			t.Errorf("%s has no properties", def.Name)
+		}
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	dst := mapping(t, `{"a": {"x": 1, "y": 2}, "b": 1}`)
+	got := mergeMaps(dst, mapping(t, `{"a": {"y": 3, "z": 4}, "c": 5}`))
+	if compact(got) != `{"a":{"x":1,"y":3,"z":4},"b":1,"c":5}` {
+		t.Errorf("mergeMaps() = %s", compact(got))
+	}
+	if compact(dst) != `{"a":{"x":1,"y":2},"b":1}` {
+		t.Errorf("mergeMaps() modified dst: %s", compact(dst))
+	}
+}
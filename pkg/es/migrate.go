@@ -0,0 +1,213 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 迁移动作
+const (
+	MigrationCreated         = "created"          // 新建索引
+	MigrationReindexed       = "reindexed"        // 版本升级，已重建索引并切换别名
+	MigrationUpdated         = "updated"          // 追加了新字段
+	MigrationUnchanged       = "unchanged"        // 无变化
+	MigrationReindexRequired = "reindex_required" // 定义有不兼容修改，需要提升版本号
+	MigrationTemplate        = "template"         // 更新索引模板
+)
+
+// MigrationResult 单个索引或模板的迁移结果
+type MigrationResult struct {
+	Name   string   `json:"name"`
+	Index  string   `json:"index"`
+	Action string   `json:"action"`
+	Detail []string `json:"detail,omitempty"`
+}
+
+// Migrate 按 MappingPath 下的定义创建缺失的模板和索引，dryRun 时只返回将要执行的动作
+func Migrate(dryRun bool) ([]*MigrationResult, error) {
+	defs, templates, err := LoadDefinitions(setting.ElasticSearchSetting.MappingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer, err := ResolveAnalyzer(setting.ElasticSearchSetting.Analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*MigrationResult
+	for _, tpl := range templates {
+		if !dryRun {
+			if err := PutIndexTemplate(tpl.Name, tpl.Body); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, &MigrationResult{Name: tpl.Name, Action: MigrationTemplate})
+	}
+
+	for _, def := range defs {
+		result, err := MigrateIndex(def, analyzer, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("migrate index %s: %w", def.Name, err)
+		}
+		if result.Action == MigrationReindexRequired {
+			log.Printf("[warn] es index %s needs reindex, bump its version: %v", def.Name, result.Detail)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// EnsureIndex 确保名为 name 的索引已按定义创建
+func EnsureIndex(name string) (*MigrationResult, error) {
+	defs, _, err := LoadDefinitions(setting.ElasticSearchSetting.MappingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, def := range defs {
+		if def.Name != name {
+			continue
+		}
+
+		analyzer, err := ResolveAnalyzer(setting.ElasticSearchSetting.Analyzer)
+		if err != nil {
+			return nil, err
+		}
+		return MigrateIndex(def, analyzer, false)
+	}
+
+	return nil, fmt.Errorf("index definition %s not found", name)
+}
+
+// MigrateIndex 迁移单个索引定义
+func MigrateIndex(def *IndexDefinition, analyzer string, dryRun bool) (*MigrationResult, error) {
+	target := def.IndexName()
+	result := &MigrationResult{Name: def.Name, Index: target}
+
+	current, err := GetAliasIndices(def.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) == 0 {
+		return migrateWithoutAlias(def, analyzer, dryRun, result)
+	}
+
+	if !containsString(current, target) {
+		result.Action = MigrationReindexed
+		result.Detail = []string{fmt.Sprintf("from %v", current)}
+		if dryRun {
+			return result, nil
+		}
+		return result, ReindexWithAlias(def.Name, target, def.Body(analyzer))
+	}
+
+	mapping, err := GetMapping(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta mappingMeta
+	if raw, ok := mapping["_meta"]; ok {
+		data, _ := json.Marshal(raw)
+		_ = json.Unmarshal(data, &meta)
+	}
+
+	if meta.Checksum == checksum(def.Mappings) && meta.SettingsChecksum == checksum(def.Settings) && meta.Analyzer == analyzer {
+		result.Action = MigrationUnchanged
+		return result, nil
+	}
+
+	diff := DiffMapping(def.Mappings, mapping)
+	if meta.SettingsChecksum != checksum(def.Settings) {
+		diff.Conflicts = append(diff.Conflicts, "settings")
+	}
+	if meta.Analyzer != analyzer {
+		diff.Conflicts = append(diff.Conflicts, fmt.Sprintf("analyzer %s -> %s", meta.Analyzer, analyzer))
+	}
+	if len(diff.Conflicts) > 0 {
+		result.Action = MigrationReindexRequired
+		result.Detail = diff.Conflicts
+		return result, nil
+	}
+
+	result.Action = MigrationUpdated
+	result.Detail = diff.Added
+	if dryRun {
+		return result, nil
+	}
+
+	mappings := make(map[string]interface{}, len(def.Mappings)+1)
+	for k, v := range def.Mappings {
+		mappings[k] = v
+	}
+	mappings["_meta"] = def.meta(analyzer)
+
+	return result, PutMapping(target, mappings)
+}
+
+// migrateWithoutAlias 处理别名还不存在的情况：全新创建，或把同名的旧索引迁移到别名下
+func migrateWithoutAlias(def *IndexDefinition, analyzer string, dryRun bool, result *MigrationResult) (*MigrationResult, error) {
+	target := def.IndexName()
+
+	legacy, err := IndexExists(def.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy {
+		// 之前由接口直接创建的同名索引：复制数据后删除，再把名字作为别名
+		result.Action = MigrationReindexed
+		result.Detail = []string{"from legacy index " + def.Name}
+		if dryRun {
+			return result, nil
+		}
+		if err := createIfNotExists(target, def.Body(analyzer)); err != nil {
+			return nil, err
+		}
+		if _, err := Reindex(def.Name, target); err != nil {
+			return nil, err
+		}
+		if err := DeleteIndex(def.Name); err != nil {
+			return nil, err
+		}
+		return result, SwapAlias(def.Name, target)
+	}
+
+	result.Action = MigrationCreated
+	if dryRun {
+		return result, nil
+	}
+	if err := createIfNotExists(target, def.Body(analyzer)); err != nil {
+		return nil, err
+	}
+
+	return result, SwapAlias(def.Name, target)
+}
+
+func createIfNotExists(index string, body map[string]interface{}) error {
+	exists, err := IndexExists(index)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	return CreateIndex(index, body)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,131 @@
+package es
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func testDefinition(t *testing.T, version int, properties string) *IndexDefinition {
+	return &IndexDefinition{
+		Name:     "products",
+		Version:  version,
+		Settings: mapping(t, `{"number_of_shards": 1}`),
+		Mappings: mapping(t, `{"properties": `+properties+`}`),
+	}
+}
+
+func TestMigrateIndex(t *testing.T) {
+	f := &fakeIndices{indices: map[string]bool{}, aliases: map[string][]string{}, mappings: map[string]interface{}{}}
+	newTestClient(t, f.ServeHTTP)
+
+	migrate := func(def *IndexDefinition, dryRun bool) *MigrationResult {
+		t.Helper()
+		result, err := MigrateIndex(def, AnalyzerStandard, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dryRun && (result.Action == MigrationCreated || result.Action == MigrationReindexed) {
+			f.aliases["products"] = []string{def.IndexName()}
+		}
+		return result
+	}
+
+	v1 := testDefinition(t, 1, `{"name": {"type": "text"}}`)
+	if r := migrate(v1, true); r.Action != MigrationCreated || f.indices["products_v1"] {
+		t.Fatalf("dry run = %+v, indices %v", r, f.indices)
+	}
+	if r := migrate(v1, false); r.Action != MigrationCreated || r.Index != "products_v1" || !f.indices["products_v1"] {
+		t.Fatalf("first migration = %+v", r)
+	}
+	if r := migrate(v1, false); r.Action != MigrationUnchanged {
+		t.Errorf("second migration = %+v, want unchanged", r)
+	}
+
+	// 只新增字段时直接 PutMapping
+	v1 = testDefinition(t, 1, `{"name": {"type": "text"}, "price": {"type": "float"}}`)
+	if r := migrate(v1, false); r.Action != MigrationUpdated || compact(r.Detail) != `["price"]` || len(f.puts) != 1 {
+		t.Errorf("added field migration = %+v, puts %v", r, f.puts)
+	}
+	if r := migrate(v1, false); r.Action != MigrationUnchanged {
+		t.Errorf("migration after PutMapping = %+v, want unchanged", r)
+	}
+
+	// 修改字段类型需要提升版本号，不做任何修改
+	changed := testDefinition(t, 1, `{"name": {"type": "keyword"}, "price": {"type": "float"}}`)
+	if r := migrate(changed, false); r.Action != MigrationReindexRequired || compact(r.Detail) != `["name.type"]` {
+		t.Errorf("changed field migration = %+v", r)
+	}
+	if result, _ := MigrateIndex(v1, AnalyzerIK, false); result.Action != MigrationReindexRequired {
+		t.Errorf("changed analyzer migration = %+v", result)
+	}
+
+	// 提升版本号后重建索引并切换别名
+	changed.Version = 2
+	if r := migrate(changed, false); r.Action != MigrationReindexed || r.Index != "products_v2" {
+		t.Errorf("version bump migration = %+v", r)
+	}
+	if compact(f.reindex) != `["{\"index\":\"products_v1\"}->{\"index\":\"products_v2\"}"]` {
+		t.Errorf("reindex = %v", f.reindex)
+	}
+}
+
+func TestMigrateLegacyIndex(t *testing.T) {
+	f := &fakeIndices{indices: map[string]bool{"products": true}, aliases: map[string][]string{}, mappings: map[string]interface{}{}}
+	newTestClient(t, f.ServeHTTP)
+
+	result, err := MigrateIndex(testDefinition(t, 1, `{}`), AnalyzerStandard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Action != MigrationReindexed {
+		t.Errorf("result = %+v", result)
+	}
+	if compact(f.deleted) != `["products"]` || !f.indices["products_v1"] {
+		t.Errorf("deleted %v, indices %v", f.deleted, f.indices)
+	}
+	if len(f.actions) != 1 || f.actions[0] != `{"add":{"alias":"products","index":"products_v1","is_write_index":true}}` {
+		t.Errorf("alias actions = %v", f.actions)
+	}
+}
+
+func TestResolveAnalyzer(t *testing.T) {
+	var plugins [][]string
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_nodes/plugins" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		nodes := map[string]interface{}{}
+		for i, names := range plugins {
+			var list []interface{}
+			for _, name := range names {
+				list = append(list, map[string]string{"name": name})
+			}
+			nodes[fmt.Sprintf("node-%d", i)] = map[string]interface{}{"plugins": list}
+		}
+		writeJSON(w, 200, map[string]interface{}{"nodes": nodes})
+	})
+
+	tests := []struct {
+		plugins [][]string
+		want    string
+	}{
+		{[][]string{{"analysis-ik"}, {"analysis-ik", "analysis-smartcn"}}, AnalyzerIK},
+		// 只有部分节点安装的插件不能使用
+		{[][]string{{"analysis-ik", "analysis-smartcn"}, {"analysis-smartcn"}}, AnalyzerSmartCN},
+		{[][]string{{"analysis-ik"}, {}}, AnalyzerStandard},
+	}
+	for _, tt := range tests {
+		plugins = tt.plugins
+		if got, err := ResolveAnalyzer(AnalyzerAuto); err != nil || got != tt.want {
+			t.Errorf("ResolveAnalyzer(auto) with %v = %q, %v, want %q", tt.plugins, got, err, tt.want)
+		}
+	}
+
+	if got, err := ResolveAnalyzer(AnalyzerSmartCN); err != nil || got != AnalyzerSmartCN {
+		t.Errorf("ResolveAnalyzer(smartcn) = %q, %v", got, err)
+	}
+	if _, err := ResolveAnalyzer("jieba"); err == nil {
+		t.Error("ResolveAnalyzer(jieba) err = nil")
+	}
+}
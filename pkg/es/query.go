@@ -0,0 +1,295 @@
+package es
+
+// Query 查询子句，Source 返回可直接序列化为 DSL 的结构
+type Query interface {
+	Source() map[string]interface{}
+}
+
+// RawQuery 直接使用手写的 DSL 片段
+type RawQuery map[string]interface{}
+
+// Source 返回原始 DSL
+func (q RawQuery) Source() map[string]interface{} {
+	return q
+}
+
+// MatchAll 匹配全部文档
+func MatchAll() Query {
+	return RawQuery{"match_all": map[string]interface{}{}}
+}
+
+// Term 精确匹配（keyword、数值、布尔等字段）
+func Term(field string, value interface{}) Query {
+	return RawQuery{"term": map[string]interface{}{field: value}}
+}
+
+// Terms 匹配任意一个值
+func Terms(field string, values ...interface{}) Query {
+	return RawQuery{"terms": map[string]interface{}{field: values}}
+}
+
+// Exists 字段存在
+func Exists(field string) Query {
+	return RawQuery{"exists": map[string]interface{}{"field": field}}
+}
+
+// Prefix 前缀匹配
+func Prefix(field, value string) Query {
+	return RawQuery{"prefix": map[string]interface{}{field: value}}
+}
+
+// MatchQuery 全文匹配
+type MatchQuery struct {
+	field     string
+	text      interface{}
+	operator  string
+	fuzziness string
+	boost     float64
+}
+
+// Match 创建全文匹配查询
+func Match(field string, text interface{}) *MatchQuery {
+	return &MatchQuery{field: field, text: text}
+}
+
+// Operator 设置词项之间的关系：or / and
+func (q *MatchQuery) Operator(operator string) *MatchQuery {
+	q.operator = operator
+	return q
+}
+
+// Fuzziness 设置模糊匹配程度，例如 AUTO
+func (q *MatchQuery) Fuzziness(fuzziness string) *MatchQuery {
+	q.fuzziness = fuzziness
+	return q
+}
+
+// Boost 设置权重
+func (q *MatchQuery) Boost(boost float64) *MatchQuery {
+	q.boost = boost
+	return q
+}
+
+// Source 返回 match DSL
+func (q *MatchQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{"query": q.text}
+	if q.operator != "" {
+		params["operator"] = q.operator
+	}
+	if q.fuzziness != "" {
+		params["fuzziness"] = q.fuzziness
+	}
+	if q.boost != 0 {
+		params["boost"] = q.boost
+	}
+
+	return map[string]interface{}{"match": map[string]interface{}{q.field: params}}
+}
+
+// MultiMatchQuery 多字段全文匹配
+type MultiMatchQuery struct {
+	text      interface{}
+	fields    []string
+	typ       string
+	operator  string
+	fuzziness string
+}
+
+// MultiMatch 创建多字段匹配查询，字段支持 title^2 形式的权重
+func MultiMatch(text interface{}, fields ...string) *MultiMatchQuery {
+	return &MultiMatchQuery{text: text, fields: fields}
+}
+
+// Type 设置 multi_match 类型：best_fields / most_fields / phrase / bool_prefix 等
+func (q *MultiMatchQuery) Type(typ string) *MultiMatchQuery {
+	q.typ = typ
+	return q
+}
+
+// Operator 设置词项之间的关系：or / and
+func (q *MultiMatchQuery) Operator(operator string) *MultiMatchQuery {
+	q.operator = operator
+	return q
+}
+
+// Fuzziness 设置模糊匹配程度，例如 AUTO
+func (q *MultiMatchQuery) Fuzziness(fuzziness string) *MultiMatchQuery {
+	q.fuzziness = fuzziness
+	return q
+}
+
+// Source 返回 multi_match DSL
+func (q *MultiMatchQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{
+		"query":  q.text,
+		"fields": q.fields,
+	}
+	if q.typ != "" {
+		params["type"] = q.typ
+	}
+	if q.operator != "" {
+		params["operator"] = q.operator
+	}
+	if q.fuzziness != "" {
+		params["fuzziness"] = q.fuzziness
+	}
+
+	return map[string]interface{}{"multi_match": params}
+}
+
+// RangeQuery 区间查询
+type RangeQuery struct {
+	field  string
+	params map[string]interface{}
+}
+
+// Range 创建区间查询
+func Range(field string) *RangeQuery {
+	return &RangeQuery{field: field, params: map[string]interface{}{}}
+}
+
+// Gte 大于等于
+func (q *RangeQuery) Gte(v interface{}) *RangeQuery {
+	q.params["gte"] = v
+	return q
+}
+
+// Gt 大于
+func (q *RangeQuery) Gt(v interface{}) *RangeQuery {
+	q.params["gt"] = v
+	return q
+}
+
+// Lte 小于等于
+func (q *RangeQuery) Lte(v interface{}) *RangeQuery {
+	q.params["lte"] = v
+	return q
+}
+
+// Lt 小于
+func (q *RangeQuery) Lt(v interface{}) *RangeQuery {
+	q.params["lt"] = v
+	return q
+}
+
+// Format 设置日期格式
+func (q *RangeQuery) Format(format string) *RangeQuery {
+	q.params["format"] = format
+	return q
+}
+
+// Source 返回 range DSL
+func (q *RangeQuery) Source() map[string]interface{} {
+	return map[string]interface{}{"range": map[string]interface{}{q.field: q.params}}
+}
+
+// NestedQuery nested 字段查询
+type NestedQuery struct {
+	path      string
+	query     Query
+	scoreMode string
+}
+
+// Nested 在 nested 对象数组上执行子查询
+func Nested(path string, query Query) *NestedQuery {
+	return &NestedQuery{path: path, query: query}
+}
+
+// ScoreMode 设置子文档得分的合并方式：avg / max / min / sum / none
+func (q *NestedQuery) ScoreMode(mode string) *NestedQuery {
+	q.scoreMode = mode
+	return q
+}
+
+// Source 返回 nested DSL
+func (q *NestedQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{
+		"path":  q.path,
+		"query": q.query.Source(),
+	}
+	if q.scoreMode != "" {
+		params["score_mode"] = q.scoreMode
+	}
+
+	return map[string]interface{}{"nested": params}
+}
+
+// BoolQuery 组合查询
+type BoolQuery struct {
+	must               []Query
+	filter             []Query
+	should             []Query
+	mustNot            []Query
+	minimumShouldMatch interface{}
+}
+
+// Bool 创建组合查询
+func Bool() *BoolQuery {
+	return &BoolQuery{}
+}
+
+// Must 必须匹配，参与算分
+func (q *BoolQuery) Must(queries ...Query) *BoolQuery {
+	q.must = append(q.must, queries...)
+	return q
+}
+
+// Filter 必须匹配，不参与算分
+func (q *BoolQuery) Filter(queries ...Query) *BoolQuery {
+	q.filter = append(q.filter, queries...)
+	return q
+}
+
+// Should 可选匹配，命中时加分
+func (q *BoolQuery) Should(queries ...Query) *BoolQuery {
+	q.should = append(q.should, queries...)
+	return q
+}
+
+// MustNot 必须不匹配
+func (q *BoolQuery) MustNot(queries ...Query) *BoolQuery {
+	q.mustNot = append(q.mustNot, queries...)
+	return q
+}
+
+// MinimumShouldMatch 设置 should 至少命中的数量或比例
+func (q *BoolQuery) MinimumShouldMatch(v interface{}) *BoolQuery {
+	q.minimumShouldMatch = v
+	return q
+}
+
+// IsEmpty 没有任何子句
+func (q *BoolQuery) IsEmpty() bool {
+	return len(q.must) == 0 && len(q.filter) == 0 && len(q.should) == 0 && len(q.mustNot) == 0
+}
+
+// Source 返回 bool DSL
+func (q *BoolQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{}
+	if len(q.must) > 0 {
+		params["must"] = querySources(q.must)
+	}
+	if len(q.filter) > 0 {
+		params["filter"] = querySources(q.filter)
+	}
+	if len(q.should) > 0 {
+		params["should"] = querySources(q.should)
+	}
+	if len(q.mustNot) > 0 {
+		params["must_not"] = querySources(q.mustNot)
+	}
+	if q.minimumShouldMatch != nil {
+		params["minimum_should_match"] = q.minimumShouldMatch
+	}
+
+	return map[string]interface{}{"bool": params}
+}
+
+func querySources(queries []Query) []map[string]interface{} {
+	sources := make([]map[string]interface{}, 0, len(queries))
+	for _, q := range queries {
+		sources = append(sources, q.Source())
+	}
+
+	return sources
+}
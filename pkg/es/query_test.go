@@ -0,0 +1,64 @@
+package es
+
+import "testing"
+
+func TestQuerySource(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"match_all", MatchAll(), `{"match_all":{}}`},
+		{"term", Term("state", 1), `{"term":{"state":1}}`},
+		{"terms", Terms("tag_id", 1, 2), `{"terms":{"tag_id":[1,2]}}`},
+		{"exists", Exists("cover"), `{"exists":{"field":"cover"}}`},
+		{"prefix", Prefix("name", "go"), `{"prefix":{"name":"go"}}`},
+		{"match", Match("title", "gin"), `{"match":{"title":{"query":"gin"}}}`},
+		{
+			"match options",
+			Match("title", "gin").Operator("and").Fuzziness("AUTO").Boost(2),
+			`{"match":{"title":{"boost":2,"fuzziness":"AUTO","operator":"and","query":"gin"}}}`,
+		},
+		{
+			"multi_match",
+			MultiMatch("gin", "title^2", "content").Type("best_fields").Operator("or"),
+			`{"multi_match":{"fields":["title^2","content"],"operator":"or","query":"gin","type":"best_fields"}}`,
+		},
+		{
+			"range",
+			Range("created_on").Gte("2024-01-01").Lt("2025-01-01").Format("yyyy-MM-dd"),
+			`{"range":{"created_on":{"format":"yyyy-MM-dd","gte":"2024-01-01","lt":"2025-01-01"}}}`,
+		},
+		{
+			"nested",
+			Nested("attrs", Term("attrs.name", "color")).ScoreMode("none"),
+			`{"nested":{"path":"attrs","query":{"term":{"attrs.name":"color"}},"score_mode":"none"}}`,
+		},
+		{"empty bool", Bool(), `{"bool":{}}`},
+		{
+			"bool",
+			Bool().Must(Match("title", "gin")).Filter(Term("state", 1), Range("price").Gt(0)).
+				Should(Term("tag", "go")).MustNot(Exists("deleted_on")).MinimumShouldMatch(1),
+			`{"bool":{"filter":[{"term":{"state":1}},{"range":{"price":{"gt":0}}}],"minimum_should_match":1,` +
+				`"must":[{"match":{"title":{"query":"gin"}}}],"must_not":[{"exists":{"field":"deleted_on"}}],"should":[{"term":{"tag":"go"}}]}}`,
+		},
+		{"raw", RawQuery{"ids": map[string]interface{}{"values": []string{"1"}}}, `{"ids":{"values":["1"]}}`},
+	}
+	for _, tt := range tests {
+		if got := compact(tt.query.Source()); got != tt.want {
+			t.Errorf("%s: Source() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBoolIsEmpty(t *testing.T) {
+	if !Bool().IsEmpty() {
+		t.Error("Bool().IsEmpty() = false")
+	}
+	if Bool().MinimumShouldMatch(1).IsEmpty() != true {
+		t.Error("Bool() with only minimum_should_match is not empty")
+	}
+	if Bool().MustNot(MatchAll()).IsEmpty() {
+		t.Error("Bool().MustNot(...).IsEmpty() = true")
+	}
+}
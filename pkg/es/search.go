@@ -0,0 +1,339 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// Highlight 高亮配置
+type Highlight struct {
+	fields   map[string]interface{}
+	preTags  []string
+	postTags []string
+}
+
+// NewHighlight 创建高亮配置，默认使用 <em></em> 包裹
+func NewHighlight(fields ...string) *Highlight {
+	h := &Highlight{
+		fields:   map[string]interface{}{},
+		preTags:  []string{"<em>"},
+		postTags: []string{"</em>"},
+	}
+	for _, field := range fields {
+		h.fields[field] = map[string]interface{}{}
+	}
+
+	return h
+}
+
+// Field 添加高亮字段，fragmentSize 为 0 时使用默认值
+func (h *Highlight) Field(field string, fragmentSize, numberOfFragments int) *Highlight {
+	params := map[string]interface{}{}
+	if fragmentSize > 0 {
+		params["fragment_size"] = fragmentSize
+	}
+	if numberOfFragments > 0 {
+		params["number_of_fragments"] = numberOfFragments
+	}
+	h.fields[field] = params
+
+	return h
+}
+
+// Tags 设置高亮前后标签
+func (h *Highlight) Tags(pre, post string) *Highlight {
+	h.preTags = []string{pre}
+	h.postTags = []string{post}
+	return h
+}
+
+// Source 返回 highlight DSL
+func (h *Highlight) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"fields":    h.fields,
+		"pre_tags":  h.preTags,
+		"post_tags": h.postTags,
+	}
+}
+
+// SearchSource 搜索请求体构造器
+type SearchSource struct {
+	query       Query
+	postFilter  Query
+	from        int
+	size        int
+	sorts       []interface{}
+	highlight   *Highlight
+	aggs        map[string]Aggregation
+	suggest     map[string]Suggester
+	includes    []string
+	searchAfter []interface{}
+}
+
+// NewSearch 创建搜索请求体，默认返回 10 条
+func NewSearch() *SearchSource {
+	return &SearchSource{size: 10}
+}
+
+// Query 设置查询条件
+func (s *SearchSource) Query(q Query) *SearchSource {
+	s.query = q
+	return s
+}
+
+// PostFilter 设置聚合之后再生效的过滤条件，常用于分面搜索
+func (s *SearchSource) PostFilter(q Query) *SearchSource {
+	s.postFilter = q
+	return s
+}
+
+// From 设置偏移量
+func (s *SearchSource) From(from int) *SearchSource {
+	s.from = from
+	return s
+}
+
+// Size 设置返回条数
+func (s *SearchSource) Size(size int) *SearchSource {
+	s.size = size
+	return s
+}
+
+// Page 按页码设置 from/size，page 从 1 开始
+func (s *SearchSource) Page(page, pageSize int) *SearchSource {
+	if page < 1 {
+		page = 1
+	}
+	s.from = (page - 1) * pageSize
+	s.size = pageSize
+
+	return s
+}
+
+// Sort 按字段排序
+func (s *SearchSource) Sort(field string, asc bool) *SearchSource {
+	order := "desc"
+	if asc {
+		order = "asc"
+	}
+	s.sorts = append(s.sorts, map[string]interface{}{field: map[string]string{"order": order}})
+
+	return s
+}
+
+// SortBy 添加原始排序定义
+func (s *SearchSource) SortBy(sort interface{}) *SearchSource {
+	s.sorts = append(s.sorts, sort)
+	return s
+}
+
+// SearchAfter 设置 search_after 游标
+func (s *SearchSource) SearchAfter(values ...interface{}) *SearchSource {
+	s.searchAfter = values
+	return s
+}
+
+// Highlight 设置高亮
+func (s *SearchSource) Highlight(h *Highlight) *SearchSource {
+	s.highlight = h
+	return s
+}
+
+// Aggregation 添加聚合
+func (s *SearchSource) Aggregation(name string, agg Aggregation) *SearchSource {
+	if s.aggs == nil {
+		s.aggs = map[string]Aggregation{}
+	}
+	s.aggs[name] = agg
+
+	return s
+}
+
+// Suggest 添加 suggester 定义
+func (s *SearchSource) Suggest(name string, suggester Suggester) *SearchSource {
+	if s.suggest == nil {
+		s.suggest = map[string]Suggester{}
+	}
+	s.suggest[name] = suggester
+
+	return s
+}
+
+// Includes 只返回指定的 _source 字段
+func (s *SearchSource) Includes(fields ...string) *SearchSource {
+	s.includes = fields
+	return s
+}
+
+// Source 返回完整的搜索请求体
+func (s *SearchSource) Source() map[string]interface{} {
+	body := map[string]interface{}{
+		"from":             s.from,
+		"size":             s.size,
+		"track_total_hits": true,
+	}
+	if s.query != nil {
+		body["query"] = s.query.Source()
+	}
+	if s.postFilter != nil {
+		body["post_filter"] = s.postFilter.Source()
+	}
+	if len(s.sorts) > 0 {
+		body["sort"] = s.sorts
+	}
+	if s.highlight != nil {
+		body["highlight"] = s.highlight.Source()
+	}
+	if len(s.aggs) > 0 {
+		body["aggs"] = aggregationSources(s.aggs)
+	}
+	if len(s.suggest) > 0 {
+		suggest := make(map[string]interface{}, len(s.suggest))
+		for name, suggester := range s.suggest {
+			suggest[name] = suggester.Source()
+		}
+		body["suggest"] = suggest
+	}
+	if len(s.includes) > 0 {
+		body["_source"] = s.includes
+	}
+	if len(s.searchAfter) > 0 {
+		body["search_after"] = s.searchAfter
+		delete(body, "from")
+	}
+
+	return body
+}
+
+// Hit 单条命中结果
+type Hit[T any] struct {
+	Index     string              `json:"index"`
+	ID        string              `json:"id"`
+	Score     float64             `json:"score"`
+	Source    T                   `json:"source"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+	Sort      []interface{}       `json:"sort,omitempty"`
+}
+
+// SearchResult 搜索结果
+type SearchResult[T any] struct {
+	Total         int64        `json:"total"`
+	TotalRelation string       `json:"total_relation"`
+	MaxScore      float64      `json:"max_score"`
+	Hits          []Hit[T]     `json:"hits"`
+	Aggregations  Aggregations `json:"aggregations,omitempty"`
+	Suggest       Suggestions  `json:"suggest,omitempty"`
+}
+
+// Sources 只返回命中的文档
+func (r *SearchResult[T]) Sources() []T {
+	sources := make([]T, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		sources = append(sources, hit.Source)
+	}
+
+	return sources
+}
+
+// Search 执行查询并把 _source 解析为 T
+func Search[T any](index string, s *SearchSource) (*SearchResult[T], error) {
+	r, err := doSearch([]string{index}, s.Source())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSearchResult[T](r)
+}
+
+func decodeSearchResult[T any](r *rawSearchResponse) (*SearchResult[T], error) {
+	result := &SearchResult[T]{
+		Total:         r.Hits.Total.Value,
+		TotalRelation: r.Hits.Total.Relation,
+		Hits:          make([]Hit[T], 0, len(r.Hits.Hits)),
+		Aggregations:  Aggregations(r.Aggregations),
+		Suggest:       Suggestions(r.Suggest),
+	}
+	if r.Hits.MaxScore != nil {
+		result.MaxScore = *r.Hits.MaxScore
+	}
+
+	for _, raw := range r.Hits.Hits {
+		hit := Hit[T]{
+			Index:     raw.Index,
+			ID:        raw.ID,
+			Highlight: raw.Highlight,
+			Sort:      raw.Sort,
+		}
+		if raw.Score != nil {
+			hit.Score = *raw.Score
+		}
+		if len(raw.Source) > 0 {
+			if err := json.Unmarshal(raw.Source, &hit.Source); err != nil {
+				return nil, fmt.Errorf("failed to decode _source of %s: %w", raw.ID, err)
+			}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	return result, nil
+}
+
+// rawHit 搜索结果中的单条命中
+type rawHit struct {
+	Index     string              `json:"_index"`
+	ID        string              `json:"_id"`
+	Score     *float64            `json:"_score"`
+	Source    json.RawMessage     `json:"_source"`
+	Sort      []interface{}       `json:"sort"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+// rawSearchResponse 搜索接口的原始响应
+type rawSearchResponse struct {
+	PitID string `json:"pit_id"`
+	Hits  struct {
+		Total struct {
+			Value    int64  `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		MaxScore *float64 `json:"max_score"`
+		Hits     []rawHit `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]json.RawMessage `json:"aggregations"`
+	Suggest      map[string]json.RawMessage `json:"suggest"`
+}
+
+// doSearch 执行搜索请求并解析响应，index 为空时用于 point-in-time 查询
+func doSearch(index []string, body map[string]interface{}) (*rawSearchResponse, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	req := esapi.SearchRequest{
+		Index: index,
+		Body:  &buf,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error searching index=%v: %s", index, string(body))
+	}
+
+	var r rawSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	return &r, nil
+}
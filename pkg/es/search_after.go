@@ -0,0 +1,154 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const defaultKeepAlive = "1m"
+
+// OpenPointInTime 打开一个 point-in-time 快照，返回 pit id
+func OpenPointInTime(index, keepAlive string) (string, error) {
+	if keepAlive == "" {
+		keepAlive = defaultKeepAlive
+	}
+
+	req := esapi.OpenPointInTimeRequest{
+		Index:     []string{index},
+		KeepAlive: keepAlive,
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return "", fmt.Errorf("open point in time failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("error opening point in time index=%s: %s", index, string(body))
+	}
+
+	var r struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("failed to decode point in time response: %w", err)
+	}
+	if r.ID == "" {
+		return "", errors.New("missing id in point in time response")
+	}
+
+	return r.ID, nil
+}
+
+// ClosePointInTime 关闭 point-in-time 快照
+func ClosePointInTime(pitID string) error {
+	data, err := json.Marshal(map[string]string{"id": pitID})
+	if err != nil {
+		return fmt.Errorf("failed to encode point in time id: %w", err)
+	}
+
+	req := esapi.ClosePointInTimeRequest{
+		Body: bytes.NewReader(data),
+	}
+	res, err := req.Do(context.Background(), ESClient)
+	if err != nil {
+		return fmt.Errorf("close point in time failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error closing point in time: %s", string(body))
+	}
+
+	return nil
+}
+
+// SearchAfterResult 一页 search_after 查询结果
+type SearchAfterResult[T any] struct {
+	*SearchResult[T]
+	SearchAfter []interface{} `json:"search_after"` // 下一页游标，取自本页最后一条命中；本页没有命中时为空
+	PitID       string        `json:"pit_id,omitempty"`
+}
+
+// SearchAfter 使用 search_after 翻页，不受 from+size 10000 条的限制
+// 游标通过 s.SearchAfter 传入，返回空页时表示没有更多数据；pitID 不为空时在快照上查询并忽略 index，否则 s 必须设置能唯一确定顺序的排序
+func SearchAfter[T any](index, pitID string, s *SearchSource) (*SearchAfterResult[T], error) {
+	body := s.Source()
+	delete(body, "from")
+
+	var indices []string
+	if pitID != "" {
+		body["pit"] = map[string]interface{}{
+			"id":         pitID,
+			"keep_alive": defaultKeepAlive,
+		}
+		if len(s.sorts) == 0 {
+			body["sort"] = []interface{}{map[string]string{"_shard_doc": "asc"}}
+		}
+	} else {
+		if len(s.sorts) == 0 {
+			return nil, errors.New("search_after without point in time requires sort")
+		}
+		indices = []string{index}
+	}
+
+	r, err := doSearch(indices, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := decodeSearchResult[T](r)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SearchAfterResult[T]{
+		SearchResult: result,
+		PitID:        r.PitID,
+	}
+	if n := len(result.Hits); n > 0 {
+		page.SearchAfter = result.Hits[n-1].Sort
+	}
+
+	return page, nil
+}
+
+// ScanAll 在 point-in-time 快照上遍历全部命中，每页回调一次，fn 返回错误时终止；s 不会被修改
+func ScanAll[T any](index string, s *SearchSource, fn func(hits []Hit[T]) error) error {
+	cursor := *s
+
+	pitID, err := OpenPointInTime(index, defaultKeepAlive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ClosePointInTime(pitID)
+	}()
+
+	for {
+		page, err := SearchAfter[T](index, pitID, &cursor)
+		if err != nil {
+			return err
+		}
+		if page.PitID != "" {
+			pitID = page.PitID
+		}
+
+		if len(page.Hits) == 0 {
+			return nil
+		}
+		if err := fn(page.Hits); err != nil {
+			return err
+		}
+
+		cursor.SearchAfter(page.SearchAfter...)
+	}
+}
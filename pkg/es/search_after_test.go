@@ -0,0 +1,99 @@
+package es
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestScanAll(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"_id": "1", "_source": map[string]interface{}{"name": "a"}, "sort": []interface{}{1}},
+		{"_id": "2", "_source": map[string]interface{}{"name": "b"}, "sort": []interface{}{2}},
+		{"_id": "3", "_source": map[string]interface{}{"name": "c"}, "sort": []interface{}{3}},
+	}
+	var searches []map[string]interface{}
+	var closed string
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/products/_pit":
+			if r.URL.Query().Get("keep_alive") != "1m" {
+				t.Errorf("keep_alive = %q", r.URL.Query().Get("keep_alive"))
+			}
+			writeJSON(w, 200, map[string]interface{}{"id": "pit-1"})
+		case r.Method == http.MethodPost && r.URL.Path == "/_search":
+			body := readJSON(r)
+			searches = append(searches, body)
+			start := 0
+			if after, ok := body["search_after"].([]interface{}); ok {
+				start = int(after[0].(float64))
+			}
+			end := start + int(body["size"].(float64))
+			if end > len(docs) {
+				end = len(docs)
+			}
+			writeJSON(w, 200, map[string]interface{}{
+				"pit_id": fmt.Sprintf("pit-%d", len(searches)+1),
+				"hits":   map[string]interface{}{"total": map[string]interface{}{"value": 3}, "hits": docs[start:end]},
+			})
+		case r.Method == http.MethodDelete && r.URL.Path == "/_pit":
+			closed = readJSON(r)["id"].(string)
+			writeJSON(w, 200, map[string]interface{}{"succeeded": true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	var names []string
+	s := NewSearch().Size(2)
+	err := ScanAll[struct{ Name string }]("products", s, func(hits []Hit[struct{ Name string }]) error {
+		for _, hit := range hits {
+			names = append(names, hit.Source.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compact(names) != `["a","b","c"]` {
+		t.Errorf("names = %v", names)
+	}
+	// 最后一页不满 size 时仍继续查询，直到返回空页
+	if len(searches) != 3 {
+		t.Fatalf("%d searches, want 3", len(searches))
+	}
+	// 第二页沿用上一页返回的 pit id 和最后一条的排序值
+	if got := compact(searches[1]["pit"]); got != `{"id":"pit-2","keep_alive":"1m"}` {
+		t.Errorf("second pit = %s", got)
+	}
+	if got := compact(searches[1]["search_after"]); got != "[2]" {
+		t.Errorf("second search_after = %s", got)
+	}
+	if got := compact(searches[0]["sort"]); got != `[{"_shard_doc":"asc"}]` {
+		t.Errorf("default sort = %s", got)
+	}
+	if _, ok := searches[0]["from"]; ok {
+		t.Error("search_after request has from")
+	}
+	if got := compact(searches[2]["search_after"]); got != "[3]" {
+		t.Errorf("third search_after = %s", got)
+	}
+	if closed != "pit-4" {
+		t.Errorf("closed pit = %q, want pit-4", closed)
+	}
+	// 调用方的请求体不受翻页影响
+	if s.searchAfter != nil {
+		t.Errorf("caller search_after = %v, want nil", s.searchAfter)
+	}
+}
+
+func TestSearchAfterRequiresSort(t *testing.T) {
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	if _, err := SearchAfter[map[string]interface{}]("products", "", NewSearch()); err == nil {
+		t.Error("SearchAfter() without sort and point in time err = nil")
+	}
+}
@@ -0,0 +1,115 @@
+package es
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSearchSource(t *testing.T) {
+	s := NewSearch().
+		Query(Match("title", "gin")).
+		PostFilter(Term("brand", "apple")).
+		Page(3, 20).
+		Sort("created_on", false).
+		SortBy("_score").
+		Highlight(NewHighlight("title").Field("content", 100, 2).Tags("<b>", "</b>")).
+		Aggregation("brands", TermsAgg("brand", 5)).
+		Includes("id", "title")
+
+	want := `{"_source":["id","title"],"aggs":{"brands":{"terms":{"field":"brand","size":5}}},"from":40,` +
+		`"highlight":{"fields":{"content":{"fragment_size":100,"number_of_fragments":2},"title":{}},"post_tags":["</b>"],"pre_tags":["<b>"]},` +
+		`"post_filter":{"term":{"brand":"apple"}},"query":{"match":{"title":{"query":"gin"}}},"size":20,` +
+		`"sort":[{"created_on":{"order":"desc"}},"_score"],"track_total_hits":true}`
+	if got := compact(s.Source()); got != want {
+		t.Errorf("Source() =\n%s\nwant\n%s", got, want)
+	}
+
+	// 设置游标后不再使用 from
+	body := s.SearchAfter(1700000000, "42").Source()
+	if _, ok := body["from"]; ok {
+		t.Error("Source() with search_after has from")
+	}
+	if compact(body["search_after"]) != `[1700000000,"42"]` {
+		t.Errorf("search_after = %s", compact(body["search_after"]))
+	}
+
+	if got := compact(NewSearch().Page(0, 10).Source()); got != `{"from":0,"size":10,"track_total_hits":true}` {
+		t.Errorf("Page(0) Source() = %s", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var query map[string]interface{}
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/articles/_search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query = readJSON(r)
+		writeJSON(w, 200, map[string]interface{}{
+			"hits": map[string]interface{}{
+				"total":     map[string]interface{}{"value": 12, "relation": "eq"},
+				"max_score": 1.5,
+				"hits": []interface{}{
+					map[string]interface{}{
+						"_index": "articles_v1", "_id": "7", "_score": 1.5,
+						"_source":   map[string]interface{}{"id": 7, "title": "gin"},
+						"highlight": map[string]interface{}{"title": []string{"<em>gin</em>"}},
+					},
+					map[string]interface{}{"_index": "articles_v1", "_id": "8", "_score": nil, "_source": map[string]interface{}{"id": 8}},
+				},
+			},
+			"aggregations": map[string]interface{}{"tags": map[string]interface{}{"buckets": []interface{}{}}},
+		})
+	})
+
+	type article struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+	result, err := Search[article]("articles", NewSearch().Query(Match("title", "gin")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compact(query["query"]) != `{"match":{"title":{"query":"gin"}}}` {
+		t.Errorf("query = %s", compact(query["query"]))
+	}
+	if result.Total != 12 || result.TotalRelation != "eq" || result.MaxScore != 1.5 || len(result.Hits) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	hit := result.Hits[0]
+	if hit.Index != "articles_v1" || hit.ID != "7" || hit.Score != 1.5 || hit.Source != (article{7, "gin"}) {
+		t.Errorf("hit = %+v", hit)
+	}
+	if len(hit.Highlight["title"]) != 1 || hit.Highlight["title"][0] != "<em>gin</em>" {
+		t.Errorf("highlight = %v", hit.Highlight)
+	}
+	if result.Hits[1].Score != 0 {
+		t.Errorf("null _score = %v", result.Hits[1].Score)
+	}
+	if compact(result.Sources()) != `[{"id":7,"title":"gin"},{"id":8,"title":""}]` {
+		t.Errorf("Sources() = %s", compact(result.Sources()))
+	}
+	if _, err := result.Aggregations.Buckets("tags"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing/_search" {
+			writeJSON(w, 404, map[string]interface{}{"error": map[string]interface{}{"type": "index_not_found_exception"}})
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"hits": map[string]interface{}{"hits": []interface{}{map[string]interface{}{"_id": "1", "_source": map[string]interface{}{"id": "x"}}}},
+		})
+	})
+
+	if _, err := Search[map[string]interface{}]("missing", NewSearch()); err == nil {
+		t.Error("Search() on missing index err = nil")
+	}
+	if _, err := Search[struct{ ID int }]("articles", NewSearch()); err == nil {
+		t.Error("Search() with mismatched _source err = nil")
+	}
+}
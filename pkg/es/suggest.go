@@ -0,0 +1,274 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Suggester suggest 定义
+type Suggester interface {
+	Source() map[string]interface{}
+}
+
+// CompletionSuggester 基于 completion 字段的前缀补全
+type CompletionSuggester struct {
+	prefix   string
+	params   map[string]interface{}
+	contexts map[string]interface{}
+}
+
+// Completion 创建 completion suggester，field 必须是 completion 类型
+func Completion(prefix, field string) *CompletionSuggester {
+	return &CompletionSuggester{
+		prefix: prefix,
+		params: map[string]interface{}{"field": field},
+	}
+}
+
+// Size 设置返回的补全数量
+func (s *CompletionSuggester) Size(size int) *CompletionSuggester {
+	s.params["size"] = size
+	return s
+}
+
+// SkipDuplicates 去掉文本相同的补全
+func (s *CompletionSuggester) SkipDuplicates() *CompletionSuggester {
+	s.params["skip_duplicates"] = true
+	return s
+}
+
+// Fuzzy 允许输入有拼写错误，fuzziness 例如 AUTO / 1
+func (s *CompletionSuggester) Fuzzy(fuzziness string) *CompletionSuggester {
+	s.params["fuzzy"] = map[string]interface{}{"fuzziness": fuzziness}
+	return s
+}
+
+// Context 按映射中定义的 category context 过滤
+func (s *CompletionSuggester) Context(name string, values ...string) *CompletionSuggester {
+	if s.contexts == nil {
+		s.contexts = map[string]interface{}{}
+	}
+	s.contexts[name] = values
+
+	return s
+}
+
+// Source 返回 suggester DSL
+func (s *CompletionSuggester) Source() map[string]interface{} {
+	params := make(map[string]interface{}, len(s.params)+1)
+	for k, v := range s.params {
+		params[k] = v
+	}
+	if len(s.contexts) > 0 {
+		params["contexts"] = s.contexts
+	}
+
+	return map[string]interface{}{
+		"prefix":     s.prefix,
+		"completion": params,
+	}
+}
+
+// TermSuggester 按词纠错
+type TermSuggester struct {
+	text   string
+	params map[string]interface{}
+}
+
+// TermSuggest 创建 term suggester
+func TermSuggest(text, field string) *TermSuggester {
+	return &TermSuggester{
+		text:   text,
+		params: map[string]interface{}{"field": field},
+	}
+}
+
+// Size 每个词返回的候选数量
+func (s *TermSuggester) Size(size int) *TermSuggester {
+	s.params["size"] = size
+	return s
+}
+
+// SuggestMode 设置 missing / popular / always
+func (s *TermSuggester) SuggestMode(mode string) *TermSuggester {
+	s.params["suggest_mode"] = mode
+	return s
+}
+
+// Source 返回 suggester DSL
+func (s *TermSuggester) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"text": s.text,
+		"term": s.params,
+	}
+}
+
+// PhraseSuggester 整句纠错，field 通常是 shingle 分词的子字段
+type PhraseSuggester struct {
+	text       string
+	params     map[string]interface{}
+	generators []map[string]interface{}
+}
+
+// PhraseSuggest 创建 phrase suggester
+func PhraseSuggest(text, field string) *PhraseSuggester {
+	return &PhraseSuggester{
+		text:   text,
+		params: map[string]interface{}{"field": field},
+	}
+}
+
+// Size 返回的候选句子数量
+func (s *PhraseSuggester) Size(size int) *PhraseSuggester {
+	s.params["size"] = size
+	return s
+}
+
+// GramSize 与字段 shingle 的最大长度一致
+func (s *PhraseSuggester) GramSize(n int) *PhraseSuggester {
+	s.params["gram_size"] = n
+	return s
+}
+
+// Highlight 标出被纠正的部分
+func (s *PhraseSuggester) Highlight(pre, post string) *PhraseSuggester {
+	s.params["highlight"] = map[string]string{"pre_tag": pre, "post_tag": post}
+	return s
+}
+
+// Collate 只保留用 query 检查后确实有结果的候选，query 中用 {{suggestion}} 引用候选
+func (s *PhraseSuggester) Collate(query map[string]interface{}) *PhraseSuggester {
+	s.params["collate"] = map[string]interface{}{
+		"query": map[string]interface{}{"source": query},
+		"prune": false,
+	}
+	return s
+}
+
+// DirectGenerator 添加候选词生成器
+func (s *PhraseSuggester) DirectGenerator(field, suggestMode string) *PhraseSuggester {
+	s.generators = append(s.generators, map[string]interface{}{
+		"field":        field,
+		"suggest_mode": suggestMode,
+	})
+	return s
+}
+
+// Source 返回 suggester DSL
+func (s *PhraseSuggester) Source() map[string]interface{} {
+	params := make(map[string]interface{}, len(s.params)+1)
+	for k, v := range s.params {
+		params[k] = v
+	}
+	if len(s.generators) > 0 {
+		params["direct_generator"] = s.generators
+	}
+
+	return map[string]interface{}{
+		"text":   s.text,
+		"phrase": params,
+	}
+}
+
+// Suggestions 搜索结果中的 suggest 部分，按名称延迟解析
+type Suggestions map[string]json.RawMessage
+
+// Suggestion 输入文本中一段（词或整句）的建议
+type Suggestion struct {
+	Text    string          `json:"text"`
+	Offset  int             `json:"offset"`
+	Length  int             `json:"length"`
+	Options []SuggestOption `json:"options"`
+}
+
+// SuggestOption 一条候选
+type SuggestOption struct {
+	Text        string              `json:"text"`
+	Score       float64             `json:"score,omitempty"`
+	Freq        int64               `json:"freq,omitempty"`
+	Highlighted string              `json:"highlighted,omitempty"`
+	ID          string              `json:"_id,omitempty"`
+	Source      json.RawMessage     `json:"_source,omitempty"`
+	Contexts    map[string][]string `json:"contexts,omitempty"`
+}
+
+// Get 解析名为 name 的 suggester 结果
+func (s Suggestions) Get(name string) ([]Suggestion, error) {
+	msg, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("suggester %s not found", name)
+	}
+
+	var suggestions []Suggestion
+	if err := json.Unmarshal(msg, &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to decode suggester %s: %w", name, err)
+	}
+
+	return suggestions, nil
+}
+
+// Options 返回名为 name 的 suggester 的全部候选
+func (s Suggestions) Options(name string) ([]SuggestOption, error) {
+	suggestions, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []SuggestOption
+	for _, suggestion := range suggestions {
+		options = append(options, suggestion.Options...)
+	}
+
+	return options, nil
+}
+
+// Corrected 用 term suggester 的第一候选替换原文中对应的词，没有可纠正的词时返回空串
+func (s Suggestions) Corrected(name, text string) string {
+	suggestions, err := s.Get(name)
+	if err != nil {
+		return ""
+	}
+
+	runes := []rune(text)
+	corrected := make([]rune, 0, len(runes))
+	changed := false
+	pos := 0
+	for _, suggestion := range suggestions {
+		// offset / length 以 UTF-16 码元计，中文都在基本平面内，按 rune 处理即可
+		if suggestion.Offset < pos || suggestion.Offset+suggestion.Length > len(runes) {
+			continue
+		}
+		corrected = append(corrected, runes[pos:suggestion.Offset]...)
+		if len(suggestion.Options) > 0 {
+			corrected = append(corrected, []rune(suggestion.Options[0].Text)...)
+			changed = true
+		} else {
+			corrected = append(corrected, runes[suggestion.Offset:suggestion.Offset+suggestion.Length]...)
+		}
+		pos = suggestion.Offset + suggestion.Length
+	}
+	if !changed {
+		return ""
+	}
+	corrected = append(corrected, runes[pos:]...)
+
+	return string(corrected)
+}
+
+// Suggest 只执行 suggesters，不返回命中文档
+func Suggest(index string, suggesters map[string]Suggester) (Suggestions, error) {
+	s := NewSearch().Size(0)
+	for name, suggester := range suggesters {
+		s.Suggest(name, suggester)
+	}
+
+	body := s.Source()
+	delete(body, "track_total_hits")
+
+	r, err := doSearch([]string{index}, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return Suggestions(r.Suggest), nil
+}
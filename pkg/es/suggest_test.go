@@ -0,0 +1,126 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSuggesterSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		suggester Suggester
+		want      string
+	}{
+		{
+			"completion",
+			Completion("iph", "suggest").Size(5).SkipDuplicates().Fuzzy("AUTO").Context("brand", "Apple"),
+			`{"completion":{"contexts":{"brand":["Apple"]},"field":"suggest","fuzzy":{"fuzziness":"AUTO"},"size":5,"skip_duplicates":true},"prefix":"iph"}`,
+		},
+		{
+			"term",
+			TermSuggest("iphnoe", "title.std").SuggestMode("missing").Size(1),
+			`{"term":{"field":"title.std","size":1,"suggest_mode":"missing"},"text":"iphnoe"}`,
+		},
+		{
+			"phrase",
+			PhraseSuggest("iphnoe case", "title.shingle").Size(3).GramSize(3).
+				DirectGenerator("title.shingle", "always").Highlight("<em>", "</em>").
+				Collate(map[string]interface{}{"match": map[string]interface{}{"title": "{{suggestion}}"}}),
+			`{"phrase":{"collate":{"prune":false,"query":{"source":{"match":{"title":"{{suggestion}}"}}}},` +
+				`"direct_generator":[{"field":"title.shingle","suggest_mode":"always"}],"field":"title.shingle","gram_size":3,` +
+				`"highlight":{"post_tag":"</em>","pre_tag":"<em>"},"size":3},"text":"iphnoe case"}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := compact(tt.suggester.Source()); got != tt.want {
+			t.Errorf("%s: Source() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	// Source 多次调用不会把 contexts 写入共享的参数
+	c := Completion("a", "suggest").Context("brand", "x")
+	c.Source()
+	if _, ok := c.params["contexts"]; ok {
+		t.Error("Source() modified the suggester params")
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	var s Suggestions
+	err := json.Unmarshal([]byte(`{
+		"term_fix": [
+			{"text": "红色", "offset": 0, "length": 2, "options": []},
+			{"text": "iphnoe", "offset": 3, "length": 6, "options": [{"text": "iphone", "score": 0.8, "freq": 12}]},
+			{"text": "kase", "offset": 10, "length": 4, "options": [{"text": "case", "score": 0.7}, {"text": "base", "score": 0.5}]}
+		],
+		"none": [{"text": "ok", "offset": 0, "length": 2, "options": []}],
+		"products": [{"text": "iph", "offset": 0, "length": 3, "options": [
+			{"text": "iPhone 15", "_id": "1", "_score": 2, "_source": {"sku": "A1"}, "contexts": {"brand": ["Apple"]}}
+		]}]
+	}`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Corrected("term_fix", "红色 iphnoe kase 壳"); got != "红色 iphone case 壳" {
+		t.Errorf("Corrected() = %q", got)
+	}
+	if got := s.Corrected("none", "ok"); got != "" {
+		t.Errorf("Corrected() without options = %q, want empty", got)
+	}
+	if got := s.Corrected("missing", "ok"); got != "" {
+		t.Errorf("Corrected() of missing suggester = %q, want empty", got)
+	}
+	// 位置超出原文时忽略该建议
+	if got := s.Corrected("term_fix", "红色 iphnoe"); got != "红色 iphone" {
+		t.Errorf("Corrected() with short text = %q", got)
+	}
+
+	options, err := s.Options("products")
+	if err != nil || len(options) != 1 {
+		t.Fatalf("Options(products) = %v, %v", options, err)
+	}
+	if o := options[0]; o.Text != "iPhone 15" || o.ID != "1" || string(o.Source) != `{"sku": "A1"}` || o.Contexts["brand"][0] != "Apple" {
+		t.Errorf("option = %+v", o)
+	}
+	if options, _ := s.Options("term_fix"); len(options) != 3 {
+		t.Errorf("Options(term_fix) = %v, want 3", options)
+	}
+	if _, err := s.Get("missing"); err == nil {
+		t.Error("Get(missing) err = nil")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	var body map[string]interface{}
+	newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/_search" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		body = readJSON(r)
+		writeJSON(w, 200, map[string]interface{}{
+			"hits":    map[string]interface{}{"hits": []interface{}{}},
+			"suggest": map[string]interface{}{"products": []interface{}{map[string]interface{}{"text": "iph", "options": []interface{}{map[string]interface{}{"text": "iPhone"}}}}},
+		})
+	})
+
+	s, err := Suggest("products", map[string]Suggester{"products": Completion("iph", "suggest")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options, err := s.Options("products"); err != nil || len(options) != 1 || options[0].Text != "iPhone" {
+		t.Errorf("Options() = %v, %v", options, err)
+	}
+
+	// 只执行 suggester，不统计命中数
+	if body["size"] != float64(0) {
+		t.Errorf("size = %v, want 0", body["size"])
+	}
+	if _, ok := body["track_total_hits"]; ok {
+		t.Error("suggest request has track_total_hits")
+	}
+	if compact(body["suggest"]) != `{"products":{"completion":{"field":"suggest"},"prefix":"iph"}}` {
+		t.Errorf("suggest = %s", compact(body["suggest"]))
+	}
+}
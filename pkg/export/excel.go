@@ -1,12 +1,23 @@
 package export
 
-import "github.com/EDDYCJY/go-gin-example/pkg/setting"
+import (
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
 
 const EXT = ".xlsx"
 
-// GetExcelFullUrl get the full access path of the Excel file
-func GetExcelFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetExcelPath() + name
+// CONTENT_TYPE the MIME type of the Excel file
+const CONTENT_TYPE = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// GetExcelFullUrl get the temporary access path of the Excel file, valid for [storage] SignedUrlExpire
+func GetExcelFullUrl(name string) (string, error) {
+	return storage.Default.SignedURL(GetExcelKey(name), setting.StorageSetting.SignedUrlExpire)
+}
+
+// GetExcelKey get the storage key of the Excel file
+func GetExcelKey(name string) string {
+	return GetExcelPath() + name
 }
 
 // GetExcelPath get the relative save path of the Excel file
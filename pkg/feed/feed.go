@@ -0,0 +1,202 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed 订阅源，同时用于生成 RSS 2.0 和 Atom 1.0
+type Feed struct {
+	Title       string
+	Link        string // 网站地址
+	Self        string // 订阅源自身的地址
+	Description string
+	Updated     time.Time
+	Items       []*Item
+}
+
+// Item 订阅源中的一篇文章
+type Item struct {
+	ID          string // 全局唯一且不变的标识，通常为文章地址
+	Title       string
+	Link        string
+	Description string // 摘要
+	Content     string // 正文 HTML
+	Author      string
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+type rss struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Atom    string      `xml:"xmlns:atom,attr"`
+	Content string      `xml:"xmlns:content,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Self          *atomLink  `xml:"atom:link,omitempty"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Links    []*atomLink  `xml:"link"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string          `xml:"title"`
+	ID         string          `xml:"id"`
+	Link       *atomLink       `xml:"link"`
+	Published  string          `xml:"published,omitempty"`
+	Updated    string          `xml:"updated"`
+	Author     *atomAuthor     `xml:"author,omitempty"`
+	Categories []*atomCategory `xml:"category"`
+	Summary    *atomText       `xml:"summary,omitempty"`
+	Content    *atomText       `xml:"content,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RSS 生成 RSS 2.0 文档，正文放在 content:encoded 中
+func RSS(f *Feed) ([]byte, error) {
+	channel := &rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+	}
+	if f.Self != "" {
+		channel.Self = &atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range f.Items {
+		ri := &rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.Link, Value: item.ID},
+			Description: item.Description,
+			Author:      item.Author,
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			ri.Content = &cdata{Value: item.Content}
+		}
+		if !item.Published.IsZero() {
+			ri.PubDate = item.Published.UTC().Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, ri)
+	}
+
+	return marshal(&rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Channel: channel,
+	})
+}
+
+// Atom 生成 Atom 1.0 文档
+func Atom(f *Feed) ([]byte, error) {
+	af := &atomFeed{
+		Title:    f.Title,
+		ID:       f.Link,
+		Links:    []*atomLink{{Href: f.Link, Rel: "alternate"}},
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+	}
+	if f.Self != "" {
+		af.ID = f.Self
+		af.Links = append(af.Links, &atomLink{Href: f.Self, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, item := range f.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := &atomEntry{
+			Title:   item.Title,
+			ID:      item.ID,
+			Link:    &atomLink{Href: item.Link, Rel: "alternate"},
+			Updated: updated.UTC().Format(time.RFC3339),
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, &atomCategory{Term: c})
+		}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Description}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		af.Entries = append(af.Entries, entry)
+	}
+
+	return marshal(af)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFeed() *Feed {
+	published := time.Date(2026, 3, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	return &Feed{
+		Title:       "Blog",
+		Link:        "https://example.com/",
+		Self:        "https://example.com/feed.rss",
+		Description: "Latest articles",
+		Updated:     published.Add(time.Hour),
+		Items: []*Item{
+			{
+				ID:          "https://example.com/articles/1",
+				Title:       "Hello <Go>",
+				Link:        "https://example.com/articles/hello-go",
+				Description: "summary",
+				Content:     "<p>body ]]> end</p>",
+				Author:      "admin",
+				Categories:  []string{"go", "web"},
+				Published:   published,
+				Updated:     published.Add(time.Hour),
+			},
+			{
+				ID:        "https://example.com/articles/2",
+				Title:     "Second",
+				Link:      "https://example.com/articles/2",
+				Published: published,
+			},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := RSS(newFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("missing XML header: %.40s", data)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Self          struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+			Items []struct {
+				Title string `xml:"title"`
+				GUID  struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Categories []string `xml:"category"`
+				PubDate    string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, data)
+	}
+
+	ch := doc.Channel
+	if doc.Version != "2.0" || ch.Title != "Blog" || ch.Self.Href != "https://example.com/feed.rss" || ch.Self.Rel != "self" {
+		t.Errorf("channel = %+v", ch)
+	}
+	if ch.LastBuildDate != "Sun, 01 Mar 2026 01:00:00 +0000" {
+		t.Errorf("lastBuildDate = %q", ch.LastBuildDate)
+	}
+	if len(ch.Items) != 2 {
+		t.Fatalf("got %d items", len(ch.Items))
+	}
+
+	first := ch.Items[0]
+	if first.Title != "Hello <Go>" || first.Content != "<p>body ]]> end</p>" || strings.Join(first.Categories, ",") != "go,web" {
+		t.Errorf("item = %+v", first)
+	}
+	// ID 与链接不同时 guid 不是永久链接
+	if first.GUID.IsPermaLink != "false" || first.GUID.Value != "https://example.com/articles/1" {
+		t.Errorf("guid = %+v", first.GUID)
+	}
+	if first.PubDate != "Sun, 01 Mar 2026 00:00:00 +0000" {
+		t.Errorf("pubDate = %q", first.PubDate)
+	}
+	if ch.Items[1].GUID.IsPermaLink != "true" || ch.Items[1].Content != "" {
+		t.Errorf("second item = %+v", ch.Items[1])
+	}
+}
+
+func TestAtom(t *testing.T) {
+	f := newFeed()
+	f.Self = "https://example.com/feed.atom"
+	data, err := Atom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID         string `xml:"id"`
+			Published  string `xml:"published"`
+			Updated    string `xml:"updated"`
+			Author     string `xml:"author>name"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Summary *struct{} `xml:"summary"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, data)
+	}
+
+	if doc.ID != f.Self || doc.Updated != "2026-03-01T01:00:00Z" || len(doc.Links) != 2 || doc.Links[1].Rel != "self" {
+		t.Errorf("feed = %+v", doc)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("got %d entries", len(doc.Entries))
+	}
+
+	first := doc.Entries[0]
+	if first.Author != "admin" || len(first.Categories) != 2 || first.Categories[1].Term != "web" || first.Summary == nil {
+		t.Errorf("entry = %+v", first)
+	}
+	if first.Content.Type != "html" || first.Content.Value != "<p>body ]]> end</p>" {
+		t.Errorf("content = %+v", first.Content)
+	}
+	if first.Published != "2026-03-01T00:00:00Z" || first.Updated != "2026-03-01T01:00:00Z" {
+		t.Errorf("entry times = %s, %s", first.Published, first.Updated)
+	}
+	// 没有修改时间时 updated 使用发布时间
+	second := doc.Entries[1]
+	if second.Updated != "2026-03-01T00:00:00Z" || second.Author != "" || second.Summary != nil {
+		t.Errorf("second entry = %+v", second)
+	}
+}
@@ -0,0 +1,129 @@
+package gredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 部署模式
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// Client 按 key 路由命令的客户端，屏蔽单机、哨兵和集群的差异
+type Client interface {
+	// Do 在 key 所在的节点执行命令，key 为空时在任意主节点执行
+	Do(ctx context.Context, key, cmd string, args ...interface{}) (interface{}, error)
+	// Conn 返回 key 所在节点的连接，用于 pipeline 和脚本，调用方负责 Close
+	Conn(ctx context.Context, key string) (redis.Conn, error)
+	// Nodes 返回全部主节点的连接池，用于 SCAN 这类需要遍历所有节点的命令
+	Nodes() []*redis.Pool
+	// Close 关闭全部连接池
+	Close() error
+}
+
+// newPool 按配置创建连接池，dial 负责建立到具体节点的连接
+func newPool(dial func() (redis.Conn, error), testOnBorrow func(c redis.Conn, t time.Time) error) *redis.Pool {
+	if testOnBorrow == nil {
+		testOnBorrow = func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < time.Minute {
+				return nil
+			}
+			_, err := c.Do("PING")
+			return err
+		}
+	}
+
+	return &redis.Pool{
+		MaxIdle:      setting.RedisSetting.MaxIdle,
+		MaxActive:    setting.RedisSetting.MaxActive,
+		IdleTimeout:  setting.RedisSetting.IdleTimeout,
+		Wait:         true,
+		Dial:         dial,
+		TestOnBorrow: testOnBorrow,
+	}
+}
+
+// dialNode 连接一个数据节点，完成认证和选库
+func dialNode(addr string) (redis.Conn, error) {
+	options := dialOptions()
+	if setting.RedisSetting.Password != "" {
+		options = append(options, redis.DialPassword(setting.RedisSetting.Password))
+	}
+	if setting.RedisSetting.DB > 0 {
+		options = append(options, redis.DialDatabase(setting.RedisSetting.DB))
+	}
+
+	return redis.Dial("tcp", addr, options...)
+}
+
+func dialOptions() []redis.DialOption {
+	var options []redis.DialOption
+	if setting.RedisSetting.DialTimeout > 0 {
+		options = append(options, redis.DialConnectTimeout(setting.RedisSetting.DialTimeout))
+	}
+	if setting.RedisSetting.ReadTimeout > 0 {
+		options = append(options, redis.DialReadTimeout(setting.RedisSetting.ReadTimeout))
+	}
+	if setting.RedisSetting.WriteTimeout > 0 {
+		options = append(options, redis.DialWriteTimeout(setting.RedisSetting.WriteTimeout))
+	}
+
+	return options
+}
+
+// doConn 执行命令，ctx 设置了截止时间时以剩余时间作为读超时
+func doConn(ctx context.Context, c redis.Conn, cmd string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		return redis.DoWithTimeout(c, timeout, cmd, args...)
+	}
+
+	return c.Do(cmd, args...)
+}
+
+// poolClient 单个连接池，用于单机和哨兵模式
+type poolClient struct {
+	pool *redis.Pool
+}
+
+func (p *poolClient) Do(ctx context.Context, key, cmd string, args ...interface{}) (interface{}, error) {
+	c, err := p.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	return doConn(ctx, c, cmd, args...)
+}
+
+func (p *poolClient) Conn(ctx context.Context, key string) (redis.Conn, error) {
+	return p.pool.GetContext(ctx)
+}
+
+func (p *poolClient) Nodes() []*redis.Pool {
+	return []*redis.Pool{p.pool}
+}
+
+func (p *poolClient) Close() error {
+	return p.pool.Close()
+}
+
+// newStandaloneClient 单机模式
+func newStandaloneClient(addr string) Client {
+	return &poolClient{pool: newPool(func() (redis.Conn, error) {
+		return dialNode(addr)
+	}, nil)}
+}
@@ -0,0 +1,293 @@
+package gredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	clusterSlots       = 16384
+	clusterMaxRedirect = 5
+)
+
+// clusterClient 集群模式：按 CRC16 计算 key 的槽位并路由到对应主节点，处理 MOVED / ASK 重定向
+type clusterClient struct {
+	mu    sync.RWMutex
+	seeds []string
+	slots [clusterSlots]string
+	pools map[string]*redis.Pool
+
+	refreshing sync.Mutex
+}
+
+// newClusterClient 集群模式，seeds 为任意几个节点的地址
+func newClusterClient(seeds []string) (Client, error) {
+	c := &clusterClient{
+		seeds: append([]string(nil), seeds...),
+		pools: make(map[string]*redis.Pool),
+	}
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// refresh 通过 CLUSTER SLOTS 重新加载槽位分布
+func (c *clusterClient) refresh() error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+
+	c.mu.RLock()
+	addrs := append([]string(nil), c.seeds...)
+	for addr := range c.pools {
+		addrs = append(addrs, addr)
+	}
+	c.mu.RUnlock()
+
+	var lastErr error
+	for _, addr := range addrs {
+		slots, err := c.loadSlots(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		c.mu.Lock()
+		c.slots = *slots
+		c.mu.Unlock()
+		return nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no seed node configured")
+	}
+	return fmt.Errorf("redis cluster: failed to load slots: %w", lastErr)
+}
+
+func (c *clusterClient) loadSlots(addr string) (*[clusterSlots]string, error) {
+	conn := c.pool(addr).Get()
+	defer conn.Close()
+
+	ranges, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var slots [clusterSlots]string
+	for _, r := range ranges {
+		// [start, end, [ip, port, id], replicas...]
+		info, err := redis.Values(r, nil)
+		if err != nil || len(info) < 3 {
+			return nil, fmt.Errorf("unexpected CLUSTER SLOTS reply: %v", r)
+		}
+		start, _ := redis.Int(info[0], nil)
+		end, _ := redis.Int(info[1], nil)
+		node, err := redis.Values(info[2], nil)
+		if err != nil || len(node) < 2 {
+			return nil, fmt.Errorf("unexpected CLUSTER SLOTS node: %v", info[2])
+		}
+		host, _ := redis.String(node[0], nil)
+		port, _ := redis.Int(node[1], nil)
+		if host == "" {
+			// 节点未声明 IP 时使用当前连接的地址
+			host, _, _ = net.SplitHostPort(addr)
+		}
+
+		master := net.JoinHostPort(host, strconv.Itoa(port))
+		for slot := start; slot <= end && slot < clusterSlots; slot++ {
+			slots[slot] = master
+		}
+	}
+
+	return &slots, nil
+}
+
+// pool 返回节点的连接池，不存在时创建
+func (c *clusterClient) pool(addr string) *redis.Pool {
+	c.mu.RLock()
+	p, ok := c.pools[addr]
+	c.mu.RUnlock()
+	if ok {
+		return p
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.pools[addr]; ok {
+		return p
+	}
+	p = newPool(func() (redis.Conn, error) {
+		return dialNode(addr)
+	}, nil)
+	c.pools[addr] = p
+
+	return p
+}
+
+// addrOf 返回 key 所在的主节点，key 为空或槽位未知时返回任意一个节点
+func (c *clusterClient) addrOf(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if key != "" {
+		if addr := c.slots[Slot(key)]; addr != "" {
+			return addr
+		}
+	}
+	for _, addr := range c.slots {
+		if addr != "" {
+			return addr
+		}
+	}
+
+	return c.seeds[0]
+}
+
+func (c *clusterClient) Do(ctx context.Context, key, cmd string, args ...interface{}) (interface{}, error) {
+	addr := c.addrOf(key)
+	asking := false
+
+	for i := 0; i < clusterMaxRedirect; i++ {
+		reply, err := c.doNode(ctx, addr, asking, cmd, args...)
+		if err == nil {
+			return reply, nil
+		}
+
+		redirect, target, ok := parseRedirect(err)
+		if !ok {
+			if isRetryable(err) {
+				time.Sleep(time.Duration(i+1) * 50 * time.Millisecond)
+				continue
+			}
+			return reply, err
+		}
+
+		addr = target
+		asking = redirect == "ASK"
+		if redirect == "MOVED" {
+			// 槽位迁移完成，更新本地槽位表
+			c.mu.Lock()
+			c.slots[Slot(key)] = target
+			c.mu.Unlock()
+			go c.refresh()
+		}
+	}
+
+	return nil, fmt.Errorf("redis cluster: too many redirects for key %s", key)
+}
+
+func (c *clusterClient) doNode(ctx context.Context, addr string, asking bool, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := c.pool(addr).GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if asking {
+		if _, err := conn.Do("ASKING"); err != nil {
+			return nil, err
+		}
+	}
+
+	return doConn(ctx, conn, cmd, args...)
+}
+
+func (c *clusterClient) Conn(ctx context.Context, key string) (redis.Conn, error) {
+	return c.pool(c.addrOf(key)).GetContext(ctx)
+}
+
+func (c *clusterClient) Nodes() []*redis.Pool {
+	c.mu.RLock()
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, addr := range c.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	c.mu.RUnlock()
+
+	pools := make([]*redis.Pool, 0, len(addrs))
+	for _, addr := range addrs {
+		pools = append(pools, c.pool(addr))
+	}
+
+	return pools
+}
+
+func (c *clusterClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for _, p := range c.pools {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// parseRedirect 解析 "MOVED 3999 127.0.0.1:6381" / "ASK 3999 127.0.0.1:6381"
+func parseRedirect(err error) (string, string, bool) {
+	e, ok := err.(redis.Error)
+	if !ok {
+		return "", "", false
+	}
+
+	fields := strings.Fields(string(e))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return "", "", false
+	}
+
+	return fields[0], fields[2], true
+}
+
+// isRetryable 槽位迁移中或集群暂时不可用
+func isRetryable(err error) bool {
+	e, ok := err.(redis.Error)
+	if !ok {
+		return false
+	}
+
+	return strings.HasPrefix(string(e), "TRYAGAIN") || strings.HasPrefix(string(e), "CLUSTERDOWN")
+}
+
+// Slot 计算 key 的槽位，只对 {hash tag} 中的部分求值，使相关 key 落在同一节点
+func Slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(crc16(key) % clusterSlots)
+}
+
+// crc16 CRC16-CCITT (XMODEM)，与 Redis Cluster 的实现一致
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
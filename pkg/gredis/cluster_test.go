@@ -0,0 +1,282 @@
+package gredis
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis/internal/redistest"
+)
+
+func TestCRC16(t *testing.T) {
+	// Redis Cluster 规范中的测试向量
+	if got := crc16("123456789"); got != 0x31c3 {
+		t.Errorf("crc16(123456789) = %#x, want 0x31c3", got)
+	}
+}
+
+func TestSlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"", 0},
+		// 只对第一个 {} 中的内容求值
+		{"{user1000}.following", Slot("user1000")},
+		{"{user1000}.followers", Slot("user1000")},
+		{"foo{{bar}}zap", Slot("{bar")},
+		{"foo{bar}{zap}", Slot("bar")},
+		// {} 为空或没有闭合时对整个 key 求值
+		{"foo{}{bar}", int(crc16("foo{}{bar}") % clusterSlots)},
+		{"foo{bar", int(crc16("foo{bar") % clusterSlots)},
+	}
+	for _, tt := range tests {
+		if got := Slot(tt.key); got != tt.want {
+			t.Errorf("Slot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	tests := []struct {
+		err      error
+		redirect string
+		target   string
+		ok       bool
+	}{
+		{redis.Error("MOVED 3999 127.0.0.1:6381"), "MOVED", "127.0.0.1:6381", true},
+		{redis.Error("ASK 3999 127.0.0.1:6381"), "ASK", "127.0.0.1:6381", true},
+		{redis.Error("ERR unknown command"), "", "", false},
+		{redis.Error("MOVED 3999"), "", "", false},
+		{net.ErrClosed, "", "", false},
+	}
+	for _, tt := range tests {
+		redirect, target, ok := parseRedirect(tt.err)
+		if redirect != tt.redirect || target != tt.target || ok != tt.ok {
+			t.Errorf("parseRedirect(%v) = %q, %q, %v", tt.err, redirect, target, ok)
+		}
+	}
+
+	if !isRetryable(redis.Error("TRYAGAIN Multiple keys request during rehashing of slot")) ||
+		!isRetryable(redis.Error("CLUSTERDOWN The cluster is down")) ||
+		isRetryable(redis.Error("MOVED 1 127.0.0.1:1")) {
+		t.Error("isRetryable mismatch")
+	}
+}
+
+// fakeCluster 两个节点的集群，槽位分布可以修改，模拟迁移
+type fakeCluster struct {
+	a, b *redistest.Server
+
+	mu    sync.Mutex
+	split int // [0, split) 属于 a，[split, 16384) 属于 b
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	f := &fakeCluster{a: redistest.NewServer(t), b: redistest.NewServer(t), split: 8192}
+	for _, s := range []*redistest.Server{f.a, f.b} {
+		s.Handle("CLUSTER", func(args []string) interface{} { return f.slots() })
+		s.Handle("ASKING", func(args []string) interface{} { return redistest.Status("OK") })
+	}
+
+	return f
+}
+
+func (f *fakeCluster) setSplit(split int) {
+	f.mu.Lock()
+	f.split = split
+	f.mu.Unlock()
+}
+
+func (f *fakeCluster) slots() interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ranges []interface{}
+	node := func(s *redistest.Server) []interface{} {
+		host, port, _ := net.SplitHostPort(s.Addr())
+		p, _ := strconv.Atoi(port)
+		return []interface{}{host, p, s.Addr()}
+	}
+	if f.split > 0 {
+		ranges = append(ranges, []interface{}{0, f.split - 1, node(f.a)})
+	}
+	if f.split < clusterSlots {
+		ranges = append(ranges, []interface{}{f.split, clusterSlots - 1, node(f.b)})
+	}
+
+	return ranges
+}
+
+// value 读取节点上的字符串，不存在时返回空串
+func value(s *redistest.Server, key string) string {
+	v, _ := s.Get(key)
+	return v
+}
+
+func newTestCluster(t *testing.T, f *fakeCluster) *clusterClient {
+	c, err := newClusterClient([]string{f.a.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c.(*clusterClient)
+}
+
+func TestClusterRouting(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+	ctx := context.Background()
+
+	// bar 在 5061 槽位属于 a，foo 在 12182 槽位属于 b
+	if _, err := c.Do(ctx, "bar", "SET", "bar", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(ctx, "foo", "SET", "foo", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if value(f.a, "bar") != "1" || f.a.Exists("foo") {
+		t.Error("bar should be stored on node a only")
+	}
+	if value(f.b, "foo") != "2" || f.b.Exists("bar") {
+		t.Error("foo should be stored on node b only")
+	}
+
+	if n := len(c.Nodes()); n != 2 {
+		t.Errorf("Nodes() = %d pools, want 2", n)
+	}
+}
+
+func TestClusterMoved(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+	ctx := context.Background()
+
+	// 槽位迁移到 a 之后，b 对 foo 返回 MOVED
+	f.a.Set("foo", "moved")
+	f.setSplit(clusterSlots)
+	f.b.Handle("GET", func(args []string) interface{} {
+		return redistest.Error("MOVED 12182 " + f.a.Addr())
+	})
+
+	v, err := redis.String(c.Do(ctx, "foo", "GET", "foo"))
+	if err != nil || v != "moved" {
+		t.Fatalf("GET foo = %q, %v, want moved", v, err)
+	}
+	if addr := c.addrOf("foo"); addr != f.a.Addr() {
+		t.Errorf("slot of foo should be updated to node a, got %s", addr)
+	}
+
+	// 之后直接发往 a
+	before := f.b.Count("GET")
+	if _, err := c.Do(ctx, "foo", "GET", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if f.b.Count("GET") != before {
+		t.Error("GET foo was sent to node b again after MOVED")
+	}
+}
+
+func TestClusterAsk(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+	ctx := context.Background()
+
+	// 迁移进行中：b 对 foo 返回 ASK，a 只接受带 ASKING 的请求
+	f.a.Set("foo", "importing")
+	f.b.Handle("GET", func(args []string) interface{} {
+		return redistest.Error("ASK 12182 " + f.a.Addr())
+	})
+
+	v, err := redis.String(c.Do(ctx, "foo", "GET", "foo"))
+	if err != nil || v != "importing" {
+		t.Fatalf("GET foo = %q, %v, want importing", v, err)
+	}
+
+	cmds := f.a.Commands()
+	if len(cmds) < 2 || cmds[len(cmds)-2][0] != "ASKING" || cmds[len(cmds)-1][0] != "GET" {
+		t.Errorf("node a should receive ASKING before GET, got %v", cmds)
+	}
+	// ASK 只影响这一次请求，不更新槽位表
+	if addr := c.addrOf("foo"); addr != f.b.Addr() {
+		t.Errorf("slot of foo should stay on node b after ASK, got %s", addr)
+	}
+}
+
+func TestClusterTooManyRedirects(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+
+	f.a.Handle("GET", func(args []string) interface{} { return redistest.Error("ASK 12182 " + f.b.Addr()) })
+	f.b.Handle("GET", func(args []string) interface{} { return redistest.Error("ASK 12182 " + f.a.Addr()) })
+
+	if _, err := c.Do(context.Background(), "foo", "GET", "foo"); err == nil {
+		t.Error("redirect loop should fail")
+	}
+}
+
+func TestClusterTryAgain(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+
+	f.b.Set("foo", "ok")
+	// 第一次返回 TRYAGAIN，之后交给 miniredis
+	f.b.Handle("GET", func(args []string) interface{} {
+		f.b.Handle("GET", nil)
+		return redistest.Error("TRYAGAIN Multiple keys request during rehashing of slot")
+	})
+
+	v, err := redis.String(c.Do(context.Background(), "foo", "GET", "foo"))
+	if n := f.b.Count("GET"); err != nil || v != "ok" || n != 2 {
+		t.Errorf("GET foo = %q, %v after %d calls, want ok after 2", v, err, n)
+	}
+}
+
+func TestClusterPipeline(t *testing.T) {
+	f := newFakeCluster(t)
+	c := newTestCluster(t, f)
+	old := client
+	client = c
+	defer func() { client = old }()
+	ctx := context.Background()
+
+	replies, err := NewPipeline().Send("SET", "bar", "1").Send("SET", "foo", "2").Send("GET", "bar").Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := redis.String(replies[2], nil); v != "1" {
+		t.Errorf("replies = %v", replies)
+	}
+	if value(f.a, "bar") != "1" || value(f.b, "foo") != "2" {
+		t.Error("pipeline commands should be grouped by node")
+	}
+
+	// 事务中的 key 不在同一槽位
+	if _, err := NewPipeline().Send("SET", "bar", "1").Send("SET", "foo", "2").ExecTx(ctx); err == nil {
+		t.Error("ExecTx across slots should fail")
+	}
+	if _, err := NewPipeline().Send("SET", "{u1}.a", "1").Send("SET", "{u1}.b", "2").ExecTx(ctx); err != nil {
+		t.Errorf("ExecTx in one slot: %v", err)
+	}
+}
+
+func TestClusterSeedDown(t *testing.T) {
+	dead := deadAddr(t)
+	f := newFakeCluster(t)
+	c, err := newClusterClient([]string{dead, f.b.Addr()})
+	if err != nil {
+		t.Fatalf("a live seed should be enough: %v", err)
+	}
+	c.Close()
+
+	if _, err := newClusterClient([]string{dead}); err == nil {
+		t.Error("no live seed should fail")
+	}
+}
@@ -0,0 +1,245 @@
+package gredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// SetEX 写入 key 并设置过期时间，一次往返完成
+func SetEX(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	_, err := Do(ctx, key, "SET", key, value, "PX", ttl.Milliseconds())
+	return err
+}
+
+// SetNX key 不存在时才写入，返回是否写入成功
+func SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	reply, err := redis.String(Do(ctx, key, "SET", key, value, "PX", ttl.Milliseconds(), "NX"))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return reply == "OK", nil
+}
+
+// GetBytes 读取 key，不存在时返回 ErrNil
+func GetBytes(ctx context.Context, key string) ([]byte, error) {
+	return redis.Bytes(Do(ctx, key, "GET", key))
+}
+
+// Del 删除 keys，返回实际删除的数量，集群模式下按节点分组发送
+func Del(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	p := NewPipeline()
+	for _, key := range keys {
+		p.Send("DEL", key)
+	}
+	replies, err := p.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, reply := range replies {
+		if err, ok := reply.(error); ok {
+			return n, err
+		}
+		v, _ := redis.Int64(reply, nil)
+		n += v
+	}
+
+	return n, nil
+}
+
+// Expire 设置过期时间，key 不存在时返回 false
+func Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return redis.Bool(Do(ctx, key, "PEXPIRE", key, ttl.Milliseconds()))
+}
+
+// TTL 返回剩余过期时间，key 不存在为 -2ms，没有过期时间为 -1ms
+func TTL(ctx context.Context, key string) (time.Duration, error) {
+	ms, err := redis.Int64(Do(ctx, key, "PTTL", key))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Incr 计数器加一
+func Incr(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(Do(ctx, key, "INCR", key))
+}
+
+// IncrBy 计数器增加 n
+func IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	return redis.Int64(Do(ctx, key, "INCRBY", key, n))
+}
+
+// incrExpireScript 只在计数器新建时设置过期时间，避免每次递增都顺延窗口
+var incrExpireScript = NewScript(1, `
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if n == tonumber(ARGV[1]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return n`)
+
+// IncrByWithExpire 计数器增加 n，计数器新建时设置过期时间，适用于固定窗口计数
+func IncrByWithExpire(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	return redis.Int64(incrExpireScript.Do(ctx, key, n, ttl.Milliseconds()))
+}
+
+// HSet 设置哈希字段
+func HSet(ctx context.Context, key, field string, value interface{}) error {
+	_, err := Do(ctx, key, "HSET", key, field, value)
+	return err
+}
+
+// HMSet 批量设置哈希字段
+func HMSet(ctx context.Context, key string, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err := Do(ctx, key, "HMSET", redis.Args{}.Add(key).AddFlat(fields)...)
+	return err
+}
+
+// HGet 读取哈希字段，不存在时返回 ErrNil
+func HGet(ctx context.Context, key, field string) (string, error) {
+	return redis.String(Do(ctx, key, "HGET", key, field))
+}
+
+// HGetAll 读取全部哈希字段
+func HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return redis.StringMap(Do(ctx, key, "HGETALL", key))
+}
+
+// HMGet 批量读取哈希字段，不存在的字段返回空串
+func HMGet(ctx context.Context, key string, fields ...string) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return redis.Strings(Do(ctx, key, "HMGET", redis.Args{}.Add(key).AddFlat(fields)...))
+}
+
+// HIncrBy 哈希字段增加 n
+func HIncrBy(ctx context.Context, key, field string, n int64) (int64, error) {
+	return redis.Int64(Do(ctx, key, "HINCRBY", key, field, n))
+}
+
+// HDel 删除哈希字段
+func HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return redis.Int64(Do(ctx, key, "HDEL", redis.Args{}.Add(key).AddFlat(fields)...))
+}
+
+// SAdd 向集合添加成员
+func SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return redis.Int64(Do(ctx, key, "SADD", redis.Args{}.Add(key).Add(members...)...))
+}
+
+// SMembers 返回集合全部成员
+func SMembers(ctx context.Context, key string) ([]string, error) {
+	return redis.Strings(Do(ctx, key, "SMEMBERS", key))
+}
+
+// SRem 从集合删除成员
+func SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return redis.Int64(Do(ctx, key, "SREM", redis.Args{}.Add(key).Add(members...)...))
+}
+
+// PFAdd 向 HyperLogLog 添加元素，返回基数估计是否变化
+func PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	return redis.Bool(Do(ctx, key, "PFADD", redis.Args{}.Add(key).Add(elements...)...))
+}
+
+// PFCount 返回 HyperLogLog 的基数估计，误差约 0.81%
+func PFCount(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(Do(ctx, key, "PFCOUNT", key))
+}
+
+// Z 有序集合成员
+type Z struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// ZAdd 添加或更新有序集合成员
+func ZAdd(ctx context.Context, key string, members ...Z) (int64, error) {
+	args := redis.Args{}.Add(key)
+	for _, z := range members {
+		args = args.Add(z.Score, z.Member)
+	}
+
+	return redis.Int64(Do(ctx, key, "ZADD", args...))
+}
+
+// ZIncrBy 成员分数增加 incr，返回新分数
+func ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	return redis.Float64(Do(ctx, key, "ZINCRBY", key, incr, member))
+}
+
+// ZScore 返回成员分数，不存在时返回 ErrNil
+func ZScore(ctx context.Context, key, member string) (float64, error) {
+	return redis.Float64(Do(ctx, key, "ZSCORE", key, member))
+}
+
+// ZRem 删除成员
+func ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return redis.Int64(Do(ctx, key, "ZREM", redis.Args{}.Add(key).Add(members...)...))
+}
+
+// ZCard 成员数量
+func ZCard(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(Do(ctx, key, "ZCARD", key))
+}
+
+// ZRange 按分数从低到高返回 [start, stop] 名次的成员
+func ZRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	return zWithScores(Do(ctx, key, "ZRANGE", key, start, stop, "WITHSCORES"))
+}
+
+// ZRevRange 按分数从高到低返回 [start, stop] 名次的成员，常用于排行榜
+func ZRevRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	return zWithScores(Do(ctx, key, "ZREVRANGE", key, start, stop, "WITHSCORES"))
+}
+
+// ZRevRank 返回成员从高到低的名次（从 0 开始），不存在时返回 ErrNil
+func ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return redis.Int64(Do(ctx, key, "ZREVRANK", key, member))
+}
+
+// ZRemRangeByRank 删除 [start, stop] 名次的成员，例如只保留前 N 名
+func ZRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
+	return redis.Int64(Do(ctx, key, "ZREMRANGEBYRANK", key, start, stop))
+}
+
+func zWithScores(reply interface{}, err error) ([]Z, error) {
+	values, err := redis.Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Z, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		member, err := redis.String(values[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		score, err := redis.Float64(values[i+1], nil)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Z{Member: member, Score: score})
+	}
+
+	return list, nil
+}
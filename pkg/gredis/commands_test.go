@@ -0,0 +1,115 @@
+package gredis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStringCommands(t *testing.T) {
+	newTestServer(t)
+	ctx := context.Background()
+
+	if ok, err := SetNX(ctx, "nx", "1", time.Minute); err != nil || !ok {
+		t.Fatalf("SetNX() = %v, %v, want true", ok, err)
+	}
+	if ok, err := SetNX(ctx, "nx", "2", time.Minute); err != nil || ok {
+		t.Fatalf("second SetNX() = %v, %v, want false", ok, err)
+	}
+
+	if n, _ := IncrByWithExpire(ctx, "window", 2, time.Minute); n != 2 {
+		t.Errorf("IncrByWithExpire() = %d, want 2", n)
+	}
+	ttl, _ := TTL(ctx, "window")
+	if n, _ := IncrByWithExpire(ctx, "window", 3, time.Hour); n != 5 {
+		t.Errorf("IncrByWithExpire() = %d, want 5", n)
+	}
+	// 已有计数器不顺延过期时间
+	if ttl2, _ := TTL(ctx, "window"); ttl2 > ttl || ttl2 <= 0 {
+		t.Errorf("TTL changed from %v to %v", ttl, ttl2)
+	}
+
+	if n, err := Del(ctx, "nx", "window", "missing"); err != nil || n != 2 {
+		t.Errorf("Del() = %d, %v, want 2", n, err)
+	}
+}
+
+func TestHashCommands(t *testing.T) {
+	newTestServer(t)
+	ctx := context.Background()
+
+	HMSet(ctx, "h", map[string]interface{}{"a": 1, "b": "x"})
+	HSet(ctx, "h", "c", 3)
+	if n, _ := HIncrBy(ctx, "h", "a", 2); n != 3 {
+		t.Errorf("HIncrBy() = %d, want 3", n)
+	}
+	all, _ := HGetAll(ctx, "h")
+	if want := map[string]string{"a": "3", "b": "x", "c": "3"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("HGetAll() = %v, want %v", all, want)
+	}
+	values, _ := HMGet(ctx, "h", "b", "missing")
+	if !reflect.DeepEqual(values, []string{"x", ""}) {
+		t.Errorf("HMGet() = %q", values)
+	}
+	if _, err := HGet(ctx, "h", "missing"); err != ErrNil {
+		t.Errorf("HGet() err = %v, want ErrNil", err)
+	}
+	if n, _ := HDel(ctx, "h", "a", "b"); n != 2 {
+		t.Errorf("HDel() = %d, want 2", n)
+	}
+}
+
+func TestSetCommands(t *testing.T) {
+	newTestServer(t)
+	ctx := context.Background()
+
+	SAdd(ctx, "s", "a", "b", "a")
+	SRem(ctx, "s", "b")
+	if members, _ := SMembers(ctx, "s"); !reflect.DeepEqual(members, []string{"a"}) {
+		t.Errorf("SMembers() = %v", members)
+	}
+
+	if added, _ := PFAdd(ctx, "hll", "u1", "u2"); !added {
+		t.Error("PFAdd() should report a change")
+	}
+	if added, _ := PFAdd(ctx, "hll", "u1"); added {
+		t.Error("PFAdd() of a seen element should not report a change")
+	}
+	if n, _ := PFCount(ctx, "hll"); n != 2 {
+		t.Errorf("PFCount() = %d, want 2", n)
+	}
+}
+
+func TestSortedSetCommands(t *testing.T) {
+	newTestServer(t)
+	ctx := context.Background()
+
+	ZAdd(ctx, "z", Z{"a", 1}, Z{"b", 2}, Z{"c", 3})
+	if score, _ := ZIncrBy(ctx, "z", 2.5, "a"); score != 3.5 {
+		t.Errorf("ZIncrBy() = %v, want 3.5", score)
+	}
+
+	top, err := ZRevRange(ctx, "z", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Z{{"a", 3.5}, {"c", 3}}; !reflect.DeepEqual(top, want) {
+		t.Errorf("ZRevRange() = %v, want %v", top, want)
+	}
+	if rank, _ := ZRevRank(ctx, "z", "b"); rank != 2 {
+		t.Errorf("ZRevRank() = %d, want 2", rank)
+	}
+	if _, err := ZScore(ctx, "z", "missing"); err != ErrNil {
+		t.Errorf("ZScore() err = %v, want ErrNil", err)
+	}
+
+	// 只保留前两名
+	ZRemRangeByRank(ctx, "z", 0, -3)
+	if n, _ := ZCard(ctx, "z"); n != 2 {
+		t.Errorf("ZCard() = %d, want 2", n)
+	}
+	if low, _ := ZRange(ctx, "z", 0, 0); len(low) != 1 || low[0].Member != "c" {
+		t.Errorf("ZRange() = %v", low)
+	}
+}
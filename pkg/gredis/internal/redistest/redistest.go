@@ -0,0 +1,108 @@
+// Package redistest 在 miniredis 上注入自定义回复，用于测试集群重定向和哨兵等 miniredis 不支持的场景
+package redistest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// Status 简单字符串回复，例如 OK
+type Status string
+
+// Error 错误回复，例如 MOVED 3999 127.0.0.1:6381
+type Error string
+
+// HandlerFunc 自定义命令，args 不含命令名；返回 nil 时回复空值
+type HandlerFunc func(args []string) interface{}
+
+// Server miniredis 服务，注册了 HandlerFunc 的命令不再交给 miniredis 执行
+type Server struct {
+	*miniredis.Miniredis
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	commands [][]string
+}
+
+// NewServer 启动服务，测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	s := &Server{Miniredis: miniredis.RunT(t), handlers: make(map[string]HandlerFunc)}
+	s.Server().SetPreHook(s.hook)
+
+	return s
+}
+
+// Handle 注册或覆盖命令，cmd 不区分大小写；h 为 nil 时恢复 miniredis 的实现
+func (s *Server) Handle(cmd string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h == nil {
+		delete(s.handlers, strings.ToUpper(cmd))
+		return
+	}
+	s.handlers[strings.ToUpper(cmd)] = h
+}
+
+// Commands 收到的全部命令，命令名为大写
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]string(nil), s.commands...)
+}
+
+// Count 收到名为 cmd 的命令的次数
+func (s *Server) Count(cmd string) int {
+	n := 0
+	for _, args := range s.Commands() {
+		if args[0] == strings.ToUpper(cmd) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (s *Server) hook(peer *server.Peer, cmd string, args ...string) bool {
+	s.mu.Lock()
+	s.commands = append(s.commands, append([]string{cmd}, args...))
+	h := s.handlers[cmd]
+	s.mu.Unlock()
+
+	if h == nil {
+		return false
+	}
+	writeReply(peer, h(args))
+
+	return true
+}
+
+func writeReply(peer *server.Peer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		peer.WriteNull()
+	case Status:
+		peer.WriteInline(string(v))
+	case Error:
+		peer.WriteError(string(v))
+	case int:
+		peer.WriteInt(v)
+	case string:
+		peer.WriteBulk(v)
+	case []string:
+		peer.WriteStrings(v)
+	case []interface{}:
+		peer.WriteLen(len(v))
+		for _, item := range v {
+			writeReply(peer, item)
+		}
+	default:
+		peer.WriteError(fmt.Sprintf("ERR redistest: unsupported reply %T", v))
+	}
+}
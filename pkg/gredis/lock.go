@@ -0,0 +1,188 @@
+package gredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	// ErrLockNotObtained 锁已被其他持有者占用
+	ErrLockNotObtained = errors.New("redis lock: not obtained")
+	// ErrLockNotHeld 锁已过期或已被其他持有者获得
+	ErrLockNotHeld = errors.New("redis lock: not held")
+)
+
+// fenceTTL 栅栏计数器在没有人加锁后保留的时间
+const fenceTTL = 24 * time.Hour
+
+// obtainScript 加锁成功时递增栅栏计数器并返回新的栅栏值，加锁失败返回 0；
+// 栅栏值取计数器加一与当前毫秒时间戳中较大者，计数器过期后重新从时间戳开始，仍然单调递增
+var obtainScript = NewScript(2, `
+if not redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 0
+end
+local fence = redis.call("INCR", KEYS[2])
+if fence < tonumber(ARGV[3]) then
+	fence = tonumber(ARGV[3])
+	redis.call("SET", KEYS[2], ARGV[3])
+end
+redis.call("PEXPIRE", KEYS[2], ARGV[4])
+return fence
+`)
+
+// releaseScript 只删除自己持有的锁
+var releaseScript = NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// refreshScript 只延长自己持有的锁
+var refreshScript = NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Lock 基于 SET NX PX 的分布式锁
+//
+// 锁只用于减少并发冲突：锁过期后可能被其他进程获得，Redis 不可用时也无法加锁，
+// 需要保证唯一性的写入应由数据库的唯一索引或事务内的检查兜底。
+// 每次加锁得到一个递增的栅栏值（Fence），存储端记录最后写入的栅栏值并拒绝更小的值，
+// 即可挡住锁过期后仍在执行的旧持有者
+type Lock struct {
+	key   string
+	token string
+	fence int64
+}
+
+// lockKey 锁的 key，名称放在 hash tag 中，集群模式下与栅栏计数器位于同一个槽
+func lockKey(name string) string {
+	return "LOCK_{" + name + "}"
+}
+
+func fenceKey(name string) string {
+	return lockKey(name) + "_FENCE"
+}
+
+// Obtain 尝试获取锁一次，被占用时返回 ErrLockNotObtained
+func Obtain(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := lockKey(name)
+	fence, err := redis.Int64(obtainScript.Do(ctx, key, fenceKey(name), token, ttl.Milliseconds(),
+		time.Now().UnixMilli(), fenceTTL.Milliseconds()))
+	if err != nil {
+		return nil, err
+	}
+	if fence == 0 {
+		return nil, ErrLockNotObtained
+	}
+
+	return &Lock{key: key, token: token, fence: fence}, nil
+}
+
+// ObtainWait 每隔 interval 重试获取锁，直到成功或 ctx 结束
+func ObtainWait(ctx context.Context, name string, ttl, interval time.Duration) (*Lock, error) {
+	for {
+		lock, err := Obtain(ctx, name, ttl)
+		if err != ErrLockNotObtained {
+			// ctx 在请求 Redis 期间结束时同样视为未获取到锁；连接按 ctx 的截止时间超时，可能早于 ctx.Err() 返回
+			if err != nil && (ctx.Err() != nil || expired(ctx)) {
+				return nil, ErrLockNotObtained
+			}
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockNotObtained
+		case <-time.After(interval):
+		}
+	}
+}
+
+// expired ctx 的截止时间是否已过
+func expired(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// Token 持有者标识
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// Fence 加锁时得到的栅栏值，同一把锁后获得的持有者总是更大
+func (l *Lock) Fence() int64 {
+	return l.fence
+}
+
+// Release 释放锁，锁已不属于自己时返回 ErrLockNotHeld
+func (l *Lock) Release(ctx context.Context) error {
+	n, err := redis.Int64(releaseScript.Do(ctx, l.key, l.token))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Refresh 把锁的过期时间重置为 ttl，用于执行时间较长的任务
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := redis.Int64(refreshScript.Do(ctx, l.key, l.token, ttl.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// KeepAlive 每隔 ttl/3 把锁的过期时间重置为 ttl，直到调用返回的 stop；用于执行时间不确定的任务，
+// 锁已被其他持有者获得时停止续期
+func (l *Lock) KeepAlive(ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := l.Refresh(context.Background(), ttl); err == ErrLockNotHeld {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
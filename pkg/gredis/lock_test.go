@@ -0,0 +1,167 @@
+package gredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis/internal/redistest"
+)
+
+func TestLock(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	l1, err := Obtain(ctx, "order", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Obtain(ctx, "order", time.Second); err != ErrLockNotObtained {
+		t.Fatalf("second Obtain() err = %v, want ErrLockNotObtained", err)
+	}
+	if err := l1.Refresh(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := l1.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l1.Release(ctx); err != ErrLockNotHeld {
+		t.Errorf("second Release() err = %v, want ErrLockNotHeld", err)
+	}
+
+	// 过期后被其他持有者获得，原持有者不能再释放或延长
+	l2, err := Obtain(ctx, "order", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.FastForward(time.Second)
+	l3, err := Obtain(ctx, "order", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l2.Release(ctx); err != ErrLockNotHeld {
+		t.Errorf("expired lock Release() err = %v, want ErrLockNotHeld", err)
+	}
+	if err := l2.Refresh(ctx, time.Second); err != ErrLockNotHeld {
+		t.Errorf("expired lock Refresh() err = %v, want ErrLockNotHeld", err)
+	}
+	if err := l3.Release(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLockFence(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	if Slot(lockKey("order")) != Slot(fenceKey("order")) {
+		t.Fatal("lock and fence keys should be in the same slot")
+	}
+
+	var last int64
+	for i := 0; i < 3; i++ {
+		l, err := Obtain(ctx, "order", time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Fence() <= last {
+			t.Fatalf("Fence() = %d, want > %d", l.Fence(), last)
+		}
+		last = l.Fence()
+		// 加锁失败不递增栅栏值
+		if _, err := Obtain(ctx, "order", time.Second); err != ErrLockNotObtained {
+			t.Fatalf("second Obtain() err = %v, want ErrLockNotObtained", err)
+		}
+		if err := l.Release(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := s.TTL(fenceKey("order")); got != fenceTTL {
+		t.Errorf("fence TTL = %v, want %v", got, fenceTTL)
+	}
+
+	// 计数器过期后从时间戳重新开始，仍大于之前的栅栏值
+	s.FastForward(fenceTTL)
+	time.Sleep(5 * time.Millisecond)
+	l, err := Obtain(ctx, "order", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Fence() <= last {
+		t.Errorf("Fence() after counter expired = %d, want > %d", l.Fence(), last)
+	}
+}
+
+func TestObtainWait(t *testing.T) {
+	newTestServer(t)
+
+	l, err := Obtain(context.Background(), "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ObtainWait(ctx, "job", time.Minute, 10*time.Millisecond); err != ErrLockNotObtained {
+		t.Errorf("ObtainWait() err = %v, want ErrLockNotObtained", err)
+	}
+}
+
+func TestObtainWaitDeadlineDuringRequest(t *testing.T) {
+	s := newTestServer(t)
+	// 加锁脚本超过 ctx 的截止时间才返回
+	s.Handle("EVALSHA", func(args []string) interface{} {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := ObtainWait(ctx, "job", time.Minute, time.Millisecond); err != ErrLockNotObtained {
+		t.Errorf("ObtainWait() err = %v, want ErrLockNotObtained", err)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	l, err := Obtain(ctx, "flush", 150*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 剩余 50ms 时开始续期，每 50ms 重置为 150ms
+	s.FastForward(100 * time.Millisecond)
+	stop := l.KeepAlive(150 * time.Millisecond)
+	time.Sleep(120 * time.Millisecond)
+	if ttl := s.TTL(lockKey("flush")); ttl != 150*time.Millisecond {
+		t.Fatalf("TTL during KeepAlive = %v, want 150ms", ttl)
+	}
+
+	stop()
+	stop()
+	s.FastForward(150 * time.Millisecond)
+	if _, err := Obtain(ctx, "flush", time.Second); err != nil {
+		t.Errorf("Obtain() after stop err = %v", err)
+	}
+}
+
+func TestScriptFallsBackToEval(t *testing.T) {
+	s := newTestServer(t)
+	l, err := Obtain(context.Background(), "eval", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Handle("EVALSHA", func(args []string) interface{} {
+		return redistest.Error("NOSCRIPT No matching script. Please use EVAL.")
+	})
+	evals := s.Count("EVAL")
+	if err := l.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Count("EVAL") - evals; n != 1 {
+		t.Errorf("EVAL sent %d times, want 1", n)
+	}
+}
@@ -0,0 +1,138 @@
+package gredis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// pipelineCmd pipeline 中的一条命令
+type pipelineCmd struct {
+	key  string
+	name string
+	args []interface{}
+}
+
+// Pipeline 批量发送命令以减少往返，集群模式下按节点分组发送
+type Pipeline struct {
+	cmds []pipelineCmd
+}
+
+// NewPipeline 创建 pipeline
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Send 添加命令，第一个参数作为路由用的 key
+func (p *Pipeline) Send(cmd string, args ...interface{}) *Pipeline {
+	key := ""
+	if len(args) > 0 {
+		key, _ = args[0].(string)
+	}
+	p.cmds = append(p.cmds, pipelineCmd{key: key, name: cmd, args: args})
+
+	return p
+}
+
+// Len 命令数量
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Exec 执行全部命令，返回与命令一一对应的结果，单条命令的错误以 redis.Error 形式放在结果中
+// 集群重定向不会自动重试，槽位迁移期间个别命令可能返回 MOVED
+func (p *Pipeline) Exec(ctx context.Context) ([]interface{}, error) {
+	replies := make([]interface{}, len(p.cmds))
+
+	groups := map[string][]int{}
+	var order []string
+	for i, cmd := range p.cmds {
+		addr := nodeAddr(cmd.key)
+		if _, ok := groups[addr]; !ok {
+			order = append(order, addr)
+		}
+		groups[addr] = append(groups[addr], i)
+	}
+
+	for _, addr := range order {
+		indexes := groups[addr]
+		if err := p.execOn(ctx, p.cmds[indexes[0]].key, indexes, replies); err != nil {
+			return replies, err
+		}
+	}
+
+	return replies, nil
+}
+
+func (p *Pipeline) execOn(ctx context.Context, key string, indexes []int, replies []interface{}) error {
+	conn, err := client.Conn(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, i := range indexes {
+		if err := conn.Send(p.cmds[i].name, p.cmds[i].args...); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	for _, i := range indexes {
+		reply, err := conn.Receive()
+		if _, ok := err.(redis.Error); ok {
+			replies[i] = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		replies[i] = reply
+	}
+
+	return nil
+}
+
+// ExecTx 以 MULTI / EXEC 事务执行，集群模式下全部 key 必须在同一槽位，可以用 {hash tag} 保证
+func (p *Pipeline) ExecTx(ctx context.Context) ([]interface{}, error) {
+	if len(p.cmds) == 0 {
+		return nil, nil
+	}
+
+	key := p.cmds[0].key
+	if _, ok := client.(*clusterClient); ok {
+		slot := Slot(key)
+		for _, cmd := range p.cmds[1:] {
+			if cmd.key != "" && Slot(cmd.key) != slot {
+				return nil, errors.New("redis: transaction keys must be in the same slot")
+			}
+		}
+	}
+
+	conn, err := client.Conn(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	for _, cmd := range p.cmds {
+		if err := conn.Send(cmd.name, cmd.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	return redis.Values(doConn(ctx, conn, "EXEC"))
+}
+
+// nodeAddr 返回 key 所在节点的地址，非集群模式全部在同一节点
+func nodeAddr(key string) string {
+	if c, ok := client.(*clusterClient); ok {
+		return c.addrOf(key)
+	}
+
+	return ""
+}
@@ -1,73 +1,59 @@
 package gredis
 
 import (
+	"context"
 	"encoding/json"
-	"time"
+	"strings"
 
 	"github.com/gomodule/redigo/redis"
 
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
 )
 
-var RedisConn *redis.Pool
+// ErrNil key 不存在
+var ErrNil = redis.ErrNil
 
-// Setup Initialize the Redis instance
+var client Client
+
+// Setup Initialize the Redis client according to the configured mode
 func Setup() error {
-	RedisConn = &redis.Pool{
-		MaxIdle:     setting.RedisSetting.MaxIdle,
-		MaxActive:   setting.RedisSetting.MaxActive,
-		IdleTimeout: setting.RedisSetting.IdleTimeout,
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", setting.RedisSetting.Host)
-			if err != nil {
-				return nil, err
-			}
-			if setting.RedisSetting.Password != "" {
-				if _, err := c.Do("AUTH", setting.RedisSetting.Password); err != nil {
-					c.Close()
-					return nil, err
-				}
-			}
-			return c, err
-		},
-		TestOnBorrow: func(c redis.Conn, t time.Time) error {
-			_, err := c.Do("PING")
-			return err
-		},
+	var err error
+	switch setting.RedisSetting.Mode {
+	case ModeSentinel:
+		client = newSentinelClient(setting.RedisSetting.Addrs, setting.RedisSetting.MasterName)
+	case ModeCluster:
+		client, err = newClusterClient(setting.RedisSetting.Addrs)
+	default:
+		client = newStandaloneClient(setting.RedisSetting.Host)
 	}
 
-	return nil
+	return err
+}
+
+// Do executes a command on the node owning key
+func Do(ctx context.Context, key, cmd string, args ...interface{}) (interface{}, error) {
+	return client.Do(ctx, key, cmd, args...)
+}
+
+// Conn gets a connection of the node owning key, the caller must close it
+func Conn(ctx context.Context, key string) (redis.Conn, error) {
+	return client.Conn(ctx, key)
 }
 
 // Set a key/value
 func Set(key string, data interface{}, time int) error {
-	conn := RedisConn.Get()
-	defer conn.Close()
-
 	value, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	_, err = conn.Do("SET", key, value)
-	if err != nil {
-		return err
-	}
-
-	_, err = conn.Do("EXPIRE", key, time)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	_, err = Do(context.Background(), key, "SET", key, value, "EX", time)
+	return err
 }
 
 // Exists check a key
 func Exists(key string) bool {
-	conn := RedisConn.Get()
-	defer conn.Close()
-
-	exists, err := redis.Bool(conn.Do("EXISTS", key))
+	exists, err := redis.Bool(Do(context.Background(), key, "EXISTS", key))
 	if err != nil {
 		return false
 	}
@@ -77,41 +63,107 @@ func Exists(key string) bool {
 
 // Get get a key
 func Get(key string) ([]byte, error) {
-	conn := RedisConn.Get()
-	defer conn.Close()
-
-	reply, err := redis.Bytes(conn.Do("GET", key))
-	if err != nil {
-		return nil, err
-	}
-
-	return reply, nil
+	return redis.Bytes(Do(context.Background(), key, "GET", key))
 }
 
 // Delete delete a kye
 func Delete(key string) (bool, error) {
-	conn := RedisConn.Get()
-	defer conn.Close()
-
-	return redis.Bool(conn.Do("DEL", key))
+	return redis.Bool(Do(context.Background(), key, "DEL", key))
 }
 
-// LikeDeletes batch delete
+// likeDeletesBatch is the COUNT hint of SCAN, keys are unlinked once per SCAN page
+const likeDeletesBatch = 500
+
+// LikeDeletes batch delete keys containing key, iterating every master with SCAN so Redis is never blocked
 func LikeDeletes(key string) error {
-	conn := RedisConn.Get()
+	for _, pool := range client.Nodes() {
+		if err := likeDeletes(pool, "*"+key+"*"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func likeDeletes(pool *redis.Pool, pattern string) error {
+	conn := pool.Get()
 	defer conn.Close()
 
-	keys, err := redis.Strings(conn.Do("KEYS", "*"+key+"*"))
-	if err != nil {
-		return err
+	cursor := 0
+	cmd := "UNLINK"
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", likeDeletesBatch))
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		if _, err := redis.Scan(values, &cursor, &keys); err != nil {
+			return err
+		}
+
+		// 集群中多 key 命令要求同一槽位，因此逐个 key 删除，通过 pipeline 一次往返
+		if len(keys) > 0 {
+			err = unlinkKeys(conn, cmd, keys)
+			if err != nil && cmd == "UNLINK" && isUnknownCommand(err) {
+				// UNLINK 在后台线程释放内存，Redis 4.0 之前退回 DEL
+				cmd = "DEL"
+				err = unlinkKeys(conn, cmd, keys)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
 	}
+}
 
+func unlinkKeys(conn redis.Conn, cmd string, keys []string) error {
 	for _, key := range keys {
-		_, err = Delete(key)
-		if err != nil {
+		if err := conn.Send(cmd, key); err != nil {
 			return err
 		}
 	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
 
-	return nil
+	var firstErr error
+	for range keys {
+		if _, err := conn.Receive(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func isUnknownCommand(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && strings.HasPrefix(strings.ToLower(string(e)), "err unknown command")
+}
+
+// generationKey is where the generation counter of a namespace is stored
+func generationKey(namespace string) string {
+	return "GEN_" + namespace
+}
+
+// Generation get the current generation of a namespace, 0 if it has never been bumped
+func Generation(namespace string) (int64, error) {
+	key := generationKey(namespace)
+	gen, err := redis.Int64(Do(context.Background(), key, "GET", key))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+
+	return gen, err
+}
+
+// BumpGeneration increase the generation of a namespace, keys built with an older
+// generation are never read again and simply expire
+func BumpGeneration(namespace string) (int64, error) {
+	return Incr(context.Background(), generationKey(namespace))
 }
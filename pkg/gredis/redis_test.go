@@ -0,0 +1,101 @@
+package gredis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis/internal/redistest"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func newTestServer(t *testing.T) *redistest.Server {
+	s := redistest.NewServer(t)
+
+	saved := *setting.RedisSetting
+	t.Cleanup(func() { *setting.RedisSetting = saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = s.Addr()
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestLikeDeletes(t *testing.T) {
+	s := newTestServer(t)
+	// 超过一页 SCAN 的数量
+	for i := 0; i < 2*likeDeletesBatch+1; i++ {
+		s.Set("ARTICLE_LIST_"+strconv.Itoa(i), "1")
+	}
+	s.Set("TAG_LIST_1", "1")
+
+	if err := LikeDeletes("ARTICLE"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := s.Keys(); len(keys) != 1 || keys[0] != "TAG_LIST_1" {
+		t.Errorf("keys after LikeDeletes = %d keys, want only TAG_LIST_1", len(keys))
+	}
+	if s.Count("KEYS") != 0 || s.Count("UNLINK") == 0 {
+		t.Error("LikeDeletes should use SCAN and UNLINK")
+	}
+}
+
+func TestLikeDeletesFallsBackToDel(t *testing.T) {
+	s := newTestServer(t)
+	s.Handle("UNLINK", func(args []string) interface{} {
+		return redistest.Error("ERR unknown command 'UNLINK'")
+	})
+	s.Set("ARTICLE_1", "1")
+
+	if err := LikeDeletes("ARTICLE"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("ARTICLE_1") {
+		t.Error("key should be deleted with DEL")
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	newTestServer(t)
+
+	if gen, err := Generation("ARTICLE"); err != nil || gen != 0 {
+		t.Fatalf("Generation() = %d, %v, want 0", gen, err)
+	}
+	BumpGeneration("ARTICLE")
+	if gen, err := BumpGeneration("ARTICLE"); err != nil || gen != 2 {
+		t.Fatalf("BumpGeneration() = %d, %v, want 2", gen, err)
+	}
+	if gen, err := Generation("ARTICLE"); err != nil || gen != 2 {
+		t.Errorf("Generation() = %d, %v, want 2", gen, err)
+	}
+	if gen, _ := Generation("TAG"); gen != 0 {
+		t.Errorf("Generation(TAG) = %d, want 0", gen)
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	newTestServer(t)
+	ctx := context.Background()
+
+	if err := Set("k", map[string]int{"a": 1}, 60); err != nil {
+		t.Fatal(err)
+	}
+	if !Exists("k") {
+		t.Fatal("k should exist")
+	}
+	data, err := Get("k")
+	if err != nil || string(data) != `{"a":1}` {
+		t.Errorf("Get() = %s, %v", data, err)
+	}
+	if ttl, err := TTL(ctx, "k"); err != nil || ttl <= 0 {
+		t.Errorf("TTL() = %v, %v", ttl, err)
+	}
+	if ok, _ := Delete("k"); !ok || Exists("k") {
+		t.Error("k should be deleted")
+	}
+	if _, err := GetBytes(ctx, "k"); err != ErrNil {
+		t.Errorf("GetBytes() err = %v, want ErrNil", err)
+	}
+}
@@ -0,0 +1,44 @@
+package gredis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Script Lua 脚本，优先使用 EVALSHA，服务端没有缓存时自动退回 EVAL
+type Script struct {
+	keyCount int
+	src      string
+	hash     string
+}
+
+// NewScript 创建脚本，keyCount 为 KEYS 的数量，集群模式下全部 KEYS 必须在同一槽位
+func NewScript(keyCount int, src string) *Script {
+	sum := sha1.Sum([]byte(src))
+
+	return &Script{keyCount: keyCount, src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Do 执行脚本，按第一个 key 路由
+func (s *Script) Do(ctx context.Context, keysAndArgs ...interface{}) (interface{}, error) {
+	key := ""
+	if s.keyCount > 0 && len(keysAndArgs) > 0 {
+		key, _ = keysAndArgs[0].(string)
+	}
+
+	args := make([]interface{}, 0, len(keysAndArgs)+2)
+	args = append(args, s.hash, s.keyCount)
+	args = append(args, keysAndArgs...)
+
+	reply, err := client.Do(ctx, key, "EVALSHA", args...)
+	if e, ok := err.(redis.Error); ok && strings.HasPrefix(string(e), "NOSCRIPT") {
+		args[0] = s.src
+		reply, err = client.Do(ctx, key, "EVAL", args...)
+	}
+
+	return reply, err
+}
@@ -0,0 +1,108 @@
+package gredis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// sentinel 通过哨兵查找当前主节点
+type sentinel struct {
+	mu         sync.Mutex
+	addrs      []string
+	masterName string
+}
+
+// masterAddr 依次询问哨兵，返回主节点地址，成功的哨兵移到最前面
+func (s *sentinel) masterAddr() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastErr error
+	for i, addr := range s.addrs {
+		master, err := s.queryMaster(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		s.addrs[0], s.addrs[i] = s.addrs[i], s.addrs[0]
+		return master, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no sentinel configured")
+	}
+	return "", fmt.Errorf("redis sentinel: no master found for %s: %w", s.masterName, lastErr)
+}
+
+func (s *sentinel) queryMaster(addr string) (string, error) {
+	c, err := redis.Dial("tcp", addr, dialOptions()...)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	reply, err := redis.Strings(c.Do("SENTINEL", "get-master-addr-by-name", s.masterName))
+	if err != nil {
+		return "", err
+	}
+	if len(reply) != 2 {
+		return "", fmt.Errorf("unexpected sentinel reply: %v", reply)
+	}
+
+	return net.JoinHostPort(reply[0], reply[1]), nil
+}
+
+// isMaster 检查连接的节点仍然是主节点，故障转移后旧连接会被连接池丢弃
+func isMaster(c redis.Conn) error {
+	reply, err := redis.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return errors.New("empty ROLE reply")
+	}
+
+	role, err := redis.String(reply[0], nil)
+	if err != nil {
+		return err
+	}
+	if role != "master" {
+		return fmt.Errorf("redis sentinel: connected node is %s", role)
+	}
+
+	return nil
+}
+
+// newSentinelClient 哨兵模式，每次建立连接时向哨兵查询主节点
+func newSentinelClient(addrs []string, masterName string) Client {
+	s := &sentinel{addrs: append([]string(nil), addrs...), masterName: masterName}
+
+	return &poolClient{pool: newPool(func() (redis.Conn, error) {
+		addr, err := s.masterAddr()
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := dialNode(addr)
+		if err != nil {
+			return nil, err
+		}
+		if err := isMaster(c); err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		return c, nil
+	}, func(c redis.Conn, t time.Time) error {
+		if time.Since(t) < time.Second {
+			return nil
+		}
+		return isMaster(c)
+	})}
+}
@@ -0,0 +1,138 @@
+package gredis
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis/internal/redistest"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// fakeSentinel 一个哨兵和两个数据节点，可以模拟故障转移
+type fakeSentinel struct {
+	sentinel *redistest.Server
+	nodes    [2]*redistest.Server
+
+	mu     sync.Mutex
+	master int
+}
+
+func newFakeSentinel(t *testing.T) *fakeSentinel {
+	f := &fakeSentinel{sentinel: redistest.NewServer(t)}
+	f.sentinel.Handle("SENTINEL", func(args []string) interface{} {
+		if len(args) != 2 || args[0] != "get-master-addr-by-name" || args[1] != "mymaster" {
+			return nil
+		}
+		host, port, _ := net.SplitHostPort(f.nodes[f.current()].Addr())
+		return []string{host, port}
+	})
+
+	for i := range f.nodes {
+		i := i
+		f.nodes[i] = redistest.NewServer(t)
+		f.nodes[i].Handle("ROLE", func(args []string) interface{} {
+			if f.current() == i {
+				return []interface{}{"master", 0, []interface{}{}}
+			}
+			return []interface{}{"slave", "127.0.0.1", 6379, "connected", 0}
+		})
+	}
+
+	return f
+}
+
+func (f *fakeSentinel) current() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.master
+}
+
+func (f *fakeSentinel) failover() {
+	f.mu.Lock()
+	f.master = 1 - f.master
+	f.mu.Unlock()
+}
+
+func deadAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	return ln.Addr().String()
+}
+
+func TestSentinelMasterAddr(t *testing.T) {
+	f := newFakeSentinel(t)
+	dead := deadAddr(t)
+
+	s := &sentinel{addrs: []string{dead, f.sentinel.Addr()}, masterName: "mymaster"}
+	addr, err := s.masterAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != f.nodes[0].Addr() {
+		t.Errorf("masterAddr() = %s, want %s", addr, f.nodes[0].Addr())
+	}
+	// 可用的哨兵移到最前面
+	if s.addrs[0] != f.sentinel.Addr() {
+		t.Errorf("addrs = %v, working sentinel should be first", s.addrs)
+	}
+
+	unknown := &sentinel{addrs: []string{f.sentinel.Addr()}, masterName: "other"}
+	if _, err := unknown.masterAddr(); err == nil {
+		t.Error("unknown master name should fail")
+	}
+	none := &sentinel{addrs: []string{dead}, masterName: "mymaster"}
+	if _, err := none.masterAddr(); err == nil {
+		t.Error("no reachable sentinel should fail")
+	}
+}
+
+func TestSentinelFailover(t *testing.T) {
+	saved := *setting.RedisSetting
+	defer func() { *setting.RedisSetting = saved }()
+	// 保留空闲连接，故障转移后借出时才会检查节点角色
+	setting.RedisSetting.MaxIdle = 4
+
+	f := newFakeSentinel(t)
+	c := newSentinelClient([]string{f.sentinel.Addr()}, "mymaster")
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.Do(ctx, "k1", "SET", "k1", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if value(f.nodes[0], "k1") != "v1" {
+		t.Fatal("k1 should be written to the first master")
+	}
+
+	// 故障转移后，空闲连接在借出时发现节点已变为从节点而被丢弃，新连接指向新的主节点
+	f.failover()
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := c.Do(ctx, "k2", "SET", "k2", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if value(f.nodes[1], "k2") != "v2" || f.nodes[0].Exists("k2") {
+		t.Error("k2 should be written to the new master only")
+	}
+}
+
+func TestSentinelStaleMaster(t *testing.T) {
+	f := newFakeSentinel(t)
+	// 哨兵还没有感知到故障转移，报告的主节点实际已是从节点
+	f.nodes[0].Handle("ROLE", func(args []string) interface{} {
+		return []interface{}{"slave", "127.0.0.1", 6379, "connected", 0}
+	})
+
+	c := newSentinelClient([]string{f.sentinel.Addr()}, "mymaster")
+	defer c.Close()
+	if _, err := c.Do(context.Background(), "k", "SET", "k", "v"); err == nil {
+		t.Error("writing through a replica should fail")
+	}
+}
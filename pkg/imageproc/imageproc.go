@@ -0,0 +1,191 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// 缩放方式
+const (
+	FitCover   = "cover"   // 填满目标尺寸，超出部分从中间裁掉
+	FitContain = "contain" // 完整显示在目标尺寸内，不裁剪
+)
+
+// 输出格式，WebP 只能解码，没有可用的编码器
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// DefaultQuality 未指定质量时 JPEG 的编码质量
+const DefaultQuality = 85
+
+var (
+	// ErrInvalidOptions 参数超出范围
+	ErrInvalidOptions = errors.New("imageproc: invalid options")
+	// ErrNotEncodable 不支持编码为该格式
+	ErrNotEncodable = errors.New("imageproc: format is not encodable")
+)
+
+// contentTypes 可以编码的格式
+var contentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+}
+
+// Options 图片处理参数，Width、Height 为 0 时按另一边等比缩放，都为 0 时保持原尺寸
+type Options struct {
+	Width   int
+	Height  int
+	Fit     string
+	Format  string // 为空时使用原图格式，原图不能编码时（GIF、WebP）使用 PNG
+	Quality int    // 1-100，只对 JPEG 有效
+}
+
+// Normalize 检查参数并填充默认值，maxWidth、maxHeight 为允许的最大尺寸
+func (o *Options) Normalize(maxWidth, maxHeight int) error {
+	if o.Width < 0 || o.Height < 0 || o.Width > maxWidth || o.Height > maxHeight {
+		return ErrInvalidOptions
+	}
+	switch o.Fit {
+	case "":
+		o.Fit = FitCover
+	case FitCover, FitContain:
+	default:
+		return ErrInvalidOptions
+	}
+	if o.Format == "jpg" {
+		o.Format = FormatJPEG
+	}
+	if _, ok := contentTypes[o.Format]; !ok && o.Format != "" {
+		if o.Format == FormatWebP {
+			return ErrNotEncodable
+		}
+		return ErrInvalidOptions
+	}
+	if o.Quality == 0 {
+		o.Quality = DefaultQuality
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return ErrInvalidOptions
+	}
+
+	return nil
+}
+
+// Key 参数的规范形式，相同的参数生成相同的 key，可用于缓存和签名
+func (o *Options) Key() string {
+	return fmt.Sprintf("w%d-h%d-%s-q%d-%s", o.Width, o.Height, o.Fit, o.Quality, o.Format)
+}
+
+// Process 按参数缩放、裁剪原图并编码，返回编码后的数据和 MIME 类型
+func Process(src []byte, o Options) ([]byte, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if o.Format == "" {
+		o.Format = format
+		if _, ok := contentTypes[format]; !ok {
+			o.Format = FormatPNG
+		}
+	}
+	contentType, ok := contentTypes[o.Format]
+	if !ok {
+		return nil, "", ErrNotEncodable
+	}
+
+	dst := resize(img, o)
+
+	var buf bytes.Buffer
+	if o.Format == FormatJPEG {
+		err = jpeg.Encode(&buf, flatten(dst), &jpeg.Options{Quality: o.Quality})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), contentType, nil
+}
+
+// resize 只缩小不放大，目标尺寸大于原图时保持原图尺寸
+func resize(src image.Image, o Options) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	w, h := o.Width, o.Height
+	if w == 0 && h == 0 || sw == 0 || sh == 0 {
+		return src
+	}
+	// 只指定一边时按原图比例计算另一边，此时 cover 和 contain 相同
+	if w == 0 {
+		w = max(1, sw*h/sh)
+	}
+	if h == 0 {
+		h = max(1, sh*w/sw)
+	}
+
+	srcRect := sb
+	var scale float64
+	if o.Fit == FitCover {
+		// 按目标比例从原图中间裁出一块
+		if sw*h > sh*w {
+			cw := sh * w / h
+			x := sb.Min.X + (sw-cw)/2
+			srcRect = image.Rect(x, sb.Min.Y, x+cw, sb.Max.Y)
+		} else {
+			ch := sw * h / w
+			y := sb.Min.Y + (sh-ch)/2
+			srcRect = image.Rect(sb.Min.X, y, sb.Max.X, y+ch)
+		}
+		scale = math.Min(float64(w)/float64(srcRect.Dx()), 1)
+	} else {
+		scale = math.Min(math.Min(float64(w)/float64(sw), float64(h)/float64(sh)), 1)
+	}
+
+	dw := max(1, int(math.Round(float64(srcRect.Dx())*scale)))
+	dh := max(1, int(math.Round(float64(srcRect.Dy())*scale)))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+
+	return dst
+}
+
+// flatten JPEG 没有透明通道，透明部分以白色作为背景
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// ContentType 格式对应的 MIME 类型，不能编码的格式返回空
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Ext 格式对应的扩展名
+func Ext(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+
+	return "." + format
+}
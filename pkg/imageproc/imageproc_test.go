@@ -0,0 +1,89 @@
+package imageproc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   Options
+		want Options
+		err  error
+	}{
+		{Options{Width: 100}, Options{Width: 100, Fit: FitCover, Quality: DefaultQuality}, nil},
+		{Options{Height: 50, Fit: FitContain, Format: "jpg", Quality: 60}, Options{Height: 50, Fit: FitContain, Format: FormatJPEG, Quality: 60}, nil},
+		{Options{Width: 3000}, Options{}, ErrInvalidOptions},
+		{Options{Width: -1}, Options{}, ErrInvalidOptions},
+		{Options{Fit: "stretch"}, Options{}, ErrInvalidOptions},
+		{Options{Format: "gif"}, Options{}, ErrInvalidOptions},
+		{Options{Format: FormatWebP}, Options{}, ErrNotEncodable},
+		{Options{Quality: 101}, Options{}, ErrInvalidOptions},
+	}
+	for _, tt := range tests {
+		o := tt.in
+		err := o.Normalize(2048, 2048)
+		if err != tt.err {
+			t.Errorf("Normalize(%+v) err = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && o != tt.want {
+			t.Errorf("Normalize(%+v) = %+v, want %+v", tt.in, o, tt.want)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	src := testPNG(t, 400, 200)
+
+	tests := []struct {
+		o           Options
+		w, h        int
+		contentType string
+	}{
+		{Options{Width: 100, Height: 100, Fit: FitCover, Quality: DefaultQuality}, 100, 100, "image/png"},
+		{Options{Width: 100, Height: 100, Fit: FitContain, Quality: DefaultQuality}, 100, 50, "image/png"},
+		{Options{Width: 200, Fit: FitCover, Quality: DefaultQuality}, 200, 100, "image/png"},
+		{Options{Height: 50, Fit: FitCover, Format: FormatJPEG, Quality: DefaultQuality}, 100, 50, "image/jpeg"},
+		// 只缩小不放大
+		{Options{Width: 800, Height: 800, Fit: FitContain, Quality: DefaultQuality}, 400, 200, "image/png"},
+		{Options{Quality: DefaultQuality}, 400, 200, "image/png"},
+	}
+	for _, tt := range tests {
+		data, contentType, err := Process(src, tt.o)
+		if err != nil {
+			t.Errorf("Process(%s) err = %v", tt.o.Key(), err)
+			continue
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("Process(%s) output does not decode: %v", tt.o.Key(), err)
+			continue
+		}
+		if cfg.Width != tt.w || cfg.Height != tt.h || contentType != tt.contentType {
+			t.Errorf("Process(%s) = %dx%d %s, want %dx%d %s", tt.o.Key(), cfg.Width, cfg.Height, contentType, tt.w, tt.h, tt.contentType)
+		}
+	}
+
+	if _, _, err := Process([]byte("not an image"), Options{}); err == nil {
+		t.Error("Process accepted invalid data")
+	}
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
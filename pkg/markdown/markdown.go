@@ -0,0 +1,670 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	closingHashes = regexp.MustCompile(`(^|\s+)#+\s*$`)
+	orderedMarker = regexp.MustCompile(`^(\d{1,9})[.)]( +|$)`)
+	tableDelim    = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	entity        = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	autolink      = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+)>`)
+)
+
+// Render 把 Markdown 渲染为 HTML，支持标题、段落、引用、列表、代码块、表格、分割线，
+// 以及强调、删除线、行内代码、链接、图片和自动链接；原文中的 HTML 会被转义，不安全的链接会被去掉
+func Render(src string) string {
+	src = strings.Replace(src, "\r\n", "\n", -1)
+	src = strings.Replace(src, "\r", "\n", -1)
+	src = strings.Replace(src, "\t", "    ", -1)
+
+	var b bytes.Buffer
+	renderBlocks(&b, strings.Split(src, "\n"), false)
+	return b.String()
+}
+
+// renderBlocks 渲染块级元素，tight 为 true 时段落不输出 <p>（紧凑列表中的列表项）
+func renderBlocks(b *bytes.Buffer, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case isFence(trimmed):
+			i = renderFence(b, lines, i)
+
+		case indent(line) >= 4:
+			i = renderIndentedCode(b, lines, i)
+
+		case headingLevel(trimmed) > 0:
+			level := headingLevel(trimmed)
+			text := closingHashes.ReplaceAllString(trimmed[level:], "")
+			writeHeading(b, level, strings.TrimSpace(text))
+			i++
+
+		case isRule(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			i = renderQuote(b, lines, i)
+
+		case isListItem(line):
+			i = renderList(b, lines, i)
+
+		case isTableStart(lines, i):
+			i = renderTable(b, lines, i)
+
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func renderFence(b *bytes.Buffer, lines []string, i int) int {
+	open := strings.TrimSpace(lines[i])
+	marker := open[:3]
+	lang := strings.Fields(strings.TrimLeft(open, marker[:1]))
+
+	var code []string
+	i++
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), marker) {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	b.WriteString("<pre><code")
+	if len(lang) > 0 {
+		b.WriteString(` class="language-` + html.EscapeString(lang[0]) + `"`)
+	}
+	b.WriteString(">")
+	writeCode(b, code)
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderIndentedCode(b *bytes.Buffer, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			code = append(code, "")
+			continue
+		}
+		if indent(lines[i]) < 4 {
+			break
+		}
+		code = append(code, lines[i][4:])
+	}
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+
+	b.WriteString("<pre><code>")
+	writeCode(b, code)
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func writeCode(b *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+}
+
+func writeHeading(b *bytes.Buffer, level int, text string) {
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ">")
+	b.WriteString(inline(text))
+	b.WriteString("</" + tag + ">\n")
+}
+
+// renderQuote 引用块，连续以 > 开头的行，去掉 > 后按块级元素渲染
+func renderQuote(b *bytes.Buffer, lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		inner = append(inner, strings.TrimPrefix(trimmed, " "))
+	}
+
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// listMarker 解析列表项的标记，返回是否有序、起始序号和内容的缩进宽度
+func listMarker(line string) (ordered bool, start int, width int, ok bool) {
+	n := indent(line)
+	if n > 3 {
+		return false, 0, 0, false
+	}
+	rest := line[n:]
+
+	if len(rest) > 0 && strings.IndexByte("-*+", rest[0]) >= 0 {
+		if len(rest) == 1 {
+			return false, 0, n + 1, true
+		}
+		if rest[1] == ' ' && !isRule(strings.TrimSpace(rest)) {
+			return false, 0, n + 1 + spaces(rest[1:]), true
+		}
+		return false, 0, 0, false
+	}
+
+	if m := orderedMarker.FindStringSubmatch(rest); m != nil {
+		start, _ = strconv.Atoi(m[1])
+		return true, start, n + len(m[0]), true
+	}
+
+	return false, 0, 0, false
+}
+
+func isListItem(line string) bool {
+	_, _, _, ok := listMarker(line)
+	return ok
+}
+
+// renderList 列表，列表项之间或列表项内部有空行时为宽松列表，段落输出 <p>
+func renderList(b *bytes.Buffer, lines []string, i int) int {
+	ordered, start, _, _ := listMarker(lines[i])
+
+	var (
+		items [][]string
+		loose bool
+	)
+	for i < len(lines) {
+		o, _, width, ok := listMarker(lines[i])
+		if !ok || o != ordered {
+			break
+		}
+
+		line := lines[i]
+		item := []string{strings.TrimSpace(line[min(width, len(line)):])}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// 空行后还有缩进的内容时属于当前列表项
+				j := i
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j < len(lines) && indent(lines[j]) >= width {
+					for ; i < j; i++ {
+						item = append(item, "")
+					}
+					loose = true
+					continue
+				}
+				if j < len(lines) && isSameList(lines[j], ordered) {
+					loose = true
+				}
+				i = j
+				break
+			}
+			if indent(line) >= width {
+				item = append(item, line[width:])
+			} else if isListItem(line) || isBlockStart(line) {
+				break
+			} else {
+				// 懒惰续行
+				item = append(item, strings.TrimSpace(line))
+			}
+			i++
+		}
+		items = append(items, item)
+
+		if i < len(lines) && !isSameList(lines[i], ordered) {
+			break
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if ordered && start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner bytes.Buffer
+		renderBlocks(&inner, item, !loose)
+		b.Write(bytes.TrimSuffix(inner.Bytes(), []byte("\n")))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isSameList(line string, ordered bool) bool {
+	o, _, _, ok := listMarker(line)
+	return ok && o == ordered
+}
+
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") && tableDelim.MatchString(strings.TrimSpace(lines[i+1]))
+}
+
+// renderTable GFM 表格，第二行为对齐方式
+func renderTable(b *bytes.Buffer, lines []string, i int) int {
+	head := splitRow(lines[i])
+	var aligns []string
+	for _, cell := range splitRow(lines[i+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns = append(aligns, "center")
+		case right:
+			aligns = append(aligns, "right")
+		case left:
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+	i += 2
+
+	writeRow := func(tag string, cells []string) {
+		b.WriteString("<tr>")
+		for j := range head {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			b.WriteString("<" + tag)
+			if j < len(aligns) && aligns[j] != "" {
+				b.WriteString(` align="` + aligns[j] + `"`)
+			}
+			b.WriteString(">" + inline(cell) + "</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	writeRow("th", head)
+	b.WriteString("</thead>\n")
+	if i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+		b.WriteString("<tbody>\n")
+		for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+			writeRow("td", splitRow(lines[i]))
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderParagraph 段落，下一行为 === 或 --- 时为 Setext 标题
+func renderParagraph(b *bytes.Buffer, lines []string, i int, tight bool) int {
+	var para []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			break
+		}
+		if len(para) > 0 {
+			if strings.Trim(trimmed, "=") == "" {
+				writeHeading(b, 1, strings.TrimSpace(strings.Join(para, "\n")))
+				return i + 1
+			}
+			if strings.Trim(trimmed, "-") == "" {
+				writeHeading(b, 2, strings.TrimSpace(strings.Join(para, "\n")))
+				return i + 1
+			}
+			if isBlockStart(line) || isListItem(line) {
+				break
+			}
+		}
+		para = append(para, strings.TrimLeft(line, " "))
+	}
+
+	text := inline(strings.TrimRight(strings.Join(para, "\n"), " "))
+	if tight {
+		b.WriteString(text + "\n")
+	} else {
+		b.WriteString("<p>" + text + "</p>\n")
+	}
+	return i
+}
+
+// isBlockStart 能打断段落的块级元素
+func isBlockStart(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return isFence(trimmed) || headingLevel(trimmed) > 0 || isRule(trimmed) || strings.HasPrefix(trimmed, ">")
+}
+
+func isFence(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// headingLevel ATX 标题的级别，不是标题时返回 0
+func headingLevel(trimmed string) int {
+	n := 0
+	for n < len(trimmed) && trimmed[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 || (n < len(trimmed) && trimmed[n] != ' ') {
+		return 0
+	}
+
+	return n
+}
+
+// isRule 分割线：三个以上相同的 - * _，中间可以有空格
+func isRule(trimmed string) bool {
+	s := strings.Replace(trimmed, " ", "", -1)
+	if len(s) < 3 || strings.IndexByte("-*_", s[0]) < 0 {
+		return false
+	}
+
+	return strings.Trim(s, s[:1]) == ""
+}
+
+func indent(line string) int {
+	return spaces(line)
+}
+
+func spaces(s string) int {
+	n := 0
+	for n < len(s) && s[n] == ' ' {
+		n++
+	}
+
+	return n
+}
+
+// inline 渲染行内元素
+func inline(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+
+		case c == '\n':
+			// 行尾两个空格为换行
+			if bytes.HasSuffix(b.Bytes(), []byte("  ")) {
+				b.Truncate(len(bytes.TrimRight(b.Bytes(), " ")))
+				b.WriteString("<br>")
+			} else {
+				b.Truncate(len(bytes.TrimRight(b.Bytes(), " ")))
+			}
+			b.WriteByte('\n')
+			i++
+
+		case c == '`':
+			i = inlineCode(&b, s, i)
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if n, ok := inlineLink(&b, s, i+1, true); ok {
+				i = n
+			} else {
+				b.WriteByte('!')
+				i++
+			}
+
+		case c == '[':
+			if n, ok := inlineLink(&b, s, i, false); ok {
+				i = n
+			} else {
+				b.WriteByte('[')
+				i++
+			}
+
+		case c == '<':
+			if m := autolink.FindStringSubmatch(s[i:]); m != nil && SafeURL(m[1]) {
+				u := html.EscapeString(m[1])
+				b.WriteString(`<a href="` + u + `">` + u + `</a>`)
+				i += len(m[0])
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+
+		case c == '&':
+			if m := entity.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+			} else {
+				b.WriteString("&amp;")
+				i++
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			i = inlineEmphasis(&b, s, i)
+
+		default:
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// inlineCode 行内代码，开始和结束的反引号数量相同
+func inlineCode(b *bytes.Buffer, s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	fence := s[i : i+n]
+
+	for j := i + n; j < len(s); {
+		k := strings.Index(s[j:], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		end := k + n
+		if end < len(s) && s[end] == '`' {
+			for end < len(s) && s[end] == '`' {
+				end++
+			}
+			j = end
+			continue
+		}
+
+		code := strings.Replace(s[i+n:k], "\n", " ", -1)
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+			code = code[1 : len(code)-1]
+		}
+		b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+		return end
+	}
+
+	b.WriteString(fence)
+	return i + n
+}
+
+// inlineLink 链接 [text](url "title") 和图片 ![alt](url "title")，i 指向 [
+func inlineLink(b *bytes.Buffer, s string, i int, image bool) (int, bool) {
+	depth, end := 0, -1
+	for j := i; j < len(s) && end < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = j
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0, false
+	}
+	// 链接地址中的括号需要成对
+	closing := -1
+	depth = 0
+	for j := end + 1; j < len(s) && closing < 0; j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				closing = j
+			}
+		}
+	}
+	if closing < 0 {
+		return 0, false
+	}
+
+	label := s[i+1 : end]
+	target := strings.TrimSpace(s[end+2 : closing])
+	url, title := target, ""
+	if k := strings.IndexAny(target, " \n"); k >= 0 {
+		url = target[:k]
+		title = strings.Trim(strings.TrimSpace(target[k:]), `"'`)
+	}
+	url = unescape(strings.TrimSuffix(strings.TrimPrefix(url, "<"), ">"))
+	title = unescape(title)
+
+	if !SafeURL(url) {
+		// 不安全的链接只保留文字
+		if !image {
+			b.WriteString(inline(label))
+		}
+		return closing + 1, true
+	}
+
+	attrs := ""
+	if title != "" {
+		attrs = ` title="` + html.EscapeString(title) + `"`
+	}
+	if image {
+		b.WriteString(`<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(label) + `"` + attrs + `>`)
+	} else {
+		b.WriteString(`<a href="` + html.EscapeString(url) + `"` + attrs + `>` + inline(label) + `</a>`)
+	}
+
+	return closing + 1, true
+}
+
+// unescape 去掉反斜杠转义并还原实体，链接地址按浏览器看到的内容检查，例如 &#106;avascript:
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+
+	return html.UnescapeString(b.String())
+}
+
+// inlineEmphasis **强调**、*斜体*、~~删除线~~，找不到结束标记时原样输出；_ 在单词中间时不作为标记
+func inlineEmphasis(b *bytes.Buffer, s string, i int) int {
+	c := s[i]
+	n := 1
+	if i+1 < len(s) && s[i+1] == c {
+		n = 2
+	}
+	if c == '~' && n == 1 {
+		b.WriteByte(c)
+		return i + 1
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		b.WriteByte(c)
+		return i + 1
+	}
+
+	marker := s[i : i+n]
+	start := i + n
+	if start >= len(s) || s[start] == ' ' || s[start] == '\n' {
+		b.WriteString(marker)
+		return start
+	}
+
+	for j := start + 1; j <= len(s)-n; j++ {
+		if s[j:j+n] != marker || s[j-1] == ' ' || s[j-1] == '\\' {
+			continue
+		}
+		// 单个标记不能匹配到双标记的一部分
+		if n == 1 && j+1 < len(s) && s[j+1] == c {
+			j++
+			continue
+		}
+		if c == '_' && j+n < len(s) && isWordByte(s[j+n]) {
+			continue
+		}
+
+		tag := "em"
+		switch {
+		case c == '~':
+			tag = "del"
+		case n == 2:
+			tag = "strong"
+		}
+		b.WriteString("<" + tag + ">" + inline(s[start:j]) + "</" + tag + ">")
+		return j + n
+	}
+
+	b.WriteString(marker)
+	return start
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,62 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"heading", "# Title #\n## Sub", "<h1>Title</h1>\n<h2>Sub</h2>\n"},
+		{"paragraph", "a\nb\n\nc", "<p>a\nb</p>\n<p>c</p>\n"},
+		{"hard break", "a  \nb", "<p>a<br>\nb</p>\n"},
+		{"emphasis", "*em* **strong** ~~del~~ snake_case_name", "<p><em>em</em> <strong>strong</strong> <del>del</del> snake_case_name</p>\n"},
+		{"inline code", "`<b>` and `` a`b ``", "<p><code>&lt;b&gt;</code> and <code>a`b</code></p>\n"},
+		{"fence", "```go\nif a < b {}\n```", "<pre><code class=\"language-go\">if a &lt; b {}\n</code></pre>\n"},
+		{"quote", "> a\n> b", "<blockquote>\n<p>a\nb</p>\n</blockquote>\n"},
+		{"list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"ordered list", "3. a\n4. b", "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"rule", "a\n\n---", "<p>a</p>\n<hr>\n"},
+		{"link", `[go](https://go.dev "Go")`, "<p><a href=\"https://go.dev\" title=\"Go\">go</a></p>\n"},
+		{"image", "![logo](/a.png)", "<p><img src=\"/a.png\" alt=\"logo\"></p>\n"},
+		{"autolink", "<https://example.com/?a=1&b=2>", "<p><a href=\"https://example.com/?a=1&amp;b=2\">https://example.com/?a=1&amp;b=2</a></p>\n"},
+		{"entity", "&copy; & &#169;", "<p>&copy; &amp; &#169;</p>\n"},
+		{"escape", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"html is escaped", "<b>x</b>", "<p>&lt;b&gt;x&lt;/b&gt;</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.in); got != tt.want {
+			t.Errorf("%s: Render(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// 文章正文按 Sanitize(Render(content)) 输出
+func TestRenderUnsafe(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"mixed case", "[x](JaVaScRiPt:alert(1))", "<p>x</p>\n"},
+		{"entity encoded", "[x](&#106;avascript&colon;alert(1))", "<p>x</p>\n"},
+		{"backslash escaped", `[x](javascript\:alert(1))`, "<p>x</p>\n"},
+		{"angle brackets", "[x](<javascript:alert(1)>)", "<p>x</p>\n"},
+		{"image", "![a](javascript:alert(1))", "<p></p>\n"},
+		{"autolink", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{"title quote", `[x](/ok "t\" onmouseover=\"alert(1)")`, "<p><a href=\"/ok\" title=\"t&#34; onmouseover=&#34;alert(1)\" rel=\"nofollow noopener\">x</a></p>\n"},
+		{"title single quote", `[x](/ok 'it"s')`, "<p><a href=\"/ok\" title=\"it&#34;s\" rel=\"nofollow noopener\">x</a></p>\n"},
+		{"title entity", `[x](/ok "&quot; onclick=&quot;alert(1)")`, "<p><a href=\"/ok\" title=\"&#34; onclick=&#34;alert(1)\" rel=\"nofollow noopener\">x</a></p>\n"},
+		{"alt quote", `![a" onerror="alert(1)](/a.png)`, "<p><img src=\"/a.png\" alt=\"a&#34; onerror=&#34;alert(1)\"></p>\n"},
+		{"raw script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"raw svg", "<svg onload=alert(1)>", "<p>&lt;svg onload=alert(1)&gt;</p>\n"},
+		{"fence info", "```x\" onclick=\"alert(1)\n<b>\n```", "<pre><code class=\"language-x&#34;\">&lt;b&gt;\n</code></pre>\n"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(Render(tt.in)); got != tt.want {
+			t.Errorf("%s: Sanitize(Render(%q)) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
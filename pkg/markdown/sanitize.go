@@ -0,0 +1,282 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// allowedTags 允许保留的标签及其属性，其余标签去掉但保留内容
+var allowedTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"blockquote": nil, "pre": nil, "code": {"class"}, "kbd": nil, "samp": nil,
+	"em": nil, "strong": nil, "b": nil, "i": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+	"sub": nil, "sup": nil, "mark": nil, "small": nil, "abbr": {"title"}, "cite": nil, "q": nil,
+	"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"a":     {"href", "title"},
+	"img":   {"src", "alt", "title", "width", "height"},
+	"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th": {"align", "colspan", "rowspan"}, "td": {"align", "colspan", "rowspan"},
+	"figure": nil, "figcaption": nil,
+}
+
+// droppedTags 连同内容一起去掉的标签
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "noembed": true,
+	"template": true, "textarea": true, "select": true, "svg": true, "math": true,
+	"title": true, "xmp": true, "head": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// urlAttrs 值为链接的属性，只允许 SafeURL
+var urlAttrs = map[string]bool{"href": true, "src": true}
+
+// Sanitize 按白名单过滤 HTML：去掉 script 等危险标签及其内容、on* 事件和 style 等不在白名单中的属性、
+// 以及 javascript: 等不安全的链接，并补全未闭合的标签
+func Sanitize(s string) string {
+	var (
+		b     bytes.Buffer
+		stack []string
+	)
+
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			b.WriteString(escapeText(s[i : i+j]))
+			i += j
+			continue
+		}
+
+		if strings.HasPrefix(s[i:], "<!--") {
+			// 从 <! 之后开始找，<!--> 和 <!---> 也是完整的注释
+			j := strings.Index(s[i+2:], "-->")
+			if j < 0 {
+				break
+			}
+			i += 2 + j + 3
+			continue
+		}
+		if strings.HasPrefix(s[i:], "<!") || strings.HasPrefix(s[i:], "<?") {
+			j := strings.IndexByte(s[i:], '>')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+			continue
+		}
+
+		t, n := parseTag(s[i:])
+		if t == nil {
+			b.WriteString("&lt;")
+			i++
+			continue
+		}
+		i += n
+
+		if droppedTags[t.name] {
+			if !t.closing && !t.selfClosing {
+				i = skipElement(s, i, t.name)
+			}
+			continue
+		}
+		allowed, ok := allowedTags[t.name]
+		if !ok {
+			continue
+		}
+
+		if t.closing {
+			// 关闭到最近的同名标签，没有打开过的关闭标签直接去掉
+			for k := len(stack) - 1; k >= 0; k-- {
+				if stack[k] == t.name {
+					for len(stack) > k {
+						b.WriteString("</" + stack[len(stack)-1] + ">")
+						stack = stack[:len(stack)-1]
+					}
+					break
+				}
+			}
+			continue
+		}
+
+		b.WriteString("<" + t.name)
+		for _, a := range t.attrs {
+			if !contains(allowed, a.name) || (urlAttrs[a.name] && !SafeURL(a.value)) {
+				continue
+			}
+			b.WriteString(" " + a.name + `="` + html.EscapeString(a.value) + `"`)
+		}
+		if t.name == "a" {
+			b.WriteString(` rel="nofollow noopener"`)
+		}
+		b.WriteString(">")
+
+		if !voidTags[t.name] {
+			stack = append(stack, t.name)
+		}
+	}
+
+	for k := len(stack) - 1; k >= 0; k-- {
+		b.WriteString("</" + stack[k] + ">")
+	}
+
+	return b.String()
+}
+
+// SafeURL 只允许相对地址和 http、https、mailto 链接
+func SafeURL(u string) bool {
+	// 浏览器会忽略协议中的空白和控制字符，例如 "java\tscript:"
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+
+	colon := strings.IndexByte(u, ':')
+	if colon < 0 || strings.IndexAny(u[:colon], "/?#") >= 0 {
+		return true
+	}
+
+	switch strings.ToLower(u[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	}
+
+	return false
+}
+
+type tag struct {
+	name        string
+	closing     bool
+	selfClosing bool
+	attrs       []attr
+}
+
+type attr struct {
+	name  string
+	value string
+}
+
+// parseTag 解析以 < 开头的标签，返回标签和长度，不是合法标签时返回 nil
+func parseTag(s string) (*tag, int) {
+	t := &tag{}
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		t.closing = true
+		i++
+	}
+
+	start := i
+	for i < len(s) && isTagNameByte(s[i], i == start) {
+		i++
+	}
+	if i == start {
+		return nil, 0
+	}
+	t.name = strings.ToLower(s[start:i])
+
+	for i < len(s) {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			if s[i] == '/' {
+				t.selfClosing = true
+			}
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0
+		}
+		if s[i] == '>' {
+			return t, i + 1
+		}
+		t.selfClosing = false
+
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		a := attr{name: strings.ToLower(s[start:i])}
+
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				end := strings.IndexByte(s[i+1:], q)
+				if end < 0 {
+					return nil, 0
+				}
+				a.value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				a.value = s[start:i]
+			}
+			a.value = html.UnescapeString(a.value)
+		}
+		t.attrs = append(t.attrs, a)
+	}
+
+	return nil, 0
+}
+
+// skipElement 跳过元素的内容直到对应的关闭标签，没有关闭标签时跳过剩余全部内容
+func skipElement(s string, i int, name string) int {
+	// 只转换 ASCII 字母，保证下标与原文一致
+	lower := []byte(s[i:])
+	for k, c := range lower {
+		if c >= 'A' && c <= 'Z' {
+			lower[k] = c + 'a' - 'A'
+		}
+	}
+	j := bytes.Index(lower, []byte("</"+name))
+	if j < 0 {
+		return len(s)
+	}
+	k := strings.IndexByte(s[i+j:], '>')
+	if k < 0 {
+		return len(s)
+	}
+
+	return i + j + k + 1
+}
+
+// escapeText 文本中的实体先还原再转义，保证输出中没有未转义的 < > & "
+func escapeText(s string) string {
+	return html.EscapeString(html.UnescapeString(s))
+}
+
+func isTagNameByte(c byte, first bool) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return true
+	}
+
+	return !first && (c >= '0' && c <= '9' || c == '-')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package markdown
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// javascript: 链接
+		{"javascript", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"mixed case", `<a href=" JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"decimal entity", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"entity without semicolon", `<a href="&#106avascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"hex entity and &colon;", `<a href="&#x6A;avascript&colon;alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"encoded tab", `<a href="java&#09;script:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"newline", "<a href=\"java\nscript:alert(1)\">x</a>", `<a rel="nofollow noopener">x</a>`},
+		{"control character", "<a href=\"\x01javascript:alert(1)\">x</a>", `<a rel="nofollow noopener">x</a>`},
+		{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="a">`, `<img alt="a">`},
+		{"safe link", `<a href="https://example.com/?a=1&amp;b=2" title="t">x</a>`, `<a href="https://example.com/?a=1&amp;b=2" title="t" rel="nofollow noopener">x</a>`},
+		{"relative link", `<a href="/tags/go">go</a>`, `<a href="/tags/go" rel="nofollow noopener">go</a>`},
+
+		// on* 和不在白名单中的属性
+		{"on attributes", `<a href="/ok" onclick="alert(1)" ONMOUSEOVER=alert(1)>x</a>`, `<a href="/ok" rel="nofollow noopener">x</a>`},
+		{"img onerror", `<img src=x onerror=alert(1)>`, `<img src="x">`},
+		{"style and class", `<p style="background:url(javascript:alert(1))" class="x">hi</p>`, `<p>hi</p>`},
+		{"slash between attributes", `<a href="/x"/onclick=alert(1)>x</a>`, `<a href="/x" rel="nofollow noopener">x</a>`},
+
+		// svg、script、style 连同内容去掉
+		{"svg", `<svg onload=alert(1)><circle/></svg>after`, `after`},
+		{"svg self closing", `<svg/onload=alert(1)>after`, ``},
+		{"script", `<SCRIPT>alert(1)</script >ok`, `ok`},
+		{"unclosed script", `<script>alert(1)`, ``},
+		{"style", `<style>body{display:none}</style>ok`, `ok`},
+		{"iframe", `<iframe src="https://example.com"></iframe>ok`, `ok`},
+		{"math", `<math><mi xlink:href="javascript:alert(1)">x</mi></math>ok`, `ok`},
+		{"unknown tag keeps text", `<blink>hi</blink>`, `hi`},
+
+		// 未闭合和错误嵌套的标签
+		{"unclosed", `<b>bold <i>both`, `<b>bold <i>both</i></b>`},
+		{"stray closing", `</b>text</i>`, `text`},
+		{"misnested", `<b><i>x</b>y`, `<b><i>x</i></b>y`},
+		{"void tags", `a<br>b<hr/>`, `a<br>b<hr>`},
+		{"unterminated tag", `<img src="/a.png" alt="a"`, `&lt;img src=&#34;/a.png&#34; alt=&#34;a&#34;`},
+		{"unterminated quote", `<a href="/x" title="a>x</a>`, `&lt;a href=&#34;/x&#34; title=&#34;a&gt;x`},
+
+		// 属性值中的引号
+		{"quote in unquoted value", `<a title=a"onmouseover=alert(1)>x</a>`, `<a title="a&#34;onmouseover=alert(1)" rel="nofollow noopener">x</a>`},
+		{"encoded quote", `<abbr title="&quot; onmouseover=&quot;alert(1)">x</abbr>`, `<abbr title="&#34; onmouseover=&#34;alert(1)">x</abbr>`},
+		{"single quotes", `<abbr title='a"b'>x</abbr>`, `<abbr title="a&#34;b">x</abbr>`},
+
+		// 文本、注释和声明
+		{"text", `1 < 2 && 3 > 2 "q"`, `1 &lt; 2 &amp;&amp; 3 &gt; 2 &#34;q&#34;`},
+		{"entities stay escaped", `&lt;script&gt;`, `&lt;script&gt;`},
+		{"comment", `<!-- <script>alert(1)</script> -->ok`, `ok`},
+		{"empty comment", `<!-->ok<!--->ok`, `okok`},
+		{"unterminated comment", `ok<!-- <b>`, `ok`},
+		{"doctype", `<!doctype html>ok`, `ok`},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("%s: Sanitize(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"mailto:a@example.com", true},
+		{"/path:with:colons", true},
+		{"?q=a:b", true},
+		{"#top", true},
+		{"images/a.png", true},
+		{"", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"java\nscript:alert(1)", false},
+		{"java\x00script:alert(1)", false},
+		{"javascript\x7f:alert(1)", false},
+		{"vbscript:x", false},
+		{"data:text/html,<script>", false},
+		{"file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if got := SafeURL(tt.url); got != tt.want {
+			t.Errorf("SafeURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
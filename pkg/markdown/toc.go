@@ -0,0 +1,138 @@
+package markdown
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 阅读速度：中日韩文字按字计，其他按单词计
+const (
+	cjkPerMinute  = 400
+	wordPerMinute = 200
+)
+
+var (
+	headingTag = regexp.MustCompile(`(?s)<h([1-6])>(.*?)</h[1-6]>`)
+	anyTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Heading 目录中的一项，Children 为下一级标题
+type Heading struct {
+	Level    int        `json:"level"`
+	ID       string     `json:"id"`
+	Text     string     `json:"text"`
+	Children []*Heading `json:"children,omitempty"`
+}
+
+// Toc 给 Sanitize 输出中的标题加上 id，并按标题级别生成嵌套的目录
+func Toc(s string) (string, []*Heading) {
+	var (
+		roots []*Heading
+		stack []*Heading
+		used  = make(map[string]int)
+	)
+
+	s = headingTag.ReplaceAllStringFunc(s, func(m string) string {
+		sub := headingTag.FindStringSubmatch(m)
+		level, _ := strconv.Atoi(sub[1])
+		text := PlainText(sub[2])
+
+		id := slug(text)
+		if n := used[id]; n > 0 {
+			used[id] = n + 1
+			id += "-" + strconv.Itoa(n)
+		} else {
+			used[id] = 1
+		}
+
+		h := &Heading{Level: level, ID: id, Text: text}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, h)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, h)
+		}
+		stack = append(stack, h)
+
+		return `<h` + sub[1] + ` id="` + html.EscapeString(id) + `">` + sub[2] + `</h` + sub[1] + `>`
+	})
+
+	return s, roots
+}
+
+// PlainText 去掉 HTML 标签后的文本，连续空白合并为一个空格
+func PlainText(s string) string {
+	s = anyTag.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// Excerpt 截取文本的前 n 个字符作为摘要，被截断时以省略号结尾
+func Excerpt(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
+
+// ReadingTime 预计阅读时间（分钟），有内容时至少为 1
+func ReadingTime(text string) int {
+	cjk, words := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	if cjk == 0 && words == 0 {
+		return 0
+	}
+
+	minutes := float64(cjk)/cjkPerMinute + float64(words)/wordPerMinute
+	return int(math.Max(1, math.Ceil(minutes)))
+}
+
+// slug 标题的锚点：保留字母和数字（包括中文），空白和连字符合并为 -
+func slug(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+
+	return b.String()
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,70 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToc(t *testing.T) {
+	html, toc := Toc("<h1>Intro</h1><h2>安装 Go</h2><h3>Linux</h3><h2>Intro</h2><h1>!!!</h1>")
+
+	wantHTML := `<h1 id="intro">Intro</h1><h2 id="安装-go">安装 Go</h2><h3 id="linux">Linux</h3><h2 id="intro-1">Intro</h2><h1 id="section">!!!</h1>`
+	if html != wantHTML {
+		t.Errorf("Toc html = %q, want %q", html, wantHTML)
+	}
+
+	want := []*Heading{
+		{Level: 1, ID: "intro", Text: "Intro", Children: []*Heading{
+			{Level: 2, ID: "安装-go", Text: "安装 Go", Children: []*Heading{
+				{Level: 3, ID: "linux", Text: "Linux"},
+			}},
+			{Level: 2, ID: "intro-1", Text: "Intro"},
+		}},
+		{Level: 1, ID: "section", Text: "!!!"},
+	}
+	if !reflect.DeepEqual(toc, want) {
+		t.Errorf("Toc headings do not match")
+	}
+}
+
+func TestPlainTextAndExcerpt(t *testing.T) {
+	text := PlainText("<p>a &amp; <b>b</b></p>\n<p>c</p>")
+	if text != "a & b c" {
+		t.Errorf("PlainText = %q", text)
+	}
+
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 6, "hello…"},
+		{"你好世界", 2, "你好…"},
+	}
+	for _, tt := range tests {
+		if got := Excerpt(tt.text, tt.n); got != tt.want {
+			t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"!!!", 0},
+		{"one word", 1},
+		{strings.Repeat("字", 800), 2},
+		{strings.Repeat("word ", 401), 3},
+		{strings.Repeat("字", 200) + strings.Repeat(" word", 100), 1},
+	}
+	for _, tt := range tests {
+		if got := ReadingTime(tt.text); got != tt.want {
+			t.Errorf("ReadingTime(%d bytes) = %d, want %d", len(tt.text), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// PointsPerMM 1 毫米对应的 PDF 单位（1/72 英寸）
+const PointsPerMM = 72 / 25.4
+
+// Document 每页为一张铺满页面的灰度图片的 PDF，用于打印标签等不需要选择文字的场景
+type Document struct {
+	Title string
+	pages []*page
+}
+
+type page struct {
+	width, height float64 // 页面尺寸（pt）
+	cols, rows    int     // 图片像素
+	data          []byte  // zlib 压缩后的灰度像素
+}
+
+func New(title string) *Document {
+	return &Document{Title: title}
+}
+
+// AddPage 添加一页，图片转为灰度后压缩保存，width、height 为页面尺寸（pt）
+func (d *Document) AddPage(img image.Image, width, height float64) error {
+	b := img.Bounds()
+	gray, ok := img.(*image.Gray)
+	if !ok || gray.Stride != b.Dx() || b.Min != (image.Point{}) {
+		gray = image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				gray.Set(x, y, color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(gray.Pix); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	d.pages = append(d.pages, &page{width: width, height: height, cols: b.Dx(), rows: b.Dy(), data: buf.Bytes()})
+	return nil
+}
+
+// Pages 页数
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// WriteTo 输出 PDF 1.4 文件
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	var offsets []int64
+	// obj 开始一个新对象，对象号从 1 开始按顺序分配
+	obj := func() int {
+		offsets = append(offsets, cw.n)
+		n := len(offsets)
+		fmt.Fprintf(cw, "%d 0 obj\n", n)
+		return n
+	}
+
+	cw.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 目录，2 页面树，3 文档信息，之后每页依次为页面、内容和图片
+	obj()
+	cw.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	obj()
+	cw.WriteString("<< /Type /Pages /Kids [")
+	for i := range d.pages {
+		fmt.Fprintf(cw, " %d 0 R", 4+i*3)
+	}
+	fmt.Fprintf(cw, " ] /Count %d >>\nendobj\n", len(d.pages))
+
+	obj()
+	fmt.Fprintf(cw, "<< /Title %s /Producer (go-gin-example) >>\nendobj\n", literal(d.Title))
+
+	for _, p := range d.pages {
+		n := obj()
+		fmt.Fprintf(cw, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /XObject << /Im0 %d 0 R >> >> >>\nendobj\n",
+			p.width, p.height, n+1, n+2)
+
+		content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", p.width, p.height)
+		obj()
+		fmt.Fprintf(cw, "<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+
+		obj()
+		fmt.Fprintf(cw, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			p.cols, p.rows, len(p.data))
+		cw.Write(p.data)
+		cw.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// literal PDF 字符串，非 ASCII 文字使用 UTF-16BE 编码
+func literal(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+
+	if ascii {
+		var b bytes.Buffer
+		b.WriteByte('(')
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '(', ')', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			default:
+				if c < 0x20 {
+					continue
+				}
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xffff {
+			r -= 0x10000
+			fmt.Fprintf(&b, "%04X%04X", 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// countWriter 记录已写入的字节数，用于生成交叉引用表；出错后不再写入
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+func (c *countWriter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", "()"},
+		{"labels", "(labels)"},
+		{`a(b)\c`, `(a\(b\)\\c)`},
+		{"a\nb\tc", "(abc)"},
+		{"标签", "<FEFF68077B7E>"},
+		{"a😀", "<FEFF0061D83DDE00>"},
+	}
+	for _, tt := range tests {
+		if got := literal(tt.s); got != tt.want {
+			t.Errorf("literal(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAddPage(t *testing.T) {
+	d := New("labels")
+
+	// 非灰度图片转为灰度
+	rgba := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	rgba.Set(0, 0, color.White)
+	rgba.Set(2, 1, color.RGBA{255, 0, 0, 255})
+	// 子图的起点不为 0，Stride 也大于宽度
+	gray := image.NewGray(image.Rect(0, 0, 10, 10))
+	gray.SetGray(5, 5, color.Gray{Y: 200})
+	sub := gray.SubImage(image.Rect(5, 5, 7, 6))
+
+	for _, img := range []image.Image{rgba, sub, image.NewGray(image.Rect(0, 0, 2, 2))} {
+		if err := d.AddPage(img, 100, 50); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d.Pages() != 3 {
+		t.Fatalf("Pages() = %d", d.Pages())
+	}
+
+	tests := []struct {
+		cols, rows int
+		pix        []byte
+	}{
+		{3, 2, []byte{255, 0, 0, 0, 0, 76}},
+		{2, 1, []byte{200, 0}},
+		{2, 2, []byte{0, 0, 0, 0}},
+	}
+	for i, tt := range tests {
+		p := d.pages[i]
+		if p.cols != tt.cols || p.rows != tt.rows || p.width != 100 || p.height != 50 {
+			t.Errorf("page %d = %dx%d %vx%v", i, p.cols, p.rows, p.width, p.height)
+		}
+		if pix := inflate(t, p.data); !bytes.Equal(pix, tt.pix) {
+			t.Errorf("page %d pixels = %v, want %v", i, pix, tt.pix)
+		}
+	}
+}
+
+func inflate(t *testing.T, data []byte) []byte {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pix
+}
+
+func TestWriteTo(t *testing.T) {
+	d := New("标签")
+	for i := 0; i < 2; i++ {
+		if err := d.AddPage(image.NewGray(image.Rect(0, 0, 4, 3)), 210*PointsPerMM, 297*PointsPerMM); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo = %d, wrote %d bytes", n, buf.Len())
+	}
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("header or trailer missing: %.20q ... %q", out, out[len(out)-10:])
+	}
+
+	for _, s := range []string{
+		"/Kids [ 4 0 R 7 0 R ] /Count 2",
+		"/Title <FEFF68077B7E>",
+		"/MediaBox [0 0 595.28 841.89] /Contents 5 0 R /Resources << /XObject << /Im0 6 0 R >> >>",
+		"q 595.28 0 0 841.89 0 0 cm /Im0 Do Q",
+		"/Width 4 /Height 3 /ColorSpace /DeviceGray",
+		"/Size 10 /Root 1 0 R /Info 3 0 R",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q", s)
+		}
+	}
+
+	// 交叉引用表中的偏移量指向对应的对象
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	if xref == nil {
+		t.Fatal("missing startxref")
+	}
+	start, _ := strconv.Atoi(xref[1])
+	if !strings.HasPrefix(out[start:], "xref\n0 10\n") {
+		t.Fatalf("startxref %d points to %.10q", start, out[start:])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[start:], -1)
+	if len(entries) != 9 {
+		t.Fatalf("got %d xref entries, want 9", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("object %d offset %d points to %.10q", i+1, offset, out[offset:])
+		}
+	}
+
+	// 内容流的 /Length 与实际长度一致
+	for _, m := range regexp.MustCompile(`<< /Length (\d+) >>\nstream\n([^\n]*)\nendstream`).FindAllStringSubmatch(out, -1) {
+		if length, _ := strconv.Atoi(m[1]); length != len(m[2]) {
+			t.Errorf("content /Length %d, stream %d bytes", length, len(m[2]))
+		}
+	}
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.n < len(p) {
+		return 0, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteToError(t *testing.T) {
+	d := New("labels")
+	// 页面数据超过 bufio 的缓冲区，写入过程中就会出错
+	img := image.NewGray(image.Rect(0, 0, 200, 200))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 >> 3)
+	}
+	d.AddPage(img, 100, 100)
+
+	if _, err := d.WriteTo(&failWriter{n: 10}); err == nil || err.Error() != "disk full" {
+		t.Errorf("WriteTo = %v, want disk full", err)
+	}
+}
@@ -0,0 +1,56 @@
+package pinyin
+
+import (
+	"strings"
+	"unicode"
+)
+
+// table 汉字到读音，由 dict 生成
+var table = make(map[rune]string, 6763)
+
+func init() {
+	for syllable, chars := range dict {
+		for _, r := range chars {
+			table[r] = syllable
+		}
+	}
+}
+
+// Of 汉字的拼音（不带声调，ü 写作 u），不在字典中时返回 false
+func Of(r rune) (string, bool) {
+	s, ok := table[r]
+	return s, ok
+}
+
+// Convert 把字符串转为拼音，每个汉字一个音节，其余连续的字母和数字保持为一段，其他字符作为分隔；
+// 不在字典中的汉字会被忽略
+func Convert(s string) []string {
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			flush()
+			if p, ok := table[r]; ok {
+				words = append(words, p)
+			}
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+	}
+	flush()
+
+	return words
+}
@@ -0,0 +1,63 @@
+package pinyin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDict(t *testing.T) {
+	// 每个汉字只能出现在一个读音下，否则 table 的结果取决于 map 的遍历顺序
+	seen := make(map[rune]string, len(table))
+	for syllable, chars := range dict {
+		if syllable == "" || strings.ToLower(syllable) != syllable || strings.ContainsAny(syllable, "üv1234") {
+			t.Errorf("invalid syllable %q", syllable)
+		}
+		for _, r := range chars {
+			if other, ok := seen[r]; ok {
+				t.Errorf("%c is listed under both %q and %q", r, other, syllable)
+			}
+			seen[r] = syllable
+		}
+	}
+	if len(table) < 6763 {
+		t.Errorf("table has %d characters, want at least 6763", len(table))
+	}
+}
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want string
+		ok   bool
+	}{
+		{'中', "zhong", true},
+		{'绿', "lu", true},
+		{'行', "xing", true},
+		{'a', "", false},
+		{'😀', "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := Of(tt.r); got != tt.want || ok != tt.ok {
+			t.Errorf("Of(%c) = %q, %v, want %q, %v", tt.r, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"你好世界", "ni hao shi jie"},
+		{"Go语言入门", "Go yu yan ru men"},
+		{"Gin 2.0 教程", "Gin 2 0 jiao cheng"},
+		{"Hello, World!", "Hello World"},
+		{"第3章：HTTP", "di 3 zhang HTTP"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(Convert(tt.s), " "); got != tt.want {
+			t.Errorf("Convert(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package poster
+
+import (
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// fonts 已解析的字体，字体文件较大，只解析一次
+var fonts sync.Map
+
+// LoadFont 读取 FontSavePath 下的字体，name 为空时使用 DefaultFont
+func LoadFont(name string) (*truetype.Font, error) {
+	if name == "" {
+		name = setting.PosterSetting.DefaultFont
+	}
+	if f, ok := fonts.Load(name); ok {
+		return f.(*truetype.Font), nil
+	}
+
+	data, err := ioutil.ReadFile(setting.AppSetting.RuntimeRootPath + setting.AppSetting.FontSavePath + name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := freetype.ParseFont(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fonts.Store(name, f)
+	return f, nil
+}
@@ -0,0 +1,278 @@
+package poster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// ellipsis 超出 MaxLines 的文字以省略号结尾
+const ellipsis = "…"
+
+// Data 图层绑定的字段值，没有值的图片字段不绘制
+type Data struct {
+	Text   map[string]string
+	Images map[string]image.Image
+}
+
+// Render 按模板绘制海报并编码，t 需要先经过 Normalize
+func Render(t *Template, d *Data) ([]byte, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, t.Width, t.Height))
+
+	bg, _ := ParseColor(t.BackgroundColor)
+	if bg == nil {
+		bg = color.White
+	}
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	if t.Background != "" {
+		img, err := LoadImage(t.Background)
+		if err != nil {
+			return nil, err
+		}
+		drawImage(dst, img, dst.Bounds(), FitCover)
+	}
+
+	for i := range t.Layers {
+		if err := drawLayer(dst, &t.Layers[i], d); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if t.Format == FormatPNG {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: t.Quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// LoadImage 读取 BackgroundSavePath 下的图片
+func LoadImage(name string) (image.Image, error) {
+	f, err := os.Open(setting.AppSetting.RuntimeRootPath + setting.PosterSetting.BackgroundSavePath + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func drawLayer(dst draw.Image, l *Layer, d *Data) error {
+	if l.Type == LayerImage {
+		img := d.Images[l.Field]
+		if l.Field == "" {
+			var err error
+			if img, err = LoadImage(l.Src); err != nil {
+				return err
+			}
+		}
+		if img != nil {
+			drawImage(dst, img, layerRect(l, img.Bounds()), l.Fit)
+		}
+		return nil
+	}
+
+	text := d.Text[l.Field]
+	if l.Field == "" || text == "" {
+		text = l.Text
+	}
+	_, err := DrawText(dst, l, text)
+	return err
+}
+
+// DrawText 按文字图层的字体、字号、颜色、对齐和换行设置绘制 text，返回绘制的行数
+func DrawText(dst draw.Image, l *Layer, text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+
+	f, err := LoadFont(l.Font)
+	if err != nil {
+		return 0, err
+	}
+	face := truetype.NewFace(f, &truetype.Options{Size: l.Size})
+	defer face.Close()
+
+	c, _ := ParseColor(l.Color)
+	if c == nil {
+		c = color.Black
+	}
+	drawer := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+
+	lines := wrap(face, text, l.Width, l.MaxLines)
+	lineHeight := int(math.Round(l.Size * l.LineHeight))
+	for i, line := range lines {
+		x := l.X
+		switch l.Align {
+		case AlignCenter:
+			x += (l.Width - drawer.MeasureString(line).Round()) / 2
+		case AlignRight:
+			x += l.Width - drawer.MeasureString(line).Round()
+		}
+		drawer.Dot = fixed.P(x, l.Y+i*lineHeight)
+		drawer.DrawString(line)
+	}
+
+	return len(lines), nil
+}
+
+// layerRect 图片图层的区域，宽高为 0 时按图片比例计算，都为 0 时使用图片原尺寸
+func layerRect(l *Layer, src image.Rectangle) image.Rectangle {
+	w, h := l.Width, l.Height
+	sw, sh := src.Dx(), src.Dy()
+	switch {
+	case w == 0 && h == 0:
+		w, h = sw, sh
+	case w == 0 && sh > 0:
+		w = sw * h / sh
+	case h == 0 && sw > 0:
+		h = sh * w / sw
+	}
+
+	return image.Rect(l.X, l.Y, l.X+w, l.Y+h)
+}
+
+// drawImage 按 fit 把 img 画到 r 中，尺寸相同时不缩放，避免二维码等图片变模糊
+func drawImage(dst draw.Image, img image.Image, r image.Rectangle, fit string) {
+	sr := img.Bounds()
+	if sr.Dx() == 0 || sr.Dy() == 0 || r.Empty() {
+		return
+	}
+	if sr.Dx() == r.Dx() && sr.Dy() == r.Dy() {
+		draw.Draw(dst, r, img, sr.Min, draw.Over)
+		return
+	}
+
+	switch fit {
+	case FitCover:
+		// 按区域比例从图片中间裁出一块
+		if sr.Dx()*r.Dy() > sr.Dy()*r.Dx() {
+			cw := sr.Dy() * r.Dx() / r.Dy()
+			x := sr.Min.X + (sr.Dx()-cw)/2
+			sr = image.Rect(x, sr.Min.Y, x+cw, sr.Max.Y)
+		} else {
+			ch := sr.Dx() * r.Dy() / r.Dx()
+			y := sr.Min.Y + (sr.Dy()-ch)/2
+			sr = image.Rect(sr.Min.X, y, sr.Max.X, y+ch)
+		}
+	case FitContain:
+		// 按图片比例缩小区域并居中
+		scale := math.Min(float64(r.Dx())/float64(sr.Dx()), float64(r.Dy())/float64(sr.Dy()))
+		w := int(math.Round(float64(sr.Dx()) * scale))
+		h := int(math.Round(float64(sr.Dy()) * scale))
+		x := r.Min.X + (r.Dx()-w)/2
+		y := r.Min.Y + (r.Dy()-h)/2
+		r = image.Rect(x, y, x+w, y+h)
+	}
+
+	draw.CatmullRom.Scale(dst, r, img, sr, draw.Over, nil)
+}
+
+// wrap 按宽度换行，英文按单词、中日韩文字按字换行，单词超过一行时按字符断开；
+// width 为 0 时只按文字中的换行符分行，maxLines 大于 0 时超出的部分省略
+func wrap(face font.Face, text string, width, maxLines int) []string {
+	measure := func(s string) int {
+		return font.MeasureString(face, s).Ceil()
+	}
+
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		if width <= 0 {
+			lines = append(lines, para)
+			continue
+		}
+
+		line := ""
+		for _, seg := range segments(para) {
+			blank := strings.TrimSpace(seg) == ""
+			if line == "" && blank {
+				continue
+			}
+			if measure(line+seg) <= width {
+				line += seg
+				continue
+			}
+			if line != "" {
+				lines = append(lines, strings.TrimRight(line, " "))
+				line = ""
+				if blank {
+					continue
+				}
+			}
+			for _, r := range seg {
+				if line != "" && measure(line+string(r)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(strings.TrimRight(lines[maxLines-1], " "))
+		for width > 0 && len(last) > 0 && measure(string(last)+ellipsis) > width {
+			last = last[:len(last)-1]
+		}
+		lines[maxLines-1] = strings.TrimRight(string(last), " ") + ellipsis
+	}
+
+	return lines
+}
+
+// segments 把文字分成换行时不能拆开的片段：连续的非空白字符（单词）、空白，以及单个中日韩文字
+func segments(s string) []string {
+	var segs []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			segs = append(segs, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			segs = append(segs, " ")
+		case isCJK(r):
+			flush()
+			segs = append(segs, string(r))
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return segs
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303f || r >= 0xff00 && r <= 0xffef
+}
@@ -0,0 +1,259 @@
+package poster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// monoFont 注册等宽字体，每个字符宽度相同，便于计算换行
+func monoFont(t *testing.T) string {
+	f, err := freetype.ParseFont(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts.Store("mono.ttf", f)
+
+	return "mono.ttf"
+}
+
+func monoFace() font.Face {
+	f, _ := freetype.ParseFont(gomono.TTF)
+	return truetype.NewFace(f, &truetype.Options{Size: 10})
+}
+
+func uniform(c color.Color, w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// isColor 像素接近 want，缩放会带来少量误差
+func isColor(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	near := func(v uint32, w uint8) bool {
+		d := int(v>>8) - int(w)
+		return d > -16 && d < 16
+	}
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+func TestWrap(t *testing.T) {
+	face := monoFace()
+	defer face.Close()
+	// 每行 10 个字符
+	width := font.MeasureString(face, strings.Repeat("a", 10)).Ceil()
+
+	tests := []struct {
+		text     string
+		width    int
+		maxLines int
+		want     string
+	}{
+		{"hello world foo", width, 0, "hello|world foo"},
+		{"  leading", width, 0, "leading"},
+		{"abcdefghijklmnop", width, 0, "abcdefghij|klmnop"},
+		{"a\nb c", width, 0, "a|b c"},
+		{"a\n\nb", 0, 0, "a||b"},
+		{"no wrap without width", 0, 0, "no wrap without width"},
+		{"aaaa bbbb cccc", width, 1, "aaaa bbbb…"},
+		{"aaaaa bbbb cccc", width, 1, "aaaaa bbb…"},
+		{"aaaaaaaaa bbbb", width, 1, "aaaaaaaaa…"},
+		{"a\nb\nc", 0, 2, "a|b…"},
+		{"short", width, 2, "short"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(wrap(face, tt.text, tt.width, tt.maxLines), "|"); got != tt.want {
+			t.Errorf("wrap(%q, %d, %d) = %q, want %q", tt.text, tt.width, tt.maxLines, got, tt.want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	got := strings.Join(segments("Go语言 is fun，对吧"), "|")
+	if want := "Go|语|言| |is| |fun|，|对|吧"; got != want {
+		t.Errorf("segments = %q, want %q", got, want)
+	}
+}
+
+func TestLayerRect(t *testing.T) {
+	src := image.Rect(0, 0, 200, 100)
+	tests := []struct {
+		w, h int
+		want image.Rectangle
+	}{
+		{0, 0, image.Rect(10, 20, 210, 120)},
+		{100, 0, image.Rect(10, 20, 110, 70)},
+		{0, 50, image.Rect(10, 20, 110, 70)},
+		{30, 40, image.Rect(10, 20, 40, 60)},
+	}
+	for _, tt := range tests {
+		l := &Layer{X: 10, Y: 20, Width: tt.w, Height: tt.h}
+		if got := layerRect(l, src); got != tt.want {
+			t.Errorf("layerRect(%dx%d) = %v, want %v", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestDrawImage(t *testing.T) {
+	// 左半边红色，右半边蓝色，中间两列分别为红、蓝
+	src := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	draw.Draw(src, image.Rect(0, 0, 4, 4), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(src, image.Rect(4, 0, 8, 4), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	newDst := func() *image.RGBA {
+		return uniform(white, 8, 8).(*image.RGBA)
+	}
+
+	// cover：从中间裁出 4x4 后缩放到 8x8，填满区域
+	dst := newDst()
+	drawImage(dst, src, dst.Bounds(), FitCover)
+	if !isColor(dst.At(0, 0), red) || !isColor(dst.At(7, 7), blue) {
+		t.Errorf("cover: corners = %v, %v", dst.At(0, 0), dst.At(7, 7))
+	}
+
+	// contain：缩放到 8x4，上下留白
+	dst = newDst()
+	drawImage(dst, src, dst.Bounds(), FitContain)
+	if !isColor(dst.At(0, 0), white) || !isColor(dst.At(0, 3), red) || !isColor(dst.At(7, 4), blue) || !isColor(dst.At(7, 7), white) {
+		t.Errorf("contain: %v %v %v %v", dst.At(0, 0), dst.At(0, 3), dst.At(7, 4), dst.At(7, 7))
+	}
+
+	// fill：拉伸到 8x8
+	dst = newDst()
+	drawImage(dst, src, dst.Bounds(), FitFill)
+	if !isColor(dst.At(0, 0), red) || !isColor(dst.At(7, 0), blue) {
+		t.Errorf("fill: %v %v", dst.At(0, 0), dst.At(7, 0))
+	}
+
+	// 尺寸相同时原样绘制
+	dst = newDst()
+	drawImage(dst, src, image.Rect(0, 2, 8, 6), FitCover)
+	if dst.At(3, 2) != red || dst.At(4, 5) != blue || dst.At(0, 1) != white {
+		t.Errorf("same size: %v %v %v", dst.At(3, 2), dst.At(4, 5), dst.At(0, 1))
+	}
+
+	// 空区域不绘制
+	drawImage(dst, src, image.Rectangle{}, FitCover)
+}
+
+func TestRender(t *testing.T) {
+	name := monoFont(t)
+	tpl := &Template{
+		Width:           60,
+		Height:          20,
+		BackgroundColor: "#00ff00",
+		Format:          FormatPNG,
+		Layers: []Layer{
+			{Type: LayerImage, Field: FieldCover, Width: 10, Height: 10, Fit: FitFill},
+			{Type: LayerImage, Field: FieldQrCode, X: 50, Width: 10, Height: 10},
+			{Type: LayerText, Field: FieldTitle, Text: "fallback", Font: name, Size: 12, X: 12, Y: 15, Color: "#000000"},
+		},
+	}
+	if err := tpl.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Render(tpl, &Data{
+		Text:   map[string]string{},
+		Images: map[string]image.Image{FieldCover: uniform(red, 4, 4)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds() != image.Rect(0, 0, 60, 20) {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+	if !isColor(img.At(5, 5), red) {
+		t.Errorf("cover pixel = %v, want red", img.At(5, 5))
+	}
+	// 没有二维码时不绘制该图层
+	if !isColor(img.At(55, 5), green) {
+		t.Errorf("qrcode area = %v, want background", img.At(55, 5))
+	}
+
+	// 标题为空时使用图层的 Text
+	dark := 0
+	for x := 12; x < 60; x++ {
+		for y := 0; y < 20; y++ {
+			if r, g, _, _ := img.At(x, y).RGBA(); r < 0x4000 && g < 0x4000 {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.Error("text was not drawn")
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	name := monoFont(t)
+	dst := image.NewRGBA(image.Rect(0, 0, 100, 100))
+
+	l := &Layer{Type: LayerText, Font: name, Size: 10, Width: 60, MaxLines: 2, LineHeight: 1.3}
+	if n, err := DrawText(dst, l, "one two three four five"); err != nil || n != 2 {
+		t.Errorf("DrawText = %d, %v, want 2 lines", n, err)
+	}
+	if n, err := DrawText(dst, l, ""); err != nil || n != 0 {
+		t.Errorf("DrawText(empty) = %d, %v", n, err)
+	}
+
+	l.Font = "missing.ttf"
+	if _, err := DrawText(dst, l, "text"); err == nil {
+		t.Error("DrawText with a missing font succeeded")
+	}
+}
+
+func TestLoadFont(t *testing.T) {
+	saved, savedPoster := *setting.AppSetting, *setting.PosterSetting
+	t.Cleanup(func() { *setting.AppSetting, *setting.PosterSetting = saved, savedPoster })
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "fonts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "fonts", "default.ttf"), gomono.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	setting.AppSetting.RuntimeRootPath = root + "/"
+	setting.AppSetting.FontSavePath = "fonts/"
+	setting.PosterSetting.DefaultFont = "default.ttf"
+
+	f, err := LoadFont("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 解析后的字体会被缓存
+	if cached, _ := LoadFont("default.ttf"); cached != f {
+		t.Error("font was parsed twice")
+	}
+	if _, err := LoadFont("missing.ttf"); err == nil {
+		t.Error("LoadFont(missing) succeeded")
+	}
+}
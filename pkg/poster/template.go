@@ -0,0 +1,211 @@
+package poster
+
+import (
+	"errors"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// 图层类型
+const (
+	LayerText  = "text"
+	LayerImage = "image"
+)
+
+// 图层可以绑定的字段，文字图层使用 Text 类字段，图片图层使用 Image 类字段
+const (
+	FieldTitle  = "title"
+	FieldDesc   = "desc"
+	FieldAuthor = "author"
+	FieldCover  = "cover"
+	FieldQrCode = "qrcode"
+)
+
+// 文字对齐方式
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// 图片缩放方式
+const (
+	FitCover   = "cover"   // 填满图层区域，超出部分从中间裁掉
+	FitContain = "contain" // 完整显示在图层区域内
+	FitFill    = "fill"    // 拉伸到图层区域大小
+)
+
+// 输出格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// MaxSize 海报的最大宽高
+const MaxSize = 4096
+
+// DefaultQuality 未指定质量时 JPEG 的编码质量
+const DefaultQuality = 90
+
+// ErrInvalidTemplate 模板的尺寸、格式或图层配置错误
+var ErrInvalidTemplate = errors.New("poster: invalid template")
+
+var textFields = map[string]bool{FieldTitle: true, FieldDesc: true, FieldAuthor: true}
+var imageFields = map[string]bool{FieldCover: true, FieldQrCode: true}
+
+// Template 海报模板，背景图之上按顺序绘制各个图层
+type Template struct {
+	Width           int     `json:"width"`
+	Height          int     `json:"height"`
+	Background      string  `json:"background"`       // 背景图文件名，位于 BackgroundSavePath 下，支持 JPEG、PNG
+	BackgroundColor string  `json:"background_color"` // 没有背景图或背景图有透明部分时的底色，默认白色
+	Format          string  `json:"format"`           // jpeg 或 png，默认 jpeg
+	Quality         int     `json:"quality"`          // 1-100，只对 JPEG 有效
+	Layers          []Layer `json:"layers"`
+}
+
+// Layer 文字或图片图层，Field 绑定文章字段，字段为空时文字图层使用 Text
+type Layer struct {
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	Text  string `json:"text"`
+	Src   string `json:"src"` // 固定图片的文件名，位于 BackgroundSavePath 下
+
+	// 文字图层的 Y 为第一行的基线位置，Width 为换行宽度，为 0 时不换行
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	Font       string  `json:"font"` // 字体文件名，位于 FontSavePath 下，为空时使用 DefaultFont
+	Size       float64 `json:"size"`
+	Color      string  `json:"color"` // #rrggbb 或 #rrggbbaa，默认黑色
+	Align      string  `json:"align"`
+	LineHeight float64 `json:"line_height"` // 行高为字号的倍数，默认 1.3
+	MaxLines   int     `json:"max_lines"`   // 超出的部分省略，0 表示不限制
+
+	Fit string `json:"fit"`
+}
+
+// Normalize 检查模板并填充默认值
+func (t *Template) Normalize() error {
+	if t.Width <= 0 || t.Height <= 0 || t.Width > MaxSize || t.Height > MaxSize {
+		return ErrInvalidTemplate
+	}
+	switch t.Format {
+	case "", "jpg":
+		t.Format = FormatJPEG
+	case FormatJPEG, FormatPNG:
+	default:
+		return ErrInvalidTemplate
+	}
+	if t.Quality == 0 {
+		t.Quality = DefaultQuality
+	}
+	if t.Quality < 1 || t.Quality > 100 || !validFile(t.Background) {
+		return ErrInvalidTemplate
+	}
+	if _, err := ParseColor(t.BackgroundColor); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	for i := range t.Layers {
+		if err := t.Layers[i].normalize(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Layer) normalize() error {
+	if l.Width < 0 || l.Height < 0 || l.Width > MaxSize || l.Height > MaxSize {
+		return ErrInvalidTemplate
+	}
+
+	switch l.Type {
+	case LayerText:
+		if l.Field != "" && !textFields[l.Field] || l.Size <= 0 || l.MaxLines < 0 || !validFile(l.Font) {
+			return ErrInvalidTemplate
+		}
+		if l.LineHeight == 0 {
+			l.LineHeight = 1.3
+		}
+		if l.LineHeight < 0 {
+			return ErrInvalidTemplate
+		}
+		switch l.Align {
+		case "":
+			l.Align = AlignLeft
+		case AlignLeft, AlignCenter, AlignRight:
+		default:
+			return ErrInvalidTemplate
+		}
+		if _, err := ParseColor(l.Color); err != nil {
+			return ErrInvalidTemplate
+		}
+	case LayerImage:
+		if l.Field != "" && !imageFields[l.Field] || l.Field == "" && l.Src == "" || !validFile(l.Src) {
+			return ErrInvalidTemplate
+		}
+		switch l.Fit {
+		case "":
+			l.Fit = FitCover
+		case FitCover, FitContain, FitFill:
+		default:
+			return ErrInvalidTemplate
+		}
+	default:
+		return ErrInvalidTemplate
+	}
+
+	return nil
+}
+
+// ContentType 输出格式的 MIME 类型
+func (t *Template) ContentType() string {
+	if t.Format == FormatPNG {
+		return "image/png"
+	}
+
+	return "image/jpeg"
+}
+
+// Ext 输出格式的扩展名
+func (t *Template) Ext() string {
+	if t.Format == FormatPNG {
+		return ".png"
+	}
+
+	return ".jpg"
+}
+
+// ParseColor 解析 #rgb、#rrggbb 或 #rrggbbaa，为空时返回 nil
+func ParseColor(s string) (color.Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 || !strings.HasPrefix(s, "#") {
+		return nil, ErrInvalidTemplate
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, ErrInvalidTemplate
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// validFile 文件名不能包含路径，防止读取目录之外的文件
+func validFile(name string) bool {
+	return !strings.ContainsAny(name, `/\`) && name != ".." && name != "."
+}
@@ -0,0 +1,115 @@
+package poster
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.Color
+		err  bool
+	}{
+		{"", nil, false},
+		{"#fff", color.NRGBA{255, 255, 255, 255}, false},
+		{"#1a2b3c", color.NRGBA{0x1a, 0x2b, 0x3c, 255}, false},
+		{"#1A2B3C80", color.NRGBA{0x1a, 0x2b, 0x3c, 0x80}, false},
+		{"fff", nil, true},
+		{"#ffff", nil, true},
+		{"#gggggg", nil, true},
+		{"#-12345", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.s)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tpl := &Template{
+		Width:  750,
+		Height: 1334,
+		Layers: []Layer{
+			{Type: LayerText, Field: FieldTitle, Size: 32},
+			{Type: LayerImage, Field: FieldQrCode, Width: 200},
+		},
+	}
+	if err := tpl.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if tpl.Format != FormatJPEG || tpl.Quality != DefaultQuality || tpl.ContentType() != "image/jpeg" || tpl.Ext() != ".jpg" {
+		t.Errorf("template defaults = %s %d %s %s", tpl.Format, tpl.Quality, tpl.ContentType(), tpl.Ext())
+	}
+	if l := tpl.Layers[0]; l.Align != AlignLeft || l.LineHeight != 1.3 {
+		t.Errorf("text layer defaults = %s %v", l.Align, l.LineHeight)
+	}
+	if l := tpl.Layers[1]; l.Fit != FitCover {
+		t.Errorf("image layer fit = %s", l.Fit)
+	}
+
+	png := &Template{Width: 1, Height: 1, Format: FormatPNG}
+	if err := png.Normalize(); err != nil || png.ContentType() != "image/png" || png.Ext() != ".png" {
+		t.Errorf("png template = %v, %s", err, png.ContentType())
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	text := func(l Layer) Template {
+		l.Type = LayerText
+		if l.Size == 0 {
+			l.Size = 20
+		}
+		return Template{Width: 100, Height: 100, Layers: []Layer{l}}
+	}
+	image := func(l Layer) Template {
+		l.Type = LayerImage
+		return Template{Width: 100, Height: 100, Layers: []Layer{l}}
+	}
+
+	tests := map[string]Template{
+		"zero width":          {Height: 100},
+		"too large":           {Width: MaxSize + 1, Height: 100},
+		"format":              {Width: 100, Height: 100, Format: "gif"},
+		"quality":             {Width: 100, Height: 100, Quality: 101},
+		"background path":     {Width: 100, Height: 100, Background: "../secret.png"},
+		"background color":    {Width: 100, Height: 100, BackgroundColor: "red"},
+		"layer type":          {Width: 100, Height: 100, Layers: []Layer{{Type: "video"}}},
+		"text image field":    text(Layer{Field: FieldCover}),
+		"text size":           text(Layer{Size: -1}),
+		"text font path":      text(Layer{Font: `..\font.ttf`}),
+		"text align":          text(Layer{Align: "justify"}),
+		"text color":          text(Layer{Color: "#12"}),
+		"text line height":    text(Layer{LineHeight: -1}),
+		"text max lines":      text(Layer{MaxLines: -1}),
+		"layer width":         text(Layer{Width: -1}),
+		"image text field":    image(Layer{Field: FieldTitle}),
+		"image without src":   image(Layer{}),
+		"image src path":      image(Layer{Src: "a/b.png"}),
+		"image fit":           image(Layer{Field: FieldCover, Fit: "tile"}),
+		"image layer too big": image(Layer{Field: FieldCover, Height: MaxSize + 1}),
+	}
+	for name, tpl := range tests {
+		if err := tpl.Normalize(); err != ErrInvalidTemplate {
+			t.Errorf("%s: Normalize() = %v, want ErrInvalidTemplate", name, err)
+		}
+	}
+}
+
+func TestValidFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"":          true,
+		"bg.png":    true,
+		"..":        false,
+		".":         false,
+		"a/b.png":   false,
+		`a\b.png`:   false,
+		"../bg.png": false,
+	} {
+		if got := validFile(name); got != want {
+			t.Errorf("validFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+	"golang.org/x/image/draw"
+)
+
+// 码制
+const (
+	KindQR         = "qr"
+	KindCode128    = "code128"
+	KindEAN13      = "ean13"
+	KindDataMatrix = "datamatrix"
+)
+
+// 输出格式
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatSVG  = "svg"
+)
+
+// DefaultModuleSize 没有指定宽度时每个模块的像素数
+const DefaultModuleSize = 4
+
+// DefaultBarHeight 没有指定高度时一维码条的高度（模块数）
+const DefaultBarHeight = 30
+
+// MaxSize 图片的最大宽高
+const MaxSize = 4096
+
+var (
+	// ErrInvalidCode 码制、输出格式或尺寸错误，或内容不能用该码制编码
+	ErrInvalidCode = errors.New("qrcode: invalid code")
+	// ErrTooSmall 指定的宽高放不下条码
+	ErrTooSmall = errors.New("qrcode: size is too small for the code")
+)
+
+// quietZones 各码制默认的静区（模块数）
+var quietZones = map[string]int{
+	KindQR:         4,
+	KindCode128:    10,
+	KindEAN13:      11,
+	KindDataMatrix: 1,
+}
+
+var contentTypes = map[string]string{
+	FormatPNG:  "image/png",
+	FormatJPEG: "image/jpeg",
+	FormatSVG:  "image/svg+xml",
+}
+
+var ean13Content = regexp.MustCompile(`^[0-9]{12,13}$`)
+
+// logoRatio logo 占二维码宽度的比例，使用最高纠错等级时遮挡这么多仍可识别
+const logoRatio = 0.2
+
+// Code 条码或二维码，图片不写入磁盘，直接编码输出
+type Code struct {
+	Kind    string
+	Content string
+	// Width、Height 为图片宽高（像素），条码按整数倍模块大小居中；
+	// Width 为 0 时每个模块 DefaultModuleSize 像素，Height 为 0 时二维码为正方形，一维码条高 DefaultBarHeight 个模块
+	Width  int
+	Height int
+	// QuietZone 四周留白的模块数，小于 0 时使用码制的默认值
+	QuietZone int
+	// Logo 放在二维码中心，只对 QR 有效，有 logo 时使用最高纠错等级
+	Logo image.Image
+}
+
+// layout 条码在图片中的位置
+type layout struct {
+	width, height int // 图片宽高
+	x, y          int // 条码左上角
+	module        int // 模块大小（像素）
+	barHeight     int // 一维码条的高度（像素）
+}
+
+// ContentType 输出格式的 MIME 类型，不支持的格式返回空
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Encode 编码为未缩放的条码，每个像素为一个模块，一维码高度为 1
+func (c *Code) Encode() (barcode.Barcode, error) {
+	if c.Content == "" {
+		return nil, ErrInvalidCode
+	}
+
+	var code barcode.Barcode
+	var err error
+	switch c.Kind {
+	case KindQR:
+		level := qr.M
+		if c.Logo != nil {
+			level = qr.H
+		}
+		code, err = qr.Encode(c.Content, level, qr.Auto)
+	case KindCode128:
+		code, err = code128.Encode(c.Content)
+	case KindEAN13:
+		// 12 位时自动计算校验位，13 位时检查校验位
+		if !ean13Content.MatchString(c.Content) {
+			return nil, ErrInvalidCode
+		}
+		code, err = ean.Encode(c.Content)
+	case KindDataMatrix:
+		code, err = datamatrix.Encode(c.Content)
+	default:
+		return nil, ErrInvalidCode
+	}
+	if err != nil {
+		return nil, ErrInvalidCode
+	}
+
+	return code, nil
+}
+
+// Write 按格式编码并写入 w
+func (c *Code) Write(w io.Writer, format string) error {
+	if _, ok := contentTypes[format]; !ok {
+		return ErrInvalidCode
+	}
+
+	code, err := c.Encode()
+	if err != nil {
+		return err
+	}
+	l, err := c.layout(code)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatSVG {
+		return c.writeSVG(w, code, l)
+	}
+
+	img := c.render(code, l)
+	if format == FormatJPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 95})
+	}
+	return png.Encode(w, img)
+}
+
+// Image 绘制条码图片
+func (c *Code) Image() (image.Image, error) {
+	code, err := c.Encode()
+	if err != nil {
+		return nil, err
+	}
+	l, err := c.layout(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.render(code, l), nil
+}
+
+func (c *Code) quietZone() int {
+	if c.QuietZone < 0 {
+		return quietZones[c.Kind]
+	}
+
+	return c.QuietZone
+}
+
+func (c *Code) layout(code barcode.Barcode) (*layout, error) {
+	if c.Width < 0 || c.Height < 0 || c.Width > MaxSize || c.Height > MaxSize {
+		return nil, ErrInvalidCode
+	}
+
+	q := c.quietZone()
+	cols := code.Bounds().Dx()
+	rows := code.Bounds().Dy()
+	oneD := code.Metadata().Dimensions == 1
+
+	l := &layout{module: DefaultModuleSize}
+	if c.Width > 0 {
+		l.module = c.Width / (cols + 2*q)
+	}
+	if !oneD && c.Height > 0 {
+		l.module = min(l.module, c.Height/(rows+2*q))
+	}
+	if l.module < 1 {
+		return nil, ErrTooSmall
+	}
+
+	l.width = c.Width
+	if l.width == 0 {
+		l.width = (cols + 2*q) * l.module
+	}
+	l.height = c.Height
+	if oneD {
+		if l.height == 0 {
+			l.height = (DefaultBarHeight + 2*q) * l.module
+		}
+		l.barHeight = l.height - 2*q*l.module
+		if l.barHeight < 1 {
+			return nil, ErrTooSmall
+		}
+	} else {
+		if l.height == 0 {
+			l.height = l.width
+			if c.Width == 0 {
+				l.height = (rows + 2*q) * l.module
+			}
+		}
+		l.barHeight = rows * l.module
+	}
+	if l.width > MaxSize || l.height > MaxSize {
+		return nil, ErrInvalidCode
+	}
+
+	l.x = (l.width - cols*l.module) / 2
+	l.y = (l.height - l.barHeight) / 2
+	return l, nil
+}
+
+func (c *Code) render(code barcode.Barcode, l *layout) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, r := range modules(code, l) {
+		draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+	}
+
+	if r, ok := c.logoRect(code, l); ok {
+		draw.Draw(img, r, image.White, image.Point{}, draw.Src)
+		pad := l.module
+		inner := image.Rect(r.Min.X+pad, r.Min.Y+pad, r.Max.X-pad, r.Max.Y-pad)
+		draw.CatmullRom.Scale(img, fitRect(c.Logo.Bounds(), inner), c.Logo, c.Logo.Bounds(), draw.Over, nil)
+	}
+
+	return img
+}
+
+// modules 深色模块合并成的矩形，同一行相邻的模块合并为一个
+func modules(code barcode.Barcode, l *layout) []image.Rectangle {
+	b := code.Bounds()
+	rowHeight := l.module
+	if code.Metadata().Dimensions == 1 {
+		rowHeight = l.barHeight
+	}
+
+	var rects []image.Rectangle
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !dark(code.At(x, y)) {
+				continue
+			}
+			start := x
+			for x+1 < b.Max.X && dark(code.At(x+1, y)) {
+				x++
+			}
+
+			x0 := l.x + (start-b.Min.X)*l.module
+			y0 := l.y + (y-b.Min.Y)*rowHeight
+			rects = append(rects, image.Rect(x0, y0, x0+(x-start+1)*l.module, y0+rowHeight))
+		}
+	}
+
+	return rects
+}
+
+// logoRect logo 及其白色底框的位置，按模块对齐并居中
+func (c *Code) logoRect(code barcode.Barcode, l *layout) (image.Rectangle, bool) {
+	if c.Logo == nil || c.Kind != KindQR || c.Logo.Bounds().Empty() {
+		return image.Rectangle{}, false
+	}
+
+	// logo 的模块数与 cols 奇偶相同，两侧留出的模块数才相等
+	cols := code.Bounds().Dx()
+	n := int(float64(cols) * logoRatio)
+	if (cols-n)%2 != 0 {
+		n--
+	}
+	if n < 3 {
+		return image.Rectangle{}, false
+	}
+	size := n * l.module
+	x := l.x + (cols-n)/2*l.module
+	y := l.y + (l.barHeight-size)/2
+	return image.Rect(x, y, x+size, y+size), true
+}
+
+// fitRect 按 src 的比例缩小 r 并居中
+func fitRect(src, r image.Rectangle) image.Rectangle {
+	w, h := r.Dx(), r.Dy()
+	if src.Dx()*h > src.Dy()*w {
+		h = max(1, src.Dy()*w/src.Dx())
+	} else {
+		w = max(1, src.Dx()*h/src.Dy())
+	}
+
+	x := r.Min.X + (r.Dx()-w)/2
+	y := r.Min.Y + (r.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+func dark(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y < 128
+}
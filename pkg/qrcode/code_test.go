@@ -0,0 +1,272 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/barcode"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		kind    string
+		content string
+		err     bool
+	}{
+		{KindQR, "https://example.com", false},
+		{KindCode128, "SKU-0001", false},
+		{KindEAN13, "590123412345", false},
+		{KindEAN13, "5901234123457", false},
+		{KindEAN13, "5901234123458", true},
+		{KindEAN13, "59012341234", true},
+		{KindEAN13, "59012341234a", true},
+		{KindDataMatrix, "LOT 42", false},
+		{KindQR, "", true},
+		{"pdf417", "x", true},
+	}
+	for _, tt := range tests {
+		code, err := (&Code{Kind: tt.kind, Content: tt.content}).Encode()
+		if tt.err {
+			if err != ErrInvalidCode {
+				t.Errorf("Encode(%s, %q) = %v, want ErrInvalidCode", tt.kind, tt.content, err)
+			}
+			continue
+		}
+		if err != nil || code == nil {
+			t.Errorf("Encode(%s, %q) = %v", tt.kind, tt.content, err)
+		}
+	}
+
+	// 12 位内容自动加上校验位
+	code, _ := (&Code{Kind: KindEAN13, Content: "590123412345"}).Encode()
+	if got := code.Content(); got != "5901234123457" {
+		t.Errorf("EAN-13 content = %q, want 5901234123457", got)
+	}
+
+	// 有 logo 时使用最高纠错等级，模块更多
+	plain, _ := (&Code{Kind: KindQR, Content: "https://example.com/articles/1"}).Encode()
+	withLogo, _ := (&Code{Kind: KindQR, Content: "https://example.com/articles/1", Logo: image.NewRGBA(image.Rect(0, 0, 1, 1))}).Encode()
+	if withLogo.Bounds().Dx() <= plain.Bounds().Dx() {
+		t.Errorf("QR with logo has %d modules, without %d", withLogo.Bounds().Dx(), plain.Bounds().Dx())
+	}
+}
+
+func encode(t *testing.T, c *Code) barcode.Barcode {
+	code, err := c.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code
+}
+
+func TestLayout(t *testing.T) {
+	qr := &Code{Kind: KindQR, Content: "hello", QuietZone: -1}
+	qrCode := encode(t, qr)
+	n := qrCode.Bounds().Dx() // 21
+	bar := &Code{Kind: KindCode128, Content: "SKU-0001", QuietZone: 2}
+	barCode := encode(t, bar)
+	cols := barCode.Bounds().Dx()
+
+	tests := []struct {
+		name   string
+		c      *Code
+		code   barcode.Barcode
+		width  int
+		height int
+		want   layout
+	}{
+		{"qr default", qr, qrCode, 0, 0, layout{
+			width: (n + 8) * 4, height: (n + 8) * 4, x: 16, y: 16, module: 4, barHeight: n * 4}},
+		{"qr width", qr, qrCode, 300, 0, layout{
+			width: 300, height: 300, x: (300 - n*10) / 2, y: (300 - n*10) / 2, module: 10, barHeight: n * 10}},
+		{"qr height limits module", qr, qrCode, 300, 60, layout{
+			width: 300, height: 60, x: (300 - n*2) / 2, y: (60 - n*2) / 2, module: 2, barHeight: n * 2}},
+		{"1d default", bar, barCode, 0, 0, layout{
+			width: (cols + 4) * 4, height: (DefaultBarHeight + 4) * 4, x: 8, y: 8, module: 4, barHeight: DefaultBarHeight * 4}},
+		{"1d size", bar, barCode, (cols + 4) * 2, 50, layout{
+			width: (cols + 4) * 2, height: 50, x: 4, y: 4, module: 2, barHeight: 42}},
+	}
+	for _, tt := range tests {
+		tt.c.Width, tt.c.Height = tt.width, tt.height
+		l, err := tt.c.layout(tt.code)
+		if err != nil || *l != tt.want {
+			t.Errorf("%s: layout = %+v, %v, want %+v", tt.name, l, err, tt.want)
+		}
+	}
+
+	errs := []struct {
+		name string
+		c    *Code
+		code barcode.Barcode
+		want error
+	}{
+		{"negative", &Code{Kind: KindQR, Width: -1}, qrCode, ErrInvalidCode},
+		{"too large", &Code{Kind: KindQR, Width: MaxSize + 1}, qrCode, ErrInvalidCode},
+		{"too narrow", &Code{Kind: KindQR, Width: n + 7, QuietZone: -1}, qrCode, ErrTooSmall},
+		{"too low", &Code{Kind: KindCode128, Width: cols + 4, Height: 4, QuietZone: 2}, barCode, ErrTooSmall},
+		{"default too large", &Code{Kind: KindCode128, QuietZone: 2000}, barCode, ErrInvalidCode},
+	}
+	for _, tt := range errs {
+		if _, err := tt.c.layout(tt.code); err != tt.want {
+			t.Errorf("%s: layout err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// checkModules 每个模块中心的颜色与条码一致，静区为白色
+func checkModules(t *testing.T, c *Code, img image.Image) {
+	code := encode(t, c)
+	l, err := c.layout(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != l.width || img.Bounds().Dy() != l.height {
+		t.Fatalf("%s: image %v, want %dx%d", c.Kind, img.Bounds(), l.width, l.height)
+	}
+
+	rowHeight := l.module
+	if code.Metadata().Dimensions == 1 {
+		rowHeight = l.barHeight
+	}
+	b := code.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px := img.At(l.x+(x-b.Min.X)*l.module+l.module/2, l.y+(y-b.Min.Y)*rowHeight+rowHeight/2)
+			if dark(px) != dark(code.At(x, y)) {
+				t.Fatalf("%s: module (%d, %d) = %v", c.Kind, x, y, px)
+			}
+		}
+	}
+	if dark(img.At(0, 0)) || dark(img.At(l.width-1, l.height-1)) {
+		t.Errorf("%s: quiet zone is not white", c.Kind)
+	}
+}
+
+func TestImage(t *testing.T) {
+	for _, c := range []*Code{
+		{Kind: KindQR, Content: "https://example.com", QuietZone: -1},
+		{Kind: KindCode128, Content: "SKU-0001", Width: 500, Height: 120, QuietZone: -1},
+		{Kind: KindEAN13, Content: "590123412345", QuietZone: -1},
+		{Kind: KindDataMatrix, Content: "LOT 42", Width: 123, QuietZone: -1},
+	} {
+		img, err := c.Image()
+		if err != nil {
+			t.Fatalf("%s: %v", c.Kind, err)
+		}
+		checkModules(t, c, img)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	c := &Code{Kind: KindQR, Content: "https://example.com", Width: 200, QuietZone: -1}
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf, FormatPNG); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkModules(t, c, img)
+
+	buf.Reset()
+	if err := c.Write(&buf, FormatJPEG); err != nil {
+		t.Fatal(err)
+	}
+	if img, err := jpeg.Decode(&buf); err != nil || img.Bounds().Dx() != 200 {
+		t.Errorf("jpeg = %v, %v", img, err)
+	}
+
+	buf.Reset()
+	if err := c.Write(&buf, FormatSVG); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200"`) ||
+		!strings.Contains(svg, `<path fill="#000" d="M`) || !strings.HasSuffix(svg, "</svg>") || strings.Contains(svg, "<image") {
+		t.Errorf("svg = %.200s", svg)
+	}
+
+	if err := c.Write(&buf, "bmp"); err != ErrInvalidCode {
+		t.Errorf("Write(bmp) = %v, want ErrInvalidCode", err)
+	}
+	if err := (&Code{Kind: KindQR}).Write(&buf, FormatPNG); err != ErrInvalidCode {
+		t.Errorf("Write(empty) = %v, want ErrInvalidCode", err)
+	}
+
+	for format, want := range map[string]string{FormatPNG: "image/png", FormatJPEG: "image/jpeg", FormatSVG: "image/svg+xml", "bmp": ""} {
+		if got := ContentType(format); got != want {
+			t.Errorf("ContentType(%s) = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestLogo(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 10; y++ {
+			logo.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	c := &Code{Kind: KindQR, Content: "https://example.com/articles/1", Width: 400, QuietZone: -1, Logo: logo}
+	code := encode(t, c)
+	l, _ := c.layout(code)
+	r, ok := c.logoRect(code, l)
+	if !ok {
+		t.Fatal("logoRect = false")
+	}
+	// 对齐到模块并居中
+	left, right := r.Min.X-l.x, l.x+code.Bounds().Dx()*l.module-r.Max.X
+	if r.Dx() != r.Dy() || left != right || left%l.module != 0 || r.Dx()%l.module != 0 {
+		t.Errorf("logoRect %v is not a centered square aligned to %dpx modules", r, l.module)
+	}
+
+	img, err := c.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 底框为白色，中间为 logo
+	if got := color.RGBAModel.Convert(img.At(r.Min.X, r.Min.Y)); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("logo border = %v", got)
+	}
+	center := image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+	if r, g, _, _ := img.At(center.X, center.Y).RGBA(); r>>8 < 200 || g>>8 > 50 {
+		t.Errorf("logo center = %v", img.At(center.X, center.Y))
+	}
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf, FormatSVG); err != nil || !strings.Contains(buf.String(), `href="data:image/png;base64,`) {
+		t.Errorf("svg with logo = %v", err)
+	}
+
+	// 一维码不放 logo
+	bar := &Code{Kind: KindCode128, Content: "SKU", QuietZone: -1, Logo: logo}
+	barCode := encode(t, bar)
+	l, _ = bar.layout(barCode)
+	if _, ok := bar.logoRect(barCode, l); ok {
+		t.Error("code128 has a logo")
+	}
+}
+
+func TestFitRect(t *testing.T) {
+	tests := []struct {
+		src, r, want image.Rectangle
+	}{
+		{image.Rect(0, 0, 20, 10), image.Rect(0, 0, 10, 10), image.Rect(0, 2, 10, 7)},
+		{image.Rect(0, 0, 10, 20), image.Rect(10, 10, 20, 20), image.Rect(12, 10, 17, 20)},
+		{image.Rect(0, 0, 5, 5), image.Rect(0, 0, 10, 10), image.Rect(0, 0, 10, 10)},
+		{image.Rect(0, 0, 1000, 1), image.Rect(0, 0, 10, 10), image.Rect(0, 4, 10, 5)},
+	}
+	for _, tt := range tests {
+		if got := fitRect(tt.src, tt.r); got != tt.want {
+			t.Errorf("fitRect(%v, %v) = %v, want %v", tt.src, tt.r, got, tt.want)
+		}
+	}
+}
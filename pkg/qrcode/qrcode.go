@@ -1,13 +1,15 @@
 package qrcode
 
 import (
+	"bytes"
+	"image"
 	"image/jpeg"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/qr"
 
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 )
 
@@ -48,7 +50,12 @@ func GetQrCodeFullPath() string {
 
 // GetQrCodeFullUrl get the full access path
 func GetQrCodeFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetQrCodePath() + name
+	return storage.Default.URL(GetQrCodeKey(name))
+}
+
+// GetQrCodeKey get the storage key of the qr file
+func GetQrCodeKey(name string) string {
+	return GetQrCodePath() + name
 }
 
 // GetQrCodeFileName get qr file name
@@ -61,32 +68,40 @@ func (q *QrCode) GetQrCodeExt() string {
 	return q.Ext
 }
 
-// Encode generate QR code
-func (q *QrCode) Encode(path string) (string, string, error) {
+// Image generate the QR code image without saving it
+func (q *QrCode) Image() (image.Image, error) {
+	code, err := qr.Encode(q.URL, q.Level, q.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return barcode.Scale(code, q.Width, q.Height)
+}
+
+// Encode generate QR code and save it to the storage, returns the file name
+func (q *QrCode) Encode() (string, error) {
 	name := GetQrCodeFileName(q.URL) + q.GetQrCodeExt()
-	src := path + name
-	if file.CheckNotExist(src) == true {
-		code, err := qr.Encode(q.URL, q.Level, q.Mode)
-		if err != nil {
-			return "", "", err
-		}
-
-		code, err = barcode.Scale(code, q.Width, q.Height)
-		if err != nil {
-			return "", "", err
-		}
-
-		f, err := file.MustOpen(name, path)
-		if err != nil {
-			return "", "", err
-		}
-		defer f.Close()
-
-		err = jpeg.Encode(f, code, nil)
-		if err != nil {
-			return "", "", err
-		}
+	key := GetQrCodeKey(name)
+	exists, err := storage.Default.Exists(key)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return name, nil
+	}
+
+	code, err := q.Image()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, code, nil); err != nil {
+		return "", err
+	}
+	if err := storage.Default.Put(key, &buf, "image/jpeg"); err != nil {
+		return "", err
 	}
 
-	return name, path, nil
+	return name, nil
 }
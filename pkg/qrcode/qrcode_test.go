@@ -0,0 +1,46 @@
+package qrcode
+
+import (
+	"image/jpeg"
+	"testing"
+
+	"github.com/boombuler/barcode/qr"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+func TestQrCodeEncode(t *testing.T) {
+	savedStorage, savedPath := storage.Default, setting.AppSetting.QrCodeSavePath
+	t.Cleanup(func() { storage.Default, setting.AppSetting.QrCodeSavePath = savedStorage, savedPath })
+	storage.Default = storage.NewLocal(t.TempDir(), "")
+	setting.AppSetting.QrCodeSavePath = "qrcode/"
+
+	q := NewQrCode("https://example.com", 150, 150, qr.M, qr.Auto)
+	name, err := q.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := GetQrCodeFileName("https://example.com") + EXT_JPG; name != want {
+		t.Errorf("Encode() = %q, want %q", name, want)
+	}
+
+	rc, err := storage.Default.Get(GetQrCodeKey(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(rc)
+	rc.Close()
+	if err != nil || img.Bounds().Dx() != 150 || img.Bounds().Dy() != 150 {
+		t.Errorf("saved image = %v, %v", img, err)
+	}
+
+	// 已经生成过时不再重复生成
+	if again, err := q.Encode(); err != nil || again != name {
+		t.Errorf("second Encode() = %q, %v", again, err)
+	}
+
+	if _, err := NewQrCode("https://example.com", 10, 10, qr.M, qr.Auto).Image(); err == nil {
+		t.Error("Image() smaller than the code succeeded")
+	}
+}
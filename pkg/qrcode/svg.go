@@ -0,0 +1,47 @@
+package qrcode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"github.com/boombuler/barcode"
+	"golang.org/x/image/draw"
+)
+
+// writeSVG 输出矢量图，打印标签时任意缩放都不会模糊；logo 以 PNG 内嵌
+func (c *Code) writeSVG(w io.Writer, code barcode.Barcode, l *layout) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/>`, l.width, l.height)
+
+	bw.WriteString(`<path fill="#000" d="`)
+	for _, r := range modules(code, l) {
+		fmt.Fprintf(bw, "M%d %dh%dv%dh-%dz", r.Min.X, r.Min.Y, r.Dx(), r.Dy(), r.Dx())
+	}
+	bw.WriteString(`"/>`)
+
+	if r, ok := c.logoRect(code, l); ok {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="#fff"/>`, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+
+		pad := l.module
+		inner := fitRect(c.Logo.Bounds(), image.Rect(r.Min.X+pad, r.Min.Y+pad, r.Max.X-pad, r.Max.Y-pad))
+		logo := image.NewRGBA(image.Rect(0, 0, inner.Dx(), inner.Dy()))
+		draw.CatmullRom.Scale(logo, logo.Bounds(), c.Logo, c.Logo.Bounds(), draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, logo); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`,
+			inner.Min.X, inner.Min.Y, inner.Dx(), inner.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+
+	bw.WriteString("</svg>")
+	return bw.Flush()
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout 超过这个时间没有请求的 key 会被清理
+const idleTimeout = 10 * time.Minute
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// memoryLimiter 进程内令牌桶，桶容量为 Limit，每 Period/Limit 补充一个令牌
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter 创建进程内限流器，多实例部署时每个实例单独计数
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (m *memoryLimiter) Allow(ctx context.Context, key string, r Rate) (*Result, error) {
+	now := time.Now()
+	every := rate.Every(r.Period / time.Duration(r.Limit))
+	limiter := m.limiter(key, every, r.Limit, now)
+
+	result := &Result{Limit: r.Limit}
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		result.RetryAfter = delay
+	} else {
+		result.Allowed = true
+	}
+
+	tokens := limiter.TokensAt(now)
+	if tokens > 0 {
+		result.Remaining = int(math.Floor(tokens))
+	}
+	result.ResetAfter = time.Duration((float64(r.Limit) - tokens) / float64(every) * float64(time.Second))
+
+	return result, nil
+}
+
+// limiter 返回 key 对应的令牌桶，配置变化时重建
+func (m *memoryLimiter) limiter(key string, every rate.Limit, burst int, now time.Time) *rate.Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > idleTimeout {
+		for k, b := range m.buckets {
+			if now.Sub(b.lastSeen) > idleTimeout {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok || b.limiter.Limit() != every || b.limiter.Burst() != burst {
+		b = &bucket{limiter: rate.NewLimiter(every, burst)}
+		m.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	return b.limiter
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 限流存储
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Rate 每 Period 最多 Limit 次
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate 解析 "100/1m"、"5/30s" 形式的配置，Period 省略单位时按秒计
+func ParseRate(s string) (Rate, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return Rate{}, fmt.Errorf("invalid rate %q, want <limit>/<period>", s)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate limit %q", parts[0])
+	}
+
+	period := strings.TrimSpace(parts[1])
+	if _, err := strconv.Atoi(period); err == nil {
+		period += "s"
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate period %q", parts[1])
+	}
+
+	return Rate{Limit: limit, Period: d}, nil
+}
+
+// Zero 未配置限流
+func (r Rate) Zero() bool {
+	return r.Limit <= 0 || r.Period <= 0
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // 配额完全恢复还需要的时间
+	RetryAfter time.Duration // 被拒绝时，下一次可以通过还需要的时间
+}
+
+// Limiter 限流器，key 区分不同的调用方（IP、用户等）
+type Limiter interface {
+	Allow(ctx context.Context, key string, rate Rate) (*Result, error)
+}
+
+// Default 由 Setup 按配置创建
+var Default Limiter
+
+// Setup 按配置初始化 Default，单机部署用进程内令牌桶，多实例部署用 Redis 滑动窗口
+func Setup() {
+	switch setting.RateLimitSetting.Store {
+	case StoreRedis:
+		Default = NewRedisLimiter()
+	default:
+		Default = NewMemoryLimiter()
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Rate
+		err  bool
+	}{
+		{"100/1m", Rate{100, time.Minute}, false},
+		{" 5 / 30s ", Rate{5, 30 * time.Second}, false},
+		{"10/60", Rate{10, time.Minute}, false},
+		{"10", Rate{}, true},
+		{"0/1m", Rate{}, true},
+		{"-1/1m", Rate{}, true},
+		{"x/1m", Rate{}, true},
+		{"10/0", Rate{}, true},
+		{"10/abc", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.s)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseRate(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.err)
+		}
+	}
+
+	if !(Rate{}).Zero() || (Rate{1, time.Second}).Zero() {
+		t.Error("Zero() is wrong")
+	}
+	if s := (Rate{5, 30 * time.Second}).String(); s != "5/30s" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	l := NewMemoryLimiter()
+	ctx := context.Background()
+	r := Rate{Limit: 3, Period: 3 * time.Second}
+
+	for i := 0; i < 3; i++ {
+		result, err := l.Allow(ctx, "a", r)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d = %+v, %v", i, result, err)
+		}
+		if result.Remaining != 2-i || result.Limit != 3 {
+			t.Errorf("request %d remaining = %d, limit = %d", i, result.Remaining, result.Limit)
+		}
+	}
+
+	result, _ := l.Allow(ctx, "a", r)
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("4th request = %+v, want rejected", result)
+	}
+	// 每秒补充一个令牌
+	if result.RetryAfter <= 900*time.Millisecond || result.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %v, want about 1s", result.RetryAfter)
+	}
+	if result.ResetAfter <= 2*time.Second || result.ResetAfter > 3*time.Second {
+		t.Errorf("ResetAfter = %v, want about 3s", result.ResetAfter)
+	}
+
+	// 被拒绝的请求不消耗令牌，其他 key 单独计数
+	if again, _ := l.Allow(ctx, "a", r); again.RetryAfter > result.RetryAfter {
+		t.Errorf("rejected request consumed a token: RetryAfter %v > %v", again.RetryAfter, result.RetryAfter)
+	}
+	if result, _ := l.Allow(ctx, "b", r); !result.Allowed {
+		t.Error("another key was limited")
+	}
+
+	// 配置变化时重建令牌桶
+	if result, _ := l.Allow(ctx, "a", Rate{Limit: 10, Period: time.Second}); !result.Allowed || result.Remaining != 9 {
+		t.Errorf("request with a new rate = %+v", result)
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	m := NewMemoryLimiter().(*memoryLimiter)
+	r := Rate{Limit: 1, Period: time.Second}
+	m.Allow(context.Background(), "idle", r)
+
+	m.buckets["idle"].lastSeen = time.Now().Add(-2 * idleTimeout)
+	m.lastSweep = time.Now().Add(-2 * idleTimeout)
+	m.Allow(context.Background(), "active", r)
+
+	if _, ok := m.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := m.buckets["active"]; !ok {
+		t.Error("active bucket missing")
+	}
+}
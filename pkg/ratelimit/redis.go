@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+)
+
+// slidingWindowSrc 滑动窗口计数，有序集合中保存窗口内每次请求的时间
+// KEYS[1] 计数 key；ARGV[1] 当前毫秒时间，ARGV[2] 窗口毫秒数，ARGV[3] 上限，ARGV[4] 本次请求的唯一标识
+// 返回 {是否通过, 窗口内请求数, 最早一次请求的时间, 最近一次请求的时间}
+const slidingWindowSrc = `
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", KEYS[1], window)
+
+local first, last = now, now
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	first = tonumber(oldest[2])
+end
+local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+if newest[2] then
+	last = tonumber(newest[2])
+end
+
+return {allowed, count, first, last}
+`
+
+var slidingWindowScript = gredis.NewScript(1, slidingWindowSrc)
+
+// redisLimiter 基于 Redis 的滑动窗口，多个实例共享计数
+type redisLimiter struct {
+	seq int64
+}
+
+// NewRedisLimiter 创建 Redis 限流器，依赖 gredis.Setup
+func NewRedisLimiter() Limiter {
+	return &redisLimiter{}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, r Rate) (*Result, error) {
+	now := time.Now()
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	window := r.Period.Milliseconds()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), atomic.AddInt64(&l.seq, 1))
+
+	values, err := redis.Int64s(slidingWindowScript.Do(ctx, "RATELIMIT_"+key, nowMs, window, r.Limit, member))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("ratelimit: unexpected script reply %v", values)
+	}
+
+	allowed, count, first, last := values[0] == 1, int(values[1]), values[2], values[3]
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      r.Limit,
+		Remaining:  r.Limit - count,
+		ResetAfter: time.Duration(last+window-nowMs) * time.Millisecond,
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if !allowed {
+		// 窗口内最早的一次请求过期后才会空出配额
+		result.RetryAfter = time.Duration(first+window-nowMs) * time.Millisecond
+	}
+
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// newTestRedis 内存 Redis，滑动窗口脚本由 miniredis 执行
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	s := miniredis.RunT(t)
+
+	saved := *setting.RedisSetting
+	t.Cleanup(func() { *setting.RedisSetting = saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = s.Addr()
+	if err := gredis.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestRedisLimiter(t *testing.T) {
+	s := newTestRedis(t)
+	l := NewRedisLimiter()
+	ctx := context.Background()
+	r := Rate{Limit: 2, Period: 200 * time.Millisecond}
+
+	for i := 0; i < 2; i++ {
+		result, err := l.Allow(ctx, "ip:1", r)
+		if err != nil || !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("request %d = %+v, %v", i, result, err)
+		}
+	}
+
+	result, err := l.Allow(ctx, "ip:1", r)
+	if err != nil || result.Allowed || result.Remaining != 0 {
+		t.Fatalf("3rd request = %+v, %v, want rejected", result, err)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > r.Period {
+		t.Errorf("RetryAfter = %v", result.RetryAfter)
+	}
+	if members, _ := s.ZMembers("RATELIMIT_ip:1"); len(members) != 2 {
+		t.Errorf("window has %d requests, want 2: rejected requests are not counted", len(members))
+	}
+
+	// 窗口滑过最早的请求后恢复配额
+	time.Sleep(result.RetryAfter + 10*time.Millisecond)
+	if result, err := l.Allow(ctx, "ip:1", r); err != nil || !result.Allowed {
+		t.Errorf("request after the window = %+v, %v", result, err)
+	}
+	if result, err := l.Allow(ctx, "ip:2", r); err != nil || !result.Allowed || result.Remaining != 1 {
+		t.Errorf("another key = %+v, %v", result, err)
+	}
+}
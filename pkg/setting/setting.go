@@ -16,11 +16,14 @@ type App struct {
 
 	ImageSavePath  string
 	ImageMaxSize   int
+	ImageMaxWidth  int
+	ImageMaxHeight int
 	ImageAllowExts []string
 
-	ExportSavePath string
-	QrCodeSavePath string
-	FontSavePath   string
+	ExportSavePath  string
+	QrCodeSavePath  string
+	FontSavePath    string
+	SitemapSavePath string
 
 	LogSavePath string
 	LogSaveName string
@@ -51,15 +54,135 @@ type Database struct {
 var DatabaseSetting = &Database{}
 
 type Redis struct {
-	Host        string
-	Password    string
-	MaxIdle     int
-	MaxActive   int
-	IdleTimeout time.Duration
+	Mode         string
+	Host         string
+	Addrs        []string
+	MasterName   string
+	Password     string
+	DB           int
+	MaxIdle      int
+	MaxActive    int
+	IdleTimeout  time.Duration
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
 }
 
 var RedisSetting = &Redis{}
 
+type Cache struct {
+	TTL         time.Duration
+	Jitter      float64
+	NegativeTTL time.Duration
+	LocalSize   int
+	LocalTTL    time.Duration
+}
+
+var CacheSetting = &Cache{}
+
+type Idempotency struct {
+	TTL     time.Duration
+	LockTTL time.Duration
+	Routes  []string
+}
+
+var IdempotencySetting = &Idempotency{}
+
+type RateLimit struct {
+	Enabled   bool
+	Store     string
+	IP        string
+	User      string
+	Groups    []string
+	LoginIP   string
+	LoginUser string
+	Comment   string
+}
+
+var RateLimitSetting = &RateLimit{}
+
+type Views struct {
+	FlushInterval    time.Duration
+	TrendingHalfLife time.Duration
+	TrendingSize     int
+}
+
+var ViewsSetting = &Views{}
+
+type Comment struct {
+	Moderation     bool
+	BlocklistPath  string
+	NotifyExchange string
+}
+
+var CommentSetting = &Comment{}
+
+type Feed struct {
+	Title        string
+	Description  string
+	ArticleUrl   string
+	Size         int
+	SitemapSize  int
+	SitemapDelay time.Duration
+}
+
+var FeedSetting = &Feed{}
+
+type Storage struct {
+	Type            string
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKey       string
+	SecretKey       string
+	PathStyle       bool
+	PublicUrl       string
+	SignedUrlExpire time.Duration
+	Timeout         time.Duration
+}
+
+var StorageSetting = &Storage{}
+
+type Image struct {
+	SignKey         string
+	VariantSavePath string
+	Variants        []string
+	MaxWidth        int
+	MaxHeight       int
+	Concurrency     int
+}
+
+var ImageSetting = &Image{}
+
+type Attachment struct {
+	ChunkSize     int
+	MaxSize       int64
+	AllowExts     []string
+	ChunkSavePath string
+	SavePath      string
+	Expire        time.Duration
+	CleanInterval time.Duration
+}
+
+var AttachmentSetting = &Attachment{}
+
+type Poster struct {
+	BackgroundSavePath string
+	DefaultFont        string
+	DefaultTemplate    string
+}
+
+var PosterSetting = &Poster{}
+
+type Label struct {
+	Sheets       []string
+	DefaultSheet string
+	DPI          int
+	MaxLabels    int
+}
+
+var LabelSetting = &Label{}
+
 type RabbitMQ struct {
 	Host string
 }
@@ -72,6 +195,11 @@ type ElasticSearch struct {
 	Username string
 	Password string
 	Timeout  string
+	Refresh  string
+
+	MappingPath string
+	Analyzer    string
+	AutoMigrate bool
 }
 
 var ElasticSearchSetting = &ElasticSearch{}
@@ -90,6 +218,17 @@ func Setup() {
 	mapTo("server", ServerSetting)
 	mapTo("database", DatabaseSetting)
 	mapTo("redis", RedisSetting)
+	mapTo("cache", CacheSetting)
+	mapTo("idempotency", IdempotencySetting)
+	mapTo("ratelimit", RateLimitSetting)
+	mapTo("views", ViewsSetting)
+	mapTo("comment", CommentSetting)
+	mapTo("feed", FeedSetting)
+	mapTo("storage", StorageSetting)
+	mapTo("image", ImageSetting)
+	mapTo("attachment", AttachmentSetting)
+	mapTo("poster", PosterSetting)
+	mapTo("label", LabelSetting)
 	mapTo("rabbitmq", RabbitMQSetting)
 	mapTo("elasticsearch", ElasticSearchSetting)
 
@@ -97,6 +236,23 @@ func Setup() {
 	ServerSetting.ReadTimeout = ServerSetting.ReadTimeout * time.Second
 	ServerSetting.WriteTimeout = ServerSetting.WriteTimeout * time.Second
 	RedisSetting.IdleTimeout = RedisSetting.IdleTimeout * time.Second
+	RedisSetting.DialTimeout = RedisSetting.DialTimeout * time.Millisecond
+	RedisSetting.ReadTimeout = RedisSetting.ReadTimeout * time.Millisecond
+	RedisSetting.WriteTimeout = RedisSetting.WriteTimeout * time.Millisecond
+	CacheSetting.TTL = CacheSetting.TTL * time.Second
+	CacheSetting.NegativeTTL = CacheSetting.NegativeTTL * time.Second
+	CacheSetting.LocalTTL = CacheSetting.LocalTTL * time.Second
+	IdempotencySetting.TTL = IdempotencySetting.TTL * time.Second
+	IdempotencySetting.LockTTL = IdempotencySetting.LockTTL * time.Second
+	ViewsSetting.FlushInterval = ViewsSetting.FlushInterval * time.Second
+	ViewsSetting.TrendingHalfLife = ViewsSetting.TrendingHalfLife * time.Hour
+	FeedSetting.SitemapDelay = FeedSetting.SitemapDelay * time.Second
+	StorageSetting.SignedUrlExpire = StorageSetting.SignedUrlExpire * time.Second
+	StorageSetting.Timeout = StorageSetting.Timeout * time.Second
+	AttachmentSetting.ChunkSize = AttachmentSetting.ChunkSize * 1024 * 1024
+	AttachmentSetting.MaxSize = AttachmentSetting.MaxSize * 1024 * 1024
+	AttachmentSetting.Expire = AttachmentSetting.Expire * time.Hour
+	AttachmentSetting.CleanInterval = AttachmentSetting.CleanInterval * time.Minute
 }
 
 // mapTo map section
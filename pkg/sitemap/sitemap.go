@@ -0,0 +1,86 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// MaxURLs 协议规定单个 sitemap 文件最多包含的 URL 数
+const MaxURLs = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL sitemap 中的一个地址
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name  `xml:"urlset"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	URLs    []*xmlURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name  `xml:"sitemapindex"`
+	Xmlns    string    `xml:"xmlns,attr"`
+	Sitemaps []*xmlURL `xml:"sitemap"`
+}
+
+type xmlURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// GetSitemapPath get the relative save path of the sitemap files
+func GetSitemapPath() string {
+	return setting.AppSetting.SitemapSavePath
+}
+
+// GetSitemapFullPath get the full save path of the sitemap files
+func GetSitemapFullPath() string {
+	return setting.AppSetting.RuntimeRootPath + GetSitemapPath()
+}
+
+// WriteURLSet 写入 urlset 文档
+func WriteURLSet(w io.Writer, urls []*URL) error {
+	set := &urlSet{Xmlns: xmlns}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, toXML(u))
+	}
+
+	return write(w, set)
+}
+
+// WriteIndex 写入 sitemap 索引文档，urls 为各个 sitemap 文件的地址
+func WriteIndex(w io.Writer, urls []*URL) error {
+	index := &sitemapIndex{Xmlns: xmlns}
+	for _, u := range urls {
+		index.Sitemaps = append(index.Sitemaps, toXML(u))
+	}
+
+	return write(w, index)
+}
+
+func toXML(u *URL) *xmlURL {
+	x := &xmlURL{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		x.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+
+	return x
+}
+
+func write(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,51 @@
+package sitemap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteURLSet(t *testing.T) {
+	var buf bytes.Buffer
+	urls := []*URL{
+		{Loc: "https://example.com/articles/a&b", LastMod: time.Date(2026, 3, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))},
+		{Loc: "https://example.com/articles/2"},
+	}
+	if err := WriteURLSet(&buf, urls); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/articles/a&amp;b</loc>
+    <lastmod>2026-03-01T00:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/articles/2</loc>
+  </url>
+</urlset>`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteURLSet =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteIndex(t *testing.T) {
+	var buf bytes.Buffer
+	urls := []*URL{{Loc: "https://example.com/sitemap-1.xml", LastMod: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	if err := WriteIndex(&buf, urls); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://example.com/sitemap-1.xml</loc>
+    <lastmod>2026-03-01T00:00:00Z</lastmod>
+  </sitemap>
+</sitemapindex>`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteIndex =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package slug
+
+import (
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/pinyin"
+)
+
+// MaxLength slug 的最大长度
+const MaxLength = 100
+
+// 常见的带变音符号的拉丁字母转为不带变音符号的字母
+var (
+	accented   = []rune("àáâãäåāçćčèéêëēěìíîïīñńňòóôõöøōùúûüūýÿžźż")
+	unaccented = []rune("aaaaaaaccceeeeeeiiiiinnnooooooouuuuuyyzzz")
+)
+
+var folds = func() map[rune]string {
+	m := map[rune]string{'ß': "ss", 'æ': "ae", 'œ': "oe"}
+	for i, r := range accented {
+		m[r] = string(unaccented[i])
+	}
+	return m
+}()
+
+// Make 由标题生成 slug：汉字转为拼音，字母转为小写，只保留 ASCII 字母和数字，单词之间用 - 连接，
+// 超过 MaxLength 时在单词边界截断；没有可用字符时返回空串
+func Make(s string) string {
+	var b strings.Builder
+	for _, word := range pinyin.Convert(s) {
+		word = asciiOnly(strings.ToLower(word))
+		if word == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if b.Len()+1+len(word) > MaxLength {
+				break
+			}
+			b.WriteByte('-')
+		} else if len(word) > MaxLength {
+			word = word[:MaxLength]
+		}
+		b.WriteString(word)
+	}
+
+	return b.String()
+}
+
+// IsNumeric slug 全为数字时会与 ID 混淆，不能使用
+func IsNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func asciiOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case folds[r] != "":
+			b.WriteString(folds[r])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Go 语言入门  ", "go-yu-yan-ru-men"},
+		{"Gin 2.0 教程", "gin-2-0-jiao-cheng"},
+		{"Crème Brûlée für Straße", "creme-brulee-fur-strasse"},
+		{"!!!", ""},
+		{"", ""},
+		{"😀 emoji", "emoji"},
+		{"2024", "2024"},
+	}
+	for _, tt := range tests {
+		if got := Make(tt.s); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMakeMaxLength(t *testing.T) {
+	// 在单词边界截断
+	words := strings.Repeat("abcdefghi ", 20)
+	got := Make(words)
+	if len(got) > MaxLength || strings.HasSuffix(got, "-") || len(got) != 99 {
+		t.Errorf("Make(20 words) = %q (%d)", got, len(got))
+	}
+
+	// 第一个单词过长时直接截断
+	if got := Make(strings.Repeat("a", MaxLength+10) + " b"); got != strings.Repeat("a", MaxLength) {
+		t.Errorf("Make(long word) = %q (%d)", got, len(got))
+	}
+}
+
+func TestIsNumeric(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"123", true},
+		{"0", true},
+		{"", false},
+		{"12a", false},
+		{"1-2", false},
+		{"１２", false},
+	}
+	for _, tt := range tests {
+		if got := IsNumeric(tt.s); got != tt.want {
+			t.Errorf("IsNumeric(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local 本地文件存储，文件由 r.StaticFS 提供访问，只适用于单实例部署
+type Local struct {
+	root      string
+	prefixUrl string
+}
+
+// NewLocal root 为保存目录，prefixUrl 为访问地址前缀，key 对应 root 下的相对路径
+func NewLocal(root, prefixUrl string) *Local {
+	return &Local{root: root, prefixUrl: strings.TrimSuffix(prefixUrl, "/")}
+}
+
+// Put 先写临时文件再重命名，读取方不会看到写了一半的文件
+func (l *Local) Put(key string, r io.Reader, contentType string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+// Compose 依次读取 srcs 写入 key，内容以流的方式复制
+func (l *Local) Compose(key string, srcs []string, contentType string) error {
+	readers := make([]io.Reader, 0, len(srcs))
+	for _, src := range srcs {
+		rc, err := l.Get(src)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		readers = append(readers, rc)
+	}
+
+	return l.Put(key, io.MultiReader(readers...), contentType)
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (l *Local) Delete(key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) Exists(key string) (bool, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(name)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (l *Local) URL(key string) string {
+	return l.prefixUrl + "/" + strings.TrimPrefix(key, "/")
+}
+
+// SignedURL 本地文件都可以公开访问，与 URL 相同
+func (l *Local) SignedURL(key string, expires time.Duration) (string, error) {
+	return l.URL(key), nil
+}
+
+// path key 对应的文件路径，不允许通过 .. 访问 root 之外的文件
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
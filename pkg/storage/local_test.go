@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	root := t.TempDir()
+	l := NewLocal(root, "http://127.0.0.1:8000/")
+	key := "upload/images/a.jpg"
+
+	if err := l.Put(key, strings.NewReader("hello"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(root, "upload", "images", "a.jpg"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("file = %q, %v", data, err)
+	}
+	tmp, _ := filepath.Glob(filepath.Join(root, "upload", "images", ".*.tmp"))
+	if len(tmp) != 0 {
+		t.Errorf("temp files left: %v", tmp)
+	}
+
+	rc, err := l.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = ioutil.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("Get = %q", data)
+	}
+
+	if got, want := l.URL(key), "http://127.0.0.1:8000/upload/images/a.jpg"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	if ok, err := l.Exists(key); !ok || err != nil {
+		t.Errorf("Exists = %v, %v", ok, err)
+	}
+	if err := l.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := l.Exists(key); ok || err != nil {
+		t.Errorf("Exists after Delete = %v, %v", ok, err)
+	}
+	if _, err := l.Get(key); err != ErrNotExist {
+		t.Errorf("Get after Delete = %v, want ErrNotExist", err)
+	}
+	if err := l.Delete(key); err != nil {
+		t.Errorf("Delete missing = %v", err)
+	}
+}
+
+func TestLocalCompose(t *testing.T) {
+	l := NewLocal(t.TempDir(), "")
+	for key, data := range map[string]string{"chunks/0": "hello, ", "chunks/1": "world"} {
+		if err := l.Put(key, strings.NewReader(data), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := l.Compose("files/a.txt", []string{"chunks/0", "chunks/1"}, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := l.Get("files/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello, world" {
+		t.Errorf("composed %q", data)
+	}
+
+	if err := l.Compose("files/b.txt", []string{"chunks/0", "chunks/9"}, ""); err != ErrNotExist {
+		t.Errorf("Compose missing source = %v, want ErrNotExist", err)
+	}
+	if ok, _ := l.Exists("files/b.txt"); ok {
+		t.Error("Compose with missing source created the file")
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	root := t.TempDir()
+	l := NewLocal(root, "")
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a/b.txt", filepath.Join(root, "a", "b.txt")},
+		{"/a/b.txt", filepath.Join(root, "a", "b.txt")},
+		{"../../etc/passwd", filepath.Join(root, "etc", "passwd")},
+		{"a/../../b.txt", filepath.Join(root, "b.txt")},
+		{"", ""},
+		{"a/", ""},
+	}
+	for _, tt := range tests {
+		got, err := l.path(tt.key)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("path(%q) = %q, want error", tt.key, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("path(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// completedPart CompleteMultipartUpload 请求中的一个分片
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// s3Result 分片上传相关的响应体，CompleteMultipartUpload 和 UploadPartCopy 即使返回 200，响应体也可能是 <Error>
+type s3Result struct {
+	XMLName  xml.Name
+	UploadId string
+	ETag     string
+	Code     string
+	Message  string
+}
+
+// putMultipart first 为已读取的第一个分片，后续分片复用它的缓冲区，失败时取消分片上传
+func (s *S3) putMultipart(key string, first *bytes.Buffer, r io.Reader, contentType string) error {
+	uploadId, err := s.createMultipart(key, contentType)
+	if err != nil {
+		return err
+	}
+
+	var parts []completedPart
+	part := first
+	for part.Len() > 0 {
+		etag, err := s.uploadPart(key, uploadId, len(parts)+1, part.Bytes())
+		if err != nil {
+			s.abortMultipart(key, uploadId)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: len(parts) + 1, ETag: etag})
+
+		part.Reset()
+		if _, err := io.CopyN(part, r, partSize); err != nil && err != io.EOF {
+			s.abortMultipart(key, uploadId)
+			return err
+		}
+	}
+
+	if err := s.completeMultipart(key, uploadId, parts); err != nil {
+		s.abortMultipart(key, uploadId)
+		return err
+	}
+
+	return nil
+}
+
+// Compose 用 UploadPartCopy 把每个 src 复制为一个分片，内容不经过本服务
+func (s *S3) Compose(key string, srcs []string, contentType string) error {
+	if len(srcs) == 0 {
+		return s.putObject(key, nil, contentType)
+	}
+
+	uploadId, err := s.createMultipart(key, contentType)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]completedPart, 0, len(srcs))
+	for i, src := range srcs {
+		etag, err := s.uploadPartCopy(key, uploadId, i+1, src)
+		if err != nil {
+			s.abortMultipart(key, uploadId)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: i + 1, ETag: etag})
+	}
+
+	if err := s.completeMultipart(key, uploadId, parts); err != nil {
+		s.abortMultipart(key, uploadId)
+		return err
+	}
+
+	return nil
+}
+
+func (s *S3) createMultipart(key, contentType string) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	result, err := s.multipartRequest(http.MethodPost, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return "", err
+	}
+	if result.UploadId == "" {
+		return "", fmt.Errorf("storage: s3 %s: missing upload id", key)
+	}
+
+	return result.UploadId, nil
+}
+
+// uploadPart 上传一个分片，返回分片的 ETag
+func (s *S3) uploadPart(key, uploadId string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadId}}
+	resp, err := s.send(http.MethodPut, key, query, nil, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, key); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// uploadPartCopy 把同一 bucket 中的 src 复制为一个分片，返回分片的 ETag
+func (s *S3) uploadPartCopy(key, uploadId string, number int, src string) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadId}}
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", "/"+s.opts.Bucket+"/"+escapePath(strings.TrimPrefix(src, "/")))
+
+	result, err := s.multipartRequest(http.MethodPut, key, query, header, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.ETag, nil
+}
+
+func (s *S3) completeMultipart(key, uploadId string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.multipartRequest(http.MethodPost, key, url.Values{"uploadId": {uploadId}}, nil, body)
+	return err
+}
+
+// abortMultipart 取消分片上传，释放已上传的分片，失败时由 bucket 的生命周期规则清理
+func (s *S3) abortMultipart(key, uploadId string) {
+	resp, err := s.send(http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// multipartRequest 发送请求并解析 XML 响应体，响应体为 <Error> 时返回错误
+func (s *S3) multipartRequest(method, key string, query url.Values, header http.Header, body []byte) (*s3Result, error) {
+	resp, err := s.send(method, key, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, key); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result s3Result
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("storage: s3 %s %s: %v", method, key, err)
+	}
+	if result.XMLName.Local == "Error" {
+		return nil, fmt.Errorf("storage: s3 %s %s: %s %s", method, key, result.Code, result.Message)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Options S3 兼容存储（AWS S3、MinIO 等）的配置
+type S3Options struct {
+	Endpoint  string // 如 https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool          // 使用 endpoint/bucket/key 形式的地址，MinIO 需要开启
+	PublicUrl string        // 对象的公开访问地址前缀，如 CDN，为空时使用 bucket 地址
+	Timeout   time.Duration // 请求超时，为 0 时不超时
+}
+
+// S3 S3 兼容存储，请求使用 AWS Signature Version 4 签名
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+}
+
+const (
+	// maxPresignExpires SigV4 预签名地址的最长有效期
+	maxPresignExpires = 7 * 24 * time.Hour
+	// partSize 分片上传的分片大小，S3 要求除最后一片外不小于 5 MiB，Timeout 对每个分片单独计算
+	partSize = 8 << 20
+)
+
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Bucket == "" || opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("storage: s3 requires bucket, access key and secret key")
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q: %v", opts.Endpoint, err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	opts.PublicUrl = strings.TrimSuffix(opts.PublicUrl, "/")
+
+	return &S3{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+// Put 内容按 partSize 读取，不足一个分片时直接上传，否则使用分片上传，内存中最多保留一个分片
+func (s *S3) Put(key string, r io.Reader, contentType string) error {
+	var part bytes.Buffer
+	_, err := io.CopyN(&part, r, partSize)
+	if err == io.EOF {
+		return s.putObject(key, part.Bytes(), contentType)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.putMultipart(key, &part, r, contentType)
+}
+
+func (s *S3) putObject(key string, body []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.send(http.MethodPut, key, nil, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, key)
+}
+
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, key)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotExist
+	}
+	if err := checkResponse(resp, key); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse(resp, key)
+}
+
+func (s *S3) Exists(key string) (bool, error) {
+	resp, err := s.do(http.MethodHead, key)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err := checkResponse(resp, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3) URL(key string) string {
+	if s.opts.PublicUrl != "" {
+		return s.opts.PublicUrl + "/" + escapePath(strings.TrimPrefix(key, "/"))
+	}
+
+	return s.objectURL(key).String()
+}
+
+// SignedURL 生成 SigV4 预签名的 GET 地址，有效期最长 7 天
+func (s *S3) SignedURL(key string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > maxPresignExpires {
+		return "", fmt.Errorf("storage: signed url expires must be between 1s and %v", maxPresignExpires)
+	}
+
+	u := s.objectURL(key)
+	s.presign(u, http.MethodGet, expires, time.Now())
+	return u.String(), nil
+}
+
+// do 发送没有请求体的请求
+func (s *S3) do(method, key string) (*http.Response, error) {
+	return s.send(method, key, nil, nil, nil)
+}
+
+// send 发送请求，body 在内存中，按内容的 SHA256 签名
+func (s *S3) send(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := s.objectURL(key)
+	u.RawQuery = query.Encode()
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	sum := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(sum[:]), time.Now())
+
+	return s.client.Do(req)
+}
+
+// objectURL 对象的地址，PathStyle 时为 endpoint/bucket/key，否则为 bucket.endpoint/key
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	p := "/" + strings.TrimPrefix(key, "/")
+	if s.opts.PathStyle {
+		p = "/" + s.opts.Bucket + p
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + p
+	u.RawPath = escapePath(u.Path)
+
+	return &u
+}
+
+// checkResponse 非 2xx 的响应转换为错误，带上 S3 返回的错误信息
+func checkResponse(resp *http.Response, key string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: s3 %s %s: %s %s", resp.Request.Method, key, resp.Status, bytes.TrimSpace(msg))
+}
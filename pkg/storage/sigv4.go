@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigAlgorithm     = "AWS4-HMAC-SHA256"
+	sigService       = "s3"
+	sigTimeFormat    = "20060102T150405Z"
+	sigDateFormat    = "20060102"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// sign 按 SigV4 在请求头中签名，签名 host 和所有 x-amz-* 请求头，S3 要求 x-amz-* 都参与签名
+func (s *S3) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("X-Amz-Date", t.Format(sigTimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.Join(values, ",")
+		}
+	}
+	signedHeaders, canonicalHeaders := canonicalHeaders(headers)
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(t)
+	req.Header.Set("Authorization", sigAlgorithm+
+		" Credential="+s.opts.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+s.signature(t, scope, canonical))
+}
+
+// presign 按 SigV4 在查询参数中签名，只签名 host
+func (s *S3) presign(u *url.URL, method string, expires time.Duration, t time.Time) {
+	t = t.UTC()
+	scope := s.scope(t)
+
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", sigAlgorithm)
+	q.Set("X-Amz-Credential", s.opts.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", t.Format(sigTimeFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(map[string]string{"host": u.Host})
+	query := canonicalQuery(q)
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		query,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	u.RawQuery = query + "&X-Amz-Signature=" + s.signature(t, scope, canonical)
+}
+
+// scope 凭证范围 日期/区域/s3/aws4_request
+func (s *S3) scope(t time.Time) string {
+	return t.Format(sigDateFormat) + "/" + s.opts.Region + "/" + sigService + "/aws4_request"
+}
+
+func (s *S3) signature(t time.Time, scope, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	stringToSign := sigAlgorithm + "\n" + t.Format(sigTimeFormat) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), t.Format(sigDateFormat))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, sigService)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalHeaders 返回按名称排序的 SignedHeaders 和 CanonicalHeaders，名称需为小写
+func canonicalHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery 按参数名排序并用 SigV4 的规则编码
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k, true)+"="+escape(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// escapePath 按 SigV4 的规则编码路径，保留 /
+func escapePath(p string) string {
+	return escape(p, false)
+}
+
+// escape 除 A-Z a-z 0-9 - _ . ~ 外的字节都编码为 %XX，encodeSlash 为 false 时保留 /
+func escape(s string, encodeSlash bool) string {
+	const hexUpper = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexUpper[c>>4])
+			b.WriteByte(hexUpper[c&15])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 存储类型
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+// MinPartSize S3 分片上传除最后一片外的最小大小
+const MinPartSize = 5 << 20
+
+// ErrNotExist 对象不存在
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Storage 对象存储，key 为以 / 分隔的相对路径，如 upload/images/xxx.jpg
+type Storage interface {
+	// Put 写入对象，已存在时覆盖
+	Put(key string, r io.Reader, contentType string) error
+	// Compose 按顺序拼接 srcs 写入 key，S3 在服务端完成，srcs 除最后一个外都不能小于 MinPartSize
+	Compose(key string, srcs []string, contentType string) error
+	// Get 读取对象，不存在时返回 ErrNotExist
+	Get(key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时不报错
+	Delete(key string) error
+	// Exists 对象是否存在
+	Exists(key string) (bool, error)
+	// URL 对象的公开访问地址
+	URL(key string) string
+	// SignedURL 在 expires 内有效的临时访问地址
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// Default 按 [storage] 配置创建的存储
+var Default Storage
+
+// Setup 按 [storage] Type 初始化 Default
+func Setup() error {
+	s := setting.StorageSetting
+	switch s.Type {
+	case "", TypeLocal:
+		Default = NewLocal(setting.AppSetting.RuntimeRootPath, setting.AppSetting.PrefixUrl)
+	case TypeS3:
+		store, err := NewS3(S3Options{
+			Endpoint:  s.Endpoint,
+			Region:    s.Region,
+			Bucket:    s.Bucket,
+			AccessKey: s.AccessKey,
+			SecretKey: s.SecretKey,
+			PathStyle: s.PathStyle,
+			PublicUrl: s.PublicUrl,
+			Timeout:   s.Timeout,
+		})
+		if err != nil {
+			return err
+		}
+		Default = store
+	default:
+		return fmt.Errorf("storage: unknown type %q", s.Type)
+	}
+
+	return nil
+}
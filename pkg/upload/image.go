@@ -1,31 +1,61 @@
 package upload
 
 import (
-	"fmt"
-	"log"
-	"mime/multipart"
-	"os"
-	"path"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"strings"
 
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
-	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
-	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 )
 
+var (
+	// ErrImageTooLarge 文件大小超过 ImageMaxSize
+	ErrImageTooLarge = errors.New("upload: image file is too large")
+	// ErrImageFormat 文件内容不是 ImageAllowExts 允许的图片格式
+	ErrImageFormat = errors.New("upload: unsupported image format")
+	// ErrImageCorrupt 文件头是图片但无法解码
+	ErrImageCorrupt = errors.New("upload: image cannot be decoded")
+	// ErrImageDimensions 宽或高超过 ImageMaxWidth / ImageMaxHeight
+	ErrImageDimensions = errors.New("upload: image dimensions exceed the limit")
+)
+
+// imageTypes 按文件内容识别出的 MIME 类型对应的扩展名及 image 包中的格式名
+var imageTypes = map[string]struct {
+	ext    string
+	format string
+}{
+	"image/jpeg": {".jpg", "jpeg"},
+	"image/png":  {".png", "png"},
+	"image/gif":  {".gif", "gif"},
+}
+
+// Image 校验并去除元数据后的图片
+type Image struct {
+	Name        string // 内容的 SHA256 加上扩展名，相同内容的图片名称相同
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
 // GetImageFullUrl get the full access path
 func GetImageFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetImagePath() + name
+	return storage.Default.URL(GetImageKey(name))
 }
 
-// GetImageName get image name
-func GetImageName(name string) string {
-	ext := path.Ext(name)
-	fileName := strings.TrimSuffix(name, ext)
-	fileName = util.EncodeMD5(fileName)
-
-	return fileName + ext
+// GetImageKey get the storage key of the image
+func GetImageKey(name string) string {
+	return GetImagePath() + name
 }
 
 // GetImagePath get save path
@@ -38,46 +68,73 @@ func GetImageFullPath() string {
 	return setting.AppSetting.RuntimeRootPath + GetImagePath()
 }
 
-// CheckImageExt check image file ext
-func CheckImageExt(fileName string) bool {
-	ext := file.GetExt(fileName)
-	for _, allowExt := range setting.AppSetting.ImageAllowExts {
-		if strings.ToUpper(allowExt) == strings.ToUpper(ext) {
-			return true
-		}
+// ReadImage 读取并校验上传的图片：最多读取 ImageMaxSize 字节，按文件内容识别格式，
+// 解码确认是完整的图片并检查像素尺寸，最后去除 EXIF（包括 GPS 位置）等元数据
+func ReadImage(r io.Reader) (*Image, error) {
+	maxSize := setting.AppSetting.ImageMaxSize
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, ErrImageTooLarge
 	}
 
-	return false
-}
+	contentType := http.DetectContentType(data)
+	t, ok := imageTypes[contentType]
+	if !ok || !allowExt(t.ext) {
+		return nil, ErrImageFormat
+	}
 
-// CheckImageSize check image size
-func CheckImageSize(f multipart.File) bool {
-	size, err := file.GetSize(f)
+	// 先只读取尺寸，避免解码尺寸过大的图片占用大量内存
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != t.format {
+		return nil, ErrImageCorrupt
+	}
+	if config.Width > setting.AppSetting.ImageMaxWidth || config.Height > setting.AppSetting.ImageMaxHeight {
+		return nil, ErrImageDimensions
+	}
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return nil, ErrImageCorrupt
+	}
+
+	data, err = stripMetadata(contentType, data)
 	if err != nil {
-		log.Println(err)
-		logging.Warn(err)
-		return false
+		return nil, ErrImageCorrupt
 	}
 
-	return size <= setting.AppSetting.ImageMaxSize
+	sum := sha256.Sum256(data)
+	return &Image{
+		Name:        hex.EncodeToString(sum[:]) + t.ext,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		Data:        data,
+	}, nil
 }
 
-// CheckImage check if the file exists
-func CheckImage(src string) error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("os.Getwd err: %v", err)
+// SaveImage 保存图片，相同内容的图片已存在时不再重复保存
+func SaveImage(img *Image) error {
+	key := GetImageKey(img.Name)
+	exists, err := storage.Default.Exists(key)
+	if err != nil || exists {
+		return err
 	}
 
-	err = file.IsNotExistMkDir(dir + "/" + src)
-	if err != nil {
-		return fmt.Errorf("file.IsNotExistMkDir err: %v", err)
-	}
+	return storage.Default.Put(key, bytes.NewReader(img.Data), img.ContentType)
+}
 
-	perm := file.CheckPermission(src)
-	if perm == true {
-		return fmt.Errorf("file.CheckPermission Permission denied src: %s", src)
+// allowExt 扩展名是否在 ImageAllowExts 中，.jpg 与 .jpeg 视为相同
+func allowExt(ext string) bool {
+	for _, allowExt := range setting.AppSetting.ImageAllowExts {
+		allowExt = strings.ToLower(allowExt)
+		if allowExt == ".jpeg" {
+			allowExt = ".jpg"
+		}
+		if allowExt == ext {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
@@ -0,0 +1,115 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image/gif"
+	"strings"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+func setupImage(t *testing.T) {
+	saved := *setting.AppSetting
+	t.Cleanup(func() { *setting.AppSetting = saved })
+	setting.AppSetting.ImageMaxSize = 1 << 20
+	setting.AppSetting.ImageMaxWidth = 100
+	setting.AppSetting.ImageMaxHeight = 100
+	setting.AppSetting.ImageAllowExts = []string{".JPEG", ".png"}
+	setting.AppSetting.ImageSavePath = "upload/images/"
+}
+
+func TestReadImage(t *testing.T) {
+	setupImage(t)
+	data := encodePNG(t)
+
+	img, err := ReadImage(bytes.NewReader(withPNGMetadata(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if img.Name != hex.EncodeToString(sum[:])+".png" || img.ContentType != "image/png" || img.Width != 4 || img.Height != 3 {
+		t.Errorf("ReadImage = %s %s %dx%d", img.Name, img.ContentType, img.Width, img.Height)
+	}
+	if !bytes.Equal(img.Data, data) {
+		t.Error("metadata was not stripped")
+	}
+
+	// 元数据不同的同一张图片名称相同
+	jpg := encodeJPEG(t)
+	a, err := ReadImage(bytes.NewReader(jpg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReadImage(bytes.NewReader(withJPEGMetadata(jpg)))
+	if err != nil || a.Name != b.Name || !strings.HasSuffix(a.Name, ".jpg") {
+		t.Errorf("names = %s, %s, %v", a.Name, b.Name, err)
+	}
+}
+
+func TestReadImageErrors(t *testing.T) {
+	setupImage(t)
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, newImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	pngData := encodePNG(t)
+
+	tests := []struct {
+		name string
+		data []byte
+		max  int
+		want error
+	}{
+		{"too large", pngData, len(pngData) - 1, ErrImageTooLarge},
+		{"not allowed", gifData.Bytes(), 0, ErrImageFormat},
+		{"not an image", []byte("<html><script>alert(1)</script></html>"), 0, ErrImageFormat},
+		{"truncated", pngData[:len(pngData)-20], 0, ErrImageCorrupt},
+	}
+	for _, tt := range tests {
+		setting.AppSetting.ImageMaxSize = 1 << 20
+		if tt.max > 0 {
+			setting.AppSetting.ImageMaxSize = tt.max
+		}
+		if _, err := ReadImage(bytes.NewReader(tt.data)); err != tt.want {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	setting.AppSetting.ImageMaxSize = 1 << 20
+	setting.AppSetting.ImageMaxWidth = 3
+	if _, err := ReadImage(bytes.NewReader(pngData)); err != ErrImageDimensions {
+		t.Errorf("too wide: err = %v, want ErrImageDimensions", err)
+	}
+}
+
+func TestSaveImage(t *testing.T) {
+	setupImage(t)
+	saved := storage.Default
+	t.Cleanup(func() { storage.Default = saved })
+	storage.Default = storage.NewLocal(t.TempDir(), "")
+
+	img := &Image{Name: "a.png", ContentType: "image/png", Data: []byte("first")}
+	if err := SaveImage(img); err != nil {
+		t.Fatal(err)
+	}
+	// 相同名称的图片内容相同，不再重复写入
+	img.Data = []byte("second")
+	if err := SaveImage(img); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := storage.Default.Get("upload/images/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(rc)
+	if buf.String() != "first" {
+		t.Errorf("saved %q, want first", buf.String())
+	}
+}
@@ -0,0 +1,119 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformed = errors.New("upload: malformed image")
+
+// stripMetadata 去除图片中的元数据，只删除数据段，不重新编码图片
+func stripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	}
+
+	return data, nil
+}
+
+// JPEG 中需要去除的段：APP1 为 EXIF / XMP，APP13 为 Photoshop / IPTC，COM 为注释
+// APP0 (JFIF)、APP2 (ICC 色彩配置) 和 APP14 (Adobe) 会影响显示效果，保留
+// EXIF 中的方向信息也会被去除
+const (
+	jpegAPP1  = 0xE1
+	jpegAPP13 = 0xED
+	jpegCOM   = 0xFE
+	jpegSOS   = 0xDA
+	jpegEOI   = 0xD9
+)
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	for i := 2; ; {
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, errMalformed
+		}
+		marker := data[i+1]
+		// 段之间可以有 0xFF 填充
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// 没有长度的标记
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		if marker == jpegEOI {
+			out.Write(data[i : i+2])
+			return out.Bytes(), nil
+		}
+
+		if i+4 > len(data) {
+			return nil, errMalformed
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return nil, errMalformed
+		}
+		// SOS 之后是压缩数据，原样保留到结尾
+		if marker == jpegSOS {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		if marker != jpegAPP1 && marker != jpegAPP13 && marker != jpegCOM {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+}
+
+// PNG 中需要去除的块：文本、EXIF 和修改时间
+var pngStripChunks = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, errMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.WriteString(pngSignature)
+	for i := len(pngSignature); i < len(data); {
+		// 长度、类型、数据、CRC
+		if i+12 > len(data) {
+			return nil, errMalformed
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i+12 {
+			return nil, errMalformed
+		}
+		typ := string(data[i+4 : i+8])
+		if !pngStripChunks[typ] {
+			out.Write(data[i:end])
+		}
+		i = end
+		if typ == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
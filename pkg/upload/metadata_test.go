@@ -0,0 +1,163 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func newImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 1, color.RGBA{R: 255, A: 255})
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, newImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newImage()); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// jpegSegment 带长度的 JPEG 段
+func jpegSegment(marker byte, payload string) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withJPEGMetadata 在 SOI 之后插入 EXIF、IPTC 和注释段
+func withJPEGMetadata(data []byte) []byte {
+	out := append([]byte(nil), data[:2]...)
+	out = append(out, jpegSegment(jpegAPP1, "Exif\x00\x00GPS 31.2N 121.5E")...)
+	out = append(out, 0xFF) // 填充
+	out = append(out, jpegSegment(jpegAPP13, "Photoshop 3.0\x00")...)
+	out = append(out, jpegSegment(jpegCOM, "secret comment")...)
+	return append(out, data[2:]...)
+}
+
+func pngChunk(typ, payload string) []byte {
+	chunk := make([]byte, 8, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	copy(chunk[4:], typ)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// withPNGMetadata 在 IEND 之前插入文本、EXIF 和时间块，IEND 之后追加多余的数据
+func withPNGMetadata(data []byte) []byte {
+	iend := len(data) - 12
+	out := append([]byte(nil), data[:iend]...)
+	out = append(out, pngChunk("tEXt", "Author\x00admin")...)
+	out = append(out, pngChunk("eXIf", "MM\x00*GPS")...)
+	out = append(out, pngChunk("tIME", "\x07\xea\x03\x01\x08\x00\x00")...)
+	out = append(out, data[iend:]...)
+	return append(out, "trailing"...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	// 在原图 SOI 之后加上 ICC 色彩配置
+	icc := jpegSegment(0xE2, "ICC_PROFILE\x00")
+	data := encodeJPEG(t)
+	data = append(append(append([]byte(nil), data[:2]...), icc...), data[2:]...)
+
+	got, err := stripJPEG(withJPEGMetadata(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"Exif", "Photoshop", "secret comment"} {
+		if bytes.Contains(got, []byte(s)) {
+			t.Errorf("%q was not removed", s)
+		}
+	}
+	// 保留 ICC 色彩配置，其余内容不变
+	if !bytes.Equal(got, data) {
+		t.Errorf("stripJPEG changed the image data")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("decode stripped JPEG: %v", err)
+	}
+
+	// 没有元数据时原样返回
+	if got, err := stripJPEG(data); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("stripJPEG without metadata = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	// 在原图 IEND 之前加上 sRGB 块
+	data := encodePNG(t)
+	iend := len(data) - 12
+	data = append(append(append([]byte(nil), data[:iend]...), pngChunk("sRGB", "\x00")...), data[iend:]...)
+
+	got, err := stripPNG(withPNGMetadata(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"tEXt", "eXIf", "tIME", "trailing"} {
+		if bytes.Contains(got, []byte(s)) {
+			t.Errorf("%q was not removed", s)
+		}
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("stripPNG changed the image data")
+	}
+	if _, err := png.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("decode stripped PNG: %v", err)
+	}
+
+	if got, err := stripPNG(data); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("stripPNG without metadata = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	jpg, pngData := encodeJPEG(t), encodePNG(t)
+
+	tests := []struct {
+		name string
+		fn   func([]byte) ([]byte, error)
+		data []byte
+	}{
+		{"jpeg empty", stripJPEG, nil},
+		{"jpeg signature", stripJPEG, pngData},
+		{"jpeg truncated header", stripJPEG, jpg[:3]},
+		{"jpeg segment past end", stripJPEG, append([]byte{0xFF, 0xD8}, 0xFF, jpegAPP1, 0xFF, 0xFF, 0)},
+		{"jpeg short segment", stripJPEG, append([]byte{0xFF, 0xD8}, 0xFF, jpegAPP1, 0, 1)},
+		{"jpeg no marker", stripJPEG, []byte{0xFF, 0xD8, 0x00, 0x00}},
+		{"png signature", stripPNG, jpg},
+		{"png truncated chunk", stripPNG, pngData[:len(pngSignature)+10]},
+		{"png chunk past end", stripPNG, pngData[:len(pngData)-1]},
+	}
+	for _, tt := range tests {
+		if _, err := tt.fn(tt.data); err != errMalformed {
+			t.Errorf("%s: err = %v, want errMalformed", tt.name, err)
+		}
+	}
+
+	// 其他格式不处理
+	if got, err := stripMetadata("image/gif", []byte("GIF89a")); err != nil || string(got) != "GIF89a" {
+		t.Errorf("stripMetadata(gif) = %q, %v", got, err)
+	}
+}
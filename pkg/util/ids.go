@@ -0,0 +1,30 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseIDs 解析逗号分隔的 ID 列表，去掉重复并保持顺序，空串返回空列表
+func ParseIDs(s string) ([]int, error) {
+	var ids []int
+	seen := make(map[int]bool)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid id %q", v)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseIDs(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []int
+		err  bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		{"1", []int{1}, false},
+		{"3, 1,3,2,", []int{3, 1, 2}, false},
+		{"1,a", nil, true},
+		{"0", nil, true},
+		{"-1", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIDs(tt.s)
+		if (err != nil) != tt.err || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseIDs(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -9,17 +9,19 @@ import (
 var jwtSecret []byte
 
 type Claims struct {
+	UserID   int    `json:"uid"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 	jwt.StandardClaims
 }
 
 // GenerateToken generate tokens used for auth
-func GenerateToken(username, password string) (string, error) {
+func GenerateToken(userID int, username, password string) (string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(3 * time.Hour)
 
 	claims := Claims{
+		userID,
 		EncodeMD5(username),
 		EncodeMD5(password),
 		jwt.StandardClaims{
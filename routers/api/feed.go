@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
+)
+
+// @Summary Get the RSS 2.0 feed of the latest published articles
+// @Produce  xml
+// @Param id path string false "TagID or slug, only for /tags/{id}/feed.rss"
+// @Success 200 {string} string
+// @Success 304 {string} string "Not Modified"
+// @Router /feed.rss [get]
+func GetRSSFeed(c *gin.Context) {
+	serveFeed(c, article_service.FeedRSS, "application/rss+xml; charset=utf-8")
+}
+
+// @Summary Get the Atom 1.0 feed of the latest published articles
+// @Produce  xml
+// @Param id path string false "TagID or slug, only for /tags/{id}/feed.atom"
+// @Success 200 {string} string
+// @Success 304 {string} string "Not Modified"
+// @Router /feed.atom [get]
+func GetAtomFeed(c *gin.Context) {
+	serveFeed(c, article_service.FeedAtom, "application/atom+xml; charset=utf-8")
+}
+
+// serveFeed 输出订阅源，支持 If-None-Match / If-Modified-Since 条件请求
+func serveFeed(c *gin.Context, format, contentType string) {
+	appG := app.Gin{C: c}
+	tagId := 0
+	switch arg := c.Param("id"); {
+	case arg == "":
+	case slug.IsNumeric(arg):
+		tagId = com.StrTo(arg).MustInt()
+		valid := validation.Validation{}
+		valid.Min(tagId, 1, "id").Message("ID必须大于0")
+		if valid.HasErrors() {
+			app.MarkErrors(valid.Errors)
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return
+		}
+	default:
+		id, err := tag_service.GetIDBySlug(arg)
+		if err != nil {
+			appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+			return
+		}
+		if id == 0 {
+			appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+			return
+		}
+		tagId = id
+	}
+
+	doc, err := article_service.Feed(format, tagId)
+	if err == pkgcache.ErrNotFound {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+		return
+	}
+	if err != nil {
+		logging.Warn("api.serveFeed err:", err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_FEED_FAIL, nil)
+		return
+	}
+
+	c.Header("ETag", doc.ETag)
+	c.Header("Last-Modified", doc.LastModified.UTC().Format(http.TimeFormat))
+	if notModified(c.Request, doc.ETag, doc.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, doc.Body)
+}
+
+// notModified If-None-Match 优先于 If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, v := range strings.Split(match, ",") {
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == etag || v == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
+
+// @Summary Get the sitemap, a sitemap index when the articles do not fit in one file
+// @Produce  xml
+// @Success 200 {string} string
+// @Router /sitemap.xml [get]
+func GetSitemap(c *gin.Context) {
+	path := filepath.Join(sitemap.GetSitemapFullPath(), article_service.SitemapName)
+	if file.CheckNotExist(path) {
+		if err := article_service.GenerateSitemap(); err != nil {
+			logging.Warn("api.GetSitemap err:", err)
+			appG := app.Gin{C: c}
+			appG.Response(http.StatusInternalServerError, e.ERROR_GET_SITEMAP_FAIL, nil)
+			return
+		}
+	}
+
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	c.File(path)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2026, 3, 1, 8, 0, 0, 500, time.UTC)
+	etag := `"abc"`
+
+	tests := []struct {
+		noneMatch, modifiedSince string
+		want                     bool
+	}{
+		{"", "", false},
+		{`"abc"`, "", true},
+		{`W/"abc"`, "", true},
+		{`"x", "abc"`, "", true},
+		{"*", "", true},
+		// If-None-Match 优先，不再比较时间
+		{`"x"`, "Sun, 01 Mar 2026 09:00:00 GMT", false},
+		{"", "Sun, 01 Mar 2026 08:00:00 GMT", true},
+		{"", "Sun, 01 Mar 2026 07:59:59 GMT", false},
+		{"", "invalid", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		if tt.noneMatch != "" {
+			r.Header.Set("If-None-Match", tt.noneMatch)
+		}
+		if tt.modifiedSince != "" {
+			r.Header.Set("If-Modified-Since", tt.modifiedSince)
+		}
+		if got := notModified(r, etag, lastModified); got != tt.want {
+			t.Errorf("notModified(%q, %q) = %v, want %v", tt.noneMatch, tt.modifiedSince, got, tt.want)
+		}
+	}
+}
@@ -2,13 +2,18 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
 	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/imageproc"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/image_service"
 )
 
 // @Summary Import Image
@@ -30,32 +35,113 @@ func UploadImage(c *gin.Context) {
 		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
 		return
 	}
+	defer file.Close()
 
-	imageName := upload.GetImageName(image.Filename)
-	fullPath := upload.GetImageFullPath()
-	savePath := upload.GetImagePath()
-	src := fullPath + imageName
-
-	if !upload.CheckImageExt(imageName) || !upload.CheckImageSize(file) {
-		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHECK_IMAGE_FORMAT, nil)
+	if image.Size > int64(setting.AppSetting.ImageMaxSize) {
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_IMAGE_TOO_LARGE, nil)
 		return
 	}
 
-	err = upload.CheckImage(fullPath)
-	if err != nil {
+	img, err := upload.ReadImage(file)
+	switch err {
+	case nil:
+	case upload.ErrImageTooLarge:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_IMAGE_TOO_LARGE, nil)
+		return
+	case upload.ErrImageDimensions:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_IMAGE_DIMENSIONS, nil)
+		return
+	case upload.ErrImageFormat, upload.ErrImageCorrupt:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHECK_IMAGE_FORMAT, nil)
+		return
+	default:
 		logging.Warn(err)
 		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_CHECK_IMAGE_FAIL, nil)
 		return
 	}
 
-	if err := c.SaveUploadedFile(image, src); err != nil {
+	if err := upload.SaveImage(img); err != nil {
 		logging.Warn(err)
 		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_SAVE_IMAGE_FAIL, nil)
 		return
 	}
 
-	appG.Response(http.StatusOK, e.SUCCESS, map[string]string{
-		"image_url":      upload.GetImageFullUrl(imageName),
-		"image_save_url": savePath + imageName,
+	// 预生成失败时不影响上传，访问时会再生成
+	variants, err := image_service.Pregenerate(img.Name)
+	if err != nil {
+		logging.Warn("image_service.Pregenerate err:", err)
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"image_url":      upload.GetImageFullUrl(img.Name),
+		"image_save_url": upload.GetImageKey(img.Name),
+		"content_type":   img.ContentType,
+		"width":          img.Width,
+		"height":         img.Height,
+		"variants":       variants,
 	})
 }
+
+// @Summary Get a resized image, the signed URLs are returned by the upload API
+// @Produce  image/jpeg
+// @Produce  image/png
+// @Param name path string true "Image name"
+// @Param w query int false "Width, 0 to keep the aspect ratio"
+// @Param h query int false "Height, 0 to keep the aspect ratio"
+// @Param fit query string false "cover (default) / contain"
+// @Param fm query string false "jpeg / png, the format of the original image if empty"
+// @Param q query int false "JPEG quality 1-100"
+// @Param s query string true "Signature"
+// @Success 200 {string} string
+// @Failure 403 {object} app.Response
+// @Failure 404 {object} app.Response "Not found, or [image] SignKey is not configured"
+// @Router /images/{name} [get]
+func GetImage(c *gin.Context) {
+	appG := app.Gin{C: c}
+	name := c.Param("name")
+	if !image_service.Enabled() {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_IMAGE, nil)
+		return
+	}
+
+	var o imageproc.Options
+	for _, p := range []struct {
+		key string
+		v   *int
+	}{{"w", &o.Width}, {"h", &o.Height}, {"q", &o.Quality}} {
+		if arg := c.Query(p.key); arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+				return
+			}
+			*p.v = n
+		}
+	}
+	o.Fit = c.Query("fit")
+	o.Format = c.Query("fm")
+
+	if err := image_service.Normalize(name, &o); err != nil {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	if !image_service.Verify(name, o, c.Query("s")) {
+		appG.Response(http.StatusForbidden, e.ERROR_IMAGE_SIGNATURE, nil)
+		return
+	}
+
+	data, contentType, err := image_service.Get(name, o)
+	if err == storage.ErrNotExist {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_IMAGE, nil)
+		return
+	}
+	if err != nil {
+		logging.Warn("image_service.Get err:", err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_PROCESS_IMAGE_FAIL, nil)
+		return
+	}
+
+	// 图片按内容命名，同一地址的内容不会变化
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -2,30 +2,47 @@ package v1
 
 import (
 	"net/http"
+	"net/url"
+	"path"
 
 	"github.com/unknwon/com"
 	"github.com/astaxie/beego/validation"
 	"github.com/boombuler/barcode/qr"
 	"github.com/gin-gonic/gin"
 
+	casbinMiddleware "github.com/EDDYCJY/go-gin-example/middleware/casbin"
+	"github.com/EDDYCJY/go-gin-example/models"
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
 	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
 	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/category_service"
+	"github.com/EDDYCJY/go-gin-example/service/poster_service"
 	"github.com/EDDYCJY/go-gin-example/service/tag_service"
 )
 
 // @Summary Get a single article
 // @Produce  json
-// @Param id path int true "ID"
+// @Param id path string true "ID or slug, an old slug is redirected to the current one"
 // @Success 200 {object} app.Response
+// @Success 301 {string} string "Moved Permanently"
 // @Failure 500 {object} app.Response
 // @Router /api/v1/articles/{id} [get]
 func GetArticle(c *gin.Context) {
 	appG := app.Gin{C: c}
-	id := com.StrTo(c.Param("id")).MustInt()
+	param := c.Param("id")
+	if !slug.IsNumeric(param) {
+		getArticleBySlug(c, param)
+		return
+	}
+
+	id := com.StrTo(param).MustInt()
 	valid := validation.Validation{}
 	valid.Min(id, 1, "id")
 
@@ -52,12 +69,57 @@ func GetArticle(c *gin.Context) {
 		return
 	}
 
+	article_service.RecordView(article, visitorID(c))
+	article.Views++
+
+	appG.Response(http.StatusOK, e.SUCCESS, article)
+}
+
+// getArticleBySlug 按 slug 获取文章，旧 slug 301 重定向到当前 slug
+func getArticleBySlug(c *gin.Context, s string) {
+	appG := app.Gin{C: c}
+	id, moved, err := article_service.ResolveSlug(s)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return
+	}
+	if id == 0 {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return
+	}
+
+	articleService := article_service.Article{ID: id}
+	article, err := articleService.Get()
+	if err == pkgcache.ErrNotFound {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return
+	}
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ARTICLE_FAIL, nil)
+		return
+	}
+
+	if moved {
+		location := path.Dir(c.Request.URL.Path) + "/" + url.PathEscape(article.Slug)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	article_service.RecordView(article, visitorID(c))
+	article.Views++
+
 	appG.Response(http.StatusOK, e.SUCCESS, article)
 }
 
 // @Summary Get multiple articles
 // @Produce  json
 // @Param tag_id body int false "TagID"
+// @Param tag_ids body string false "TagIDs separated by commas"
+// @Param tag_match body string false "any (default) / all"
+// @Param category_id body int false "CategoryID, including its descendants"
 // @Param state body int false "State"
 // @Param created_by body int false "CreatedBy"
 // @Success 200 {object} app.Response
@@ -73,10 +135,25 @@ func GetArticles(c *gin.Context) {
 		valid.Range(state, 0, 1, "state")
 	}
 
-	tagId := -1
+	tagIds, err := util.ParseIDs(c.PostForm("tag_ids"))
+	if err != nil {
+		valid.SetError("tag_ids", err.Error())
+	}
 	if arg := c.PostForm("tag_id"); arg != "" {
-		tagId = com.StrTo(arg).MustInt()
+		tagId := com.StrTo(arg).MustInt()
 		valid.Min(tagId, 1, "tag_id")
+		tagIds = append([]int{tagId}, tagIds...)
+	}
+
+	tagMatch := c.DefaultPostForm("tag_match", article_service.TagMatchAny)
+	if tagMatch != article_service.TagMatchAny && tagMatch != article_service.TagMatchAll {
+		valid.SetError("tag_match", "tag_match must be any or all")
+	}
+
+	categoryId := 0
+	if arg := c.PostForm("category_id"); arg != "" {
+		categoryId = com.StrTo(arg).MustInt()
+		valid.Min(categoryId, 1, "category_id")
 	}
 
 	if valid.HasErrors() {
@@ -86,10 +163,12 @@ func GetArticles(c *gin.Context) {
 	}
 
 	articleService := article_service.Article{
-		TagID:    tagId,
-		State:    state,
-		PageNum:  util.GetPage(c),
-		PageSize: setting.AppSetting.PageSize,
+		TagIDs:     tagIds,
+		TagMatch:   tagMatch,
+		CategoryID: categoryId,
+		State:      state,
+		PageNum:    util.GetPage(c),
+		PageSize:   setting.AppSetting.PageSize,
 	}
 
 	total, err := articleService.Count()
@@ -112,23 +191,29 @@ func GetArticles(c *gin.Context) {
 }
 
 type AddArticleForm struct {
-	TagID         int    `form:"tag_id" valid:"Required;Min(1)"`
+	TagID         int    `form:"tag_id" valid:"Min(0)"`
+	TagIDs        string `form:"tag_ids" valid:"MaxSize(255)"`
+	CategoryID    int    `form:"category_id" valid:"Min(0)"`
 	Title         string `form:"title" valid:"Required;MaxSize(100)"`
-	Desc          string `form:"desc" valid:"Required;MaxSize(255)"`
+	Slug          string `form:"slug" valid:"MaxSize(100)"`
+	Desc          string `form:"desc" valid:"MaxSize(255)"`
 	Content       string `form:"content" valid:"Required;MaxSize(65535)"`
+	ContentFormat string `form:"content_format" valid:"MaxSize(10)"`
 	CreatedBy     string `form:"created_by" valid:"Required;MaxSize(100)"`
 	CoverImageUrl string `form:"cover_image_url" valid:"Required;MaxSize(255)"`
-	State         int    `form:"state" valid:"Range(0,1)"`
 }
 
 // @Summary Add article
 // @Produce  json
-// @Param tag_id body int true "TagID"
+// @Param tag_id body int false "TagID, required if tag_ids is empty"
+// @Param tag_ids body string false "TagIDs separated by commas, the first one is the primary tag"
+// @Param category_id body int false "CategoryID"
 // @Param title body string true "Title"
-// @Param desc body string true "Desc"
+// @Param slug body string false "Slug, generated from the title if empty"
+// @Param desc body string false "Desc, an excerpt of the content if empty"
 // @Param content body string true "Content"
+// @Param content_format body string false "markdown (default) / html"
 // @Param created_by body string true "CreatedBy"
-// @Param state body int true "State"
 // @Success 200 {object} app.Response
 // @Failure 500 {object} app.Response
 // @Router /api/v1/articles [post]
@@ -144,29 +229,33 @@ func AddArticle(c *gin.Context) {
 		return
 	}
 
-	tagService := tag_service.Tag{ID: form.TagID}
-	exists, err := tagService.ExistByID()
-	if err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+	if !article_service.ValidContentFormat(form.ContentFormat) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
 		return
 	}
 
-	if !exists {
-		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+	subject, ok := authorizeArticle(&appG, article_service.ActionCreate)
+	if !ok {
+		return
+	}
+
+	tagIds, ok := articleTagIDs(&appG, form.TagID, form.TagIDs)
+	if !ok || !checkArticleTags(&appG, tagIds) || !checkArticleCategory(&appG, form.CategoryID) {
 		return
 	}
 
 	articleService := article_service.Article{
-		TagID:         form.TagID,
+		TagIDs:        tagIds,
+		CategoryID:    form.CategoryID,
 		Title:         form.Title,
+		Slug:          form.Slug,
 		Desc:          form.Desc,
 		Content:       form.Content,
+		ContentFormat: form.ContentFormat,
 		CoverImageUrl: form.CoverImageUrl,
-		State:         form.State,
 		CreatedBy:     form.CreatedBy,
 	}
-	if err := articleService.Add(); err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_ARTICLE_FAIL, nil)
+	if !respondArticleSlugError(&appG, articleService.Add(subject), e.ERROR_ADD_ARTICLE_FAIL) {
 		return
 	}
 
@@ -175,24 +264,30 @@ func AddArticle(c *gin.Context) {
 
 type EditArticleForm struct {
 	ID            int    `form:"id" valid:"Required;Min(1)"`
-	TagID         int    `form:"tag_id" valid:"Required;Min(1)"`
+	TagID         int    `form:"tag_id" valid:"Min(0)"`
+	TagIDs        string `form:"tag_ids" valid:"MaxSize(255)"`
+	CategoryID    int    `form:"category_id" valid:"Min(0)"`
 	Title         string `form:"title" valid:"Required;MaxSize(100)"`
-	Desc          string `form:"desc" valid:"Required;MaxSize(255)"`
+	Slug          string `form:"slug" valid:"MaxSize(100)"`
+	Desc          string `form:"desc" valid:"MaxSize(255)"`
 	Content       string `form:"content" valid:"Required;MaxSize(65535)"`
+	ContentFormat string `form:"content_format" valid:"MaxSize(10)"`
 	ModifiedBy    string `form:"modified_by" valid:"Required;MaxSize(100)"`
 	CoverImageUrl string `form:"cover_image_url" valid:"Required;MaxSize(255)"`
-	State         int    `form:"state" valid:"Range(0,1)"`
 }
 
-// @Summary Update article
+// @Summary Update article, a published article goes back to review
 // @Produce  json
 // @Param id path int true "ID"
-// @Param tag_id body string false "TagID"
+// @Param tag_id body int false "TagID, required if tag_ids is empty"
+// @Param tag_ids body string false "TagIDs separated by commas, the first one is the primary tag"
+// @Param category_id body int false "CategoryID"
 // @Param title body string false "Title"
-// @Param desc body string false "Desc"
+// @Param slug body string false "Slug, regenerated from the title if empty and the title changes"
+// @Param desc body string false "Desc, an excerpt of the content if empty"
 // @Param content body string false "Content"
+// @Param content_format body string false "markdown / html, keeps the current format if empty"
 // @Param modified_by body string true "ModifiedBy"
-// @Param state body int false "State"
 // @Success 200 {object} app.Response
 // @Failure 500 {object} app.Response
 // @Router /api/v1/articles/{id} [put]
@@ -208,15 +303,27 @@ func EditArticle(c *gin.Context) {
 		return
 	}
 
+	if !article_service.ValidContentFormat(form.ContentFormat) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	tagIds, ok := articleTagIDs(&appG, form.TagID, form.TagIDs)
+	if !ok {
+		return
+	}
+
 	articleService := article_service.Article{
 		ID:            form.ID,
-		TagID:         form.TagID,
+		TagIDs:        tagIds,
+		CategoryID:    form.CategoryID,
 		Title:         form.Title,
+		Slug:          form.Slug,
 		Desc:          form.Desc,
 		Content:       form.Content,
+		ContentFormat: form.ContentFormat,
 		CoverImageUrl: form.CoverImageUrl,
 		ModifiedBy:    form.ModifiedBy,
-		State:         form.State,
 	}
 	exists, err := articleService.ExistByID()
 	if err != nil {
@@ -228,21 +335,17 @@ func EditArticle(c *gin.Context) {
 		return
 	}
 
-	tagService := tag_service.Tag{ID: form.TagID}
-	exists, err = tagService.ExistByID()
-	if err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+	if !checkArticleTags(&appG, tagIds) || !checkArticleCategory(&appG, form.CategoryID) {
 		return
 	}
 
-	if !exists {
-		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
 		return
 	}
 
-	err = articleService.Edit()
-	if err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_EDIT_ARTICLE_FAIL, nil)
+	if !respondArticleSlugError(&appG, articleService.Edit(subject), e.ERROR_EDIT_ARTICLE_FAIL) {
 		return
 	}
 
@@ -267,6 +370,11 @@ func DeleteArticle(c *gin.Context) {
 		return
 	}
 
+	subject, ok := authorizeArticle(&appG, article_service.ActionDelete)
+	if !ok {
+		return
+	}
+
 	articleService := article_service.Article{ID: id}
 	exists, err := articleService.ExistByID()
 	if err != nil {
@@ -278,9 +386,7 @@ func DeleteArticle(c *gin.Context) {
 		return
 	}
 
-	err = articleService.Delete()
-	if err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_ARTICLE_FAIL, nil)
+	if !respondArticleSlugError(&appG, articleService.Delete(subject), e.ERROR_DELETE_ARTICLE_FAIL) {
 		return
 	}
 
@@ -291,35 +397,170 @@ const (
 	QRCODE_URL = "https://github.com/EDDYCJY/blog#gin%E7%B3%BB%E5%88%97%E7%9B%AE%E5%BD%95"
 )
 
+type GenerateArticlePosterForm struct {
+	ID       int    `form:"id" valid:"Min(0)"`
+	Template string `form:"template" valid:"MaxSize(100)"`
+}
+
+// @Summary Generate article poster
+// @Produce  json
+// @Param id body int false "Article ID, the poster shows the article and its qr code links to the article"
+// @Param template body string false "Poster template name, defaults to [poster] DefaultTemplate"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/poster/generate [post]
 func GenerateArticlePoster(c *gin.Context) {
-	appG := app.Gin{C: c}
-	article := &article_service.Article{}
-	qr := qrcode.NewQrCode(QRCODE_URL, 300, 300, qr.M, qr.Auto)
-	posterName := article_service.GetPosterFlag() + "-" + qrcode.GetQrCodeFileName(qr.URL) + qr.GetQrCodeExt()
-	articlePoster := article_service.NewArticlePoster(posterName, article, qr)
-	articlePosterBgService := article_service.NewArticlePosterBg(
-		"bg.jpg",
-		articlePoster,
-		&article_service.Rect{
-			X0: 0,
-			Y0: 0,
-			X1: 550,
-			Y1: 700,
-		},
-		&article_service.Pt{
-			X: 125,
-			Y: 298,
-		},
+	var (
+		appG = app.Gin{C: c}
+		form GenerateArticlePosterForm
 	)
 
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	template, version, err := poster_service.Load(form.Template)
+	if !respondPosterTemplateError(&appG, err, e.ERROR_GEN_ARTICLE_POSTER_FAIL) {
+		return
+	}
+
+	articlePosterBgService, ok := newArticlePoster(&appG, form.ID, form.Template, template, version)
+	if !ok {
+		return
+	}
+
 	_, filePath, err := articlePosterBgService.Generate()
 	if err != nil {
+		logging.Warn(err)
 		appG.Response(http.StatusInternalServerError, e.ERROR_GEN_ARTICLE_POSTER_FAIL, nil)
 		return
 	}
 
 	appG.Response(http.StatusOK, e.SUCCESS, map[string]string{
-		"poster_url":      qrcode.GetQrCodeFullUrl(posterName),
-		"poster_save_url": filePath + posterName,
+		"poster_url":      qrcode.GetQrCodeFullUrl(articlePosterBgService.PosterName),
+		"poster_save_url": filePath + articlePosterBgService.PosterName,
 	})
 }
+
+// newArticlePoster id 为 0 时不绑定文章，二维码指向 QRCODE_URL，否则指向文章页
+func newArticlePoster(appG *app.Gin, id int, name string, template *poster.Template, version int) (*article_service.ArticlePosterBg, bool) {
+	article := &article_service.Article{}
+	url := QRCODE_URL
+	var current *models.Article
+	if id > 0 {
+		articleService := article_service.Article{ID: id}
+		if !checkArticleExists(appG, &articleService) {
+			return nil, false
+		}
+
+		var err error
+		current, err = articleService.Get()
+		if err != nil {
+			appG.Response(http.StatusInternalServerError, e.ERROR_GET_ARTICLE_FAIL, nil)
+			return nil, false
+		}
+		article = &article_service.Article{
+			ID:            current.ID,
+			Title:         current.Title,
+			Desc:          current.Desc,
+			CoverImageUrl: current.CoverImageUrl,
+			CreatedBy:     current.CreatedBy,
+		}
+		url = article_service.ArticleURL(current)
+	}
+
+	qr := qrcode.NewQrCode(url, 300, 300, qr.M, qr.Auto)
+	posterName := article_service.GetPosterName(url, name, version, current, template)
+	articlePoster := article_service.NewArticlePoster(posterName, article, qr)
+	return article_service.NewArticlePosterBg(name, articlePoster, template), true
+}
+
+// articleTagIDs 合并 tag_id 和 tag_ids，tag_id 作为主标签放在第一个，至少需要一个标签
+func articleTagIDs(appG *app.Gin, tagId int, tagIds string) ([]int, bool) {
+	ids, err := util.ParseIDs(tagIds)
+	if err != nil {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return nil, false
+	}
+	if tagId > 0 {
+		merged := []int{tagId}
+		for _, id := range ids {
+			if id != tagId {
+				merged = append(merged, id)
+			}
+		}
+		ids = merged
+	}
+	if len(ids) == 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return nil, false
+	}
+
+	return ids, true
+}
+
+// authorizeArticle 检查当前用户能否对文章执行 action，不能时输出错误响应并返回 false
+func authorizeArticle(appG *app.Gin, action string) (string, bool) {
+	subject, err := casbinMiddleware.Subject(appG.C)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return "", false
+	}
+
+	return subject, respondArticleSlugError(appG, article_service.Authorize(subject, action), e.ERROR)
+}
+
+// checkArticleTags 标签不存在时输出错误响应并返回 false
+func checkArticleTags(appG *app.Gin, tagIds []int) bool {
+	exists, err := tag_service.ExistByIDs(tagIds)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+		return false
+	}
+
+	return true
+}
+
+// checkArticleCategory 指定的分类不存在时输出错误响应并返回 false，0 表示不分类
+func checkArticleCategory(appG *app.Gin, categoryId int) bool {
+	if categoryId == 0 {
+		return true
+	}
+
+	categoryService := category_service.Category{ID: categoryId}
+	exists, err := categoryService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_CATEGORY_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_CATEGORY, nil)
+		return false
+	}
+
+	return true
+}
+
+// respondArticleSlugError 把保存文章时 slug 相关的错误和权限错误转换为响应，err 为 nil 时返回 true
+func respondArticleSlugError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case article_service.ErrInvalidSlug:
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+	case article_service.ErrSlugExists:
+		appG.Response(http.StatusOK, e.ERROR_EXIST_ARTICLE_SLUG, nil)
+	case article_service.ErrForbidden:
+		appG.Response(http.StatusForbidden, e.ERROR_ARTICLE_FORBIDDEN, nil)
+	default:
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
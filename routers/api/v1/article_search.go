@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+const searchDateLayout = "2006-01-02"
+
+// @Summary Search articles
+// @Produce  json
+// @Param q query string false "Keyword"
+// @Param tag_id query int false "TagID"
+// @Param created_by query string false "CreatedBy"
+// @Param state query int false "State"
+// @Param start_date query string false "StartDate (2006-01-02)"
+// @Param end_date query string false "EndDate (2006-01-02)"
+// @Param page query int false "Page"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/search [get]
+func SearchArticles(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+
+	keyword := c.Query("q")
+	valid.MaxSize(keyword, 100, "q")
+
+	state := -1
+	if arg := c.Query("state"); arg != "" {
+		state = com.StrTo(arg).MustInt()
+		valid.Range(state, 0, 1, "state")
+	}
+
+	tagId := -1
+	if arg := c.Query("tag_id"); arg != "" {
+		tagId = com.StrTo(arg).MustInt()
+		valid.Min(tagId, 1, "tag_id")
+	}
+
+	var startTime, endTime int
+	if arg := c.Query("start_date"); arg != "" {
+		t, err := time.ParseInLocation(searchDateLayout, arg, time.Local)
+		if err != nil {
+			valid.SetError("start_date", err.Error())
+		}
+		startTime = int(t.Unix())
+	}
+	if arg := c.Query("end_date"); arg != "" {
+		t, err := time.ParseInLocation(searchDateLayout, arg, time.Local)
+		if err != nil {
+			valid.SetError("end_date", err.Error())
+		}
+		// 包含结束日期当天
+		endTime = int(t.AddDate(0, 0, 1).Unix()) - 1
+	}
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	articleSearch := article_service.ArticleSearch{
+		Keyword:   keyword,
+		TagID:     tagId,
+		CreatedBy: c.Query("created_by"),
+		State:     state,
+		StartTime: startTime,
+		EndTime:   endTime,
+		PageNum:   util.GetPage(c),
+		PageSize:  setting.AppSetting.PageSize,
+	}
+
+	result, err := articleSearch.Search()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_SEARCH_ARTICLES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, result)
+}
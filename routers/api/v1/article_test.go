@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	casbinPkg "github.com/EDDYCJY/go-gin-example/pkg/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+)
+
+// setupCasbin 加载 conf/rbac_policy.csv，user:2 为 editor，user:3 为 viewer
+func setupCasbin(t *testing.T) {
+	saved := setting.AppSetting.RuntimeRootPath
+	t.Cleanup(func() { setting.AppSetting.RuntimeRootPath = saved })
+	setting.AppSetting.RuntimeRootPath = "../../../runtime/"
+	if err := casbinPkg.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	util.Setup()
+}
+
+func TestArticleForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupCasbin(t)
+
+	r := gin.New()
+	r.POST("/articles", AddArticle)
+	r.DELETE("/articles/:id", DeleteArticle)
+
+	form := url.Values{
+		"tag_id":          {"1"},
+		"title":           {"title"},
+		"content":         {"content"},
+		"created_by":      {"viewer"},
+		"cover_image_url": {"cover.png"},
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID int
+		status int
+	}{
+		// 没有权限时在查询数据库之前返回
+		{"viewer add", http.MethodPost, "/articles", 3, http.StatusForbidden},
+		{"editor delete", http.MethodDelete, "/articles/1", 2, http.StatusForbidden},
+		{"viewer delete", http.MethodDelete, "/articles/1", 3, http.StatusForbidden},
+		{"add without token", http.MethodPost, "/articles", 0, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.userID > 0 {
+			token, err := util.GenerateToken(tt.userID, "user", "password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d, body %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
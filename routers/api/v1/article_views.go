@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+// 热门榜单默认返回的数量
+const defaultRankLimit = 10
+
+// visitorID 独立访客标识：登录用户按用户名，否则按 IP 和 User-Agent
+func visitorID(c *gin.Context) string {
+	if claims := jwt.GetClaims(c); claims != nil && claims.Username != "" {
+		return "u:" + claims.Username
+	}
+
+	sum := sha1.Sum([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return "a:" + hex.EncodeToString(sum[:])
+}
+
+// rankParams 解析榜单接口的 tag_id / limit 参数
+func rankParams(c *gin.Context, valid *validation.Validation) (int, int) {
+	tagId := 0
+	if arg := c.Query("tag_id"); arg != "" {
+		tagId = com.StrTo(arg).MustInt()
+		valid.Min(tagId, 1, "tag_id")
+	}
+
+	limit := defaultRankLimit
+	if arg := c.Query("limit"); arg != "" {
+		limit = com.StrTo(arg).MustInt()
+		valid.Range(limit, 1, 100, "limit")
+	}
+
+	return tagId, limit
+}
+
+// @Summary Get trending articles
+// @Produce  json
+// @Param tag_id query int false "TagID"
+// @Param limit query int false "Limit"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/trending [get]
+func GetTrendingArticles(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+
+	tagId, limit := rankParams(c, &valid)
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	articles, err := article_service.Trending(tagId, limit)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_TRENDING_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": articles,
+	})
+}
+
+// @Summary Get most viewed articles
+// @Produce  json
+// @Param tag_id query int false "TagID"
+// @Param limit query int false "Limit"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/popular [get]
+func GetPopularArticles(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+
+	tagId, limit := rankParams(c, &valid)
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	articles, err := article_service.Popular(tagId, limit)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_TRENDING_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": articles,
+	})
+}
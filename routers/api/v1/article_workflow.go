@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	casbinMiddleware "github.com/EDDYCJY/go-gin-example/middleware/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+const publishAtLayout = "2006-01-02 15:04:05"
+
+type TransitionArticleForm struct {
+	ID         int    `form:"id" valid:"Required;Min(1)"`
+	Action     string `form:"action" valid:"Required;MaxSize(20)"`
+	PublishAt  string `form:"publish_at" valid:"MaxSize(19)"`
+	ModifiedBy string `form:"modified_by" valid:"MaxSize(100)"`
+}
+
+// @Summary Change article workflow status
+// @Produce  json
+// @Param id path int true "ID"
+// @Param action body string true "submit / approve / reject / archive / reopen"
+// @Param publish_at body string false "PublishAt (2006-01-02 15:04:05), only for approve"
+// @Param modified_by body string false "ModifiedBy"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/workflow [put]
+func TransitionArticle(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = TransitionArticleForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	var publishAt int
+	if form.PublishAt != "" {
+		t, err := time.ParseInLocation(publishAtLayout, form.PublishAt, time.Local)
+		if err != nil {
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return
+		}
+		publishAt = int(t.Unix())
+	}
+	if !article_service.ValidAction(form.Action) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	articleService := article_service.Article{ID: form.ID, ModifiedBy: form.ModifiedBy}
+	if !checkArticleExists(&appG, &articleService) {
+		return
+	}
+
+	err = articleService.Transition(subject, form.Action, publishAt)
+	if !respondWorkflowError(&appG, err, e.ERROR_ARTICLE_TRANSITION_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Get article revisions
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/revisions [get]
+func GetArticleRevisions(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, _, ok := revisionParams(&appG, false)
+	if !ok {
+		return
+	}
+
+	articleService := article_service.Article{ID: id}
+	if !checkArticleExists(&appG, &articleService) {
+		return
+	}
+
+	revisions, err := articleService.Revisions()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_REVISIONS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": revisions,
+	})
+}
+
+// @Summary Get a single article revision
+// @Produce  json
+// @Param id path int true "ID"
+// @Param revision path int true "Revision"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/revisions/{revision} [get]
+func GetArticleRevision(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, revision, ok := revisionParams(&appG, true)
+	if !ok {
+		return
+	}
+
+	articleService := article_service.Article{ID: id}
+	r, err := articleService.Revision(revision)
+	if !respondWorkflowError(&appG, err, e.ERROR_GET_REVISIONS_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, r)
+}
+
+// @Summary Diff two article revisions
+// @Produce  json
+// @Param id path int true "ID"
+// @Param revision path int true "Revision"
+// @Param from query int false "Compare against this revision, defaults to the previous one"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/revisions/{revision}/diff [get]
+func DiffArticleRevision(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, revision, ok := revisionParams(&appG, true)
+	if !ok {
+		return
+	}
+
+	from := 0
+	if arg := c.Query("from"); arg != "" {
+		from = com.StrTo(arg).MustInt()
+		if from < 1 {
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return
+		}
+	}
+
+	articleService := article_service.Article{ID: id}
+	result, err := articleService.Diff(from, revision)
+	if !respondWorkflowError(&appG, err, e.ERROR_GET_REVISIONS_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, result)
+}
+
+// @Summary Restore an article revision
+// @Produce  json
+// @Param id path int true "ID"
+// @Param revision path int true "Revision"
+// @Param modified_by body string true "ModifiedBy"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/revisions/{revision}/restore [put]
+func RestoreArticleRevision(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, revision, ok := revisionParams(&appG, true)
+	if !ok {
+		return
+	}
+
+	modifiedBy := c.PostForm("modified_by")
+	valid := validation.Validation{}
+	valid.Required(modifiedBy, "modified_by")
+	valid.MaxSize(modifiedBy, 100, "modified_by")
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	articleService := article_service.Article{ID: id, ModifiedBy: modifiedBy}
+	if !checkArticleExists(&appG, &articleService) {
+		return
+	}
+
+	err = articleService.Restore(subject, revision)
+	if !respondWorkflowError(&appG, err, e.ERROR_RESTORE_REVISION_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// revisionParams 解析路径中的文章 ID 和版本号
+func revisionParams(appG *app.Gin, withRevision bool) (int, int, bool) {
+	valid := validation.Validation{}
+	id := com.StrTo(appG.C.Param("id")).MustInt()
+	valid.Min(id, 1, "id")
+
+	revision := 0
+	if withRevision {
+		revision = com.StrTo(appG.C.Param("revision")).MustInt()
+		valid.Min(revision, 1, "revision")
+	}
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return 0, 0, false
+	}
+
+	return id, revision, true
+}
+
+// checkArticleExists 文章不存在时输出错误响应并返回 false
+func checkArticleExists(appG *app.Gin, articleService *article_service.Article) bool {
+	exists, err := articleService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return false
+	}
+
+	return true
+}
+
+// respondWorkflowError 把流程相关的错误转换为响应，err 为 nil 时返回 true
+func respondWorkflowError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case article_service.ErrForbidden:
+		appG.Response(http.StatusForbidden, e.ERROR_ARTICLE_FORBIDDEN, nil)
+	case article_service.ErrInvalidTransition:
+		appG.Response(http.StatusConflict, e.ERROR_ARTICLE_INVALID_TRANSITION, nil)
+	case article_service.ErrRevisionNotFound:
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_REVISION, nil)
+	default:
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
@@ -0,0 +1,286 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	casbinMiddleware "github.com/EDDYCJY/go-gin-example/middleware/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+)
+
+type InitUploadForm struct {
+	FileName  string `form:"file_name" valid:"Required;MaxSize(255)"`
+	Size      int64  `form:"size"`
+	SHA256    string `form:"sha256" valid:"Required;Length(64)"`
+	OwnerType string `form:"owner_type" valid:"Required;MaxSize(20)"`
+	OwnerID   int    `form:"owner_id" valid:"Required;Min(1)"`
+}
+
+// @Summary Start a chunked upload
+// @Produce  json
+// @Param file_name body string true "FileName"
+// @Param size body int true "Size in bytes"
+// @Param sha256 body string true "SHA-256 of the whole file"
+// @Param owner_type body string true "article / stock_product / order"
+// @Param owner_id body int true "OwnerID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/uploads [post]
+func InitUpload(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form InitUploadForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	upload := attachment_service.Upload{
+		FileName:  form.FileName,
+		Size:      form.Size,
+		SHA256:    form.SHA256,
+		OwnerType: form.OwnerType,
+		OwnerID:   form.OwnerID,
+		CreatedBy: subject,
+	}
+	progress, err := upload.Init()
+	if !respondUploadError(&appG, err, e.ERROR_INIT_UPLOAD_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, progress)
+}
+
+// @Summary Get the progress of a chunked upload
+// @Produce  json
+// @Param id path string true "UploadID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/uploads/{id} [get]
+func GetUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	progress, err := attachment_service.GetProgress(c.Param("id"), subject)
+	if !respondUploadError(&appG, err, e.ERROR_GET_UPLOAD_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, progress)
+}
+
+// @Summary Upload a chunk, the request body is the raw chunk
+// @Accept  application/octet-stream
+// @Produce  json
+// @Param id path string true "UploadID"
+// @Param index path int true "Chunk index, starts from 0"
+// @Param X-Chunk-Sha256 header string true "SHA-256 of the chunk"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/uploads/{id}/chunks/{index} [put]
+func PutUploadChunk(c *gin.Context) {
+	appG := app.Gin{C: c}
+	index, err := com.StrTo(c.Param("index")).Int()
+	checksum := c.GetHeader("X-Chunk-Sha256")
+	if err != nil || checksum == "" {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	err = attachment_service.PutChunk(c.Param("id"), subject, index, c.Request.Body, checksum)
+	if !respondUploadError(&appG, err, e.ERROR_SAVE_UPLOAD_CHUNK_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Complete a chunked upload and save it as an attachment
+// @Produce  json
+// @Param id path string true "UploadID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/uploads/{id}/complete [post]
+func CompleteUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	attachment, err := attachment_service.Complete(c.Param("id"), subject)
+	if !respondUploadError(&appG, err, e.ERROR_COMPLETE_UPLOAD_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, attachment)
+}
+
+// @Summary Abort a chunked upload
+// @Produce  json
+// @Param id path string true "UploadID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/uploads/{id} [delete]
+func AbortUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	err = attachment_service.Abort(c.Param("id"), subject)
+	if !respondUploadError(&appG, err, e.ERROR_ABORT_UPLOAD_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Get the attachments of an article, a stock product or an order
+// @Produce  json
+// @Param owner_type query string true "article / stock_product / order"
+// @Param owner_id query int true "OwnerID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/attachments [get]
+func GetAttachments(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ownerType := c.Query("owner_type")
+	ownerID := com.StrTo(c.Query("owner_id")).MustInt()
+	if ownerType == "" || ownerID <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{OwnerType: ownerType, OwnerID: ownerID}
+	attachments, err := attachmentService.GetAll()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ATTACHMENTS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": attachments,
+	})
+}
+
+// @Summary Download an attachment, redirects to a temporary url
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 302
+// @Failure 500 {object} app.Response
+// @Router /api/v1/attachments/{id} [get]
+func GetAttachment(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{ID: id}
+	attachment, err := attachmentService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ATTACHMENTS_FAIL, nil)
+		return
+	}
+	if attachment == nil {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ATTACHMENT, nil)
+		return
+	}
+
+	url, err := attachment_service.URL(attachment)
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ATTACHMENTS_FAIL, nil)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// @Summary Delete an attachment
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/attachments/{id} [delete]
+func DeleteAttachment(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{ID: id}
+	attachment, err := attachmentService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_ATTACHMENT_FAIL, nil)
+		return
+	}
+	if attachment == nil {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ATTACHMENT, nil)
+		return
+	}
+
+	if err := attachmentService.Delete(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_ATTACHMENT_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+func respondUploadError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case attachment_service.ErrUploadNotFound:
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_UPLOAD, nil)
+	case attachment_service.ErrInvalidFile:
+		appG.Response(http.StatusBadRequest, e.ERROR_ATTACHMENT_INVALID, nil)
+	case attachment_service.ErrUploadClosed:
+		appG.Response(http.StatusConflict, e.ERROR_UPLOAD_CLOSED, nil)
+	case attachment_service.ErrInvalidChunk:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHUNK_INVALID, nil)
+	case attachment_service.ErrChunkChecksum:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHUNK_CHECKSUM, nil)
+	case attachment_service.ErrIncomplete:
+		appG.Response(http.StatusConflict, e.ERROR_UPLOAD_INCOMPLETE, nil)
+	case attachment_service.ErrChecksum:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHECKSUM, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
@@ -0,0 +1,194 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/service/category_service"
+)
+
+// @Summary Get the category tree
+// @Produce  json
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/categories [get]
+func GetCategories(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	categories, err := category_service.Tree()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_CATEGORIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": categories,
+	})
+}
+
+type AddCategoryForm struct {
+	ParentID  int    `form:"parent_id" valid:"Min(0)"`
+	Name      string `form:"name" valid:"Required;MaxSize(100)"`
+	Sort      int    `form:"sort" valid:"Min(0)"`
+	CreatedBy string `form:"created_by" valid:"Required;MaxSize(100)"`
+}
+
+// @Summary Add category
+// @Produce  json
+// @Param parent_id body int false "ParentID"
+// @Param name body string true "Name"
+// @Param sort body int false "Sort"
+// @Param created_by body string true "CreatedBy"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/categories [post]
+func AddCategory(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form AddCategoryForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	categoryService := category_service.Category{
+		ParentID:  form.ParentID,
+		Name:      form.Name,
+		Sort:      form.Sort,
+		CreatedBy: form.CreatedBy,
+	}
+	exists, err := categoryService.ExistByName()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_CATEGORY_FAIL, nil)
+		return
+	}
+	if exists {
+		appG.Response(http.StatusOK, e.ERROR_EXIST_CATEGORY, nil)
+		return
+	}
+
+	err = categoryService.Add()
+	if !respondCategoryError(&appG, err, e.ERROR_ADD_CATEGORY_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"id": categoryService.ID,
+	})
+}
+
+type EditCategoryForm struct {
+	ID         int    `form:"id" valid:"Required;Min(1)"`
+	ParentID   int    `form:"parent_id" valid:"Min(0)"`
+	Name       string `form:"name" valid:"Required;MaxSize(100)"`
+	Sort       int    `form:"sort" valid:"Min(0)"`
+	ModifiedBy string `form:"modified_by" valid:"Required;MaxSize(100)"`
+}
+
+// @Summary Update category
+// @Produce  json
+// @Param id path int true "ID"
+// @Param parent_id body int false "ParentID"
+// @Param name body string true "Name"
+// @Param sort body int false "Sort"
+// @Param modified_by body string true "ModifiedBy"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/categories/{id} [put]
+func EditCategory(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = EditCategoryForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	categoryService := category_service.Category{
+		ID:         form.ID,
+		ParentID:   form.ParentID,
+		Name:       form.Name,
+		Sort:       form.Sort,
+		ModifiedBy: form.ModifiedBy,
+	}
+	exists, err := categoryService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_CATEGORY_FAIL, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_CATEGORY, nil)
+		return
+	}
+
+	err = categoryService.Edit()
+	if !respondCategoryError(&appG, err, e.ERROR_EDIT_CATEGORY_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Delete category
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/categories/{id} [delete]
+func DeleteCategory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	categoryService := category_service.Category{ID: id}
+	exists, err := categoryService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_CATEGORY_FAIL, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_CATEGORY, nil)
+		return
+	}
+
+	err = categoryService.Delete()
+	if !respondCategoryError(&appG, err, e.ERROR_DELETE_CATEGORY_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// respondCategoryError 把分类相关的错误转换为响应，err 为 nil 时返回 true
+func respondCategoryError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case category_service.ErrParentNotFound, category_service.ErrInvalidParent:
+		appG.Response(http.StatusOK, e.ERROR_CATEGORY_INVALID_PARENT, nil)
+	case category_service.ErrHasChildren:
+		appG.Response(http.StatusOK, e.ERROR_CATEGORY_HAS_CHILDREN, nil)
+	default:
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
@@ -0,0 +1,215 @@
+package v1
+
+import (
+	"bytes"
+	"image"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/stock_service"
+)
+
+// 产品明细可以生成条码的字段
+const (
+	codeFieldCode       = "code"
+	codeFieldHiddenCode = "hidden_code"
+)
+
+type CodeForm struct {
+	Kind    string `form:"kind" valid:"MaxSize(20)"`
+	Content string `form:"content" valid:"MaxSize(500)"`
+	Field   string `form:"field" valid:"MaxSize(20)"`
+	Format  string `form:"format" valid:"MaxSize(10)"`
+	Width   int    `form:"width" valid:"Min(0);Max(4096)"`
+	Height  int    `form:"height" valid:"Min(0);Max(4096)"`
+	Quiet   string `form:"quiet" valid:"MaxSize(3)"`
+	Logo    string `form:"logo" valid:"MaxSize(100)"`
+}
+
+// @Summary Generate a barcode or a QR code, the image is returned directly and not saved
+// @Produce  image/png
+// @Produce  image/jpeg
+// @Produce  image/svg+xml
+// @Param kind query string true "qr / code128 / ean13 / datamatrix"
+// @Param content query string true "Content"
+// @Param format query string false "png / jpeg / svg, defaults to png"
+// @Param width query int false "Image width in pixels"
+// @Param height query int false "Image height in pixels"
+// @Param quiet query int false "Quiet zone in modules, defaults to the recommended value of the kind"
+// @Param logo query string false "Uploaded image name shown in the center of a QR code"
+// @Success 200 {string} string "Code image"
+// @Failure 400 {object} app.Response
+// @Router /api/v1/codes [get]
+func GetCode(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form CodeForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+	if form.Kind == "" || form.Content == "" {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	writeCode(&appG, &form)
+}
+
+// @Summary Generate a label code for a stock product detail, the image is returned directly and not saved
+// @Produce  image/png
+// @Produce  image/jpeg
+// @Produce  image/svg+xml
+// @Param id path int true "Detail ID"
+// @Param field query string false "code / hidden_code, defaults to code"
+// @Param kind query string false "qr / code128 / ean13 / datamatrix, defaults to code128"
+// @Param format query string false "png / jpeg / svg, defaults to png"
+// @Param width query int false "Image width in pixels"
+// @Param height query int false "Image height in pixels"
+// @Param quiet query int false "Quiet zone in modules, defaults to the recommended value of the kind"
+// @Param logo query string false "Uploaded image name shown in the center of a QR code"
+// @Success 200 {string} string "Code image"
+// @Failure 400 {object} app.Response
+// @Router /api/v1/stock/product-detail/{id}/code [get]
+func GetStockProductDetailCode(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form CodeForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	exists, err := stock_service.ExistStockProductDetailByID(id)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST, nil)
+		return
+	}
+	detail, err := stock_service.GetStockProductDetailByID(id)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
+		return
+	}
+
+	switch form.Field {
+	case "", codeFieldCode:
+		form.Content = detail.Code
+	case codeFieldHiddenCode:
+		form.Content = detail.HiddenCode
+	default:
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	if form.Kind == "" {
+		form.Kind = qrcode.KindCode128
+	}
+	if form.Content == "" {
+		appG.Response(http.StatusOK, e.ERROR_CODE_INVALID, nil)
+		return
+	}
+
+	writeCode(&appG, &form)
+}
+
+// writeCode 生成条码并直接写入响应，不保存到 runtime/qrcode
+func writeCode(appG *app.Gin, form *CodeForm) {
+	code, ok := newCode(appG, form)
+	if !ok {
+		return
+	}
+	if form.Format == "" {
+		form.Format = qrcode.FormatPNG
+	}
+	if form.Format == "jpg" {
+		form.Format = qrcode.FormatJPEG
+	}
+
+	var buf bytes.Buffer
+	err := code.Write(&buf, form.Format)
+	switch err {
+	case nil:
+	case qrcode.ErrInvalidCode, qrcode.ErrTooSmall:
+		appG.Response(http.StatusBadRequest, e.ERROR_CODE_INVALID, nil)
+		return
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GEN_CODE_FAIL, nil)
+		return
+	}
+
+	appG.C.Header("Cache-Control", "private, no-cache")
+	appG.C.Data(http.StatusOK, qrcode.ContentType(form.Format), buf.Bytes())
+}
+
+// newCode 按表单创建条码，logo 为已上传图片的文件名
+func newCode(appG *app.Gin, form *CodeForm) (*qrcode.Code, bool) {
+	code := &qrcode.Code{
+		Kind:      form.Kind,
+		Content:   form.Content,
+		Width:     form.Width,
+		Height:    form.Height,
+		QuietZone: -1,
+	}
+	if form.Quiet != "" {
+		quiet, err := strconv.Atoi(form.Quiet)
+		if err != nil || quiet < 0 || quiet > 100 {
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return nil, false
+		}
+		code.QuietZone = quiet
+	}
+
+	if form.Logo != "" {
+		if path.Base(form.Logo) != form.Logo {
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return nil, false
+		}
+
+		rc, err := storage.Default.Get(upload.GetImageKey(form.Logo))
+		if err == storage.ErrNotExist {
+			appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_IMAGE, nil)
+			return nil, false
+		}
+		if err != nil {
+			logging.Warn(err)
+			appG.Response(http.StatusInternalServerError, e.ERROR_GEN_CODE_FAIL, nil)
+			return nil, false
+		}
+		defer rc.Close()
+
+		logo, _, err := image.Decode(rc)
+		if err != nil {
+			logging.Warn(err)
+			appG.Response(http.StatusInternalServerError, e.ERROR_GEN_CODE_FAIL, nil)
+			return nil, false
+		}
+		code.Logo = logo
+	}
+
+	return code, true
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+func TestGetCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	savedStorage, savedPath := storage.Default, setting.AppSetting.ImageSavePath
+	t.Cleanup(func() { storage.Default, setting.AppSetting.ImageSavePath = savedStorage, savedPath })
+	storage.Default = storage.NewLocal(t.TempDir(), "")
+	setting.AppSetting.ImageSavePath = "upload/images/"
+
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	if err := storage.Default.Put("upload/images/logo.png", &logo, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/codes", GetCode)
+
+	tests := []struct {
+		query       string
+		status      int
+		contentType string
+		code        int
+	}{
+		{"kind=qr&content=hello", http.StatusOK, "image/png", 0},
+		{"kind=qr&content=hello&format=jpg", http.StatusOK, "image/jpeg", 0},
+		{"kind=code128&content=SKU-1&format=svg&quiet=0", http.StatusOK, "image/svg+xml", 0},
+		{"kind=qr&content=hello&logo=logo.png&width=300", http.StatusOK, "image/png", 0},
+		{"kind=qr", http.StatusBadRequest, "", e.INVALID_PARAMS},
+		{"kind=pdf417&content=hello", http.StatusBadRequest, "", e.ERROR_CODE_INVALID},
+		{"kind=ean13&content=abc", http.StatusBadRequest, "", e.ERROR_CODE_INVALID},
+		{"kind=qr&content=hello&width=10", http.StatusBadRequest, "", e.ERROR_CODE_INVALID},
+		{"kind=qr&content=hello&format=bmp", http.StatusBadRequest, "", e.ERROR_CODE_INVALID},
+		{"kind=qr&content=hello&quiet=-1", http.StatusBadRequest, "", e.INVALID_PARAMS},
+		{"kind=qr&content=hello&quiet=x", http.StatusBadRequest, "", e.INVALID_PARAMS},
+		{"kind=qr&content=hello&logo=../conf/app.ini", http.StatusBadRequest, "", e.INVALID_PARAMS},
+		{"kind=qr&content=hello&logo=missing.png", http.StatusOK, "", e.ERROR_NOT_EXIST_IMAGE},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/codes?"+tt.query, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d: %s", tt.query, w.Code, tt.status, w.Body)
+			continue
+		}
+		if tt.contentType != "" {
+			if got := w.Header().Get("Content-Type"); got != tt.contentType || w.Body.Len() == 0 {
+				t.Errorf("%s: Content-Type = %q, body %d bytes", tt.query, got, w.Body.Len())
+			}
+			continue
+		}
+		if want := `"code":` + strconv.Itoa(tt.code); !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s: body = %s, want code %d", tt.query, w.Body, tt.code)
+		}
+	}
+}
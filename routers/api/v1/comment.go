@@ -0,0 +1,306 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	casbinMiddleware "github.com/EDDYCJY/go-gin-example/middleware/casbin"
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/auth_service"
+	"github.com/EDDYCJY/go-gin-example/service/comment_service"
+)
+
+// @Summary Get the approved comments of an article as threads
+// @Produce  json
+// @Param id path int true "ID"
+// @Param page query int false "Page"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/comments [get]
+func GetArticleComments(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	commentService := comment_service.Comment{
+		ArticleID: id,
+		PageNum:   util.GetPage(c),
+		PageSize:  setting.AppSetting.PageSize,
+	}
+
+	total, err := commentService.CountThreads()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_COMMENTS_FAIL, nil)
+		return
+	}
+
+	comments, err := commentService.GetThreads()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_COMMENTS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": comments,
+		"total": total,
+	})
+}
+
+type AddCommentForm struct {
+	ArticleID int    `form:"article_id" valid:"Required;Min(1)"`
+	ParentID  int    `form:"parent_id" valid:"Min(0)"`
+	Content   string `form:"content" valid:"Required;MaxSize(2000)"`
+}
+
+// @Summary Add comment
+// @Produce  json
+// @Param article_id body int true "ArticleID"
+// @Param parent_id body int false "ParentID, the comment being replied to"
+// @Param content body string true "Content"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/comments [post]
+func AddComment(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form AddCommentForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	username, err := currentUsername(c)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_COMMENT_FAIL, nil)
+		return
+	}
+	content := strings.TrimSpace(form.Content)
+	if username == "" || content == "" {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	commentService := comment_service.Comment{
+		ArticleID: form.ArticleID,
+		ParentID:  form.ParentID,
+		Content:   content,
+		CreatedBy: username,
+	}
+	comment, err := commentService.Add()
+	switch err {
+	case nil:
+	case comment_service.ErrArticleNotOpen:
+		appG.Response(http.StatusOK, e.ERROR_COMMENT_ARTICLE_NOT_OPEN, nil)
+		return
+	case comment_service.ErrParentNotFound:
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_COMMENT, nil)
+		return
+	default:
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_COMMENT_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, comment)
+}
+
+// @Summary Get the comment moderation queue
+// @Produce  json
+// @Param status query string false "pending (default) / approved / spam"
+// @Param article_id query int false "ArticleID"
+// @Param page query int false "Page"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/comments [get]
+func GetComments(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+
+	status := c.DefaultQuery("status", models.CommentStatusPending)
+	articleId := 0
+	if arg := c.Query("article_id"); arg != "" {
+		articleId = com.StrTo(arg).MustInt()
+		valid.Min(articleId, 1, "article_id")
+	}
+
+	if valid.HasErrors() || !comment_service.ValidStatus(status) {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	if !checkCommentModerator(&appG) {
+		return
+	}
+
+	commentService := comment_service.Comment{
+		ArticleID: articleId,
+		Status:    status,
+		PageNum:   util.GetPage(c),
+		PageSize:  setting.AppSetting.PageSize,
+	}
+
+	total, err := commentService.CountQueue()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_COMMENTS_FAIL, nil)
+		return
+	}
+
+	comments, err := commentService.GetQueue()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_COMMENTS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": comments,
+		"total": total,
+	})
+}
+
+type ModerateCommentsForm struct {
+	IDs    string `form:"ids" valid:"Required;MaxSize(1000)"`
+	Status string `form:"status" valid:"Required;MaxSize(20)"`
+}
+
+// @Summary Moderate comments
+// @Produce  json
+// @Param ids body string true "Comment IDs separated by commas"
+// @Param status body string true "approved / spam / pending"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/comments/moderate [put]
+func ModerateComments(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form ModerateCommentsForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	ids, err := util.ParseIDs(form.IDs)
+	if err != nil || len(ids) == 0 || !comment_service.ValidStatus(form.Status) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	moderatedBy, err := currentUsername(c)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_MODERATE_COMMENT_FAIL, nil)
+		return
+	}
+
+	err = comment_service.Moderate(subject, ids, form.Status, moderatedBy)
+	if !respondCommentError(&appG, err, e.ERROR_MODERATE_COMMENT_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Delete comment
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/comments/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	commentService := comment_service.Comment{ID: id}
+	comment, err := commentService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_COMMENTS_FAIL, nil)
+		return
+	}
+	if comment == nil {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_COMMENT, nil)
+		return
+	}
+
+	err = commentService.Delete(subject)
+	if !respondCommentError(&appG, err, e.ERROR_DELETE_COMMENT_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// checkCommentModerator 当前用户没有审核权限时输出错误响应并返回 false
+func checkCommentModerator(appG *app.Gin) bool {
+	subject, err := casbinMiddleware.Subject(appG.C)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return false
+	}
+
+	return respondCommentError(appG, comment_service.Authorize(subject), e.ERROR_GET_COMMENTS_FAIL)
+}
+
+// respondCommentError 把评论相关的错误转换为响应，err 为 nil 时返回 true
+func respondCommentError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case comment_service.ErrForbidden:
+		appG.Response(http.StatusForbidden, e.ERROR_COMMENT_FORBIDDEN, nil)
+	default:
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
+
+// currentUsername 当前登录用户的用户名，未登录或用户不存在时返回空字符串；
+// token 中的用户名是 MD5 摘要，需要按用户 ID 查询
+func currentUsername(c *gin.Context) (string, error) {
+	claims := jwt.GetClaims(c)
+	if claims == nil || claims.UserID == 0 {
+		return "", nil
+	}
+
+	auth := auth_service.Auth{ID: claims.UserID}
+	return auth.GetUsername()
+}
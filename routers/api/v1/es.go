@@ -1,9 +1,6 @@
 package v1
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -12,59 +9,25 @@ import (
 
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
 	"github.com/EDDYCJY/go-gin-example/pkg/es"
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/gin-gonic/gin"
 )
 
+type EsArticle struct {
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Author      string    `json:"author"`
+	Tags        []string  `json:"tags"`
+	PublishedAt time.Time `json:"published_at"`
+	Views       int       `json:"views"`
+}
+
 func AddEsData(c *gin.Context) {
 	appG := app.Gin{C: c}
 	index := "articles"
 
-	// 1. 创建复杂索引（带字段类型定义）
-	mapping := map[string]interface{}{
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"title": map[string]interface{}{
-					"type": "text",
-				},
-				"content": map[string]interface{}{
-					"type": "text",
-				},
-				"author": map[string]interface{}{
-					"type": "keyword",
-				},
-				"tags": map[string]interface{}{
-					"type": "keyword",
-				},
-				"published_at": map[string]interface{}{
-					"type": "date",
-				},
-				"views": map[string]interface{}{
-					"type": "integer",
-				},
-			},
-		},
-	}
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(mapping); err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "failed to encode mapping")
-		return
-	}
-
-	req := esapi.IndicesCreateRequest{
-		Index: index,
-		Body:  &buf,
-	}
-	res, err := req.Do(context.Background(), es.ESClient)
-	if err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "failed to create index")
-		return
-	}
-	defer res.Body.Close()
-
-	if res.IsError() {
-		appG.Response(http.StatusInternalServerError, -1, "index already exists or error occurred")
+	// 1. 按 conf/es 下的映射定义确保索引存在
+	if _, err := es.EnsureIndex(index); err != nil {
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
 		return
 	}
 
@@ -77,6 +40,12 @@ func AddEsData(c *gin.Context) {
 		{"news", "world"},
 	}
 
+	bulk, err := es.NewBulkIndexer(es.BulkOptions{Index: index, Refresh: "wait_for"})
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
+		return
+	}
+
 	for i := 1; i <= 100; i++ {
 		doc := map[string]interface{}{
 			"title":        fmt.Sprintf("第 %d 篇文章", i),
@@ -87,13 +56,22 @@ func AddEsData(c *gin.Context) {
 			"views":        rand.Intn(10000),
 		}
 
-		_, err := es.Index(index, "", doc)
-		if err != nil {
+		if err := bulk.Index("", "", doc); err != nil {
 			appG.Response(http.StatusInternalServerError, -1, fmt.Sprintf("failed to insert doc %d: %v", i, err))
 			return
 		}
 	}
 
+	result, err := bulk.Close()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
+		return
+	}
+	if result.Failed > 0 {
+		appG.Response(http.StatusInternalServerError, -1, result)
+		return
+	}
+
 	appG.Response(http.StatusOK, 200, "索引创建并插入 100 条数据成功")
 }
 
@@ -109,64 +87,29 @@ func GetEsData(c *gin.Context) {
 		page = 1
 	}
 	pageSize := 10
-	from := (page - 1) * pageSize
 
 	// 构造查询
-	must := []map[string]interface{}{}
-	filter := []map[string]interface{}{}
-
+	query := es.Bool()
 	if keyword != "" {
-		must = append(must, map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":  keyword,
-				"fields": []string{"title", "author", "content"},
-			},
-		})
+		query.Must(es.MultiMatch(keyword, "title", "author", "content"))
 	}
-
 	if author != "" {
-		filter = append(filter, map[string]interface{}{
-			"term": map[string]interface{}{
-				"author": author,
-			},
-		})
+		query.Filter(es.Term("author", author))
 	}
-
 	if tag != "" {
-		filter = append(filter, map[string]interface{}{
-			"term": map[string]interface{}{
-				"tags": tag,
-			},
-		})
+		query.Filter(es.Term("tags", tag))
 	}
 
 	// 时间范围：过去 180 天
-	filter = append(filter, map[string]interface{}{
-		"range": map[string]interface{}{
-			"published_at": map[string]interface{}{
-				"gte": time.Now().AddDate(0, 0, -180).Format(time.RFC3339),
-			},
-		},
-	})
-
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must":   must,
-				"filter": filter,
-			},
-		},
-		"sort": []map[string]interface{}{
-			{"views": map[string]string{"order": "desc"}},
-		},
-		"highlight": map[string]interface{}{
-			"fields": map[string]interface{}{
-				"title": map[string]interface{}{},
-			},
-		},
-	}
+	query.Filter(es.Range("published_at").Gte(time.Now().AddDate(0, 0, -180).Format(time.RFC3339)))
+
+	search := es.NewSearch().
+		Query(query).
+		Page(page, pageSize).
+		Sort("views", false).
+		Highlight(es.NewHighlight("title"))
 
-	results, err := es.Search("articles", query, from, pageSize)
+	results, err := es.Search[EsArticle]("articles", search)
 	if err != nil {
 		appG.Response(http.StatusInternalServerError, -1, err.Error())
 		return
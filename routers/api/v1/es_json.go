@@ -1,18 +1,17 @@
 package v1
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
 	"github.com/EDDYCJY/go-gin-example/pkg/es"
+	"github.com/EDDYCJY/go-gin-example/service/product_service"
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,63 +19,41 @@ func AddEsJsonData(c *gin.Context) {
 	appG := app.Gin{C: c}
 	index := "products"
 
-	// 1. 创建索引并定义 mapping
-	mapping := map[string]interface{}{
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"title":    map[string]interface{}{"type": "text"},
-				"category": map[string]interface{}{"type": "keyword"},
-				"price":    map[string]interface{}{"type": "float"},
-				"brand": map[string]interface{}{
-					"properties": map[string]interface{}{
-						"name":   map[string]interface{}{"type": "keyword"},
-						"origin": map[string]interface{}{"type": "keyword"},
-					},
-				},
-				"tags": map[string]interface{}{"type": "keyword"},
-				"comments": map[string]interface{}{ // 不用 nested，仅作为对象数组
-					"type": "object",
-				},
-				"attributes": map[string]interface{}{ // 同上
-					"type": "object",
-				},
-			},
-		},
-	}
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(mapping); err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "编码 mapping 失败")
+	// 1. 按 conf/es 下的映射定义确保索引存在
+	if _, err := es.EnsureIndex(index); err != nil {
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
 		return
 	}
 
-	req := esapi.IndicesCreateRequest{
-		Index: index,
-		Body:  &buf,
-	}
-	res, err := req.Do(context.Background(), es.ESClient)
+	// 2. 插入 10 条模拟商品数据
+	bulk, err := es.NewBulkIndexer(es.BulkOptions{Index: index, Refresh: "wait_for"})
 	if err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "创建索引失败")
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
 		return
 	}
-	defer res.Body.Close()
 
-	if res.IsError() {
-		appG.Response(http.StatusInternalServerError, -1, "索引已存在或创建失败")
-		return
+	brands := []product_service.Brand{
+		{Name: "测试品牌", Origin: "中国"},
+		{Name: "Apple", Origin: "美国"},
+		{Name: "Samsung", Origin: "韩国"},
 	}
+	categories := []string{"手机", "平板"}
+	colors := []string{"黑色", "白色", "蓝色"}
+	storages := []string{"128GB", "256GB"}
 
-	// 2. 插入 10 条模拟商品数据
 	for i := 1; i <= 10; i++ {
+		brand := brands[i%len(brands)]
+		category := categories[i%len(categories)]
+		title := fmt.Sprintf("测试%s %d", category, i)
+		sku := fmt.Sprintf("PHN-%04d-BK", i)
 		doc := map[string]interface{}{
-			"title":    fmt.Sprintf("测试手机 %d", i),
-			"category": "手机",
+			"sku":      sku,
+			"title":    title,
+			"suggest":  map[string]interface{}{"input": []string{title, sku}}, // 上下文取自 brand.name / category
+			"category": category,
 			"price":    rand.Float64()*3000 + 1000,
-			"brand": map[string]interface{}{
-				"name":   "测试品牌",
-				"origin": "中国",
-			},
-			"tags": []string{"新品", "热销", "5G"},
+			"brand":    brand,
+			"tags":     []string{"新品", "热销", "5G"},
 			"comments": []map[string]interface{}{
 				{
 					"user":         fmt.Sprintf("user_%02d", i),
@@ -85,96 +62,52 @@ func AddEsJsonData(c *gin.Context) {
 					"comment_date": time.Now().AddDate(0, 0, -rand.Intn(30)).Format("2006-01-02"),
 				},
 			},
-			"attributes": []map[string]interface{}{
-				{"name": "颜色", "value": "黑色"},
-				{"name": "存储", "value": "128GB"},
+			"attributes": []product_service.Attribute{
+				{Name: "颜色", Value: colors[i%len(colors)]},
+				{Name: "存储", Value: storages[i%len(storages)]},
 			},
+			"created_at": time.Now().AddDate(0, 0, -i),
 		}
 
-		_, err := es.Index(index, "", doc)
-		if err != nil {
+		if err := bulk.Index("", "", doc); err != nil {
 			appG.Response(http.StatusInternalServerError, -1, fmt.Sprintf("插入文档 %d 失败: %v", i, err))
 			return
 		}
 	}
 
+	result, err := bulk.Close()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, err.Error())
+		return
+	}
+	if result.Failed > 0 {
+		appG.Response(http.StatusInternalServerError, -1, result)
+		return
+	}
+
 	appG.Response(http.StatusOK, 200, "创建 products 索引并成功插入 10 条商品数据")
 }
 
-type Product struct {
-	Title      string                   `json:"title"`
-	Category   string                   `json:"category"`
-	Price      float64                  `json:"price"`
-	Brand      map[string]string        `json:"brand"`
-	Tags       []string                 `json:"tags"`
-	Comments   []map[string]interface{} `json:"comments"`
-	Attributes []map[string]interface{} `json:"attributes"`
-}
+type Product = product_service.Product
 
 func SearchProductByKeyword(c *gin.Context) {
 	appG := app.Gin{C: c}
 	keyword := c.Query("q")
 
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":  keyword,
-				"fields": []string{"title", "tags"},
-			},
-		},
-	}
+	search := es.NewSearch().Query(es.MultiMatch(keyword, "title", "tags", "sku"))
+	withDidYouMean(search, keyword)
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		appG.Response(http.StatusBadRequest, -1, "查询构造失败")
-		return
-	}
-
-	req := esapi.SearchRequest{
-		Index: []string{"products"},
-		Body:  &buf,
-	}
-	res, err := req.Do(context.Background(), es.ESClient)
-	if err != nil || res.IsError() {
+	result, err := es.Search[Product]("products", search)
+	if err != nil {
 		appG.Response(http.StatusInternalServerError, -1, "搜索请求失败")
 		return
 	}
-	defer res.Body.Close()
 
-	// 原始 JSON -> 泛型 map
-	var raw map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "结果解析失败")
-		return
-	}
-
-	// 提取 hits
-	hits := raw["hits"].(map[string]interface{})["hits"].([]interface{})
-	var results []Product
-
-	for _, hit := range hits {
-		source := hit.(map[string]interface{})["_source"]
-		sourceBytes, _ := json.Marshal(source)
-
-		var p Product
-		if err := json.Unmarshal(sourceBytes, &p); err == nil {
-			results = append(results, p)
-		}
-	}
-
-	appG.Response(http.StatusOK, 200, results)
-}
-
-type Product2 struct {
-	ID         string                   `json:"id"`
-	Title      string                   `json:"title"`
-	Category   string                   `json:"category"`
-	Price      float64                  `json:"price"`
-	Brand      map[string]string        `json:"brand"`
-	Tags       []string                 `json:"tags"`
-	Comments   []map[string]interface{} `json:"comments"`
-	Attributes []map[string]interface{} `json:"attributes"`
-	Highlight  map[string][]string      `json:"highlight,omitempty"`
+	appG.Response(http.StatusOK, 200, gin.H{
+		"list":         result.Sources(),
+		"total":        result.Total,
+		"did_you_mean": didYouMean(result, keyword),
+	})
 }
 
 func SearchProductWithHighlight(c *gin.Context) {
@@ -186,148 +119,291 @@ func SearchProductWithHighlight(c *gin.Context) {
 	if page <= 0 {
 		page = 1
 	}
-	from := (page - 1) * size
 
 	// 构造带高亮的查询
-	query := map[string]interface{}{
-		"from": from,
-		"size": size,
-		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":  keyword,
-				"fields": []string{"title", "tags"},
-			},
-		},
-		"highlight": map[string]interface{}{
-			"fields": map[string]interface{}{
-				"title": map[string]interface{}{},
-				"tags":  map[string]interface{}{},
-			},
-			"pre_tags":  []string{"<em>"},
-			"post_tags": []string{"</em>"},
-		},
-	}
+	search := es.NewSearch().
+		Query(es.MultiMatch(keyword, "title", "tags", "sku")).
+		Page(page, size).
+		Highlight(es.NewHighlight("title", "tags"))
+	withDidYouMean(search, keyword)
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		appG.Response(http.StatusBadRequest, -1, "查询构造失败")
+	result, err := es.Search[Product]("products", search)
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "搜索请求失败")
 		return
 	}
 
-	req := esapi.SearchRequest{
-		Index: []string{"products"},
-		Body:  &buf,
-	}
-	res, err := req.Do(context.Background(), es.ESClient)
-	if err != nil || res.IsError() {
-		appG.Response(http.StatusInternalServerError, -1, "搜索请求失败")
+	appG.Response(http.StatusOK, 200, gin.H{
+		"list":         result.Hits,
+		"total":        result.Total,
+		"max_score":    result.MaxScore,
+		"page":         page,
+		"size":         size,
+		"did_you_mean": didYouMean(result, keyword),
+	})
+}
+
+func SearchByBrandOrigin(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	query := es.Bool().Must(
+		es.Match("brand.name", "测试品牌"),
+		es.Match("brand.origin", "中国"),
+	)
+
+	result, err := es.Search[Product]("products", es.NewSearch().Query(query))
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "品牌查询失败")
 		return
 	}
-	defer res.Body.Close()
 
-	var raw map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
-		appG.Response(http.StatusInternalServerError, -1, "结果解析失败")
+	appG.Response(http.StatusOK, 200, result)
+}
+
+func FilterProductByPrice(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	query := es.Range("price").Gte(2000).Lte(4000)
+
+	result, err := es.Search[Product]("products", es.NewSearch().Query(query))
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "区间查询失败")
 		return
 	}
 
-	hits := raw["hits"].(map[string]interface{})["hits"].([]interface{})
-	var results []Product2
+	appG.Response(http.StatusOK, 200, result)
+}
+
+// withDidYouMean 在搜索请求中附带纠错 suggester，没有命中时用于给出建议
+func withDidYouMean(search *es.SearchSource, keyword string) {
+	if keyword == "" {
+		return
+	}
 
-	for _, hit := range hits {
-		hitMap := hit.(map[string]interface{})
-		source := hitMap["_source"]
-		sourceBytes, _ := json.Marshal(source)
+	search.Suggest("phrase_fix", es.PhraseSuggest(keyword, "title.shingle").
+		Size(3).
+		GramSize(3).
+		DirectGenerator("title.shingle", "always").
+		Highlight("<em>", "</em>").
+		Collate(map[string]interface{}{"match": map[string]interface{}{"title": "{{suggestion}}"}}))
+	search.Suggest("term_fix", es.TermSuggest(keyword, "title.std").SuggestMode("missing").Size(1))
+	search.Suggest("sku_fix", es.TermSuggest(keyword, "sku").SuggestMode("missing").Size(1))
+}
 
-		var p Product2
-		if err := json.Unmarshal(sourceBytes, &p); err == nil {
-			p.ID = hitMap["_id"].(string)
+// didYouMean 没有命中时返回纠正后的查询词，优先使用 phrase suggester 的结果
+func didYouMean(result *es.SearchResult[Product], keyword string) []string {
+	if result.Total > 0 || keyword == "" {
+		return nil
+	}
 
-			if hl, ok := hitMap["highlight"]; ok {
-				p.Highlight = map[string][]string{}
-				for field, val := range hl.(map[string]interface{}) {
-					strs := []string{}
-					for _, v := range val.([]interface{}) {
-						strs = append(strs, v.(string))
-					}
-					p.Highlight[field] = strs
-				}
-			}
+	var queries []string
+	if options, err := result.Suggest.Options("phrase_fix"); err == nil {
+		for _, option := range options {
+			queries = append(queries, option.Text)
+		}
+	}
+	if len(queries) > 0 {
+		return queries
+	}
 
-			results = append(results, p)
+	for _, name := range []string{"term_fix", "sku_fix"} {
+		if q := result.Suggest.Corrected(name, keyword); q != "" {
+			queries = append(queries, q)
 		}
 	}
 
-	appG.Response(http.StatusOK, 200, gin.H{
-		"list":  results,
-		"total": raw["hits"].(map[string]interface{})["total"],
-		"page":  page,
-		"size":  size,
-	})
+	return queries
 }
 
-func SearchByBrandOrigin(c *gin.Context) {
+// ProductSuggestion 一条商品补全
+type ProductSuggestion struct {
+	Text     string  `json:"text"`
+	ID       string  `json:"id"`
+	Sku      string  `json:"sku"`
+	Title    string  `json:"title"`
+	Brand    string  `json:"brand"`
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+}
+
+// SuggestProducts 输入框自动补全，按 brand / category 上下文过滤
+// completion 只能匹配前缀，数量不足时再用 search_as_you_type 字段补充 SKU / 标题中间部分的匹配
+func SuggestProducts(c *gin.Context) {
 	appG := app.Gin{C: c}
+	keyword := strings.TrimSpace(c.Query("q"))
+	brand := c.Query("brand")
+	category := c.Query("category")
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
+	if size <= 0 || size > 20 {
+		size = 5
+	}
 
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": []map[string]interface{}{
-					{"match": map[string]interface{}{"brand.name": "测试品牌"}},
-					{"match": map[string]interface{}{"brand.origin": "中国"}},
-				},
-			},
-		},
+	if keyword == "" {
+		appG.Response(http.StatusOK, 200, []*ProductSuggestion{})
+		return
 	}
 
-	var buf bytes.Buffer
-	_ = json.NewEncoder(&buf).Encode(query)
+	completion := es.Completion(keyword, "suggest").Size(size).SkipDuplicates().Fuzzy("AUTO")
+	if brand != "" {
+		completion.Context("brand", brand)
+	}
+	if category != "" {
+		completion.Context("category", category)
+	}
 
-	res, err := esapi.SearchRequest{
-		Index: []string{"products"},
-		Body:  &buf,
-	}.Do(context.Background(), es.ESClient)
-	if err != nil || res.IsError() {
-		appG.Response(http.StatusInternalServerError, -1, "品牌查询失败")
+	suggest, err := es.Suggest("products", map[string]es.Suggester{"products": completion})
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "补全请求失败")
+		return
+	}
+	options, err := suggest.Options("products")
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "补全请求失败")
 		return
 	}
-	defer res.Body.Close()
 
-	var result map[string]interface{}
-	_ = json.NewDecoder(res.Body).Decode(&result)
+	seen := map[string]bool{}
+	suggestions := make([]*ProductSuggestion, 0, size)
+	for _, option := range options {
+		var product Product
+		_ = json.Unmarshal(option.Source, &product)
+		seen[option.ID] = true
+		suggestions = append(suggestions, newProductSuggestion(option.Text, option.ID, option.Score, &product))
+	}
 
-	appG.Response(http.StatusOK, 200, result)
+	if len(suggestions) < size {
+		query := es.Bool().Must(es.MultiMatch(keyword,
+			"sku.sayt", "sku.sayt._2gram", "sku.sayt._3gram",
+			"title.sayt", "title.sayt._2gram", "title.sayt._3gram",
+		).Type("bool_prefix"))
+		if brand != "" {
+			query.Filter(es.Term("brand.name", brand))
+		}
+		if category != "" {
+			query.Filter(es.Term("category", category))
+		}
+
+		result, err := es.Search[Product]("products", es.NewSearch().Query(query).Size(size*2))
+		if err != nil {
+			appG.Response(http.StatusInternalServerError, -1, "补全请求失败")
+			return
+		}
+		for i := range result.Hits {
+			hit := &result.Hits[i]
+			if seen[hit.ID] || len(suggestions) >= size {
+				continue
+			}
+			text := hit.Source.Title
+			if strings.Contains(strings.ToLower(hit.Source.Sku), strings.ToLower(keyword)) {
+				text = hit.Source.Sku
+			}
+			suggestions = append(suggestions, newProductSuggestion(text, hit.ID, hit.Score, &hit.Source))
+		}
+	}
+
+	appG.Response(http.StatusOK, 200, suggestions)
 }
 
-func FilterProductByPrice(c *gin.Context) {
+func newProductSuggestion(text, id string, score float64, product *Product) *ProductSuggestion {
+	return &ProductSuggestion{
+		Text:     text,
+		ID:       id,
+		Sku:      product.Sku,
+		Title:    product.Title,
+		Brand:    product.Brand.Name,
+		Category: product.Category,
+		Score:    score,
+	}
+}
+
+// SearchProducts 商品分面搜索，返回结果和侧边栏过滤项
+// 多选参数可重复传入或用逗号分隔，例如 brand=Apple,Samsung；属性过滤为 attr=颜色:黑色
+func SearchProducts(c *gin.Context) {
 	appG := app.Gin{C: c}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if size <= 0 || size > 100 {
+		size = 10
+	}
 
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"range": map[string]interface{}{
-				"price": map[string]interface{}{
-					"gte": 2000,
-					"lte": 4000,
-				},
-			},
-		},
+	search := product_service.ProductSearch{
+		Keyword:    strings.TrimSpace(c.Query("q")),
+		Categories: queryValues(c, "category"),
+		Brands:     queryValues(c, "brand"),
+		Origins:    queryValues(c, "origin"),
+		Tags:       queryValues(c, "tag"),
+		Sort:       c.DefaultQuery("sort", product_service.SortRelevance),
+		Page:       page,
+		PageSize:   size,
 	}
 
-	var buf bytes.Buffer
-	_ = json.NewEncoder(&buf).Encode(query)
+	switch search.Sort {
+	case product_service.SortRelevance, product_service.SortPriceAsc, product_service.SortPriceDesc, product_service.SortNewest:
+	default:
+		appG.Response(http.StatusBadRequest, -1, "不支持的排序方式: "+search.Sort)
+		return
+	}
 
-	res, err := esapi.SearchRequest{
-		Index: []string{"products"},
-		Body:  &buf,
-	}.Do(context.Background(), es.ESClient)
-	if err != nil || res.IsError() {
-		appG.Response(http.StatusInternalServerError, -1, "区间查询失败")
+	for _, p := range []struct {
+		name   string
+		target **float64
+	}{
+		{"price_min", &search.PriceMin},
+		{"price_max", &search.PriceMax},
+	} {
+		arg := c.Query(p.name)
+		if arg == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			appG.Response(http.StatusBadRequest, -1, p.name+" 必须是数字")
+			return
+		}
+		*p.target = &v
+	}
+
+	if arg := c.Query("price_interval"); arg != "" {
+		search.PriceInterval, _ = strconv.ParseFloat(arg, 64)
+	}
+
+	for _, attr := range queryValues(c, "attr") {
+		name, value, ok := strings.Cut(attr, ":")
+		if !ok || name == "" || value == "" {
+			appG.Response(http.StatusBadRequest, -1, "属性过滤格式为 名称:取值")
+			return
+		}
+		if search.Attributes == nil {
+			search.Attributes = map[string][]string{}
+		}
+		search.Attributes[name] = append(search.Attributes[name], value)
+	}
+
+	result, err := search.Search()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, -1, "商品搜索失败")
 		return
 	}
-	defer res.Body.Close()
 
-	var result map[string]interface{}
-	_ = json.NewDecoder(res.Body).Decode(&result)
+	appG.Response(http.StatusOK, 200, gin.H{
+		"list":   result.Lists,
+		"total":  result.Total,
+		"facets": result.Facets,
+		"page":   page,
+		"size":   size,
+	})
+}
 
-	appG.Response(http.StatusOK, 200, result)
+// queryValues 读取可重复且支持逗号分隔的查询参数
+func queryValues(c *gin.Context, key string) []string {
+	var values []string
+	for _, arg := range c.QueryArray(key) {
+		for _, v := range strings.Split(arg, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
 }
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/label_service"
+	"github.com/EDDYCJY/go-gin-example/service/stock_service"
+)
+
+type StockLabelsForm struct {
+	DetailIDs string `form:"detail_ids" valid:"MaxSize(20000)"`
+	ProductID int    `form:"product_id" valid:"Min(0)"`
+	Sheet     string `form:"sheet" valid:"MaxSize(50)"`
+	Kind      string `form:"kind" valid:"MaxSize(20)"`
+	Field     string `form:"field" valid:"MaxSize(20)"`
+}
+
+// @Summary 打印产品明细标签
+// @Tags 产品明细管理
+// @Produce application/pdf
+// @Param detail_ids body string false "明细ID，逗号分隔，与 product_id 二选一"
+// @Param product_id body int false "产品ID，打印该产品的全部明细"
+// @Param sheet body string false "标签纸，见 [label] Sheets，默认 DefaultSheet"
+// @Param kind body string false "qr / code128 / ean13 / datamatrix，默认 code128"
+// @Param field body string false "code / hidden_code，默认 code"
+// @Success 200 {string} string "PDF"
+// @Failure 400 {object} app.Response
+// @Router /api/v1/stock/labels [post]
+func GenerateStockLabels(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form StockLabelsForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+	ids, err := util.ParseIDs(form.DetailIDs)
+	if err != nil || len(ids) == 0 && form.ProductID == 0 || len(ids) > 0 && form.ProductID > 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	if form.ProductID > 0 {
+		exists, err := stock_service.ExistStockProductByID(form.ProductID)
+		if err != nil {
+			appG.Response(http.StatusInternalServerError, e.ERROR, nil)
+			return
+		}
+		if !exists {
+			appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST, nil)
+			return
+		}
+	}
+
+	labels := label_service.Labels{
+		DetailIDs: ids,
+		ProductID: form.ProductID,
+		Sheet:     form.Sheet,
+		Kind:      form.Kind,
+		Field:     form.Field,
+	}
+	doc, err := labels.PDF()
+	switch err {
+	case nil:
+	case label_service.ErrInvalid:
+		appG.Response(http.StatusBadRequest, e.ERROR_LABEL_INVALID, nil)
+		return
+	case label_service.ErrTooSmall:
+		appG.Response(http.StatusBadRequest, e.ERROR_LABEL_TOO_SMALL, nil)
+		return
+	case label_service.ErrNoDetails:
+		appG.Response(http.StatusOK, e.ERROR_LABEL_NO_DETAILS, nil)
+		return
+	case label_service.ErrTooMany:
+		appG.Response(http.StatusBadRequest, e.ERROR_LABEL_TOO_MANY, nil)
+		return
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GEN_LABEL_FAIL, nil)
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GEN_LABEL_FAIL, nil)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="labels.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -31,16 +31,10 @@ func AddEmails(c *gin.Context) {
 	}
 
 	// 创建 ES 索引
-	esDoc := map[string]interface{}{
-		"user_id": id,
-		"subject": form.Subject,
-		"body":    form.Body,
-		"status":  form.Status,
-	}
-
+	email.ID = id
 	docID := strconv.Itoa(id)
 
-	if _, err := es.Index("email_index", docID, esDoc); err != nil {
+	if _, err := es.Index("email_index", docID, emailEsDoc(email)); err != nil {
 		// 索引失败不阻断主流程，但记录日志
 		log.Printf("failed to index email to ES: %v", err)
 	}
@@ -74,7 +68,7 @@ func UpdateEmail(c *gin.Context) {
 	esDocID := strconv.Itoa(email.ID) // 用 ID 作为 Elasticsearch 的文档 ID
 	index := "email_index"            // 确保这是你在 ES 中用的索引名
 
-	_, err = es.Index(index, esDocID, email)
+	_, err = es.Index(index, esDocID, emailEsDoc(email))
 	if err != nil {
 		// ES 更新失败，不影响主流程，但可以记录日志或设置告警
 		log.Printf("failed to update email in ES, ID=%d: %v", email.ID, err)
@@ -83,6 +77,17 @@ func UpdateEmail(c *gin.Context) {
 	appG.Response(http.StatusOK, e.SUCCESS, nil)
 }
 
+// emailEsDoc 构造与 conf/es/email_index.json 映射一致的文档
+func emailEsDoc(email rabbitmq_service.Email) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      email.ID,
+		"user_id": email.UserID,
+		"subject": email.Subject,
+		"body":    email.Body,
+		"status":  email.Status,
+	}
+}
+
 // Search 执行带分页的查询
 func GetEmails(c *gin.Context) {
 	appG := app.Gin{C: c}
@@ -90,7 +95,6 @@ func GetEmails(c *gin.Context) {
 	// 获取分页参数
 	pageNum, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	from := (pageNum - 1) * pageSize
 
 	// 其他查询参数
 	userIDStr := c.Query("user_id")
@@ -98,45 +102,25 @@ func GetEmails(c *gin.Context) {
 	keyword := c.Query("q")
 
 	// 构造 Elasticsearch 查询
-	boolQuery := map[string]interface{}{
-		"must": []interface{}{},
-	}
-
-	// 添加条件
+	query := es.Bool()
 	if userIDStr != "" {
 		if userID, err := strconv.Atoi(userIDStr); err == nil {
-			boolQuery["must"] = append(boolQuery["must"].([]interface{}), map[string]interface{}{
-				"term": map[string]interface{}{"user_id": userID},
-			})
+			query.Must(es.Term("user_id", userID))
 		}
 	}
 	if status != "" {
-		boolQuery["must"] = append(boolQuery["must"].([]interface{}), map[string]interface{}{
-			"term": map[string]interface{}{"status": status},
-		})
+		query.Must(es.Term("status", status))
 	}
 	if keyword != "" {
-		boolQuery["must"] = append(boolQuery["must"].([]interface{}), map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":  keyword,
-				"fields": []string{"subject", "body"},
-			},
-		})
-	}
-
-	// 完整 query 对象
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"bool": boolQuery,
-		},
+		query.Must(es.MultiMatch(keyword, "subject", "body"))
 	}
 
 	// 调用封装的 Search 方法
-	results, err := es.Search("email_index", query, from, pageSize)
+	result, err := es.Search[map[string]interface{}]("email_index", es.NewSearch().Query(query).Page(pageNum, pageSize))
 	if err != nil {
 		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
 		return
 	}
 
-	appG.Response(http.StatusOK, e.SUCCESS, results)
+	appG.Response(http.StatusOK, e.SUCCESS, result.Sources())
 }
@@ -70,6 +70,10 @@ func AddOrder(c *gin.Context) {
 
 	order := order_service.ConvertAddFormToOrder(form)
 	if err := order.Add(); err != nil {
+		if err == order_service.ErrOrderExists {
+			appG.Response(http.StatusBadRequest, e.ERROR_EXIST, nil)
+			return
+		}
 		appG.Response(http.StatusInternalServerError, e.ERROR_EDIT_ORDER_FAIL, nil)
 		return
 	}
@@ -0,0 +1,251 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	casbinMiddleware "github.com/EDDYCJY/go-gin-example/middleware/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
+	"github.com/EDDYCJY/go-gin-example/service/poster_service"
+)
+
+type PosterTemplateForm struct {
+	Name       string           `json:"name" valid:"Required;MaxSize(100)"`
+	Definition *poster.Template `json:"definition"`
+}
+
+type PreviewArticlePosterForm struct {
+	ArticleID  int              `json:"article_id"`
+	Template   string           `json:"template"`
+	Definition *poster.Template `json:"definition"`
+}
+
+// @Summary Get poster templates
+// @Produce  json
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/templates [get]
+func GetPosterTemplates(c *gin.Context) {
+	appG := app.Gin{C: c}
+	templates, err := poster_service.GetAll()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_POSTER_TEMPLATES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists":   templates,
+		"default": poster_service.Default(),
+	})
+}
+
+// @Summary Get a single poster template
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/templates/{id} [get]
+func GetPosterTemplate(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	templateService := poster_service.Template{ID: id}
+	template, err := templateService.Get()
+	if !respondPosterTemplateError(&appG, err, e.ERROR_GET_POSTER_TEMPLATES_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, template)
+}
+
+// @Summary Add poster template
+// @Accept  json
+// @Produce  json
+// @Param name body string true "Name"
+// @Param definition body string true "Template definition: size, background, format and layers"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/templates [post]
+func AddPosterTemplate(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form PosterTemplateForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	templateService := poster_service.Template{
+		Name:       form.Name,
+		Definition: form.Definition,
+		CreatedBy:  subject,
+	}
+	err = templateService.Add()
+	if !respondPosterTemplateError(&appG, err, e.ERROR_ADD_POSTER_TEMPLATE_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]int{
+		"id": templateService.ID,
+	})
+}
+
+// @Summary Update poster template
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID"
+// @Param name body string true "Name"
+// @Param definition body string true "Template definition: size, background, format and layers"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/templates/{id} [put]
+func EditPosterTemplate(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form PosterTemplateForm
+	)
+
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	subject, err := casbinMiddleware.Subject(c)
+	if err != nil {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	templateService := poster_service.Template{
+		ID:         id,
+		Name:       form.Name,
+		Definition: form.Definition,
+		ModifiedBy: subject,
+	}
+	err = templateService.Edit()
+	if !respondPosterTemplateError(&appG, err, e.ERROR_EDIT_POSTER_TEMPLATE_FAIL) {
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Delete poster template
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/templates/{id} [delete]
+func DeletePosterTemplate(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id <= 0 {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	templateService := poster_service.Template{ID: id}
+	_, err := templateService.Get()
+	if !respondPosterTemplateError(&appG, err, e.ERROR_DELETE_POSTER_TEMPLATE_FAIL) {
+		return
+	}
+
+	if err := templateService.Delete(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_POSTER_TEMPLATE_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Preview article poster, returns the image without saving it
+// @Accept  json
+// @Produce  image/jpeg
+// @Produce  image/png
+// @Param article_id body int false "Article ID"
+// @Param template body string false "Saved template name, used when definition is empty"
+// @Param definition body string false "Unsaved template definition"
+// @Success 200 {string} string "Poster image"
+// @Failure 500 {object} app.Response
+// @Router /api/v1/posters/preview [post]
+func PreviewArticlePoster(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form PreviewArticlePosterForm
+	)
+
+	httpCode, errCode := app.BindJsonAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	template, name := form.Definition, form.Template
+	var err error
+	if template != nil {
+		err = template.Normalize()
+	} else {
+		template, _, err = poster_service.Load(name)
+	}
+	if !respondPosterTemplateError(&appG, err, e.ERROR_PREVIEW_ARTICLE_POSTER_FAIL) {
+		return
+	}
+
+	articlePosterBgService, ok := newArticlePoster(&appG, form.ArticleID, name, template, 0)
+	if !ok {
+		return
+	}
+
+	data, err := articlePosterBgService.Render()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_PREVIEW_ARTICLE_POSTER_FAIL, nil)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, template.ContentType(), data)
+}
+
+// respondPosterTemplateError 把模板相关的错误转换为响应，err 为 nil 时返回 true
+func respondPosterTemplateError(appG *app.Gin, err error, failCode int) bool {
+	switch err {
+	case nil:
+		return true
+	case poster_service.ErrTemplateNotFound:
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_POSTER_TEMPLATE, nil)
+	case poster_service.ErrTemplateExists:
+		appG.Response(http.StatusOK, e.ERROR_EXIST_POSTER_TEMPLATE, nil)
+	case poster.ErrInvalidTemplate:
+		appG.Response(http.StatusBadRequest, e.ERROR_POSTER_TEMPLATE_INVALID, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, failCode, nil)
+	}
+
+	return false
+}
@@ -693,6 +693,10 @@ func CreateOrderWithNestedTransaction(c *gin.Context) {
 	}
 
 	if err := stock_service.CreateOrderWithNestedTransaction(req.OrderSN, items); err != nil {
+		if err == stock_service.ErrOrderExists {
+			appG.Response(http.StatusBadRequest, e.ERROR_EXIST, err.Error())
+			return
+		}
 		appG.Response(http.StatusInternalServerError, e.ERROR, err.Error())
 		return
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/EDDYCJY/go-gin-example/pkg/export"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 	"github.com/EDDYCJY/go-gin-example/service/tag_service"
 )
@@ -55,8 +56,59 @@ func GetTags(c *gin.Context) {
 	})
 }
 
+// @Summary Get a single article tag
+// @Produce  json
+// @Param id path string true "ID or slug"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/tags/{id} [get]
+func GetTag(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, ok := tagIDParam(&appG, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	tagService := tag_service.Tag{ID: id}
+	tag, err := tagService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_TAG_FAIL, nil)
+		return
+	}
+	if tag == nil {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_TAG, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, tag)
+}
+
+// tagIDParam 路径中的标签 ID 或 slug，slug 不存在时 ID 为 0
+func tagIDParam(appG *app.Gin, param string) (int, bool) {
+	if !slug.IsNumeric(param) {
+		id, err := tag_service.GetIDBySlug(param)
+		if err != nil {
+			appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+			return 0, false
+		}
+		return id, true
+	}
+
+	id := com.StrTo(param).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return 0, false
+	}
+
+	return id, true
+}
+
 type AddTagForm struct {
 	Name      string `json:"name" valid:"Required;MaxSize(100)"`
+	Slug      string `json:"slug" valid:"MaxSize(100)"`
 	CreatedBy string `json:"created_by" valid:"Required;MaxSize(100)"`
 	State     int    `json:"state" valid:"Range(0,1)"`
 }
@@ -64,6 +116,7 @@ type AddTagForm struct {
 // @Summary Add article tag
 // @Produce  json
 // @Param name body string true "Name"
+// @Param slug body string false "Slug, generated from the name if empty"
 // @Param state body int false "State"
 // @Param created_by body int false "CreatedBy"
 // @Success 200 {object} app.Response
@@ -81,8 +134,14 @@ func AddTag(c *gin.Context) {
 		return
 	}
 
+	if form.Slug != "" && !tag_service.ValidSlug(form.Slug) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
 	tagService := tag_service.Tag{
 		Name:      form.Name,
+		Slug:      form.Slug,
 		CreatedBy: form.CreatedBy,
 		State:     form.State,
 	}
@@ -96,6 +155,16 @@ func AddTag(c *gin.Context) {
 		return
 	}
 
+	exists, err = tagService.ExistBySlug()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+		return
+	}
+	if exists {
+		appG.Response(http.StatusOK, e.ERROR_EXIST_TAG_SLUG, nil)
+		return
+	}
+
 	err = tagService.Add()
 	if err != nil {
 		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_TAG_FAIL, nil)
@@ -108,6 +177,7 @@ func AddTag(c *gin.Context) {
 type EditTagForm struct {
 	ID         int    `form:"id" valid:"Required;Min(1)"`
 	Name       string `form:"name" valid:"Required;MaxSize(100)"`
+	Slug       string `form:"slug" valid:"MaxSize(100)"`
 	ModifiedBy string `form:"modified_by" valid:"Required;MaxSize(100)"`
 	State      int    `form:"state" valid:"Range(0,1)"`
 }
@@ -116,6 +186,7 @@ type EditTagForm struct {
 // @Produce  json
 // @Param id path int true "ID"
 // @Param name body string true "Name"
+// @Param slug body string false "Slug, regenerated from the name if empty and the name changes"
 // @Param state body int false "State"
 // @Param modified_by body string true "ModifiedBy"
 // @Success 200 {object} app.Response
@@ -133,9 +204,15 @@ func EditTag(c *gin.Context) {
 		return
 	}
 
+	if form.Slug != "" && !tag_service.ValidSlug(form.Slug) {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
 	tagService := tag_service.Tag{
 		ID:         form.ID,
 		Name:       form.Name,
+		Slug:       form.Slug,
 		ModifiedBy: form.ModifiedBy,
 		State:      form.State,
 	}
@@ -151,6 +228,16 @@ func EditTag(c *gin.Context) {
 		return
 	}
 
+	exists, err = tagService.ExistBySlug()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+		return
+	}
+	if exists {
+		appG.Response(http.StatusOK, e.ERROR_EXIST_TAG_SLUG, nil)
+		return
+	}
+
 	err = tagService.Edit()
 	if err != nil {
 		appG.Response(http.StatusInternalServerError, e.ERROR_EDIT_TAG_FAIL, nil)
@@ -223,9 +310,16 @@ func ExportTag(c *gin.Context) {
 		return
 	}
 
+	url, err := export.GetExcelFullUrl(filename)
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_EXPORT_TAG_FAIL, nil)
+		return
+	}
+
 	appG.Response(http.StatusOK, e.SUCCESS, map[string]string{
-		"export_url":      export.GetExcelFullUrl(filename),
-		"export_save_url": export.GetExcelPath() + filename,
+		"export_url":      url,
+		"export_save_url": export.GetExcelKey(filename),
 	})
 }
 
@@ -6,31 +6,69 @@ import (
 	"github.com/gin-gonic/gin"
 
 	_ "github.com/EDDYCJY/go-gin-example/docs"
+	"github.com/EDDYCJY/go-gin-example/middleware/idempotency"
 	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/middleware/ratelimit"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 
 	"github.com/EDDYCJY/go-gin-example/pkg/export"
 	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
 	"github.com/EDDYCJY/go-gin-example/pkg/upload"
 	"github.com/EDDYCJY/go-gin-example/routers/api"
 	v1 "github.com/EDDYCJY/go-gin-example/routers/api/v1"
 	v1Test "github.com/EDDYCJY/go-gin-example/routers/api/v1/test"
 )
 
+// withStatic gin 1.4 的路由树不允许静态路径与 :param 同级，
+// 由 :param 路由按参数值分发到静态路径的处理函数
+func withStatic(param string, static map[string]gin.HandlerFunc, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h, ok := static[c.Param(param)]; ok {
+			h(c)
+			return
+		}
+
+		handler(c)
+	}
+}
+
+// articleStaticRoutes 与 /api/v1/articles/:id 同级的静态路径，GET 请求由 :id 路由分发到这里；
+// 新增 /articles/xxx 形式的 GET 路由时在这里登记，文章 ID 为数字，不会与这些名字冲突
+var articleStaticRoutes = map[string]gin.HandlerFunc{
+	//搜索文章
+	"search": v1.SearchArticles,
+	//热门文章
+	"trending": v1.GetTrendingArticles,
+	//累计浏览最多的文章
+	"popular": v1.GetPopularArticles,
+}
+
 // InitRouter initialize routing information
 func InitRouter() *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
+	r.Use(ratelimit.IP())
 
 	r.StaticFS("/export", http.Dir(export.GetExcelFullPath()))
 	r.StaticFS("/upload/images", http.Dir(upload.GetImageFullPath()))
 	r.StaticFS("/qrcode", http.Dir(qrcode.GetQrCodeFullPath()))
+	r.StaticFS("/sitemap", http.Dir(sitemap.GetSitemapFullPath()))
 
-	r.POST("/auth", api.GetAuth)
+	r.POST("/auth", ratelimit.Login(), api.GetAuth)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	r.POST("/upload", api.UploadImage)
+	// 缩放后的图片，地址需要签名
+	r.GET("/images/:name", api.GetImage)
+
+	// 订阅源和 sitemap（不需要登录）
+	r.GET("/feed.rss", api.GetRSSFeed)
+	r.GET("/feed.atom", api.GetAtomFeed)
+	r.GET("/tags/:id/feed.rss", api.GetRSSFeed)
+	r.GET("/tags/:id/feed.atom", api.GetAtomFeed)
+	r.GET("/sitemap.xml", api.GetSitemap)
 
 	// 协程测试（不需要登录）
 	coroutine := r.Group("/api/v1/coroutine")
@@ -40,6 +78,8 @@ func InitRouter() *gin.Engine {
 
 	apiv1 := r.Group("/api/v1")
 	apiv1.Use(jwt.JWT())
+	apiv1.Use(ratelimit.User(), ratelimit.Groups())
+	apiv1.Use(idempotency.Idempotency()) // 按 [idempotency] 配置的接口，重复提交返回首次的响应
 	{
 		// Casbin 权限管理接口
 		casbin := apiv1.Group("/casbin")
@@ -85,8 +125,11 @@ func InitRouter() *gin.Engine {
 
 			es.POST("addEsJsonData", v1.AddEsJsonData)
 			es.GET("searchProductByKeyword", v1.SearchProductByKeyword)
+			es.GET("searchProductWithHighlight", v1.SearchProductWithHighlight)
+			es.GET("suggestProducts", v1.SuggestProducts) // 商品搜索框自动补全
 			es.GET("searchByBrandOrigin", v1.SearchByBrandOrigin)
 			es.GET("filterProductByPrice", v1.FilterProductByPrice)
+			es.GET("searchProducts", v1.SearchProducts) // 商品分面搜索
 		}
 
 		gmp := apiv1.Group("/gmp")
@@ -135,9 +178,11 @@ func InitRouter() *gin.Engine {
 			stock.GET("/product-details", v1.GetStockProductDetails)               // 获取明细列表
 			stock.GET("/product-details/summary", v1.GetStockProductDetailSummary) // 获取明细汇总
 			stock.GET("/product-detail/:id", v1.GetStockProductDetail)             // 获取单个明细
+			stock.GET("/product-detail/:id/code", v1.GetStockProductDetailCode)    // 明细序列号的条码
 			stock.POST("/product-detail", v1.AddStockProductDetail)                // 创建明细
 			stock.PUT("/product-detail", v1.UpdateStockProductDetail)              // 更新明细
 			stock.DELETE("/product-detail/:id", v1.DeleteStockProductDetail)       // 删除明细
+			stock.POST("/labels", v1.GenerateStockLabels)                          // 打印明细标签（PDF）
 
 			// 事务相关
 			stock.POST("/transaction/batch-create", v1.BatchCreateProductWithDetails) // 批量创建产品及明细（事务）
@@ -162,8 +207,19 @@ func InitRouter() *gin.Engine {
 			stock.POST("/goroutine/fan-out-in", v1.ProcessWithFanOutFanIn)           // Fan-out/Fan-in 模式
 		}
 
+		// 评论及审核
+		comments := apiv1.Group("/comments")
+		{
+			comments.POST("", ratelimit.Comment(), v1.AddComment) // 发表评论
+			comments.GET("", v1.GetComments)                      // 审核队列
+			comments.PUT("/moderate", v1.ModerateComments)        // 批量审核
+			comments.DELETE("/:id", v1.DeleteComment)             // 删除评论
+		}
+
 		//获取标签列表
 		apiv1.GET("/tags", v1.GetTags)
+		//获取指定标签，支持 ID 或 slug
+		apiv1.GET("/tags/:id", v1.GetTag)
 		//新建标签
 		apiv1.POST("/tags", v1.AddTag)
 		//更新指定标签
@@ -175,18 +231,70 @@ func InitRouter() *gin.Engine {
 		//导入标签
 		r.POST("/tags/import", v1.ImportTag)
 
+		//创建分片上传，完成后保存为文章、库存产品或订单的附件
+		apiv1.POST("/uploads", v1.InitUpload)
+		//获取上传进度，断线后从缺少的分片继续上传
+		apiv1.GET("/uploads/:id", v1.GetUpload)
+		//上传分片，请求体为分片内容
+		apiv1.PUT("/uploads/:id/chunks/:index", v1.PutUploadChunk)
+		//完成上传
+		apiv1.POST("/uploads/:id/complete", v1.CompleteUpload)
+		//取消上传
+		apiv1.DELETE("/uploads/:id", v1.AbortUpload)
+		//获取附件列表
+		apiv1.GET("/attachments", v1.GetAttachments)
+		//下载指定附件
+		apiv1.GET("/attachments/:id", v1.GetAttachment)
+		//删除指定附件
+		apiv1.DELETE("/attachments/:id", v1.DeleteAttachment)
+
+		//获取分类树
+		apiv1.GET("/categories", v1.GetCategories)
+		//新建分类
+		apiv1.POST("/categories", v1.AddCategory)
+		//更新指定分类
+		apiv1.PUT("/categories/:id", v1.EditCategory)
+		//删除指定分类，其下的文章移到上级分类
+		apiv1.DELETE("/categories/:id", v1.DeleteCategory)
+
 		//获取文章列表
 		apiv1.GET("/articles", v1.GetArticles)
-		//获取指定文章
-		apiv1.GET("/articles/:id", v1.GetArticle)
+		//获取指定文章，以及 articleStaticRoutes 中的静态路径
+		apiv1.GET("/articles/:id", withStatic("id", articleStaticRoutes, v1.GetArticle))
 		//新建文章
 		apiv1.POST("/articles", v1.AddArticle)
 		//更新指定文章
 		apiv1.PUT("/articles/:id", v1.EditArticle)
 		//删除指定文章
 		apiv1.DELETE("/articles/:id", v1.DeleteArticle)
+		//文章流程：提交审核、审核通过（可定时发布）、驳回、归档、重新编辑
+		apiv1.PUT("/articles/:id/workflow", v1.TransitionArticle)
+		//获取文章修订记录
+		apiv1.GET("/articles/:id/revisions", v1.GetArticleRevisions)
+		//获取指定修订版本
+		apiv1.GET("/articles/:id/revisions/:revision", v1.GetArticleRevision)
+		//比较修订版本
+		apiv1.GET("/articles/:id/revisions/:revision/diff", v1.DiffArticleRevision)
+		//恢复修订版本
+		apiv1.PUT("/articles/:id/revisions/:revision/restore", v1.RestoreArticleRevision)
+		//获取文章评论，按楼层分页，回复嵌套在楼层中
+		apiv1.GET("/articles/:id/comments", v1.GetArticleComments)
 		//生成文章海报
 		apiv1.POST("/articles/poster/generate", v1.GenerateArticlePoster)
+		//预览文章海报，可以使用未保存的模板
+		apiv1.POST("/posters/preview", v1.PreviewArticlePoster)
+		//获取海报模板列表
+		apiv1.GET("/posters/templates", v1.GetPosterTemplates)
+		//获取指定海报模板
+		apiv1.GET("/posters/templates/:id", v1.GetPosterTemplate)
+		//新建海报模板
+		apiv1.POST("/posters/templates", v1.AddPosterTemplate)
+		//更新指定海报模板
+		apiv1.PUT("/posters/templates/:id", v1.EditPosterTemplate)
+		//删除指定海报模板
+		apiv1.DELETE("/posters/templates/:id", v1.DeletePosterTemplate)
+		//生成条码或二维码，直接返回图片
+		apiv1.GET("/codes", v1.GetCode)
 	}
 
 	return r
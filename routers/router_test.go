@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInitRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// gin 的路由冲突在注册时 panic
+	InitRouter()
+}
+
+func TestWithStatic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/articles/:id", withStatic("id", map[string]gin.HandlerFunc{
+		"search": func(c *gin.Context) { c.String(http.StatusOK, "search") },
+	}, func(c *gin.Context) { c.String(http.StatusOK, "article "+c.Param("id")) }))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/articles/search", "search"},
+		{"/articles/1", "article 1"},
+		{"/articles/searchx", "article searchx"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,117 +1,200 @@
 package article_service
 
 import (
-	"encoding/json"
+	"github.com/jinzhu/gorm"
 
 	"github.com/EDDYCJY/go-gin-example/models"
-	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+	"github.com/EDDYCJY/go-gin-example/service/category_service"
+)
+
+// 按多个标签筛选时的匹配方式
+const (
+	TagMatchAny = "any" // 带有任意一个标签
+	TagMatchAll = "all" // 带有全部标签
 )
 
 type Article struct {
 	ID            int
-	TagID         int
+	TagIDs        []int // 第一个为主标签
+	CategoryID    int
 	Title         string
+	Slug          string // 为空时由标题生成，修改时标题不变则保持原 slug
 	Desc          string
 	Content       string
+	ContentFormat string // 为空时新文章使用 markdown，修改时保持原格式
 	CoverImageUrl string
 	State         int
 	CreatedBy     string
 	ModifiedBy    string
 
+	// TagMatch 按 TagIDs 筛选列表时的匹配方式，默认 TagMatchAny
+	TagMatch string
+
 	PageNum  int
 	PageSize int
 }
 
-func (a *Article) Add() error {
+// Add 新文章为草稿，需要经过审核流程才会发布，subject 为 Casbin 主体（例如 user:1）
+func (a *Article) Add(subject string) error {
+	if err := authorize(subject, ActionCreate); err != nil {
+		return err
+	}
+	if a.ContentFormat == "" {
+		a.ContentFormat = models.ContentFormatMarkdown
+	}
+	if a.Desc == "" {
+		a.Desc = excerpt(a.ContentFormat, a.Content)
+	}
+	s, err := newSlug(0, a.Slug, a.Title)
+	if err != nil {
+		return err
+	}
+	a.Slug = s
+
 	article := map[string]interface{}{
-		"tag_id":          a.TagID,
+		"tag_ids":         a.TagIDs,
+		"category_id":     a.CategoryID,
 		"title":           a.Title,
+		"slug":            a.Slug,
 		"desc":            a.Desc,
 		"content":         a.Content,
+		"content_format":  a.ContentFormat,
 		"created_by":      a.CreatedBy,
 		"cover_image_url": a.CoverImageUrl,
-		"state":           a.State,
 	}
 
-	if err := models.AddArticle(article); err != nil {
+	id, err := models.AddArticle(article)
+	if err != nil {
 		return err
 	}
 
+	a.ID = id
+	cache_service.Invalidate(cache_service.ArticleTag(a.ID))
+	cache_service.BumpNamespace(cache_service.ArticleListNamespace)
+	a.syncIndex()
 	return nil
 }
 
-func (a *Article) Edit() error {
-	return models.EditArticle(a.ID, map[string]interface{}{
-		"tag_id":          a.TagID,
+// Edit 修改文章内容并保存为新的修订版本，subject 为 Casbin 主体（例如 user:1）；
+// 已发布的文章修改后下线并重新进入审核，审核通过后才会再次发布
+func (a *Article) Edit(subject string) error {
+	if err := authorize(subject, ActionEdit); err != nil {
+		return err
+	}
+
+	current, err := models.GetArticle(a.ID)
+	if err != nil {
+		return err
+	}
+	if a.ContentFormat == "" {
+		a.ContentFormat = current.ContentFormat
+	}
+	if a.Desc == "" {
+		a.Desc = excerpt(a.ContentFormat, a.Content)
+	}
+	if a.Slug, err = a.nextSlug(current); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"category_id":     a.CategoryID,
 		"title":           a.Title,
+		"slug":            a.Slug,
 		"desc":            a.Desc,
 		"content":         a.Content,
+		"content_format":  a.ContentFormat,
 		"cover_image_url": a.CoverImageUrl,
-		"state":           a.State,
 		"modified_by":     a.ModifiedBy,
-	})
+	}
+	reviewAgain(current, data)
+
+	err = models.EditArticleWithRevision(a.ID, data, a.TagIDs, a.ModifiedBy)
+	if err != nil {
+		return err
+	}
+
+	a.afterWrite()
+	return nil
 }
 
 func (a *Article) Get() (*models.Article, error) {
-	var cacheArticle *models.Article
-
 	cache := cache_service.Article{ID: a.ID}
-	key := cache.GetArticleKey()
-	if gredis.Exists(key) {
-		data, err := gredis.Get(key)
+
+	article, err := pkgcache.Get(pkgcache.Default, cache.GetArticleKey(), func() (*models.Article, error) {
+		article, err := models.GetArticle(a.ID)
 		if err != nil {
-			logging.Info(err)
-		} else {
-			json.Unmarshal(data, &cacheArticle)
-			return cacheArticle, nil
+			return nil, err
+		}
+		if article.ID == 0 {
+			return nil, pkgcache.ErrNotFound
 		}
-	}
 
-	article, err := models.GetArticle(a.ID)
+		render(article)
+		return article, nil
+	}, cache_service.ArticleTag(a.ID))
 	if err != nil {
 		return nil, err
 	}
 
-	gredis.Set(key, article, 3600)
+	addPendingViews(article)
+	addCommentCounts(article)
+	article.Visitors = visitors(article.ID)
 	return article, nil
 }
 
 func (a *Article) GetAll() ([]*models.Article, error) {
-	var (
-		articles, cacheArticles []*models.Article
-	)
+	maps, err := a.getMaps()
+	if err != nil {
+		return nil, err
+	}
 
 	cache := cache_service.Article{
-		TagID: a.TagID,
-		State: a.State,
+		TagIDs:     a.TagIDs,
+		TagMatch:   a.TagMatch,
+		CategoryID: a.CategoryID,
+		State:      a.State,
 
 		PageNum:  a.PageNum,
 		PageSize: a.PageSize,
 	}
-	key := cache.GetArticlesKey()
-	if gredis.Exists(key) {
-		data, err := gredis.Get(key)
+
+	articles, err := pkgcache.Get(pkgcache.Default, cache.GetArticlesKey(), func() ([]*models.Article, error) {
+		articles, err := models.GetArticles(a.PageNum, a.PageSize, maps, a.scopes()...)
 		if err != nil {
-			logging.Info(err)
-		} else {
-			json.Unmarshal(data, &cacheArticles)
-			return cacheArticles, nil
+			return nil, err
 		}
-	}
 
-	articles, err := models.GetArticles(a.PageNum, a.PageSize, a.getMaps())
+		render(articles...)
+		return articles, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	gredis.Set(key, articles, 3600)
+	addPendingViews(articles...)
+	addCommentCounts(articles...)
 	return articles, nil
 }
 
-func (a *Article) Delete() error {
-	return models.DeleteArticle(a.ID)
+// Delete 删除文章，subject 为 Casbin 主体（例如 user:1）
+func (a *Article) Delete(subject string) error {
+	if err := authorize(subject, ActionDelete); err != nil {
+		return err
+	}
+	if err := models.DeleteArticle(a.ID); err != nil {
+		return err
+	}
+
+	cache_service.Invalidate(cache_service.ArticleTag(a.ID))
+	cache_service.BumpNamespace(cache_service.ArticleListNamespace)
+
+	a.removeIndex()
+	a.removeTrending()
+	refreshSitemap()
+	return nil
 }
 
 func (a *Article) ExistByID() (bool, error) {
@@ -119,18 +202,64 @@ func (a *Article) ExistByID() (bool, error) {
 }
 
 func (a *Article) Count() (int, error) {
-	return models.GetArticleTotal(a.getMaps())
+	maps, err := a.getMaps()
+	if err != nil {
+		return 0, err
+	}
+
+	return models.GetArticleTotal(maps, a.scopes()...)
 }
 
-func (a *Article) getMaps() map[string]interface{} {
+func (a *Article) getMaps() (map[string]interface{}, error) {
 	maps := make(map[string]interface{})
 	maps["deleted_on"] = 0
 	if a.State != -1 {
 		maps["state"] = a.State
 	}
-	if a.TagID != -1 {
-		maps["tag_id"] = a.TagID
+	if a.CategoryID > 0 {
+		// 包含下级分类的文章
+		ids, err := category_service.Descendants(a.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			ids = []int{a.CategoryID}
+		}
+		maps["category_id"] = ids
 	}
 
-	return maps
+	return maps, nil
+}
+
+// scopes 按 TagIDs 和 TagMatch 筛选
+func (a *Article) scopes() []func(*gorm.DB) *gorm.DB {
+	if len(a.TagIDs) == 0 {
+		return nil
+	}
+	if a.TagMatch == TagMatchAll {
+		return []func(*gorm.DB) *gorm.DB{models.WithAllTags(a.TagIDs)}
+	}
+
+	return []func(*gorm.DB) *gorm.DB{models.WithAnyTags(a.TagIDs)}
+}
+
+// addCommentCounts 填写文章已通过的评论数，评论数变化频繁，不随文章缓存
+func addCommentCounts(articles ...*models.Article) {
+	if len(articles) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+
+	counts, err := models.GetArticleCommentCounts(ids)
+	if err != nil {
+		logging.Warn("article_service.addCommentCounts err:", err)
+		return
+	}
+	for _, article := range articles {
+		article.Comments = counts[article.ID]
+	}
 }
@@ -1,17 +1,17 @@
 package article_service
 
 import (
+	"bytes"
+	"fmt"
 	"image"
-	"image/draw"
-	"image/jpeg"
-	"io/ioutil"
-	"os"
+	"strings"
 
-	"github.com/golang/freetype"
-
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
 	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
-	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
 )
 
 type ArticlePoster struct {
@@ -32,166 +32,104 @@ func GetPosterFlag() string {
 	return "poster"
 }
 
-func (a *ArticlePoster) CheckMergedImage(path string) bool {
-	if file.CheckNotExist(path+a.PosterName) == true {
-		return false
+// GetPosterName 海报文件名，二维码内容、模板或文章修改后生成新的海报，article 为 nil 时不绑定文章
+func GetPosterName(qrURL, template string, version int, article *models.Article, t *poster.Template) string {
+	var id, modifiedOn int
+	if article != nil {
+		id, modifiedOn = article.ID, article.ModifiedOn
 	}
 
-	return true
+	hash := util.EncodeMD5(fmt.Sprintf("%s|%s|%d|%d|%d", qrURL, template, version, id, modifiedOn))
+	return GetPosterFlag() + "-" + hash + t.Ext()
 }
 
-func (a *ArticlePoster) OpenMergedImage(path string) (*os.File, error) {
-	f, err := file.MustOpen(a.PosterName, path)
-	if err != nil {
-		return nil, err
-	}
-
-	return f, nil
+// CheckMergedImage 海报是否已经生成
+func (a *ArticlePoster) CheckMergedImage() (bool, error) {
+	return storage.Default.Exists(qrcode.GetQrCodeKey(a.PosterName))
 }
 
+// ArticlePosterBg 按模板生成海报，Name 为模板名
 type ArticlePosterBg struct {
 	Name string
 	*ArticlePoster
-	*Rect
-	*Pt
-}
-
-type Rect struct {
-	Name string
-	X0   int
-	Y0   int
-	X1   int
-	Y1   int
-}
-
-type Pt struct {
-	X int
-	Y int
+	Template *poster.Template
 }
 
-func NewArticlePosterBg(name string, ap *ArticlePoster, rect *Rect, pt *Pt) *ArticlePosterBg {
+func NewArticlePosterBg(name string, ap *ArticlePoster, template *poster.Template) *ArticlePosterBg {
 	return &ArticlePosterBg{
 		Name:          name,
 		ArticlePoster: ap,
-		Rect:          rect,
-		Pt:            pt,
+		Template:      template,
 	}
 }
 
-type DrawText struct {
-	JPG    draw.Image
-	Merged *os.File
-
-	Title string
-	X0    int
-	Y0    int
-	Size0 float64
-
-	SubTitle string
-	X1       int
-	Y1       int
-	Size1    float64
-}
-
-func (a *ArticlePosterBg) DrawPoster(d *DrawText, fontName string) error {
-	fontSource := setting.AppSetting.RuntimeRootPath + setting.AppSetting.FontSavePath + fontName
-	fontSourceBytes, err := ioutil.ReadFile(fontSource)
+// Render 按模板绘制海报，不保存，用于预览
+func (a *ArticlePosterBg) Render() ([]byte, error) {
+	qrImage, err := a.Qr.Image()
 	if err != nil {
-		return err
-	}
-
-	trueTypeFont, err := freetype.ParseFont(fontSourceBytes)
-	if err != nil {
-		return err
-	}
-
-	fc := freetype.NewContext()
-	fc.SetDPI(72)
-	fc.SetFont(trueTypeFont)
-	fc.SetFontSize(d.Size0)
-	fc.SetClip(d.JPG.Bounds())
-	fc.SetDst(d.JPG)
-	fc.SetSrc(image.Black)
-
-	pt := freetype.Pt(d.X0, d.Y0)
-	_, err = fc.DrawString(d.Title, pt)
-	if err != nil {
-		return err
-	}
-
-	fc.SetFontSize(d.Size1)
-	_, err = fc.DrawString(d.SubTitle, freetype.Pt(d.X1, d.Y1))
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = jpeg.Encode(d.Merged, d.JPG, nil)
+	// 封面读取失败时不绘制封面，不影响生成海报
+	cover, err := coverImage(a.CoverImageUrl)
 	if err != nil {
-		return err
+		logging.Warn("article_service.coverImage err:", err)
 	}
 
-	return nil
+	return poster.Render(a.Template, &poster.Data{
+		Text: map[string]string{
+			poster.FieldTitle:  a.Title,
+			poster.FieldDesc:   a.Desc,
+			poster.FieldAuthor: a.CreatedBy,
+		},
+		Images: map[string]image.Image{
+			poster.FieldCover:  cover,
+			poster.FieldQrCode: qrImage,
+		},
+	})
 }
 
+// Generate 生成海报并保存到存储，已经生成过时直接返回，返回二维码文件名和海报所在路径
 func (a *ArticlePosterBg) Generate() (string, string, error) {
-	fullPath := qrcode.GetQrCodeFullPath()
-	fileName, path, err := a.Qr.Encode(fullPath)
+	fileName, err := a.Qr.Encode()
 	if err != nil {
 		return "", "", err
 	}
+	path := qrcode.GetQrCodePath()
 
-	if !a.CheckMergedImage(path) {
-		mergedF, err := a.OpenMergedImage(path)
-		if err != nil {
-			return "", "", err
-		}
-		defer mergedF.Close()
-
-		bgF, err := file.MustOpen(a.Name, path)
-		if err != nil {
-			return "", "", err
-		}
-		defer bgF.Close()
-
-		qrF, err := file.MustOpen(fileName, path)
-		if err != nil {
-			return "", "", err
-		}
-		defer qrF.Close()
-
-		bgImage, err := jpeg.Decode(bgF)
+	exists, err := a.CheckMergedImage()
+	if err != nil {
+		return "", "", err
+	}
+	if !exists {
+		data, err := a.Render()
 		if err != nil {
 			return "", "", err
 		}
-		qrImage, err := jpeg.Decode(qrF)
-		if err != nil {
+		if err := storage.Default.Put(qrcode.GetQrCodeKey(a.PosterName), bytes.NewReader(data), a.Template.ContentType()); err != nil {
 			return "", "", err
 		}
+	}
 
-		jpg := image.NewRGBA(image.Rect(a.Rect.X0, a.Rect.Y0, a.Rect.X1, a.Rect.Y1))
-
-		draw.Draw(jpg, jpg.Bounds(), bgImage, bgImage.Bounds().Min, draw.Over)
-		draw.Draw(jpg, jpg.Bounds(), qrImage, qrImage.Bounds().Min.Sub(image.Pt(a.Pt.X, a.Pt.Y)), draw.Over)
-
-		err = a.DrawPoster(&DrawText{
-			JPG:    jpg,
-			Merged: mergedF,
-
-			Title: "Golang Gin 系列文章",
-			X0:    80,
-			Y0:    160,
-			Size0: 42,
+	return fileName, path, nil
+}
 
-			SubTitle: "---煎鱼",
-			X1:       320,
-			Y1:       220,
-			Size1:    36,
-		}, "msyhbd.ttc")
+// coverImage 读取保存在本站存储中的封面图，外部地址不下载，返回 nil
+func coverImage(url string) (image.Image, error) {
+	prefix := storage.Default.URL("")
+	if url == "" || !strings.HasPrefix(url, prefix) {
+		return nil, nil
+	}
 
-		if err != nil {
-			return "", "", err
-		}
+	rc, err := storage.Default.Get(strings.TrimPrefix(url, prefix))
+	if err == storage.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	defer rc.Close()
 
-	return fileName, path, nil
+	img, _, err := image.Decode(rc)
+	return img, err
 }
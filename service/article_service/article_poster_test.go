@@ -0,0 +1,67 @@
+package article_service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+func TestGetPosterName(t *testing.T) {
+	jpeg, pngTpl := &poster.Template{Format: poster.FormatJPEG}, &poster.Template{Format: poster.FormatPNG}
+	article := &models.Article{}
+	article.ID, article.ModifiedOn = 1, 100
+
+	name := GetPosterName("https://example.com/a", "default", 0, article, jpeg)
+	if !strings.HasPrefix(name, "poster-") || !strings.HasSuffix(name, ".jpg") {
+		t.Errorf("GetPosterName = %q", name)
+	}
+	if again := GetPosterName("https://example.com/a", "default", 0, article, jpeg); again != name {
+		t.Errorf("GetPosterName is not stable: %q, %q", name, again)
+	}
+
+	modified := *article
+	modified.ModifiedOn = 200
+	// 二维码内容、模板、模板版本、文章修改时间和格式变化时生成新的文件名
+	for _, other := range []string{
+		GetPosterName("https://example.com/b", "default", 0, article, jpeg),
+		GetPosterName("https://example.com/a", "card", 0, article, jpeg),
+		GetPosterName("https://example.com/a", "default", 1, article, jpeg),
+		GetPosterName("https://example.com/a", "default", 0, &modified, jpeg),
+		GetPosterName("https://example.com/a", "default", 0, nil, jpeg),
+		GetPosterName("https://example.com/a", "default", 0, article, pngTpl),
+	} {
+		if other == name {
+			t.Errorf("GetPosterName did not change: %q", other)
+		}
+	}
+}
+
+func TestCoverImage(t *testing.T) {
+	saved := storage.Default
+	t.Cleanup(func() { storage.Default = saved })
+	storage.Default = storage.NewLocal(t.TempDir(), "http://127.0.0.1:8000/")
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	if err := storage.Default.Put("upload/images/cover.png", &buf, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := coverImage("http://127.0.0.1:8000/upload/images/cover.png")
+	if err != nil || img == nil || img.Bounds().Dx() != 3 {
+		t.Fatalf("coverImage = %v, %v", img, err)
+	}
+
+	// 外部地址、空地址和不存在的文件都不绘制封面
+	for _, url := range []string{"", "https://cdn.example.com/cover.png", "http://127.0.0.1:8000/upload/images/missing.png"} {
+		if img, err := coverImage(url); img != nil || err != nil {
+			t.Errorf("coverImage(%q) = %v, %v", url, img, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package article_service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
+	"github.com/EDDYCJY/go-gin-example/pkg/feed"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// 订阅源格式
+const (
+	FeedRSS  = "rss"
+	FeedAtom = "atom"
+)
+
+// FeedDocument 生成好的订阅源，ETag 为内容的摘要
+type FeedDocument struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// ArticleURL 文章页的完整地址，由 [feed] ArticleUrl 配置，文章还没有 slug 时 {slug} 使用 ID
+func ArticleURL(article *models.Article) string {
+	return articleURL(article.ID, article.Slug)
+}
+
+// articleURL 不随 slug 变化的地址用空的 slug 调用，订阅源的条目 ID 不会因为改名而变化
+func articleURL(id int, slug string) string {
+	if slug == "" {
+		slug = strconv.Itoa(id)
+	}
+	r := strings.NewReplacer("{id}", strconv.Itoa(id), "{slug}", slug)
+	return setting.AppSetting.PrefixUrl + r.Replace(setting.FeedSetting.ArticleUrl)
+}
+
+// Feed 最新发布文章的订阅源，tagID 大于 0 时只包含带有该标签的文章，标签不存在时返回 pkgcache.ErrNotFound
+func Feed(format string, tagID int) (*FeedDocument, error) {
+	cache := cache_service.Feed{Format: format, TagID: tagID}
+	return pkgcache.Get(pkgcache.Default, cache.GetFeedKey(), func() (*FeedDocument, error) {
+		return buildFeed(format, tagID)
+	})
+}
+
+func buildFeed(format string, tagID int) (*FeedDocument, error) {
+	prefix := setting.AppSetting.PrefixUrl
+	f := &feed.Feed{
+		Title:       setting.FeedSetting.Title,
+		Link:        prefix + "/",
+		Self:        prefix + "/feed." + format,
+		Description: setting.FeedSetting.Description,
+	}
+
+	var scopes []func(*gorm.DB) *gorm.DB
+	if tagID > 0 {
+		tag, err := models.GetTag(tagID)
+		if err != nil {
+			return nil, err
+		}
+		if tag == nil {
+			return nil, pkgcache.ErrNotFound
+		}
+		f.Title += " - " + tag.Name
+		f.Self = prefix + "/tags/" + strconv.Itoa(tagID) + "/feed." + format
+		scopes = append(scopes, models.WithAnyTags([]int{tagID}))
+	}
+
+	articles, err := models.GetLatestArticles(setting.FeedSetting.Size, map[string]interface{}{"deleted_on": 0, "state": 1}, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	render(articles...)
+
+	for _, article := range articles {
+		item := &feed.Item{
+			ID:          articleURL(article.ID, ""),
+			Title:       article.Title,
+			Link:        ArticleURL(article),
+			Description: article.Desc,
+			Content:     article.ContentHTML,
+			Author:      article.CreatedBy,
+			Published:   time.Unix(int64(publishedOn(article)), 0),
+			Updated:     time.Unix(int64(lastModifiedOn(article)), 0),
+		}
+		for _, tag := range article.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+
+	var body []byte
+	if format == FeedAtom {
+		body, err = feed.Atom(f)
+	} else {
+		body, err = feed.RSS(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeedDocument{
+		Body:         body,
+		ETag:         `"` + util.EncodeMD5(string(body)) + `"`,
+		LastModified: f.Updated,
+	}, nil
+}
+
+// publishedOn 发布时间，流程上线前发布的文章没有 publish_at，使用创建时间
+func publishedOn(article *models.Article) int {
+	if article.PublishAt > 0 {
+		return article.PublishAt
+	}
+
+	return article.CreatedOn
+}
+
+// lastModifiedOn 最后修改时间，定时发布的文章发布时间可能晚于修改时间
+func lastModifiedOn(article *models.Article) int {
+	t := publishedOn(article)
+	if article.ModifiedOn > t {
+		t = article.ModifiedOn
+	}
+
+	return t
+}
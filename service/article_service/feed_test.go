@@ -0,0 +1,58 @@
+package article_service
+
+import (
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func TestArticleURL(t *testing.T) {
+	savedPrefix, savedURL := setting.AppSetting.PrefixUrl, setting.FeedSetting.ArticleUrl
+	defer func() { setting.AppSetting.PrefixUrl, setting.FeedSetting.ArticleUrl = savedPrefix, savedURL }()
+	setting.AppSetting.PrefixUrl = "https://example.com"
+
+	tests := []struct {
+		pattern string
+		article *models.Article
+		want    string
+	}{
+		{"/articles/{slug}", &models.Article{Slug: "hello-go"}, "https://example.com/articles/hello-go"},
+		{"/articles/{slug}", &models.Article{}, "https://example.com/articles/3"},
+		{"/articles/{id}/{slug}", &models.Article{Slug: "hello-go"}, "https://example.com/articles/3/hello-go"},
+	}
+	for _, tt := range tests {
+		setting.FeedSetting.ArticleUrl = tt.pattern
+		tt.article.ID = 3
+		if got := ArticleURL(tt.article); got != tt.want {
+			t.Errorf("ArticleURL(%s, %q) = %q, want %q", tt.pattern, tt.article.Slug, got, tt.want)
+		}
+	}
+
+	// 订阅源条目的 ID 不随 slug 变化
+	setting.FeedSetting.ArticleUrl = "/articles/{slug}"
+	if got := articleURL(3, ""); got != "https://example.com/articles/3" {
+		t.Errorf("articleURL(3, \"\") = %q", got)
+	}
+}
+
+func TestFeedTimes(t *testing.T) {
+	tests := []struct {
+		publishAt, createdOn, modifiedOn int
+		published, lastModified          int
+	}{
+		{0, 100, 150, 100, 150},
+		{200, 100, 150, 200, 200},
+		{200, 100, 300, 200, 300},
+	}
+	for _, tt := range tests {
+		article := &models.Article{PublishAt: tt.publishAt}
+		article.CreatedOn, article.ModifiedOn = tt.createdOn, tt.modifiedOn
+		if got := publishedOn(article); got != tt.published {
+			t.Errorf("publishedOn(%+v) = %d, want %d", tt, got, tt.published)
+		}
+		if got := lastModifiedOn(article); got != tt.lastModified {
+			t.Errorf("lastModifiedOn(%+v) = %d, want %d", tt, got, tt.lastModified)
+		}
+	}
+}
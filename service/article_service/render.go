@@ -0,0 +1,48 @@
+package article_service
+
+import (
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/markdown"
+)
+
+// excerptLength 自动生成摘要的字数
+const excerptLength = 120
+
+// ValidContentFormat 是否为支持的正文格式，空值使用默认的 markdown
+func ValidContentFormat(format string) bool {
+	switch format {
+	case "", models.ContentFormatMarkdown, models.ContentFormatHTML:
+		return true
+	}
+
+	return false
+}
+
+// renderHTML 正文转为过滤后的 HTML，Markdown 先渲染再过滤，HTML 直接过滤
+func renderHTML(format, content string) string {
+	if format == models.ContentFormatHTML {
+		return markdown.Sanitize(content)
+	}
+
+	return markdown.Sanitize(markdown.Render(content))
+}
+
+// excerpt 从正文截取摘要
+func excerpt(format, content string) string {
+	return markdown.Excerpt(markdown.PlainText(renderHTML(format, content)), excerptLength)
+}
+
+// render 生成正文的 HTML、目录和阅读时间，没有摘要时从正文截取
+func render(articles ...*models.Article) {
+	for _, article := range articles {
+		article.ContentHTML, article.Toc = markdown.Toc(renderHTML(article.ContentFormat, article.Content))
+
+		text := markdown.PlainText(article.ContentHTML)
+		article.ReadingTime = markdown.ReadingTime(text)
+		if strings.TrimSpace(article.Desc) == "" {
+			article.Desc = markdown.Excerpt(text, excerptLength)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package article_service
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/diff"
+)
+
+// diffContext diff 中每处变化前后保留的行数
+const diffContext = 3
+
+// ErrRevisionNotFound 修订版本不存在
+var ErrRevisionNotFound = errors.New("article: revision not found")
+
+// FieldChange 单个字段的变化
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// RevisionDiff 两个版本之间的差异
+type RevisionDiff struct {
+	From    int                     `json:"from"`
+	To      int                     `json:"to"`
+	Fields  map[string]*FieldChange `json:"fields"`
+	Stats   diff.Stats              `json:"stats"`
+	Hunks   []*diff.Hunk            `json:"hunks"`
+	Unified string                  `json:"unified"`
+}
+
+// Revisions 文章的全部修订记录（不含正文），新版本在前
+func (a *Article) Revisions() ([]*models.ArticleRevision, error) {
+	return models.GetArticleRevisions(a.ID)
+}
+
+// Revision 单个修订版本
+func (a *Article) Revision(revision int) (*models.ArticleRevision, error) {
+	r, err := models.GetArticleRevision(a.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrRevisionNotFound
+	}
+
+	return r, nil
+}
+
+// Diff 比较 from 和 to 两个版本，from 为 0 时与 to 的上一个版本比较
+func (a *Article) Diff(from, to int) (*RevisionDiff, error) {
+	if from <= 0 {
+		from = to - 1
+	}
+
+	newer, err := a.Revision(to)
+	if err != nil {
+		return nil, err
+	}
+	older := &models.ArticleRevision{}
+	if from > 0 {
+		if older, err = a.Revision(from); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := map[string]*FieldChange{}
+	if oldTags, newTags := older.TagIDList(), newer.TagIDList(); !equalIDs(oldTags, newTags) {
+		fields["tag_ids"] = &FieldChange{Old: oldTags, New: newTags}
+	}
+	if older.CategoryID != newer.CategoryID {
+		fields["category_id"] = &FieldChange{Old: older.CategoryID, New: newer.CategoryID}
+	}
+	if older.Title != newer.Title {
+		fields["title"] = &FieldChange{Old: older.Title, New: newer.Title}
+	}
+	if older.Desc != newer.Desc {
+		fields["desc"] = &FieldChange{Old: older.Desc, New: newer.Desc}
+	}
+	if older.ContentFormat != newer.ContentFormat {
+		fields["content_format"] = &FieldChange{Old: older.ContentFormat, New: newer.ContentFormat}
+	}
+	if older.CoverImageUrl != newer.CoverImageUrl {
+		fields["cover_image_url"] = &FieldChange{Old: older.CoverImageUrl, New: newer.CoverImageUrl}
+	}
+
+	lines := diff.Strings(older.Content, newer.Content)
+	return &RevisionDiff{
+		From:    from,
+		To:      to,
+		Fields:  fields,
+		Stats:   diff.Stat(lines),
+		Hunks:   diff.Hunks(lines, diffContext),
+		Unified: diff.Unified("revision "+strconv.Itoa(from), "revision "+strconv.Itoa(to), lines, diffContext),
+	}, nil
+}
+
+// Restore 用历史版本的内容覆盖文章，并作为新版本保存，原有版本保持不变；与 Edit 一样，已发布的文章需要重新审核
+func (a *Article) Restore(subject string, revision int) error {
+	if err := authorize(subject, ActionEdit); err != nil {
+		return err
+	}
+
+	r, err := a.Revision(revision)
+	if err != nil {
+		return err
+	}
+	current, err := models.GetArticle(a.ID)
+	if err != nil {
+		return err
+	}
+	a.Title = r.Title
+	if a.Slug, err = a.nextSlug(current); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"category_id":     r.CategoryID,
+		"title":           r.Title,
+		"slug":            a.Slug,
+		"desc":            r.Desc,
+		"content":         r.Content,
+		"content_format":  r.ContentFormat,
+		"cover_image_url": r.CoverImageUrl,
+		"modified_by":     a.ModifiedBy,
+	}
+	reviewAgain(current, data)
+
+	err = models.EditArticleWithRevision(a.ID, data, r.TagIDList(), a.ModifiedBy)
+	if err != nil {
+		return err
+	}
+
+	a.afterWrite()
+	return nil
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
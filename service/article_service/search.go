@@ -0,0 +1,349 @@
+package article_service
+
+import (
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/es"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+)
+
+// ArticleIndex 文章搜索索引（别名），定义见 conf/es/blog_articles.json
+const ArticleIndex = "blog_articles"
+
+// 分面返回的桶数量
+const facetSize = 10
+
+// ArticleDoc 写入 Elasticsearch 的文章文档
+type ArticleDoc struct {
+	ID            int      `json:"id"`
+	TagID         int      `json:"tag_id"`
+	TagName       string   `json:"tag_name"`
+	TagIDs        []int    `json:"tag_ids"`
+	TagNames      []string `json:"tag_names"`
+	CategoryID    int      `json:"category_id"`
+	Title         string   `json:"title"`
+	Desc          string   `json:"desc"`
+	Content       string   `json:"content"`
+	CoverImageUrl string   `json:"cover_image_url"`
+	CreatedBy     string   `json:"created_by"`
+	ModifiedBy    string   `json:"modified_by"`
+	State         int      `json:"state"`
+	CreatedOn     int      `json:"created_on"`
+	ModifiedOn    int      `json:"modified_on"`
+}
+
+// NewArticleDoc 由数据库记录构造索引文档
+func NewArticleDoc(article *models.Article) *ArticleDoc {
+	tagIDs := make([]int, 0, len(article.Tags))
+	tagNames := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	return &ArticleDoc{
+		ID:            article.ID,
+		TagID:         article.TagID,
+		TagName:       article.Tag.Name,
+		TagIDs:        tagIDs,
+		TagNames:      tagNames,
+		CategoryID:    article.CategoryID,
+		Title:         article.Title,
+		Desc:          article.Desc,
+		Content:       article.Content,
+		CoverImageUrl: article.CoverImageUrl,
+		CreatedBy:     article.CreatedBy,
+		ModifiedBy:    article.ModifiedBy,
+		State:         article.State,
+		CreatedOn:     article.CreatedOn,
+		ModifiedOn:    article.ModifiedOn,
+	}
+}
+
+// syncIndex 把文章最新内容写入索引，失败只记录日志，不影响数据库写入
+func (a *Article) syncIndex() {
+	if !es.Enabled() {
+		return
+	}
+
+	article, err := models.GetArticle(a.ID)
+	if err != nil {
+		logging.Warn("article_service.syncIndex get article err:", a.ID, err)
+		return
+	}
+
+	if _, err := es.Index(ArticleIndex, strconv.Itoa(a.ID), NewArticleDoc(article)); err != nil {
+		logging.Warn("article_service.syncIndex index err:", a.ID, err)
+	}
+}
+
+// removeIndex 从索引中删除文章
+func (a *Article) removeIndex() {
+	if !es.Enabled() {
+		return
+	}
+
+	if err := es.Delete(ArticleIndex, strconv.Itoa(a.ID)); err != nil {
+		logging.Warn("article_service.removeIndex err:", a.ID, err)
+	}
+}
+
+// Facet 分面统计中的一项
+type Facet struct {
+	Key   string `json:"key"`
+	Name  string `json:"name,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// SearchHit 一条搜索结果
+type SearchHit struct {
+	*ArticleDoc
+	Score     float64             `json:"score,omitempty"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+}
+
+// SearchResult 文章搜索结果
+type SearchResult struct {
+	Lists  []*SearchHit        `json:"lists"`
+	Total  int64               `json:"total"`
+	Facets map[string][]*Facet `json:"facets"`
+	Engine string              `json:"engine"`
+}
+
+// ArticleSearch 文章搜索条件，StartTime / EndTime 为 Unix 时间戳，0 表示不限
+type ArticleSearch struct {
+	Keyword   string
+	TagID     int
+	CreatedBy string
+	State     int
+	StartTime int
+	EndTime   int
+
+	PageNum  int
+	PageSize int
+}
+
+// Search 优先使用 Elasticsearch 搜索，未配置时退回数据库 LIKE 查询
+func (s *ArticleSearch) Search() (*SearchResult, error) {
+	if es.Enabled() {
+		return s.searchES()
+	}
+
+	return s.searchDB()
+}
+
+func (s *ArticleSearch) searchES() (*SearchResult, error) {
+	query := es.Bool()
+	if s.Keyword != "" {
+		query.Must(es.MultiMatch(s.Keyword, "title^3", "title.std^2", "desc^2", "content").Type("best_fields"))
+	}
+	if s.TagID > 0 {
+		query.Filter(es.Term("tag_ids", s.TagID))
+	}
+	if s.CreatedBy != "" {
+		query.Filter(es.Term("created_by", s.CreatedBy))
+	}
+	if s.State != -1 {
+		query.Filter(es.Term("state", s.State))
+	}
+	if s.StartTime > 0 || s.EndTime > 0 {
+		r := es.Range("created_on")
+		if s.StartTime > 0 {
+			r.Gte(s.StartTime)
+		}
+		if s.EndTime > 0 {
+			r.Lte(s.EndTime)
+		}
+		query.Filter(r)
+	}
+
+	source := es.NewSearch().
+		Query(query).
+		From(s.PageNum).
+		Size(s.PageSize).
+		Highlight(es.NewHighlight("title", "desc").Field("content", 150, 3)).
+		Aggregation("tags", es.TermsAgg("tag_ids", facetSize)).
+		Aggregation("authors", es.TermsAgg("created_by", facetSize))
+	if s.Keyword == "" {
+		source.Sort("created_on", false)
+	}
+
+	result, err := es.Search[ArticleDoc](ArticleIndex, source)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SearchResult{
+		Lists:  make([]*SearchHit, 0, len(result.Hits)),
+		Total:  result.Total,
+		Facets: map[string][]*Facet{},
+		Engine: "elasticsearch",
+	}
+	for i := range result.Hits {
+		hit := result.Hits[i]
+		data.Lists = append(data.Lists, &SearchHit{
+			ArticleDoc: &hit.Source,
+			Score:      hit.Score,
+			Highlight:  hit.Highlight,
+		})
+	}
+
+	tags, err := result.Aggregations.Buckets("tags")
+	if err != nil {
+		return nil, err
+	}
+	tagFacets := make([]*Facet, 0, len(tags))
+	for _, b := range tags {
+		facet := &Facet{Key: b.KeyAsString, Count: b.DocCount}
+		if facet.Key == "" {
+			facet.Key = strconv.FormatFloat(toFloat(b.Key), 'f', -1, 64)
+		}
+		tagFacets = append(tagFacets, facet)
+	}
+	if data.Facets["tags"], err = nameTagFacets(tagFacets); err != nil {
+		return nil, err
+	}
+
+	authors, err := result.Aggregations.Buckets("authors")
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range authors {
+		key, _ := b.Key.(string)
+		data.Facets["authors"] = append(data.Facets["authors"], &Facet{Key: key, Count: b.DocCount})
+	}
+
+	return data, nil
+}
+
+func (s *ArticleSearch) searchDB() (*SearchResult, error) {
+	maps := s.getMaps()
+
+	scopes := s.scopes()
+
+	total, err := models.SearchArticleTotal(s.Keyword, maps, s.StartTime, s.EndTime, scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	articles, err := models.SearchArticles(s.Keyword, maps, s.StartTime, s.EndTime, s.PageNum, s.PageSize, scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SearchResult{
+		Lists:  make([]*SearchHit, 0, len(articles)),
+		Total:  int64(total),
+		Facets: map[string][]*Facet{},
+		Engine: "database",
+	}
+	for _, article := range articles {
+		data.Lists = append(data.Lists, &SearchHit{ArticleDoc: NewArticleDoc(article)})
+	}
+
+	tagFacets, err := models.SearchArticleTagFacets(s.Keyword, maps, s.StartTime, s.EndTime, facetSize, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	facets := make([]*Facet, 0, len(tagFacets))
+	for _, f := range tagFacets {
+		facets = append(facets, &Facet{Key: f.Key, Count: int64(f.Count)})
+	}
+	if data.Facets["tags"], err = nameTagFacets(facets); err != nil {
+		return nil, err
+	}
+
+	authorFacets, err := models.SearchArticleFacets("created_by", s.Keyword, maps, s.StartTime, s.EndTime, facetSize, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range authorFacets {
+		data.Facets["authors"] = append(data.Facets["authors"], &Facet{Key: f.Key, Count: int64(f.Count)})
+	}
+
+	return data, nil
+}
+
+func (s *ArticleSearch) getMaps() map[string]interface{} {
+	maps := make(map[string]interface{})
+	maps["deleted_on"] = 0
+	if s.State != -1 {
+		maps["state"] = s.State
+	}
+	if s.CreatedBy != "" {
+		maps["created_by"] = s.CreatedBy
+	}
+
+	return maps
+}
+
+func (s *ArticleSearch) scopes() []func(*gorm.DB) *gorm.DB {
+	if s.TagID <= 0 {
+		return nil
+	}
+
+	return []func(*gorm.DB) *gorm.DB{models.WithAnyTags([]int{s.TagID})}
+}
+
+// nameTagFacets 按标签 ID 填写分面的标签名称
+func nameTagFacets(facets []*Facet) ([]*Facet, error) {
+	if len(facets) == 0 {
+		return facets, nil
+	}
+
+	ids := make([]string, 0, len(facets))
+	for _, f := range facets {
+		ids = append(ids, f.Key)
+	}
+	tags, err := models.GetTags(0, 0, map[string]interface{}{"id": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		names[strconv.Itoa(tag.ID)] = tag.Name
+	}
+	for _, f := range facets {
+		f.Name = names[f.Key]
+	}
+
+	return facets, nil
+}
+
+// SyncAllToIndex 把数据库中全部未删除的文章批量写入索引，用于首次上线或重建索引后回填
+func SyncAllToIndex() (*es.BulkResult, error) {
+	bulk, err := es.NewBulkIndexer(es.BulkOptions{Index: ArticleIndex})
+	if err != nil {
+		return nil, err
+	}
+
+	const pageSize = 500
+	maps := map[string]interface{}{"deleted_on": 0}
+	for offset := 0; ; offset += pageSize {
+		articles, err := models.GetArticles(offset, pageSize, maps)
+		if err != nil {
+			bulk.Close()
+			return nil, err
+		}
+		for _, article := range articles {
+			if err := bulk.Index("", strconv.Itoa(article.ID), NewArticleDoc(article)); err != nil {
+				bulk.Close()
+				return nil, err
+			}
+		}
+		if len(articles) < pageSize {
+			break
+		}
+	}
+
+	return bulk.Close()
+}
+
+func toFloat(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
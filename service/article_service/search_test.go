@@ -0,0 +1,160 @@
+package article_service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/es"
+)
+
+// newTestES 把 es.ESClient 指向 handler，测试结束后恢复
+func newTestES(t *testing.T, handler http.HandlerFunc) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}, DisableRetry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := es.ESClient
+	es.ESClient = client
+	t.Cleanup(func() { es.ESClient = saved })
+}
+
+func TestNewArticleDoc(t *testing.T) {
+	article := &models.Article{
+		Model: models.Model{ID: 3, CreatedOn: 100, ModifiedOn: 200},
+		TagID: 1,
+		Tag:   models.Tag{Name: "go"},
+		Tags:  []models.Tag{{Model: models.Model{ID: 1}, Name: "go"}, {Model: models.Model{ID: 2}, Name: "gin"}},
+		Title: "t",
+		State: 1,
+	}
+
+	doc := NewArticleDoc(article)
+	data, _ := json.Marshal(doc)
+	want := `{"id":3,"tag_id":1,"tag_name":"go","tag_ids":[1,2],"tag_names":["go","gin"],"category_id":0,"title":"t",` +
+		`"desc":"","content":"","cover_image_url":"","created_by":"","modified_by":"","state":1,"created_on":100,"modified_on":200}`
+	if string(data) != want {
+		t.Errorf("NewArticleDoc() =\n%s\nwant\n%s", data, want)
+	}
+
+	// 没有标签时为空数组，不是 null
+	if data, _ := json.Marshal(NewArticleDoc(&models.Article{}).TagIDs); string(data) != "[]" {
+		t.Errorf("TagIDs without tags = %s, want []", data)
+	}
+}
+
+func TestSearchMaps(t *testing.T) {
+	tests := []struct {
+		search ArticleSearch
+		maps   string
+		scopes int
+	}{
+		{ArticleSearch{State: -1}, `{"deleted_on":0}`, 0},
+		{ArticleSearch{State: 0}, `{"deleted_on":0,"state":0}`, 0},
+		{ArticleSearch{State: 1, CreatedBy: "alice", TagID: 2}, `{"created_by":"alice","deleted_on":0,"state":1}`, 1},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(tt.search.getMaps())
+		if string(data) != tt.maps {
+			t.Errorf("%+v getMaps() = %s, want %s", tt.search, data, tt.maps)
+		}
+		if got := len(tt.search.scopes()); got != tt.scopes {
+			t.Errorf("%+v scopes() = %d, want %d", tt.search, got, tt.scopes)
+		}
+	}
+}
+
+func TestSearchES(t *testing.T) {
+	var body map[string]json.RawMessage
+	newTestES(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+ArticleIndex+"/_search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"hits": {"total": {"value": 12, "relation": "eq"}, "hits": [
+				{"_id": "7", "_score": 1.5, "_source": {"id": 7, "title": "gin"}, "highlight": {"title": ["<em>gin</em>"]}}
+			]},
+			"aggregations": {
+				"tags": {"buckets": []},
+				"authors": {"buckets": [{"key": "alice", "doc_count": 5}, {"key": "bob", "doc_count": 2}]}
+			}
+		}`))
+	})
+
+	s := &ArticleSearch{Keyword: "gin", TagID: 2, CreatedBy: "alice", State: 1, StartTime: 100, EndTime: 200, PageNum: 10, PageSize: 5}
+	result, err := s.Search()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantQuery := `{"bool":{"filter":[{"term":{"tag_ids":2}},{"term":{"created_by":"alice"}},{"term":{"state":1}},` +
+		`{"range":{"created_on":{"gte":100,"lte":200}}}],` +
+		`"must":[{"multi_match":{"fields":["title^3","title.std^2","desc^2","content"],"query":"gin","type":"best_fields"}}]}}`
+	if string(body["query"]) != wantQuery {
+		t.Errorf("query =\n%s\nwant\n%s", body["query"], wantQuery)
+	}
+	if string(body["from"]) != "10" || string(body["size"]) != "5" {
+		t.Errorf("from, size = %s, %s, want 10, 5", body["from"], body["size"])
+	}
+	// 有关键词时按相关度排序
+	if _, ok := body["sort"]; ok {
+		t.Errorf("sort with keyword = %s", body["sort"])
+	}
+
+	if result.Engine != "elasticsearch" || result.Total != 12 || len(result.Lists) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	hit := result.Lists[0]
+	if hit.ID != 7 || hit.Title != "gin" || hit.Score != 1.5 || hit.Highlight["title"][0] != "<em>gin</em>" {
+		t.Errorf("hit = %+v", hit)
+	}
+	if len(result.Facets["tags"]) != 0 {
+		t.Errorf("tag facets = %v, want none", result.Facets["tags"])
+	}
+	if authors := result.Facets["authors"]; len(authors) != 2 || *authors[0] != (Facet{Key: "alice", Count: 5}) {
+		t.Errorf("author facets = %v", authors)
+	}
+
+	// 没有任何条件时查询为空，按创建时间倒序
+	if _, err := (&ArticleSearch{State: -1, PageSize: 10}).Search(); err != nil {
+		t.Fatal(err)
+	}
+	if string(body["query"]) != `{"bool":{}}` {
+		t.Errorf("empty query = %s", body["query"])
+	}
+	if string(body["sort"]) != `[{"created_on":{"order":"desc"}}]` {
+		t.Errorf("sort without keyword = %s", body["sort"])
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want float64
+	}{
+		{float64(3), 3},
+		{"3", 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := toFloat(tt.v); got != tt.want {
+			t.Errorf("toFloat(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
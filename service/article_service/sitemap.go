@@ -0,0 +1,152 @@
+package article_service
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
+)
+
+// SitemapName 入口文件名，文章数不超过 SitemapSize 时为 urlset，否则为指向 sitemap-N.xml 的索引
+const SitemapName = "sitemap.xml"
+
+var (
+	// sitemapSignal 文章变化的信号，容量为 1，生成前的多次变化只生成一次
+	sitemapSignal = make(chan struct{}, 1)
+	sitemapMu     sync.Mutex
+)
+
+// refreshSitemap 通知 StartSitemapGenerator 重新生成 sitemap，不阻塞
+func refreshSitemap() {
+	select {
+	case sitemapSignal <- struct{}{}:
+	default:
+	}
+}
+
+// GenerateSitemap 按已发布文章重新生成 sitemap 文件
+func GenerateSitemap() error {
+	sitemapMu.Lock()
+	defer sitemapMu.Unlock()
+
+	dir := sitemap.GetSitemapFullPath()
+	if err := file.IsNotExistMkDir(dir); err != nil {
+		return err
+	}
+
+	size := setting.FeedSetting.SitemapSize
+	if size <= 0 || size > sitemap.MaxURLs {
+		size = sitemap.MaxURLs
+	}
+	maps := map[string]interface{}{"deleted_on": 0, "state": 1}
+
+	var (
+		index   []*sitemap.URL
+		afterID int
+	)
+	for {
+		articles, err := models.GetArticleStamps(afterID, size, maps)
+		if err != nil {
+			return err
+		}
+
+		urls := make([]*sitemap.URL, 0, len(articles))
+		var lastMod time.Time
+		for _, article := range articles {
+			u := &sitemap.URL{Loc: ArticleURL(article), LastMod: time.Unix(int64(lastModifiedOn(article)), 0)}
+			if u.LastMod.After(lastMod) {
+				lastMod = u.LastMod
+			}
+			urls = append(urls, u)
+			afterID = article.ID
+		}
+
+		// 全部文章放得下一个文件时直接写入口文件
+		if len(index) == 0 && len(articles) < size {
+			if err := writeSitemap(filepath.Join(dir, SitemapName), urls, false); err != nil {
+				return err
+			}
+			return removeStaleSitemaps(dir, 0)
+		}
+		if len(articles) == 0 {
+			break
+		}
+
+		name := "sitemap-" + strconv.Itoa(len(index)+1) + ".xml"
+		if err := writeSitemap(filepath.Join(dir, name), urls, false); err != nil {
+			return err
+		}
+		index = append(index, &sitemap.URL{
+			Loc:     setting.AppSetting.PrefixUrl + "/" + sitemap.GetSitemapPath() + name,
+			LastMod: lastMod,
+		})
+		if len(articles) < size {
+			break
+		}
+	}
+
+	if err := writeSitemap(filepath.Join(dir, SitemapName), index, true); err != nil {
+		return err
+	}
+	return removeStaleSitemaps(dir, len(index))
+}
+
+// StartSitemapGenerator 启动时生成一次 sitemap，之后在文章变化 SitemapDelay 后重新生成
+func StartSitemapGenerator() {
+	go func() {
+		if err := GenerateSitemap(); err != nil {
+			logging.Warn("article_service.GenerateSitemap err:", err)
+		}
+
+		for range sitemapSignal {
+			time.Sleep(setting.FeedSetting.SitemapDelay)
+			if err := GenerateSitemap(); err != nil {
+				logging.Warn("article_service.GenerateSitemap err:", err)
+			}
+		}
+	}()
+}
+
+// writeSitemap 先写临时文件再重命名，读取方不会看到写了一半的文件
+func writeSitemap(path string, urls []*sitemap.URL, isIndex bool) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if isIndex {
+		err = sitemap.WriteIndex(f, urls)
+	} else {
+		err = sitemap.WriteURLSet(f, urls)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// removeStaleSitemaps 删除文章减少后多出来的 sitemap-N.xml，n 为当前的文件数
+func removeStaleSitemaps(dir string, n int) error {
+	for i := n + 1; ; i++ {
+		err := os.Remove(filepath.Join(dir, "sitemap-"+strconv.Itoa(i)+".xml"))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package article_service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
+)
+
+func TestWriteSitemap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SitemapName)
+
+	if err := writeSitemap(path, []*sitemap.URL{{Loc: "https://example.com/sitemap-1.xml"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "<sitemapindex") {
+		t.Errorf("sitemap = %s, %v", data, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left: %v", err)
+	}
+}
+
+func TestRemoveStaleSitemaps(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sitemap-1.xml", "sitemap-2.xml", "sitemap-3.xml", "sitemap-5.xml"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := removeStaleSitemaps(dir, 1); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"sitemap-1.xml": true, "sitemap-2.xml": false, "sitemap-3.xml": false} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestRefreshSitemap(t *testing.T) {
+	// 多次变化只保留一个信号，不阻塞调用方
+	refreshSitemap()
+	refreshSitemap()
+	if len(sitemapSignal) != 1 {
+		t.Errorf("pending signals = %d, want 1", len(sitemapSignal))
+	}
+	<-sitemapSignal
+}
@@ -0,0 +1,142 @@
+package article_service
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+var (
+	// ErrSlugExists 指定的 slug 已被其他文章使用
+	ErrSlugExists = errors.New("article: slug already exists")
+	// ErrInvalidSlug 指定的 slug 规范化后为空、全为数字或与保留路径同名
+	ErrInvalidSlug = errors.New("article: invalid slug")
+)
+
+// reservedSlugs 与 /articles/ 下的静态路径同名，不能作为 slug
+var reservedSlugs = map[string]bool{
+	"search":   true,
+	"trending": true,
+	"popular":  true,
+}
+
+// defaultSlug 标题中没有可用字符时使用
+const defaultSlug = "article"
+
+// backfillBatch 每批补全 slug 的文章数
+const backfillBatch = 100
+
+// ResolveSlug 按 slug 查找文章，旧 slug 找到的文章 moved 为 true，没有找到时 id 为 0
+func ResolveSlug(s string) (id int, moved bool, err error) {
+	s = slug.Make(s)
+	if s == "" {
+		return 0, false, nil
+	}
+
+	id, err = models.GetArticleIDBySlug(s)
+	if err != nil || id > 0 {
+		return id, false, err
+	}
+
+	id, err = models.GetArticleIDByOldSlug(s)
+	return id, id > 0, err
+}
+
+// validSlug slug 可以用于路由：非空、不全为数字、不与保留路径同名
+func validSlug(s string) bool {
+	return s != "" && !slug.IsNumeric(s) && !reservedSlugs[s]
+}
+
+// newSlug 指定了 want 时规范化后使用，已被占用时返回 ErrSlugExists；否则由标题生成不重复的 slug
+func newSlug(id int, want, title string) (string, error) {
+	if want == "" {
+		return uniqueSlug(id, slug.Make(title))
+	}
+
+	s := slug.Make(want)
+	if !validSlug(s) {
+		return "", ErrInvalidSlug
+	}
+	exists, err := models.ExistArticleSlug(s, id)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", ErrSlugExists
+	}
+
+	return s, nil
+}
+
+// uniqueSlug 在 base 后依次加上 -2、-3 等后缀，直到没有被其他文章使用
+func uniqueSlug(id int, base string) (string, error) {
+	if base == "" {
+		base = defaultSlug
+	}
+
+	for i := 1; ; i++ {
+		s := base
+		if i > 1 {
+			s = base + "-" + strconv.Itoa(i)
+		}
+		if !validSlug(s) {
+			continue
+		}
+
+		exists, err := models.ExistArticleSlug(s, id)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return s, nil
+		}
+	}
+}
+
+// nextSlug 修改文章时的 slug：指定了 slug 或标题改变时重新生成，否则保持不变
+func (a *Article) nextSlug(current *models.Article) (string, error) {
+	if a.Slug == "" && current.Slug != "" && current.Title == a.Title {
+		return current.Slug, nil
+	}
+
+	return newSlug(a.ID, a.Slug, a.Title)
+}
+
+// BackfillSlugs 为功能上线前没有 slug 的文章生成 slug
+func BackfillSlugs() error {
+	for {
+		articles, err := models.GetArticlesWithoutSlug(backfillBatch)
+		if err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			return nil
+		}
+
+		for _, article := range articles {
+			s, err := uniqueSlug(article.ID, slug.Make(article.Title))
+			if err != nil {
+				return err
+			}
+			if err := models.SetArticleSlug(article.ID, s); err != nil {
+				return err
+			}
+			cache_service.Invalidate(cache_service.ArticleTag(article.ID))
+		}
+		cache_service.BumpNamespace(cache_service.ArticleListNamespace)
+		refreshSitemap()
+	}
+}
+
+// StartSlugBackfill 在后台补全文章的 slug
+func StartSlugBackfill() {
+	go func() {
+		if err := BackfillSlugs(); err != nil {
+			logging.Warn("article_service.BackfillSlugs err:", err)
+		}
+	}()
+}
@@ -0,0 +1,23 @@
+package article_service
+
+import "testing"
+
+func TestValidSlug(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"hello-go", true},
+		{"go-2", true},
+		{"", false},
+		{"123", false},
+		{"search", false},
+		{"trending", false},
+		{"popular", false},
+	}
+	for _, tt := range tests {
+		if got := validSlug(tt.s); got != tt.want {
+			t.Errorf("validSlug(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package article_service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 浏览量先累加到 PENDING，定期改名为 FLUSHING 后写入数据库；两者使用同一个 hash tag，集群模式下可以 RENAME
+const (
+	viewsPendingKey  = "{" + e.CACHE_ARTICLE + "_VIEWS}_PENDING"
+	viewsFlushingKey = "{" + e.CACHE_ARTICLE + "_VIEWS}_FLUSHING"
+	viewsFlushLock   = e.CACHE_ARTICLE + "_VIEWS_FLUSH"
+	// viewsBatchField FLUSHING 中保存批次号的字段，不是文章 ID
+	viewsBatchField = "_batch"
+
+	trendingKey = "{" + e.CACHE_ARTICLE + "_TRENDING}"
+)
+
+// viewsFlushLockTTL 写入期间每隔三分之一的时间续期一次
+const viewsFlushLockTTL = time.Minute
+
+// trendingEpoch 热度的时间起点，只影响分数的绝对值，不影响排序
+var trendingEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// trendingScript 在有序集合中累加按时间衰减的热度
+//
+// 一次浏览的热度为 2^((t-epoch)/halfLife)，越晚的浏览权重越大，相当于旧的热度按半衰期衰减；
+// 分数保存其以 2 为底的对数，累加时用 log2(2^a + 2^b) 计算，避免数值溢出
+// KEYS[1] 榜单；ARGV[1] 文章 ID，ARGV[2] 本次浏览的 log2 权重，ARGV[3] 榜单保留数量
+var trendingScript = gredis.NewScript(1, `
+local x = tonumber(ARGV[2])
+local size = tonumber(ARGV[3])
+local v = x
+local s = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if s then
+	s = tonumber(s)
+	local hi, lo = math.max(s, x), math.min(s, x)
+	v = hi + math.log(1 + 2 ^ (lo - hi)) / math.log(2)
+end
+redis.call("ZADD", KEYS[1], v, ARGV[1])
+if size > 0 and redis.call("ZCARD", KEYS[1]) > size then
+	redis.call("ZREMRANGEBYRANK", KEYS[1], 0, -size - 1)
+end
+return 1
+`)
+
+func trendingTagKey(tagID int) string {
+	return trendingKey + "_TAG_" + strconv.Itoa(tagID)
+}
+
+func visitorsKey(id int) string {
+	return e.CACHE_ARTICLE + "_VISITORS_" + strconv.Itoa(id)
+}
+
+// trendingWeight 当前时刻一次浏览的 log2 权重
+func trendingWeight(t time.Time) float64 {
+	halfLife := setting.ViewsSetting.TrendingHalfLife
+	if halfLife <= 0 {
+		halfLife = 24 * time.Hour
+	}
+
+	return float64(t.Sub(trendingEpoch)) / float64(halfLife)
+}
+
+// RecordView 记录一次浏览：累加浏览量、记录独立访客并更新全站及文章各标签的热门榜单，失败只记录日志
+func RecordView(article *models.Article, visitor string) {
+	ctx := context.Background()
+	id := article.ID
+	member := strconv.Itoa(id)
+
+	if _, err := gredis.HIncrBy(ctx, viewsPendingKey, member, 1); err != nil {
+		logging.Warn("article_service.RecordView incr err:", id, err)
+	}
+	if visitor != "" {
+		if _, err := gredis.PFAdd(ctx, visitorsKey(id), visitor); err != nil {
+			logging.Warn("article_service.RecordView pfadd err:", id, err)
+		}
+	}
+
+	weight := strconv.FormatFloat(trendingWeight(time.Now()), 'f', -1, 64)
+	keys := []string{trendingKey}
+	for _, tag := range article.Tags {
+		keys = append(keys, trendingTagKey(tag.ID))
+	}
+	for _, key := range keys {
+		if _, err := trendingScript.Do(ctx, key, member, weight, setting.ViewsSetting.TrendingSize); err != nil {
+			logging.Warn("article_service.RecordView trending err:", id, key, err)
+		}
+	}
+}
+
+// addPendingViews 把尚未写入数据库的浏览量加到文章上
+func addPendingViews(articles ...*models.Article) {
+	if len(articles) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	fields := make([]string, 0, len(articles))
+	for _, article := range articles {
+		fields = append(fields, strconv.Itoa(article.ID))
+	}
+
+	for _, key := range []string{viewsPendingKey, viewsFlushingKey} {
+		values, err := gredis.HMGet(ctx, key, fields...)
+		if err != nil {
+			logging.Warn("article_service.addPendingViews err:", key, err)
+			continue
+		}
+		for i, v := range values {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && i < len(articles) {
+				articles[i].Views += n
+			}
+		}
+	}
+}
+
+// visitors 文章的独立访客数
+func visitors(id int) int64 {
+	n, err := gredis.PFCount(context.Background(), visitorsKey(id))
+	if err != nil {
+		logging.Warn("article_service.visitors err:", id, err)
+	}
+
+	return n
+}
+
+// FlushViews 把缓冲的浏览量写入数据库，多个实例同时运行时只有一个会执行
+//
+// 每批浏览量有一个批次号，与浏览量在同一个事务中写入数据库；写入后删除 FLUSHING 失败或进程退出时，
+// 下次重试会跳过已经写入的批次，不会重复累加
+func FlushViews() error {
+	return flushViews(models.AddArticleViews)
+}
+
+// flushViews apply 写入一批浏览量，批次已写入过时返回 false
+func flushViews(apply func(batch string, views map[int]int64) (bool, error)) error {
+	ctx := context.Background()
+	lock, err := gredis.Obtain(ctx, viewsFlushLock, viewsFlushLockTTL)
+	if err == gredis.ErrLockNotObtained {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Release(ctx); err != nil {
+			logging.Warn("article_service.FlushViews release lock err:", err)
+		}
+	}()
+	defer lock.KeepAlive(viewsFlushLockTTL)()
+
+	return flushBatch(ctx, apply)
+}
+
+// flushBatch 在持有锁时把 FLUSHING 中的一批浏览量交给 apply，成功后删除 FLUSHING
+func flushBatch(ctx context.Context, apply func(batch string, views map[int]int64) (bool, error)) error {
+	// 上次写入数据库失败时 FLUSHING 仍然存在，先处理它，避免被 RENAME 覆盖
+	exists, err := redis.Bool(gredis.Do(ctx, viewsFlushingKey, "EXISTS", viewsFlushingKey))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := gredis.Do(ctx, viewsPendingKey, "RENAME", viewsPendingKey, viewsFlushingKey)
+		if rerr, ok := err.(redis.Error); ok && strings.Contains(string(rerr), "no such key") {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// 批次号在第一次处理这批浏览量时生成，重试时沿用
+	batch, err := newViewsBatch()
+	if err != nil {
+		return err
+	}
+	if _, err := gredis.Do(ctx, viewsFlushingKey, "HSETNX", viewsFlushingKey, viewsBatchField, batch); err != nil {
+		return err
+	}
+
+	fields, err := gredis.HGetAll(ctx, viewsFlushingKey)
+	if err != nil {
+		return err
+	}
+
+	views := make(map[int]int64, len(fields))
+	for field, value := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		views[id] = n
+	}
+
+	// 不清除文章缓存：读取时会加上 PENDING 和 FLUSHING 中的浏览量，缓存中的浏览量最多滞后一个缓存周期
+	if _, err := apply(fields[viewsBatchField], views); err != nil {
+		return err
+	}
+
+	_, err = gredis.Del(ctx, viewsFlushingKey)
+	return err
+}
+
+func newViewsBatch() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// StartViewFlusher 按配置的间隔定期执行 FlushViews
+func StartViewFlusher() {
+	interval := setting.ViewsSetting.FlushInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := FlushViews(); err != nil {
+				logging.Warn("article_service.FlushViews err:", err)
+			}
+		}
+	}()
+}
+
+// TrendingArticle 热门文章
+type TrendingArticle struct {
+	*models.Article
+	Score float64 `json:"score"`
+}
+
+// Trending 按衰减后的热度返回热门文章，tagID 大于 0 时返回该标签的榜单
+func Trending(tagID, limit int) ([]*TrendingArticle, error) {
+	key := trendingKey
+	if tagID > 0 {
+		key = trendingTagKey(tagID)
+	}
+
+	// 榜单中可能有已删除、已下线或已改标签的文章，多取一些用于过滤
+	members, err := gredis.ZRevRange(context.Background(), key, 0, int64(limit*2-1))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	scores := make(map[int]float64, len(members))
+	now := trendingWeight(time.Now())
+	for _, z := range members {
+		id, err := strconv.Atoi(z.Member)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		// 换算成当前时刻的等效浏览量
+		scores[id] = math.Pow(2, z.Score-now)
+	}
+
+	articles, err := models.GetArticlesByIDs(ids, map[string]interface{}{"deleted_on": 0, "state": 1}, rankScopes(tagID)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	render(articles...)
+	addPendingViews(articles...)
+	addCommentCounts(articles...)
+
+	list := make([]*TrendingArticle, 0, len(articles))
+	for _, article := range articles {
+		list = append(list, &TrendingArticle{Article: article, Score: scores[article.ID]})
+	}
+
+	return list, nil
+}
+
+// Popular 按累计浏览量返回文章
+func Popular(tagID, limit int) ([]*models.Article, error) {
+	articles, err := models.GetPopularArticles(limit, map[string]interface{}{"deleted_on": 0, "state": 1}, rankScopes(tagID)...)
+	if err != nil {
+		return nil, err
+	}
+	render(articles...)
+	addPendingViews(articles...)
+	addCommentCounts(articles...)
+
+	return articles, nil
+}
+
+// rankScopes tagID 大于 0 时只返回带有该标签的文章
+func rankScopes(tagID int) []func(*gorm.DB) *gorm.DB {
+	if tagID <= 0 {
+		return nil
+	}
+
+	return []func(*gorm.DB) *gorm.DB{models.WithAnyTags([]int{tagID})}
+}
+
+// removeTrending 文章删除后从全站榜单移除，标签榜单在读取时过滤
+func (a *Article) removeTrending() {
+	if _, err := gredis.ZRem(context.Background(), trendingKey, strconv.Itoa(a.ID)); err != nil {
+		logging.Warn("article_service.removeTrending err:", a.ID, err)
+	}
+}
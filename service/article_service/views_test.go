@@ -0,0 +1,209 @@
+package article_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	s := miniredis.RunT(t)
+
+	saved := *setting.RedisSetting
+	t.Cleanup(func() { *setting.RedisSetting = saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = s.Addr()
+	if err := gredis.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func setViews(t *testing.T, v setting.Views) {
+	saved := *setting.ViewsSetting
+	t.Cleanup(func() { *setting.ViewsSetting = saved })
+	*setting.ViewsSetting = v
+}
+
+// fakeViews 模拟 AddArticleViews：同一批次只累加一次
+type fakeViews struct {
+	batches []string
+	views   map[int]int64
+}
+
+func (f *fakeViews) apply(batch string, views map[int]int64) (bool, error) {
+	f.batches = append(f.batches, batch)
+	for _, b := range f.batches[:len(f.batches)-1] {
+		if b == batch {
+			return false, nil
+		}
+	}
+	for id, n := range views {
+		f.views[id] += n
+	}
+	return true, nil
+}
+
+func TestFlushBatch(t *testing.T) {
+	s := newTestRedis(t)
+	ctx := context.Background()
+	f := &fakeViews{views: map[int]int64{}}
+
+	s.HSet(viewsPendingKey, "1", "3", "2", "1")
+
+	// 写入数据库后删除 FLUSHING 失败，重试时沿用同一批次号
+	s.Server().SetPreHook(func(peer *server.Peer, cmd string, args ...string) bool {
+		if cmd != "DEL" {
+			return false
+		}
+		peer.WriteError("ERR connection reset")
+		return true
+	})
+	if err := flushBatch(ctx, f.apply); err == nil {
+		t.Fatal("flushBatch() with failed DEL err = nil")
+	}
+	s.HIncr(viewsPendingKey, "1", 5)
+	s.Server().SetPreHook(nil)
+	if err := flushBatch(ctx, f.apply); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.batches) != 2 || f.batches[0] == "" || f.batches[0] != f.batches[1] {
+		t.Fatalf("batches = %q, want the same batch twice", f.batches)
+	}
+	if f.views[1] != 3 || f.views[2] != 1 {
+		t.Errorf("views after retry = %v, want map[1:3 2:1]", f.views)
+	}
+
+	// 重试期间新增的浏览量属于下一批
+	if err := flushBatch(ctx, f.apply); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.batches) != 3 || f.batches[2] == f.batches[0] {
+		t.Fatalf("batches = %q, want a new batch", f.batches)
+	}
+	if f.views[1] != 8 {
+		t.Errorf("views[1] = %d, want 8", f.views[1])
+	}
+
+	// 没有缓冲的浏览量时不调用 apply
+	if err := flushBatch(ctx, f.apply); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.batches) != 3 {
+		t.Errorf("apply called %d times, want 3", len(f.batches))
+	}
+
+	// 读取时会加上缓冲的浏览量，写入数据库后不让列表缓存失效
+	if s.Exists("GEN_" + cache_service.ArticleListNamespace) {
+		t.Error("flushBatch() should not bump the article list namespace")
+	}
+}
+
+func TestFlushBatchApplyError(t *testing.T) {
+	s := newTestRedis(t)
+	s.HSet(viewsPendingKey, "1", "3")
+
+	failed := errors.New("db down")
+	var batch string
+	err := flushBatch(context.Background(), func(b string, views map[int]int64) (bool, error) {
+		batch = b
+		return false, failed
+	})
+	if err != failed {
+		t.Fatalf("flushBatch() err = %v, want %v", err, failed)
+	}
+	if got := s.HGet(viewsFlushingKey, viewsBatchField); got != batch {
+		t.Errorf("FLUSHING batch = %q, want %q kept for retry", got, batch)
+	}
+}
+
+func TestTrendingWeight(t *testing.T) {
+	tests := []struct {
+		halfLife time.Duration
+		t        time.Time
+		want     float64
+	}{
+		{0, trendingEpoch, 0},
+		{0, trendingEpoch.Add(48 * time.Hour), 2},
+		{12 * time.Hour, trendingEpoch.Add(48 * time.Hour), 4},
+		{12 * time.Hour, trendingEpoch.Add(-6 * time.Hour), -0.5},
+	}
+	for _, tt := range tests {
+		setViews(t, setting.Views{TrendingHalfLife: tt.halfLife})
+		if got := trendingWeight(tt.t); got != tt.want {
+			t.Errorf("trendingWeight(%v) with half-life %v = %v, want %v", tt.t, tt.halfLife, got, tt.want)
+		}
+	}
+}
+
+func TestRecordView(t *testing.T) {
+	s := newTestRedis(t)
+	setViews(t, setting.Views{})
+	article := &models.Article{Model: models.Model{ID: 1}, Tags: []models.Tag{{Model: models.Model{ID: 7}}}}
+
+	before := trendingWeight(time.Now())
+	for _, visitor := range []string{"a", "b", "a", ""} {
+		RecordView(article, visitor)
+	}
+	after := trendingWeight(time.Now())
+
+	if got := s.HGet(viewsPendingKey, "1"); got != "4" {
+		t.Errorf("pending views = %q, want 4", got)
+	}
+	if got := visitors(1); got != 2 {
+		t.Errorf("visitors(1) = %d, want 2", got)
+	}
+	if got := visitors(2); got != 0 {
+		t.Errorf("visitors(2) = %d, want 0", got)
+	}
+
+	// 四次浏览的热度相加，分数为 log2(4 * 2^weight)
+	for _, key := range []string{trendingKey, trendingTagKey(7)} {
+		score, _ := s.ZScore(key, "1")
+		if score < before+2-1e-9 || score > after+2+1e-9 {
+			t.Errorf("%s score = %v, want in [%v, %v]", key, score, before+2, after+2)
+		}
+	}
+}
+
+func TestRecordViewTrendingSize(t *testing.T) {
+	s := newTestRedis(t)
+	setViews(t, setting.Views{TrendingSize: 2})
+	s.ZAdd(trendingKey, 0, "9")
+
+	for _, id := range []int{1, 2} {
+		RecordView(&models.Article{Model: models.Model{ID: id}}, "")
+	}
+
+	// 超出保留数量时移除热度最低的文章
+	if members, _ := s.ZMembers(trendingKey); len(members) != 2 || members[0] == "9" || members[1] == "9" {
+		t.Errorf("members = %v, want 1 and 2 without the lowest article", members)
+	}
+}
+
+func TestAddPendingViews(t *testing.T) {
+	s := newTestRedis(t)
+	s.HSet(viewsPendingKey, "1", "3")
+	s.HSet(viewsFlushingKey, "1", "2", viewsBatchField, "batch")
+
+	articles := []*models.Article{
+		{Model: models.Model{ID: 1}, Views: 10},
+		{Model: models.Model{ID: 2}, Views: 1},
+	}
+	addPendingViews(articles...)
+
+	if articles[0].Views != 15 || articles[1].Views != 1 {
+		t.Errorf("views = %d, %d, want 15, 1", articles[0].Views, articles[1].Views)
+	}
+}
@@ -0,0 +1,159 @@
+package article_service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	casbinPkg "github.com/EDDYCJY/go-gin-example/pkg/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// casbinObject 文章流程权限在 Casbin 中的资源名，操作名即流程动作，例如 p, editor, article, submit
+const casbinObject = "article"
+
+// publishInterval 检查定时发布的间隔
+const publishInterval = time.Minute
+
+var (
+	// ErrForbidden 当前用户没有执行该操作的权限
+	ErrForbidden = errors.New("article: forbidden")
+	// ErrInvalidTransition 文章当前状态不允许该操作
+	ErrInvalidTransition = errors.New("article: invalid status transition")
+)
+
+// 流程动作
+const (
+	ActionSubmit  = "submit"  // 编辑提交审核
+	ActionApprove = "approve" // 管理员审核通过并发布，可指定定时发布时间
+	ActionReject  = "reject"  // 管理员驳回，退回草稿
+	ActionArchive = "archive" // 下线归档
+	ActionReopen  = "reopen"  // 归档的文章重新作为草稿编辑
+	ActionEdit    = "edit"    // 编辑内容、恢复历史版本
+	ActionCreate  = "create"  // 新建文章
+	ActionDelete  = "delete"  // 删除文章
+)
+
+type transition struct {
+	from, to string
+}
+
+// transitions draft → in_review → published → archived
+var transitions = map[string]transition{
+	ActionSubmit:  {models.ArticleStatusDraft, models.ArticleStatusInReview},
+	ActionApprove: {models.ArticleStatusInReview, models.ArticleStatusPublished},
+	ActionReject:  {models.ArticleStatusInReview, models.ArticleStatusDraft},
+	ActionArchive: {models.ArticleStatusPublished, models.ArticleStatusArchived},
+	ActionReopen:  {models.ArticleStatusArchived, models.ArticleStatusDraft},
+}
+
+// ValidAction 是否为流程动作
+func ValidAction(action string) bool {
+	_, ok := transitions[action]
+	return ok
+}
+
+// Authorize 检查 subject 能否对文章执行 action，接口在查询数据库之前调用，没有权限时尽早返回
+func Authorize(subject, action string) error {
+	return authorize(subject, action)
+}
+
+// authorize 按 Casbin 策略检查 subject 能否对文章执行 action
+func authorize(subject, action string) error {
+	ok, err := casbinPkg.Enforce(subject, casbinObject, action)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// reviewAgain 修改已发布的文章时把状态改回审核中并下线，避免内容绕过审核直接生效
+func reviewAgain(current *models.Article, data map[string]interface{}) {
+	if current.Status != models.ArticleStatusPublished {
+		return
+	}
+
+	data["status"] = models.ArticleStatusInReview
+	data["state"] = 0
+}
+
+// Transition 执行流程动作，subject 为 Casbin 主体（例如 user:1）
+// approve 时 publishAt 为 0 或已过去表示立即发布，否则到时间后由定时任务发布
+func (a *Article) Transition(subject, action string, publishAt int) error {
+	t, ok := transitions[action]
+	if !ok {
+		return ErrInvalidTransition
+	}
+	if err := authorize(subject, action); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"status": t.to,
+		"state":  0,
+	}
+	if a.ModifiedBy != "" {
+		data["modified_by"] = a.ModifiedBy
+	}
+	if action == ActionApprove {
+		now := int(time.Now().Unix())
+		if publishAt <= now {
+			publishAt = now
+			data["state"] = 1
+		}
+		data["publish_at"] = publishAt
+	}
+
+	ok, err := models.TransitionArticle(a.ID, t.from, data)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTransition
+	}
+
+	a.afterWrite()
+	return nil
+}
+
+// afterWrite 文章内容或可见性变化后刷新缓存、搜索索引和 sitemap
+func (a *Article) afterWrite() {
+	cache_service.Invalidate(cache_service.ArticleTag(a.ID))
+	cache_service.BumpNamespace(cache_service.ArticleListNamespace)
+	a.syncIndex()
+	refreshSitemap()
+}
+
+// PublishScheduled 发布到时间的定时文章
+func PublishScheduled() error {
+	ids, err := models.PublishDueArticles(int(time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		a := &Article{ID: id}
+		a.afterWrite()
+	}
+
+	return nil
+}
+
+// StartPublisher 定期执行 PublishScheduled；多个实例同时执行时条件更新保证同一篇文章只发布一次
+func StartPublisher() {
+	go func() {
+		ticker := time.NewTicker(publishInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := PublishScheduled(); err != nil {
+				logging.Warn("article_service.PublishScheduled err:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,49 @@
+package article_service
+
+import (
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+)
+
+func TestReviewAgain(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantStatus interface{}
+		wantState  interface{}
+	}{
+		{models.ArticleStatusPublished, models.ArticleStatusInReview, 0},
+		{models.ArticleStatusDraft, nil, nil},
+		{models.ArticleStatusInReview, nil, nil},
+		{models.ArticleStatusArchived, nil, nil},
+	}
+	for _, tt := range tests {
+		data := map[string]interface{}{"title": "t"}
+		reviewAgain(&models.Article{Status: tt.status}, data)
+		if data["status"] != tt.wantStatus || data["state"] != tt.wantState {
+			t.Errorf("reviewAgain(%s) = status %v state %v, want %v %v",
+				tt.status, data["status"], data["state"], tt.wantStatus, tt.wantState)
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	for _, action := range []string{ActionSubmit, ActionApprove, ActionReject, ActionArchive, ActionReopen} {
+		if !ValidAction(action) {
+			t.Errorf("ValidAction(%s) = false", action)
+		}
+	}
+	// 编辑、新建和删除不是状态流转，不能通过 workflow 接口执行
+	for _, action := range []string{ActionEdit, ActionCreate, ActionDelete} {
+		if ValidAction(action) {
+			t.Errorf("ValidAction(%s) = true", action)
+		}
+	}
+
+	// 只有审核通过才能进入发布状态
+	for action, tr := range transitions {
+		if tr.to == models.ArticleStatusPublished && action != ActionApprove {
+			t.Errorf("%s publishes an article without approval", action)
+		}
+	}
+}
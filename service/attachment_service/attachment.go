@@ -0,0 +1,92 @@
+package attachment_service
+
+import (
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/models/stock"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+type Attachment struct {
+	ID        int
+	OwnerType string
+	OwnerID   int
+}
+
+// ExistOwner 附件所属的文章、库存产品或订单是否存在
+func ExistOwner(ownerType string, ownerID int) (bool, error) {
+	switch ownerType {
+	case models.AttachmentOwnerArticle:
+		return models.ExistArticleByID(ownerID)
+	case models.AttachmentOwnerStockProduct:
+		return stock.ExistStockProductByID(ownerID)
+	case models.AttachmentOwnerOrder:
+		order, err := models.GetOrderById(ownerID)
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return order.ID > 0 && order.Deleted == 0, nil
+	}
+
+	return false, nil
+}
+
+// AllowFile 文件扩展名在 AllowExts 中
+func AllowFile(fileName string) bool {
+	ext := strings.ToLower(path.Ext(fileName))
+	for _, allowExt := range setting.AttachmentSetting.AllowExts {
+		if strings.ToLower(allowExt) == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Attachment) Get() (*models.Attachment, error) {
+	return models.GetAttachment(a.ID)
+}
+
+func (a *Attachment) GetAll() ([]*models.Attachment, error) {
+	return models.GetAttachments(a.OwnerType, a.OwnerID)
+}
+
+// Delete 删除附件，没有其他附件使用相同内容时同时删除文件
+func (a *Attachment) Delete() error {
+	attachment, err := models.GetAttachment(a.ID)
+	if err != nil || attachment == nil {
+		return err
+	}
+	if err := models.DeleteAttachment(a.ID); err != nil {
+		return err
+	}
+
+	count, err := models.CountAttachmentsByKey(attachment.Key)
+	if err != nil || count > 0 {
+		return err
+	}
+	return storage.Default.Delete(attachment.Key)
+}
+
+// URL 附件的临时下载地址
+func URL(attachment *models.Attachment) (string, error) {
+	return storage.Default.SignedURL(attachment.Key, setting.StorageSetting.SignedUrlExpire)
+}
+
+// contentType 按扩展名判断 MIME 类型
+func contentType(fileName string) string {
+	if t := mime.TypeByExtension(strings.ToLower(path.Ext(fileName))); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
@@ -0,0 +1,414 @@
+package attachment_service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+var (
+	// ErrInvalidFile 所属对象不存在，或文件类型、大小不允许
+	ErrInvalidFile = errors.New("attachment: invalid owner, file type or size")
+	// ErrUploadNotFound 上传不存在或不是当前用户创建的
+	ErrUploadNotFound = errors.New("attachment: upload not found")
+	// ErrUploadClosed 上传已完成、已取消或已过期
+	ErrUploadClosed = errors.New("attachment: upload is closed")
+	// ErrInvalidChunk 分片序号超出范围或大小不对
+	ErrInvalidChunk = errors.New("attachment: invalid chunk index or size")
+	// ErrChunkChecksum 分片内容与校验和不一致
+	ErrChunkChecksum = errors.New("attachment: chunk checksum mismatch")
+	// ErrIncomplete 还有分片没有上传
+	ErrIncomplete = errors.New("attachment: upload is incomplete")
+	// ErrChecksum 合并后的文件与创建上传时声明的 SHA-256 不一致
+	ErrChecksum = errors.New("attachment: file checksum mismatch")
+)
+
+const (
+	// cleanBatch 每次清理的过期上传数
+	cleanBatch = 100
+	// completingGrace 合并中的上传过期后再等待多久才清理，避免删除正在合并的分片；
+	// 合并中途进程退出的上传停留在合并中，超过这个时间后同样被清理
+	completingGrace = time.Hour
+)
+
+// Upload 分片上传：Init 创建上传，PutChunk 上传各个分片（可以重传、乱序），Complete 校验并合并为附件
+type Upload struct {
+	FileName  string
+	Size      int64
+	SHA256    string // 完整文件的 SHA-256，十六进制
+	OwnerType string
+	OwnerID   int
+	CreatedBy string
+}
+
+// Progress 上传进度，Chunks 为已收到的分片序号，断线后从缺少的分片继续上传
+type Progress struct {
+	*models.Upload
+	TotalChunks int   `json:"total_chunks"`
+	Chunks      []int `json:"chunks"`
+}
+
+// Init 创建上传，分片大小由 [attachment] ChunkSize 决定
+func (u *Upload) Init() (*Progress, error) {
+	if u.Size <= 0 || u.Size > setting.AttachmentSetting.MaxSize || !AllowFile(u.FileName) {
+		return nil, ErrInvalidFile
+	}
+	if sum, err := hex.DecodeString(u.SHA256); err != nil || len(sum) != sha256.Size {
+		return nil, ErrChecksum
+	}
+	exists, err := ExistOwner(u.OwnerType, u.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrInvalidFile
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+	upload := &models.Upload{
+		UploadID:  id,
+		FileName:  path.Base(u.FileName),
+		Size:      u.Size,
+		ChunkSize: uploadChunkSize(),
+		SHA256:    strings.ToLower(u.SHA256),
+		OwnerType: u.OwnerType,
+		OwnerID:   u.OwnerID,
+		Status:    models.UploadStatusUploading,
+		CreatedBy: u.CreatedBy,
+		ExpiresOn: int(time.Now().Add(setting.AttachmentSetting.Expire).Unix()),
+	}
+	if err := models.AddUpload(upload); err != nil {
+		return nil, err
+	}
+
+	return &Progress{Upload: upload, TotalChunks: totalChunks(upload), Chunks: []int{}}, nil
+}
+
+// GetProgress 获取上传进度，只有创建者可以访问
+func GetProgress(uploadID, subject string) (*Progress, error) {
+	upload, err := getUpload(uploadID, subject)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := models.GetUploadChunks(upload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0, len(chunks))
+	for _, chunk := range chunks {
+		indexes = append(indexes, chunk.Index)
+	}
+	return &Progress{Upload: upload, TotalChunks: totalChunks(upload), Chunks: indexes}, nil
+}
+
+// PutChunk 保存一个分片，checksum 为分片内容的 SHA-256，同一分片重传时覆盖
+func PutChunk(uploadID, subject string, index int, r io.Reader, checksum string) error {
+	upload, err := getUpload(uploadID, subject)
+	if err != nil {
+		return err
+	}
+	if !isOpen(upload) {
+		return ErrUploadClosed
+	}
+	if index < 0 || index >= totalChunks(upload) {
+		return ErrInvalidChunk
+	}
+
+	// 多读一个字节判断分片是否超出大小
+	size := chunkSize(upload, index)
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(size)+1))
+	if err != nil {
+		return err
+	}
+	if len(data) != size {
+		return ErrInvalidChunk
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != strings.ToLower(checksum) {
+		return ErrChunkChecksum
+	}
+
+	if err := storage.Default.Put(chunkKey(upload, index), bytes.NewReader(data), "application/octet-stream"); err != nil {
+		return err
+	}
+	return models.SaveUploadChunk(&models.UploadChunk{
+		UploadID: upload.ID,
+		Index:    index,
+		Size:     size,
+		SHA256:   hex.EncodeToString(sum[:]),
+	})
+}
+
+// Complete 校验全部分片合并后的 SHA-256 并保存为附件，重复调用返回同一个附件
+func Complete(uploadID, subject string) (*models.Attachment, error) {
+	upload, err := getUpload(uploadID, subject)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status == models.UploadStatusCompleted {
+		return models.GetAttachment(upload.AttachmentID)
+	}
+	if !isOpen(upload) {
+		return nil, ErrUploadClosed
+	}
+
+	chunks, err := models.GetUploadChunks(upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) != totalChunks(upload) {
+		return nil, ErrIncomplete
+	}
+
+	// 合并期间不再接受分片，也防止重复合并
+	ok, err := models.SetUploadStatus(upload.ID, []string{models.UploadStatusUploading}, models.UploadStatusCompleting, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUploadClosed
+	}
+
+	attachment, err := complete(upload)
+	if err != nil {
+		if _, serr := models.SetUploadStatus(upload.ID, []string{models.UploadStatusCompleting}, models.UploadStatusUploading, nil); serr != nil {
+			logging.Warn("attachment_service.Complete reset status err:", serr)
+		}
+		return nil, err
+	}
+
+	_, err = models.SetUploadStatus(upload.ID, []string{models.UploadStatusCompleting}, models.UploadStatusCompleted, map[string]interface{}{
+		"attachment_id": attachment.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	removeChunks(upload)
+
+	return attachment, nil
+}
+
+// complete 读一遍分片校验 SHA-256，一致后由存储拼接分片写入附件（S3 在服务端复制），
+// 分片内容只经过本服务一次，不会用错误的内容覆盖已有的同名文件
+func complete(upload *models.Upload) (*models.Attachment, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, newChunkReader(upload)); err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != upload.SHA256 {
+		return nil, ErrChecksum
+	}
+
+	// 相同内容的附件共用一个文件
+	key := setting.AttachmentSetting.SavePath + upload.SHA256 + strings.ToLower(path.Ext(upload.FileName))
+	exists, err := storage.Default.Exists(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		chunks := make([]string, totalChunks(upload))
+		for i := range chunks {
+			chunks[i] = chunkKey(upload, i)
+		}
+		if err := storage.Default.Compose(key, chunks, contentType(upload.FileName)); err != nil {
+			return nil, err
+		}
+	}
+
+	attachment := &models.Attachment{
+		OwnerType:   upload.OwnerType,
+		OwnerID:     upload.OwnerID,
+		FileName:    upload.FileName,
+		Key:         key,
+		Size:        upload.Size,
+		ContentType: contentType(upload.FileName),
+		SHA256:      upload.SHA256,
+		CreatedBy:   upload.CreatedBy,
+	}
+	if err := models.AddAttachment(attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// Abort 取消上传并删除已上传的分片
+func Abort(uploadID, subject string) error {
+	upload, err := getUpload(uploadID, subject)
+	if err != nil {
+		return err
+	}
+
+	ok, err := models.SetUploadStatus(upload.ID, []string{models.UploadStatusUploading}, models.UploadStatusAborted, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUploadClosed
+	}
+
+	removeChunks(upload)
+	return nil
+}
+
+// CleanExpired 取消过期未完成的上传并删除分片，合并中的上传在过期 completingGrace 后才清理
+func CleanExpired() error {
+	for {
+		now := time.Now()
+		uploads, err := models.GetExpiredUploads(int(now.Unix()), int(now.Add(-completingGrace).Unix()), cleanBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, upload := range uploads {
+			ok, err := models.SetUploadStatus(upload.ID, []string{upload.Status}, models.UploadStatusAborted, nil)
+			if err != nil {
+				return err
+			}
+			if ok {
+				removeChunks(upload)
+			}
+		}
+		if len(uploads) < cleanBatch {
+			return nil
+		}
+	}
+}
+
+// StartUploadCleaner 按 CleanInterval 定期清理过期的上传
+func StartUploadCleaner() {
+	interval := setting.AttachmentSetting.CleanInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := CleanExpired(); err != nil {
+				logging.Warn("attachment_service.CleanExpired err:", err)
+			}
+		}
+	}()
+}
+
+func getUpload(uploadID, subject string) (*models.Upload, error) {
+	upload, err := models.GetUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil || upload.CreatedBy != subject {
+		return nil, ErrUploadNotFound
+	}
+
+	return upload, nil
+}
+
+// isOpen 上传中且没有过期
+func isOpen(upload *models.Upload) bool {
+	return upload.Status == models.UploadStatusUploading && int64(upload.ExpiresOn) > time.Now().Unix()
+}
+
+// uploadChunkSize 分片大小，分片由存储拼接，除最后一片外不能小于 storage.MinPartSize
+func uploadChunkSize() int {
+	if setting.AttachmentSetting.ChunkSize < storage.MinPartSize {
+		return storage.MinPartSize
+	}
+
+	return setting.AttachmentSetting.ChunkSize
+}
+
+func totalChunks(upload *models.Upload) int {
+	return int((upload.Size + int64(upload.ChunkSize) - 1) / int64(upload.ChunkSize))
+}
+
+// chunkSize 除最后一片外都是 ChunkSize
+func chunkSize(upload *models.Upload, index int) int {
+	if index == totalChunks(upload)-1 {
+		return int(upload.Size - int64(index)*int64(upload.ChunkSize))
+	}
+
+	return upload.ChunkSize
+}
+
+func chunkKey(upload *models.Upload, index int) string {
+	return setting.AttachmentSetting.ChunkSavePath + upload.UploadID + "/" + strconv.Itoa(index)
+}
+
+// removeChunks 删除分片文件和记录，失败时只记录日志，不影响上传的结果
+func removeChunks(upload *models.Upload) {
+	for i := 0; i < totalChunks(upload); i++ {
+		if err := storage.Default.Delete(chunkKey(upload, i)); err != nil {
+			logging.Warn("attachment_service.removeChunks err:", err)
+		}
+	}
+	if err := models.DeleteUploadChunks(upload.ID); err != nil {
+		logging.Warn("attachment_service.removeChunks err:", err)
+	}
+}
+
+// newUploadID 随机的上传 ID，不可猜测
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// chunkReader 按顺序读取全部分片
+type chunkReader struct {
+	upload  *models.Upload
+	index   int
+	current io.ReadCloser
+}
+
+func newChunkReader(upload *models.Upload) *chunkReader {
+	return &chunkReader{upload: upload}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.index >= totalChunks(r.upload) {
+				return 0, io.EOF
+			}
+			rc, err := storage.Default.Get(chunkKey(r.upload, r.index))
+			if err != nil {
+				return 0, err
+			}
+			r.current = rc
+			r.index++
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,83 @@
+package attachment_service
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		size      int64
+		chunkSize int
+		total     int
+		last      int
+	}{
+		{1, 10, 1, 1},
+		{10, 10, 1, 10},
+		{11, 10, 2, 1},
+		{25, 10, 3, 5},
+	}
+	for _, tt := range tests {
+		upload := &models.Upload{Size: tt.size, ChunkSize: tt.chunkSize}
+		if got := totalChunks(upload); got != tt.total {
+			t.Errorf("totalChunks(%d/%d) = %d, want %d", tt.size, tt.chunkSize, got, tt.total)
+			continue
+		}
+		if tt.total > 1 && chunkSize(upload, 0) != tt.chunkSize {
+			t.Errorf("chunkSize(%d/%d, 0) = %d, want %d", tt.size, tt.chunkSize, chunkSize(upload, 0), tt.chunkSize)
+		}
+		if got := chunkSize(upload, tt.total-1); got != tt.last {
+			t.Errorf("chunkSize(%d/%d, last) = %d, want %d", tt.size, tt.chunkSize, got, tt.last)
+		}
+	}
+}
+
+func TestUploadChunkSize(t *testing.T) {
+	saved := setting.AttachmentSetting.ChunkSize
+	defer func() { setting.AttachmentSetting.ChunkSize = saved }()
+
+	tests := []struct {
+		setting int
+		want    int
+	}{
+		{1 << 20, storage.MinPartSize},
+		{storage.MinPartSize, storage.MinPartSize},
+		{8 << 20, 8 << 20},
+	}
+	for _, tt := range tests {
+		setting.AttachmentSetting.ChunkSize = tt.setting
+		if got := uploadChunkSize(); got != tt.want {
+			t.Errorf("uploadChunkSize() with ChunkSize %d = %d, want %d", tt.setting, got, tt.want)
+		}
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	savedStorage, savedPath := storage.Default, setting.AttachmentSetting.ChunkSavePath
+	defer func() { storage.Default, setting.AttachmentSetting.ChunkSavePath = savedStorage, savedPath }()
+	storage.Default = storage.NewLocal(t.TempDir(), "")
+	setting.AttachmentSetting.ChunkSavePath = "chunks/"
+
+	upload := &models.Upload{UploadID: "u1", Size: 11, ChunkSize: 4}
+	for i, data := range []string{"hell", "o, w", "orl"} {
+		if err := storage.Default.Put(chunkKey(upload, i), strings.NewReader(data), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := ioutil.ReadAll(newChunkReader(upload))
+	if err != nil || string(data) != "hello, worl" {
+		t.Errorf("chunkReader = %q, %v", data, err)
+	}
+
+	// 缺少分片时返回存储的错误
+	upload.Size = 13
+	if _, err := ioutil.ReadAll(newChunkReader(upload)); err != storage.ErrNotExist {
+		t.Errorf("chunkReader with missing chunk = %v, want ErrNotExist", err)
+	}
+}
@@ -3,10 +3,23 @@ package auth_service
 import "github.com/EDDYCJY/go-gin-example/models"
 
 type Auth struct {
+	ID       int
 	Username string
 	Password string
 }
 
+// Check 校验用户名和密码，通过时设置 ID
 func (a *Auth) Check() (bool, error) {
-	return models.CheckAuth(a.Username, a.Password)
+	id, err := models.GetAuthID(a.Username, a.Password)
+	if err != nil {
+		return false, err
+	}
+
+	a.ID = id
+	return id > 0, nil
+}
+
+// GetUsername 按 ID 查询用户名，用户不存在时返回空字符串
+func (a *Auth) GetUsername() (string, error) {
+	return models.GetAuthUsername(a.ID)
 }
@@ -8,29 +8,38 @@ import (
 )
 
 type Article struct {
-	ID    int
-	TagID int
-	State int
+	ID         int
+	TagIDs     []int
+	TagMatch   string
+	CategoryID int
+	State      int
 
 	PageNum  int
 	PageSize int
 }
 
 func (a *Article) GetArticleKey() string {
-	return e.CACHE_ARTICLE + "_" + strconv.Itoa(a.ID)
+	return e.CACHE_ARTICLE + "_G" + strconv.FormatInt(generation(ArticleNamespace), 10) + "_" + strconv.Itoa(a.ID)
 }
 
 func (a *Article) GetArticlesKey() string {
 	keys := []string{
-		e.CACHE_ARTICLE,
-		"LIST",
+		ArticleListNamespace,
+		"G" + strconv.FormatInt(generation(ArticleListNamespace), 10),
 	}
 
 	if a.ID > 0 {
 		keys = append(keys, strconv.Itoa(a.ID))
 	}
-	if a.TagID > 0 {
-		keys = append(keys, strconv.Itoa(a.TagID))
+	if len(a.TagIDs) > 0 {
+		ids := make([]string, 0, len(a.TagIDs))
+		for _, id := range a.TagIDs {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		keys = append(keys, "T"+a.TagMatch+strings.Join(ids, "-"))
+	}
+	if a.CategoryID > 0 {
+		keys = append(keys, "C"+strconv.Itoa(a.CategoryID))
 	}
 	if a.State >= 0 {
 		keys = append(keys, strconv.Itoa(a.State))
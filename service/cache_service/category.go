@@ -0,0 +1,11 @@
+package cache_service
+
+import (
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+// 分类数量少，全部分类缓存在一个 key 中，任何修改后按 tag 失效
+const (
+	CategoryAllKey = e.CACHE_CATEGORY + "_ALL"
+	CategoryAllTag = e.CACHE_CATEGORY + "_ALL"
+)
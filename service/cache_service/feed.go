@@ -0,0 +1,24 @@
+package cache_service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type Feed struct {
+	Format string
+	TagID  int
+}
+
+// GetFeedKey 订阅源包含文章和标签名称，key 中带有两者列表命名空间的版本号
+func (f *Feed) GetFeedKey() string {
+	return strings.Join([]string{
+		e.CACHE_FEED,
+		f.Format,
+		strconv.Itoa(f.TagID),
+		"G" + strconv.FormatInt(generation(ArticleListNamespace), 10),
+		"G" + strconv.FormatInt(generation(TagListNamespace), 10),
+	}, "_")
+}
@@ -0,0 +1,53 @@
+package cache_service
+
+import (
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/cache"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+)
+
+// 缓存的命名空间，key 中带有命名空间的版本号，写操作后递增版本号即可让旧 key 全部失效；
+// ArticleNamespace 用于单篇文章缓存，标签改名或删除时一次让全部文章失效
+const (
+	ArticleNamespace     = e.CACHE_ARTICLE
+	ArticleListNamespace = e.CACHE_ARTICLE + "_LIST"
+	TagListNamespace     = e.CACHE_TAG + "_LIST"
+)
+
+// ArticleTag 单篇文章的失效 tag
+func ArticleTag(id int) string {
+	return e.CACHE_ARTICLE + "_" + strconv.Itoa(id)
+}
+
+// generation 读取命名空间当前的版本号，Redis 不可用时返回 0
+func generation(namespace string) int64 {
+	gen, err := gredis.Generation(namespace)
+	if err != nil {
+		logging.Warn("cache_service.generation err:", namespace, err)
+	}
+
+	return gen
+}
+
+// BumpNamespace 递增命名空间的版本号，O(1) 地让该命名空间下的全部列表缓存失效
+func BumpNamespace(namespaces ...string) {
+	for _, namespace := range namespaces {
+		if _, err := gredis.BumpGeneration(namespace); err != nil {
+			logging.Warn("cache_service.BumpNamespace err:", namespace, err)
+		}
+	}
+}
+
+// Invalidate 按 tag 删除缓存，失败只记录日志，缓存最终会随过期时间失效
+func Invalidate(tags ...string) {
+	if cache.Default == nil {
+		return
+	}
+
+	if err := cache.Default.Invalidate(tags...); err != nil {
+		logging.Warn("cache_service.Invalidate err:", tags, err)
+	}
+}
@@ -0,0 +1,86 @@
+package cache_service
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+
+	saved := *setting.RedisSetting
+	t.Cleanup(func() { *setting.RedisSetting = saved })
+	setting.RedisSetting.Mode = ""
+	setting.RedisSetting.Host = mr.Addr()
+	if err := gredis.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	return mr
+}
+
+func TestListKeys(t *testing.T) {
+	newTestRedis(t)
+
+	articles := &Article{TagIDs: []int{1, 2}, TagMatch: "all", CategoryID: 3, State: 1, PageNum: 2, PageSize: 10}
+	if got, want := articles.GetArticlesKey(), "ARTICLE_LIST_G0_Tall1-2_C3_1_2_10"; got != want {
+		t.Errorf("GetArticlesKey() = %q, want %q", got, want)
+	}
+	tags := &Tag{Name: "go", State: -1, PageSize: 10}
+	if got, want := tags.GetTagsKey(), "TAG_LIST_G0_go_10"; got != want {
+		t.Errorf("GetTagsKey() = %q, want %q", got, want)
+	}
+
+	// 递增版本号后只影响对应命名空间的 key
+	BumpNamespace(ArticleListNamespace)
+	if got, want := articles.GetArticlesKey(), "ARTICLE_LIST_G1_Tall1-2_C3_1_2_10"; got != want {
+		t.Errorf("GetArticlesKey() after bump = %q, want %q", got, want)
+	}
+	if got, want := tags.GetTagsKey(), "TAG_LIST_G0_go_10"; got != want {
+		t.Errorf("GetTagsKey() after bumping articles = %q, want %q", got, want)
+	}
+}
+
+func TestInvalidateWithoutCache(t *testing.T) {
+	// 没有初始化缓存时直接返回
+	Invalidate(ArticleTag(1))
+}
+
+func TestFeedKey(t *testing.T) {
+	newTestRedis(t)
+
+	f := &Feed{Format: "rss", TagID: 1}
+	if got, want := f.GetFeedKey(), "FEED_rss_1_G0_G0"; got != want {
+		t.Errorf("GetFeedKey() = %q, want %q", got, want)
+	}
+
+	// 订阅源同时依赖文章和标签列表，任一命名空间变化都会换用新的 key
+	BumpNamespace(ArticleListNamespace)
+	BumpNamespace(TagListNamespace)
+	if got, want := f.GetFeedKey(), "FEED_rss_1_G1_G1"; got != want {
+		t.Errorf("GetFeedKey() after bump = %q, want %q", got, want)
+	}
+}
+
+func TestArticleKey(t *testing.T) {
+	newTestRedis(t)
+
+	a := &Article{ID: 1}
+	if got, want := a.GetArticleKey(), "ARTICLE_G0_1"; got != want {
+		t.Errorf("GetArticleKey() = %q, want %q", got, want)
+	}
+
+	// 全部文章通过命名空间版本号失效，不再维护一个会无限增长的 tag 集合
+	BumpNamespace(ArticleNamespace)
+	if got, want := a.GetArticleKey(), "ARTICLE_G1_1"; got != want {
+		t.Errorf("GetArticleKey() after bump = %q, want %q", got, want)
+	}
+	BumpNamespace(ArticleListNamespace)
+	if got, want := a.GetArticleKey(), "ARTICLE_G1_1"; got != want {
+		t.Errorf("GetArticleKey() after bumping lists = %q, want %q", got, want)
+	}
+}
@@ -3,8 +3,6 @@ package cache_service
 import (
 	"strconv"
 	"strings"
-
-	"github.com/EDDYCJY/go-gin-example/pkg/e"
 )
 
 type Tag struct {
@@ -18,8 +16,8 @@ type Tag struct {
 
 func (t *Tag) GetTagsKey() string {
 	keys := []string{
-		e.CACHE_TAG,
-		"LIST",
+		TagListNamespace,
+		"G" + strconv.FormatInt(generation(TagListNamespace), 10),
 	}
 
 	if t.Name != "" {
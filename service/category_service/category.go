@@ -0,0 +1,189 @@
+package category_service
+
+import (
+	"errors"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+var (
+	// ErrParentNotFound 上级分类不存在
+	ErrParentNotFound = errors.New("category: parent not found")
+	// ErrInvalidParent 上级分类不能是自己或自己的下级
+	ErrInvalidParent = errors.New("category: parent is the category itself or one of its descendants")
+	// ErrHasChildren 分类下还有子分类，不能删除
+	ErrHasChildren = errors.New("category: category has children")
+)
+
+type Category struct {
+	ID         int
+	ParentID   int
+	Name       string
+	Sort       int
+	CreatedBy  string
+	ModifiedBy string
+}
+
+// all 全部分类（平铺），带缓存
+func all() ([]*models.Category, error) {
+	return pkgcache.Get(pkgcache.Default, cache_service.CategoryAllKey, models.GetAllCategories, cache_service.CategoryAllTag)
+}
+
+// Tree 分类树，同级按 sort 排序
+func Tree() ([]*models.Category, error) {
+	categories, err := all()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := make([]*models.Category, 0)
+	for _, c := range categories {
+		if parent, ok := byID[c.ParentID]; ok {
+			parent.Children = append(parent.Children, c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	return roots, nil
+}
+
+// Descendants 分类及其全部下级分类的 ID，分类不存在时返回空
+func Descendants(id int) ([]int, error) {
+	categories, err := all()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int, len(categories))
+	exists := false
+	for _, c := range categories {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+		if c.ID == id {
+			exists = true
+		}
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+
+	return ids, nil
+}
+
+func (c *Category) ExistByID() (bool, error) {
+	return models.ExistCategoryByID(c.ID)
+}
+
+func (c *Category) ExistByName() (bool, error) {
+	return models.ExistCategoryByName(c.ParentID, c.Name)
+}
+
+func (c *Category) Add() error {
+	if err := c.checkParent(); err != nil {
+		return err
+	}
+
+	category := &models.Category{
+		ParentID:  c.ParentID,
+		Name:      c.Name,
+		Sort:      c.Sort,
+		CreatedBy: c.CreatedBy,
+	}
+	if err := models.AddCategory(category); err != nil {
+		return err
+	}
+
+	c.ID = category.ID
+	cache_service.Invalidate(cache_service.CategoryAllTag)
+	return nil
+}
+
+// Edit 修改分类，可以移动到其他分类下，但不能移动到自己的下级
+func (c *Category) Edit() error {
+	if err := c.checkParent(); err != nil {
+		return err
+	}
+
+	err := models.EditCategory(c.ID, map[string]interface{}{
+		"parent_id":   c.ParentID,
+		"name":        c.Name,
+		"sort":        c.Sort,
+		"modified_by": c.ModifiedBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	cache_service.Invalidate(cache_service.CategoryAllTag)
+	// 按分类筛选的文章列表包含下级分类，树结构变化后列表缓存失效
+	cache_service.BumpNamespace(cache_service.ArticleListNamespace)
+	return nil
+}
+
+// Delete 删除没有子分类的分类，其下的文章移到上级分类
+func (c *Category) Delete() error {
+	categories, err := all()
+	if err != nil {
+		return err
+	}
+
+	parentID := 0
+	for _, category := range categories {
+		if category.ParentID == c.ID {
+			return ErrHasChildren
+		}
+		if category.ID == c.ID {
+			parentID = category.ParentID
+		}
+	}
+
+	if err := models.DeleteCategory(c.ID, parentID); err != nil {
+		return err
+	}
+
+	cache_service.Invalidate(cache_service.CategoryAllTag)
+	cache_service.BumpNamespace(cache_service.ArticleNamespace, cache_service.ArticleListNamespace)
+	return nil
+}
+
+// checkParent 检查上级分类存在，且不是自己或自己的下级
+func (c *Category) checkParent() error {
+	if c.ParentID == 0 {
+		return nil
+	}
+
+	exists, err := models.ExistCategoryByID(c.ParentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrParentNotFound
+	}
+	if c.ID == 0 {
+		return nil
+	}
+
+	ids, err := Descendants(c.ID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == c.ParentID {
+			return ErrInvalidParent
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package category_service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// setCategories 用进程内缓存提供分类列表，不访问数据库
+func setCategories(t *testing.T, categories ...[2]int) {
+	saved := pkgcache.Default
+	t.Cleanup(func() { pkgcache.Default = saved })
+	pkgcache.Default = pkgcache.New(pkgcache.Options{TTL: time.Minute}, pkgcache.NewLRUStore(10, time.Minute))
+
+	var list []*models.Category
+	for _, c := range categories {
+		category := &models.Category{ParentID: c[1], Name: fmt.Sprint("c", c[0])}
+		category.ID = c[0]
+		list = append(list, category)
+	}
+	if err := pkgcache.Default.Set(cache_service.CategoryAllKey, list, cache_service.CategoryAllTag); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// render 把分类树写成 "1(2(4) 3) 5" 的形式
+func render(categories []*models.Category) string {
+	s := ""
+	for i, c := range categories {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprint(c.ID)
+		if len(c.Children) > 0 {
+			s += "(" + render(c.Children) + ")"
+		}
+	}
+
+	return s
+}
+
+func TestTree(t *testing.T) {
+	// {ID, ParentID}，按 sort 排好序；9 的上级 8 已删除，作为顶层分类
+	setCategories(t, [2]int{1, 0}, [2]int{5, 0}, [2]int{3, 1}, [2]int{2, 1}, [2]int{4, 2}, [2]int{9, 8})
+
+	tree, err := Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := render(tree), "1(3 2(4)) 5 9"; got != want {
+		t.Errorf("Tree() = %s, want %s", got, want)
+	}
+
+	// 缓存中保存的是编码后的数据，多次调用不会重复挂载子分类
+	tree, _ = Tree()
+	if got, want := render(tree), "1(3 2(4)) 5 9"; got != want {
+		t.Errorf("second Tree() = %s, want %s", got, want)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	setCategories(t, [2]int{1, 0}, [2]int{2, 1}, [2]int{3, 1}, [2]int{4, 2}, [2]int{5, 0})
+
+	tests := []struct {
+		id   int
+		want []int
+	}{
+		{1, []int{1, 2, 3, 4}},
+		{2, []int{2, 4}},
+		{5, []int{5}},
+		{6, nil},
+	}
+	for _, tt := range tests {
+		got, err := Descendants(tt.id)
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("Descendants(%d) = %v, %v, want %v", tt.id, got, err, tt.want)
+		}
+	}
+}
+
+func TestDeleteWithChildren(t *testing.T) {
+	setCategories(t, [2]int{1, 0}, [2]int{2, 1})
+
+	if err := (&Category{ID: 1}).Delete(); err != ErrHasChildren {
+		t.Errorf("Delete() = %v, want ErrHasChildren", err)
+	}
+}
+
+func TestCheckParentTopLevel(t *testing.T) {
+	if err := (&Category{ID: 1}).checkParent(); err != nil {
+		t.Errorf("checkParent() without parent = %v", err)
+	}
+}
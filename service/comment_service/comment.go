@@ -0,0 +1,248 @@
+package comment_service
+
+import (
+	"errors"
+	"log"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/blocklist"
+	casbinPkg "github.com/EDDYCJY/go-gin-example/pkg/casbin"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// casbinObject / casbinAction 审核评论的权限，例如 p, editor, comment, moderate
+const (
+	casbinObject = "comment"
+	casbinAction = "moderate"
+)
+
+var (
+	// ErrArticleNotOpen 文章不存在或未发布
+	ErrArticleNotOpen = errors.New("comment: article is not published")
+	// ErrParentNotFound 回复的评论不存在或不属于该文章
+	ErrParentNotFound = errors.New("comment: parent comment not found")
+	// ErrForbidden 当前用户没有审核评论的权限
+	ErrForbidden = errors.New("comment: forbidden")
+)
+
+// filter 屏蔽词过滤器，由 Setup 加载
+var filter = blocklist.New(nil)
+
+// Setup 加载 [comment] BlocklistPath 配置的屏蔽词
+func Setup() {
+	f, err := blocklist.Load(setting.CommentSetting.BlocklistPath)
+	if err != nil {
+		log.Fatalf("comment_service.Setup, fail to load blocklist %q: %v", setting.CommentSetting.BlocklistPath, err)
+	}
+	filter = f
+}
+
+type Comment struct {
+	ID        int
+	ArticleID int
+	ParentID  int
+	Content   string
+	CreatedBy string
+	Status    string
+
+	PageNum  int
+	PageSize int
+}
+
+// Add 发表评论：命中屏蔽词的标记为 spam，开启审核时其余评论为 pending，否则直接通过
+func (c *Comment) Add() (*models.Comment, error) {
+	article, err := models.GetArticle(c.ArticleID)
+	if err != nil {
+		return nil, err
+	}
+	if article.ID == 0 || article.State != 1 {
+		return nil, ErrArticleNotOpen
+	}
+
+	comment := &models.Comment{
+		ArticleID: c.ArticleID,
+		ParentID:  c.ParentID,
+		Content:   c.Content,
+		CreatedBy: c.CreatedBy,
+		Status:    models.CommentStatusApproved,
+	}
+
+	if c.ParentID > 0 {
+		parent, err := models.GetComment(c.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.ArticleID != c.ArticleID || parent.Status != models.CommentStatusApproved {
+			return nil, ErrParentNotFound
+		}
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID
+		}
+	}
+
+	if _, ok := filter.Match(c.Content); ok {
+		comment.Status = models.CommentStatusSpam
+	} else if setting.CommentSetting.Moderation {
+		comment.Status = models.CommentStatusPending
+	}
+
+	if err := models.AddComment(comment); err != nil {
+		return nil, err
+	}
+
+	c.ID = comment.ID
+	if comment.Status == models.CommentStatusApproved {
+		notifyApproved(article, comment)
+	}
+
+	return comment, nil
+}
+
+// Get 单条评论，不存在时返回 nil
+func (c *Comment) Get() (*models.Comment, error) {
+	return models.GetComment(c.ID)
+}
+
+// GetThreads 文章已通过的评论，按顶层评论分页，回复按回复关系嵌套在 Replies 中
+func (c *Comment) GetThreads() ([]*models.Comment, error) {
+	roots, err := models.GetComments(c.PageNum, c.PageSize, c.threadMaps())
+	if err != nil {
+		return nil, err
+	}
+
+	rootIDs := make([]int, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+	replies, err := models.GetCommentReplies(rootIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nest(roots, replies)
+	return roots, nil
+}
+
+// nest 把回复挂到被回复的评论下；replies 按 ID 升序，父评论总是先于子评论出现，被回复的评论未通过或已删除时挂到顶层评论下
+func nest(roots, replies []*models.Comment) {
+	byID := make(map[int]*models.Comment, len(roots)+len(replies))
+	for _, comment := range roots {
+		byID[comment.ID] = comment
+	}
+	for _, comment := range replies {
+		byID[comment.ID] = comment
+	}
+	for _, reply := range replies {
+		parent, ok := byID[reply.ParentID]
+		if !ok {
+			parent = byID[reply.RootID]
+		}
+		parent.Replies = append(parent.Replies, reply)
+	}
+}
+
+// CountThreads 文章已通过的顶层评论数
+func (c *Comment) CountThreads() (int, error) {
+	return models.GetCommentTotal(c.threadMaps())
+}
+
+// GetQueue 审核队列，按状态和文章筛选
+func (c *Comment) GetQueue() ([]*models.Comment, error) {
+	return models.GetComments(c.PageNum, c.PageSize, c.queueMaps())
+}
+
+// CountQueue 审核队列中的评论数
+func (c *Comment) CountQueue() (int, error) {
+	return models.GetCommentTotal(c.queueMaps())
+}
+
+// Moderate 审核评论，status 为 approved / spam / pending；评论首次通过时通知文章作者
+func Moderate(subject string, ids []int, status, moderatedBy string) error {
+	if err := Authorize(subject); err != nil {
+		return err
+	}
+
+	comments, err := models.GetCommentsByIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	found := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		found = append(found, comment.ID)
+	}
+	if err := models.ModerateComments(found, status, moderatedBy); err != nil {
+		return err
+	}
+
+	if status == models.CommentStatusApproved {
+		for _, comment := range comments {
+			if comment.Status == models.CommentStatusApproved {
+				continue
+			}
+			comment.Status = status
+			if article, err := models.GetArticle(comment.ArticleID); err == nil && article.ID > 0 {
+				notifyApproved(article, comment)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Delete 删除评论，删除顶层评论时一并删除其回复
+func (c *Comment) Delete(subject string) error {
+	if err := Authorize(subject); err != nil {
+		return err
+	}
+
+	return models.DeleteComment(c.ID)
+}
+
+// ValidStatus 是否为评论审核状态
+func ValidStatus(status string) bool {
+	switch status {
+	case models.CommentStatusPending, models.CommentStatusApproved, models.CommentStatusSpam:
+		return true
+	}
+
+	return false
+}
+
+// Authorize 按 Casbin 策略检查 subject 能否审核评论
+func Authorize(subject string) error {
+	ok, err := casbinPkg.Enforce(subject, casbinObject, casbinAction)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (c *Comment) threadMaps() map[string]interface{} {
+	return map[string]interface{}{
+		"article_id": c.ArticleID,
+		"parent_id":  0,
+		"status":     models.CommentStatusApproved,
+		"deleted_on": 0,
+	}
+}
+
+func (c *Comment) queueMaps() map[string]interface{} {
+	maps := map[string]interface{}{
+		"status":     c.Status,
+		"deleted_on": 0,
+	}
+	if c.ArticleID > 0 {
+		maps["article_id"] = c.ArticleID
+	}
+
+	return maps
+}
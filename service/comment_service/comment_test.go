@@ -0,0 +1,113 @@
+package comment_service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+)
+
+func newComment(id, parentID, rootID int) *models.Comment {
+	c := &models.Comment{ParentID: parentID, RootID: rootID}
+	c.ID = id
+	return c
+}
+
+func TestNest(t *testing.T) {
+	roots := []*models.Comment{newComment(1, 0, 0), newComment(2, 0, 0)}
+	replies := []*models.Comment{
+		newComment(3, 1, 1),
+		newComment(4, 3, 1),
+		newComment(5, 2, 2),
+		// 被回复的评论 6 未通过，挂到顶层评论下
+		newComment(7, 6, 1),
+	}
+	nest(roots, replies)
+
+	ids := func(comments []*models.Comment) []int {
+		var ids []int
+		for _, c := range comments {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		comment *models.Comment
+		want    []int
+	}{
+		{roots[0], []int{3, 7}},
+		{replies[0], []int{4}},
+		{roots[1], []int{5}},
+		{replies[1], nil},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.comment.Replies); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("comment %d replies = %v, want %v", tt.comment.ID, got, tt.want)
+		}
+	}
+}
+
+func TestValidStatus(t *testing.T) {
+	for _, status := range []string{models.CommentStatusPending, models.CommentStatusApproved, models.CommentStatusSpam} {
+		if !ValidStatus(status) {
+			t.Errorf("ValidStatus(%s) = false", status)
+		}
+	}
+	if ValidStatus("deleted") || ValidStatus("") {
+		t.Error("ValidStatus accepted an unknown status")
+	}
+}
+
+func TestQueueMaps(t *testing.T) {
+	maps := (&Comment{Status: models.CommentStatusPending}).queueMaps()
+	if _, ok := maps["article_id"]; ok || maps["status"] != models.CommentStatusPending {
+		t.Errorf("queueMaps() = %v", maps)
+	}
+	if maps := (&Comment{ArticleID: 3}).queueMaps(); maps["article_id"] != 3 {
+		t.Errorf("queueMaps() with article = %v", maps)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	short := strings.Repeat("评", excerptLength)
+	if got := excerpt(short); got != short {
+		t.Errorf("excerpt(%d runes) was truncated", excerptLength)
+	}
+	if got := excerpt(short + "论"); got != short+"…" {
+		t.Errorf("excerpt(%d runes) = %q", excerptLength+1, got)
+	}
+}
+
+func TestApprovedEvent(t *testing.T) {
+	article := &models.Article{Title: "t"}
+	article.ID = 1
+
+	tests := []struct {
+		name          string
+		author        string
+		commenter     string
+		wantRecipient string
+	}{
+		// 作者评论自己的文章时不通知
+		{"own article", "alice", "alice", ""},
+		{"other reader", "alice", "bob", "alice"},
+		{"article without author", "", "bob", ""},
+	}
+	for _, tt := range tests {
+		article.CreatedBy = tt.author
+		comment := &models.Comment{ArticleID: 1, Content: "c", CreatedBy: tt.commenter}
+		event := approvedEvent(article, comment)
+
+		got := ""
+		if event != nil {
+			got = event.Recipient
+			if event.Commenter != tt.commenter || event.ArticleID != 1 {
+				t.Errorf("%s: event = %+v", tt.name, event)
+			}
+		}
+		if got != tt.wantRecipient {
+			t.Errorf("%s: recipient = %q, want %q", tt.name, got, tt.wantRecipient)
+		}
+	}
+}
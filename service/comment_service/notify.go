@@ -0,0 +1,85 @@
+package comment_service
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/rabbitmq"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// EventCommentApproved 评论对读者可见（直接发布或审核通过）时发送给文章作者的事件
+const EventCommentApproved = "comment.approved"
+
+// excerptLength 通知中评论内容的最大字符数
+const excerptLength = 200
+
+// Event 发布到 [comment] NotifyExchange 的通知事件，routing key 为 Type
+type Event struct {
+	Type         string `json:"type"`
+	Recipient    string `json:"recipient"`
+	ArticleID    int    `json:"article_id"`
+	ArticleTitle string `json:"article_title"`
+	CommentID    int    `json:"comment_id"`
+	ParentID     int    `json:"parent_id"`
+	Commenter    string `json:"commenter"`
+	Excerpt      string `json:"excerpt"`
+	Time         int64  `json:"time"`
+}
+
+// notifyApproved 通知文章作者有新评论
+func notifyApproved(article *models.Article, comment *models.Comment) {
+	if event := approvedEvent(article, comment); event != nil {
+		publish(event)
+	}
+}
+
+// approvedEvent 评论通过的通知事件，作者评论自己的文章时返回 nil
+func approvedEvent(article *models.Article, comment *models.Comment) *Event {
+	if article.CreatedBy == "" || article.CreatedBy == comment.CreatedBy {
+		return nil
+	}
+
+	return &Event{
+		Type:         EventCommentApproved,
+		Recipient:    article.CreatedBy,
+		ArticleID:    article.ID,
+		ArticleTitle: article.Title,
+		CommentID:    comment.ID,
+		ParentID:     comment.ParentID,
+		Commenter:    comment.CreatedBy,
+		Excerpt:      excerpt(comment.Content),
+		Time:         time.Now().Unix(),
+	}
+}
+
+// publish 异步发布事件，RabbitMQ 不可用时只记录日志，不影响评论本身
+func publish(event *Event) {
+	exchange := setting.CommentSetting.NotifyExchange
+	if exchange == "" || rabbitmq.RabbitMQConn == nil {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logging.Warn("comment_service.publish marshal err:", err)
+		return
+	}
+
+	go func() {
+		if err := rabbitmq.Publish(exchange, "topic", event.Type, string(body)); err != nil {
+			logging.Warn("comment_service.publish err:", event.Type, event.CommentID, err)
+		}
+	}()
+}
+
+func excerpt(s string) string {
+	if utf8.RuneCountInString(s) <= excerptLength {
+		return s
+	}
+
+	return string([]rune(s)[:excerptLength]) + "…"
+}
@@ -0,0 +1,221 @@
+package image_service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/imageproc"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+)
+
+// Preset 上传时预先生成的图片规格
+type Preset struct {
+	Name    string
+	Options imageproc.Options
+}
+
+// weakSignKeys 不能作为签名密钥的值，change-me 是配置文件中公开的占位值
+var weakSignKeys = map[string]bool{"": true, "change-me": true}
+
+var (
+	presets []Preset
+	// limiter 限制同时处理的图片数，解码大图占用较多内存和 CPU
+	limiter chan struct{}
+)
+
+// Setup 解析 [image] Variants，格式为 名称:宽x高[:cover|contain]
+func Setup() {
+	presets = presets[:0]
+	for _, v := range setting.ImageSetting.Variants {
+		p, err := parsePreset(v)
+		if err != nil {
+			log.Fatalf("image_service.Setup, invalid variant %q: %v", v, err)
+		}
+		presets = append(presets, p)
+	}
+
+	n := setting.ImageSetting.Concurrency
+	if n <= 0 {
+		n = 1
+	}
+	limiter = make(chan struct{}, n)
+
+	if !Enabled() {
+		log.Printf("[warn] image_service: [image] SignKey is empty or change-me, /images/:name is disabled")
+	}
+}
+
+// Enabled 配置了签名密钥时才处理图片，密钥为空或是占位值时任何人都能伪造签名
+func Enabled() bool {
+	return !weakSignKeys[setting.ImageSetting.SignKey]
+}
+
+func parsePreset(s string) (Preset, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return Preset{}, fmt.Errorf("want name:WxH[:fit]")
+	}
+	size := strings.SplitN(parts[1], "x", 2)
+	if len(size) != 2 {
+		return Preset{}, fmt.Errorf("want WxH")
+	}
+	w, err := strconv.Atoi(size[0])
+	if err != nil {
+		return Preset{}, err
+	}
+	h, err := strconv.Atoi(size[1])
+	if err != nil {
+		return Preset{}, err
+	}
+
+	o := imageproc.Options{Width: w, Height: h}
+	if len(parts) == 3 {
+		o.Fit = parts[2]
+	}
+	if err := o.Normalize(setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight); err != nil {
+		return Preset{}, err
+	}
+
+	return Preset{Name: parts[0], Options: o}, nil
+}
+
+// Normalize 检查参数并填充默认值，未指定格式时使用原图的格式
+func Normalize(name string, o *imageproc.Options) error {
+	if err := o.Normalize(setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight); err != nil {
+		return err
+	}
+	if o.Format == "" {
+		o.Format = sourceFormat(name)
+	}
+
+	return nil
+}
+
+// sourceFormat 按原图扩展名选择输出格式，不能编码的格式使用 PNG
+func sourceFormat(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg":
+		return imageproc.FormatJPEG
+	}
+
+	return imageproc.FormatPNG
+}
+
+// Sign 图片名称和规范化参数的签名，只有签过名的尺寸才会生成
+func Sign(name string, o imageproc.Options) string {
+	mac := hmac.New(sha256.New, []byte(setting.ImageSetting.SignKey))
+	mac.Write([]byte(name + "?" + o.Key()))
+
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Verify 检查签名，o 需要已经 Normalize，未启用时总是返回 false
+func Verify(name string, o imageproc.Options, sig string) bool {
+	if !Enabled() {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(name, o)), []byte(sig))
+}
+
+// URL 带签名的图片处理地址，o 需要已经 Normalize
+func URL(name string, o imageproc.Options) string {
+	q := url.Values{}
+	q.Set("w", strconv.Itoa(o.Width))
+	q.Set("h", strconv.Itoa(o.Height))
+	q.Set("fit", o.Fit)
+	q.Set("fm", o.Format)
+	q.Set("q", strconv.Itoa(o.Quality))
+	q.Set("s", Sign(name, o))
+
+	return setting.AppSetting.PrefixUrl + "/images/" + url.PathEscape(name) + "?" + q.Encode()
+}
+
+// Get 获取处理后的图片，优先读取缓存的版本，没有时生成并保存，原图不存在时返回 storage.ErrNotExist
+func Get(name string, o imageproc.Options) ([]byte, string, error) {
+	key := variantKey(name, o)
+	contentType := imageproc.ContentType(o.Format)
+
+	rc, err := storage.Default.Get(key)
+	if err == nil {
+		defer rc.Close()
+		data, err := ioutil.ReadAll(rc)
+		return data, contentType, err
+	}
+	if err != storage.ErrNotExist {
+		return nil, "", err
+	}
+
+	data, err := generate(name, o, key)
+	return data, contentType, err
+}
+
+// Pregenerate 生成所有预设规格，返回规格名称到地址的映射，未启用时不生成
+func Pregenerate(name string) (map[string]string, error) {
+	if !Enabled() {
+		return map[string]string{}, nil
+	}
+
+	urls := make(map[string]string, len(presets))
+	for _, p := range presets {
+		o := p.Options
+		if o.Format == "" {
+			o.Format = sourceFormat(name)
+		}
+
+		key := variantKey(name, o)
+		exists, err := storage.Default.Exists(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			if _, err := generate(name, o, key); err != nil {
+				return nil, err
+			}
+		}
+		urls[p.Name] = URL(name, o)
+	}
+
+	return urls, nil
+}
+
+func generate(name string, o imageproc.Options, key string) ([]byte, error) {
+	limiter <- struct{}{}
+	defer func() { <-limiter }()
+
+	rc, err := storage.Default.Get(upload.GetImageKey(name))
+	if err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	data, contentType, err := imageproc.Process(src, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.Default.Put(key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// variantKey 处理后图片在存储中的 key，同一原图的所有版本在同一目录下
+func variantKey(name string, o imageproc.Options) string {
+	return setting.ImageSetting.VariantSavePath + name + "/" + o.Key() + imageproc.Ext(o.Format)
+}
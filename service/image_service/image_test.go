@@ -0,0 +1,69 @@
+package image_service
+
+import (
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/imageproc"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func TestVerify(t *testing.T) {
+	saved := setting.ImageSetting.SignKey
+	defer func() { setting.ImageSetting.SignKey = saved }()
+
+	o := imageproc.Options{Width: 200, Height: 200, Fit: imageproc.FitCover, Format: imageproc.FormatJPEG, Quality: 85}
+	other := o
+	other.Width = 400
+
+	setting.ImageSetting.SignKey = "0123456789abcdef"
+	sig := Sign("a.jpg", o)
+	if !Enabled() || !Verify("a.jpg", o, sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if Verify("b.jpg", o, sig) || Verify("a.jpg", other, sig) || Verify("a.jpg", o, "") {
+		t.Error("signature accepted for another image, size or an empty signature")
+	}
+
+	// 没有配置密钥或使用占位值时，任何人都能算出签名，一律拒绝
+	for _, key := range []string{"", "change-me"} {
+		setting.ImageSetting.SignKey = key
+		if Enabled() {
+			t.Errorf("Enabled() with SignKey %q", key)
+		}
+		if Verify("a.jpg", o, Sign("a.jpg", o)) {
+			t.Errorf("Verify() accepted a signature made with SignKey %q", key)
+		}
+		urls, err := Pregenerate("a.jpg")
+		if err != nil || len(urls) != 0 {
+			t.Errorf("Pregenerate() with SignKey %q = %v, %v", key, urls, err)
+		}
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	saved := *setting.ImageSetting
+	defer func() { *setting.ImageSetting = saved }()
+	setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight = 2048, 2048
+
+	tests := []struct {
+		in   string
+		want Preset
+		ok   bool
+	}{
+		{"thumb:200x200:cover", Preset{"thumb", imageproc.Options{Width: 200, Height: 200, Fit: "cover", Quality: 85}}, true},
+		{" medium:1200x0:contain ", Preset{"medium", imageproc.Options{Width: 1200, Fit: "contain", Quality: 85}}, true},
+		{"small:100x100", Preset{"small", imageproc.Options{Width: 100, Height: 100, Fit: "cover", Quality: 85}}, true},
+		{"thumb", Preset{}, false},
+		{":100x100", Preset{}, false},
+		{"thumb:100", Preset{}, false},
+		{"thumb:ax100", Preset{}, false},
+		{"huge:5000x0", Preset{}, false},
+		{"thumb:100x100:stretch", Preset{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parsePreset(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parsePreset(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
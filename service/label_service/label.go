@@ -0,0 +1,237 @@
+package label_service
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+
+	"github.com/EDDYCJY/go-gin-example/models/stock"
+	"github.com/EDDYCJY/go-gin-example/pkg/pdf"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
+	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// 标签上条码的内容
+const (
+	FieldCode       = "code"
+	FieldHiddenCode = "hidden_code"
+)
+
+var (
+	// ErrInvalid 标签纸、条码类型或字段不存在，或明细的内容不能用该条码类型编码
+	ErrInvalid = errors.New("label: invalid sheet, kind, field or content")
+	// ErrTooSmall 标签放不下条码
+	ErrTooSmall = errors.New("label: label is too small for the code")
+	// ErrNoDetails 没有可以打印的明细（不存在或内容为空）
+	ErrNoDetails = errors.New("label: no details to print")
+	// ErrTooMany 超过 MaxLabels
+	ErrTooMany = errors.New("label: too many labels")
+)
+
+// labelPadding 标签内容与边缘的距离（毫米）
+const labelPadding = 1.5
+
+// lineHeight 行高为字号的倍数
+const lineHeight = 1.2
+
+// Labels 按明细 ID 或产品 ID 打印标签，每个明细一个标签：条码或二维码、产品名称、单位和备注
+type Labels struct {
+	DetailIDs []int
+	ProductID int
+	Sheet     string
+	Kind      string // 默认 code128
+	Field     string // 默认 code
+}
+
+type textLine struct {
+	text     string
+	size     float64
+	maxLines int
+}
+
+// PDF 按标签纸版式生成分页的 PDF
+func (l *Labels) PDF() (*pdf.Document, error) {
+	sheet := GetSheet(l.Sheet)
+	if sheet == nil {
+		return nil, ErrInvalid
+	}
+	if l.Kind == "" {
+		l.Kind = qrcode.KindCode128
+	}
+	if l.Field == "" {
+		l.Field = FieldCode
+	}
+	if l.Field != FieldCode && l.Field != FieldHiddenCode {
+		return nil, ErrInvalid
+	}
+
+	details, err := l.details()
+	if err != nil {
+		return nil, err
+	}
+
+	px := func(mm float64) int {
+		return int(math.Round(mm * float64(setting.LabelSetting.DPI) / 25.4))
+	}
+	doc := pdf.New("labels")
+	perPage := sheet.PerPage()
+	for start := 0; start < len(details); start += perPage {
+		page := image.NewGray(image.Rect(0, 0, px(sheet.PageWidth), px(sheet.PageHeight)))
+		draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+
+		for i, detail := range details[start:min(start+perPage, len(details))] {
+			x := sheet.MarginLeft + float64(i%sheet.Columns)*(sheet.LabelWidth()+sheet.GapX)
+			y := sheet.MarginTop + float64(i/sheet.Columns)*(sheet.LabelHeight()+sheet.GapY)
+			r := image.Rect(px(x), px(y), px(x+sheet.LabelWidth()), px(y+sheet.LabelHeight()))
+			if err := l.draw(page, r.Inset(px(labelPadding)), detail); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := doc.AddPage(page, sheet.PageWidth*pdf.PointsPerMM, sheet.PageHeight*pdf.PointsPerMM); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// details 要打印的明细，按 DetailIDs 的顺序，内容为空的明细不打印
+func (l *Labels) details() ([]*stock.StockProductDetail, error) {
+	max := setting.LabelSetting.MaxLabels
+	var details []*stock.StockProductDetail
+	var err error
+	if len(l.DetailIDs) > 0 {
+		if len(l.DetailIDs) > max {
+			return nil, ErrTooMany
+		}
+		details, err = stock.GetStockProductDetailsWithProductByIDs(l.DetailIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		byID := make(map[int]*stock.StockProductDetail, len(details))
+		for _, detail := range details {
+			byID[detail.ID] = detail
+		}
+		details = details[:0]
+		for _, id := range l.DetailIDs {
+			if detail, ok := byID[id]; ok {
+				details = append(details, detail)
+			}
+		}
+	} else {
+		details, err = stock.GetStockProductDetailsWithProductByProductID(l.ProductID, max+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(details) > max {
+			return nil, ErrTooMany
+		}
+	}
+
+	printable := details[:0]
+	for _, detail := range details {
+		if l.content(detail) != "" {
+			printable = append(printable, detail)
+		}
+	}
+	if len(printable) == 0 {
+		return nil, ErrNoDetails
+	}
+
+	return printable, nil
+}
+
+func (l *Labels) content(detail *stock.StockProductDetail) string {
+	if l.Field == FieldHiddenCode {
+		return detail.HiddenCode
+	}
+
+	return detail.Code
+}
+
+// draw 绘制一个标签：一维码在上、文字居中在下；二维码在左、文字在右
+func (l *Labels) draw(dst draw.Image, r image.Rectangle, detail *stock.StockProductDetail) error {
+	h := float64(r.Dy())
+	content := l.content(detail)
+	var info []string
+	if detail.StockProduct.Unit != "" {
+		info = append(info, "单位："+detail.StockProduct.Unit)
+	}
+	if detail.Note != "" {
+		info = append(info, detail.Note)
+	}
+
+	code := &qrcode.Code{Kind: l.Kind, Content: content, QuietZone: -1}
+	twoD := l.Kind == qrcode.KindQR || l.Kind == qrcode.KindDataMatrix
+	if twoD {
+		code.Width, code.Height = r.Dy(), r.Dy()
+	} else {
+		code.Width, code.Height = r.Dx(), r.Dy()*45/100
+	}
+
+	img, err := code.Image()
+	switch err {
+	case nil:
+	case qrcode.ErrTooSmall:
+		return ErrTooSmall
+	case qrcode.ErrInvalidCode:
+		return ErrInvalid
+	default:
+		return err
+	}
+	draw.Draw(dst, img.Bounds().Add(r.Min), img, image.Point{}, draw.Src)
+
+	if twoD {
+		x := r.Min.X + code.Width + r.Dy()/20
+		return drawLines(dst, image.Rect(x, r.Min.Y, r.Max.X, r.Max.Y), poster.AlignLeft, []textLine{
+			{text: detail.StockProduct.Name, size: h * 0.16, maxLines: 2},
+			{text: strings.Join(info, "  "), size: h * 0.12, maxLines: 2},
+			{text: content, size: h * 0.1, maxLines: 2},
+		})
+	}
+
+	return drawLines(dst, image.Rect(r.Min.X, r.Min.Y+code.Height, r.Max.X, r.Max.Y), poster.AlignCenter, []textLine{
+		{text: content, size: h * 0.09, maxLines: 1},
+		{text: detail.StockProduct.Name, size: h * 0.13, maxLines: 1},
+		{text: strings.Join(info, "  "), size: h * 0.1, maxLines: 1},
+	})
+}
+
+// drawLines 在 r 中从上到下依次绘制文字，放不下的行省略
+func drawLines(dst draw.Image, r image.Rectangle, align string, lines []textLine) error {
+	y := r.Min.Y
+	for _, line := range lines {
+		if line.text == "" || line.size < 1 {
+			continue
+		}
+
+		step := line.size * lineHeight
+		maxLines := min(line.maxLines, int(float64(r.Max.Y-y)/step))
+		if maxLines <= 0 || r.Dx() <= 0 {
+			return nil
+		}
+
+		// 基线约在字号高度处
+		n, err := poster.DrawText(dst, &poster.Layer{
+			X:          r.Min.X,
+			Y:          y + int(line.size),
+			Width:      r.Dx(),
+			Size:       line.size,
+			Align:      align,
+			LineHeight: lineHeight,
+			MaxLines:   maxLines,
+		}, line.text)
+		if err != nil {
+			return err
+		}
+		y += int(math.Round(float64(n) * step))
+	}
+
+	return nil
+}
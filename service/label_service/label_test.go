@@ -0,0 +1,174 @@
+package label_service
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font/gofont/gomono"
+
+	"github.com/EDDYCJY/go-gin-example/models/stock"
+	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// setupFont 把 Go Mono 作为默认字体放到临时目录
+func setupFont(t *testing.T) {
+	saved, savedPoster := *setting.AppSetting, *setting.PosterSetting
+	t.Cleanup(func() { *setting.AppSetting, *setting.PosterSetting = saved, savedPoster })
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "fonts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "fonts", "label.ttf"), gomono.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	setting.AppSetting.RuntimeRootPath = root + "/"
+	setting.AppSetting.FontSavePath = "fonts/"
+	setting.PosterSetting.DefaultFont = "label.ttf"
+}
+
+func newDetail(code, name, unit, note string) *stock.StockProductDetail {
+	detail := &stock.StockProductDetail{Code: code, HiddenCode: "H-" + code, Note: note}
+	detail.StockProduct.Name = name
+	detail.StockProduct.Unit = unit
+	return detail
+}
+
+func newPage(w, h int) *image.Gray {
+	page := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+	return page
+}
+
+// darkRows 每行是否有深色像素
+func darkRows(img *image.Gray, r image.Rectangle) []bool {
+	rows := make([]bool, r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.GrayAt(x, y).Y < 128 {
+				rows[y-r.Min.Y] = true
+				break
+			}
+		}
+	}
+
+	return rows
+}
+
+func TestDraw(t *testing.T) {
+	setupFont(t)
+	detail := newDetail("SN-0001", "Product", "pcs", "note")
+
+	// 一维码在上，文字在下
+	page := newPage(600, 360)
+	r := image.Rect(0, 0, 600, 360)
+	l := &Labels{Kind: qrcode.KindCode128, Field: FieldCode}
+	if err := l.draw(page, r, detail); err != nil {
+		t.Fatal(err)
+	}
+	rows := darkRows(page, r)
+	barEnd := 360 * 45 / 100
+	if !rows[barEnd/2] || !rows[barEnd+60] {
+		t.Errorf("code128 label: bars %v, text %v", rows[barEnd/2], rows[barEnd+60])
+	}
+
+	// 二维码在左，文字在右
+	page = newPage(600, 200)
+	r = image.Rect(0, 0, 600, 200)
+	l = &Labels{Kind: qrcode.KindQR, Field: FieldHiddenCode}
+	if err := l.draw(page, r, detail); err != nil {
+		t.Fatal(err)
+	}
+	textArea := image.Rect(210, 0, 600, 200)
+	dark := false
+	for _, d := range darkRows(page, textArea) {
+		dark = dark || d
+	}
+	if !dark || page.GrayAt(0, 0).Y != 255 {
+		t.Errorf("qr label: text drawn %v, quiet zone %v", dark, page.GrayAt(0, 0))
+	}
+
+	tests := []struct {
+		kind string
+		r    image.Rectangle
+		want error
+	}{
+		{qrcode.KindCode128, image.Rect(0, 0, 20, 100), ErrTooSmall},
+		{qrcode.KindQR, image.Rect(0, 0, 100, 10), ErrTooSmall},
+		{qrcode.KindEAN13, image.Rect(0, 0, 600, 300), ErrInvalid},
+	}
+	for _, tt := range tests {
+		l := &Labels{Kind: tt.kind, Field: FieldCode}
+		if err := l.draw(newPage(600, 300), tt.r, detail); err != tt.want {
+			t.Errorf("draw(%s, %v) = %v, want %v", tt.kind, tt.r, err, tt.want)
+		}
+	}
+}
+
+func TestDrawLines(t *testing.T) {
+	setupFont(t)
+
+	page := newPage(200, 40)
+	// 空行跳过，放不下的行不绘制
+	err := drawLines(page, page.Bounds(), "left", []textLine{
+		{text: "", size: 20, maxLines: 1},
+		{text: "first", size: 20, maxLines: 1},
+		{text: "second", size: 20, maxLines: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := darkRows(page, page.Bounds())
+	if !rows[10] {
+		t.Error("first line was not drawn")
+	}
+	for y := 25; y < 40; y++ {
+		if rows[y] {
+			t.Fatalf("second line was drawn at row %d", y)
+		}
+	}
+
+	// 宽度为 0 时不绘制
+	if err := drawLines(page, image.Rect(10, 0, 10, 40), "left", []textLine{{text: "x", size: 10, maxLines: 1}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPDFInvalid(t *testing.T) {
+	saved, savedSheets := *setting.LabelSetting, sheets
+	t.Cleanup(func() { *setting.LabelSetting, sheets = saved, savedSheets })
+	setting.LabelSetting.Sheets = []string{"thermal:50x30:1x1"}
+	setting.LabelSetting.DefaultSheet = "thermal"
+	setting.LabelSetting.MaxLabels = 2
+	Setup()
+
+	// 参数错误时在查询明细之前返回
+	tests := []struct {
+		l    *Labels
+		want error
+	}{
+		{&Labels{Sheet: "letter", DetailIDs: []int{1}}, ErrInvalid},
+		{&Labels{Field: "name", DetailIDs: []int{1}}, ErrInvalid},
+		{&Labels{DetailIDs: []int{1, 2, 3}}, ErrTooMany},
+	}
+	for _, tt := range tests {
+		if _, err := tt.l.PDF(); err != tt.want {
+			t.Errorf("PDF(%+v) = %v, want %v", tt.l, err, tt.want)
+		}
+	}
+}
+
+func TestContent(t *testing.T) {
+	detail := newDetail("SN-1", "", "", "")
+	if got := (&Labels{Field: FieldCode}).content(detail); got != "SN-1" {
+		t.Errorf("content(code) = %q", got)
+	}
+	if got := (&Labels{Field: FieldHiddenCode}).content(detail); got != "H-SN-1" {
+		t.Errorf("content(hidden_code) = %q", got)
+	}
+}
@@ -0,0 +1,116 @@
+package label_service
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// Sheet 标签纸的版式，单位为毫米，每个标签的大小由纸张、边距和间距计算
+type Sheet struct {
+	Name       string
+	PageWidth  float64
+	PageHeight float64
+	Columns    int
+	Rows       int
+	MarginTop  float64
+	MarginLeft float64
+	GapX       float64
+	GapY       float64
+}
+
+var sheets = map[string]*Sheet{}
+
+// Setup 解析 [label] Sheets
+func Setup() {
+	sheets = make(map[string]*Sheet)
+	for _, v := range setting.LabelSetting.Sheets {
+		s, err := parseSheet(v)
+		if err != nil {
+			log.Fatalf("label_service.Setup, invalid sheet %q: %v", v, err)
+		}
+		sheets[s.Name] = s
+	}
+}
+
+// GetSheet name 为空时使用 DefaultSheet，不存在时返回 nil
+func GetSheet(name string) *Sheet {
+	if name == "" {
+		name = setting.LabelSetting.DefaultSheet
+	}
+
+	return sheets[name]
+}
+
+// LabelWidth 标签宽度
+func (s *Sheet) LabelWidth() float64 {
+	return (s.PageWidth - 2*s.MarginLeft - float64(s.Columns-1)*s.GapX) / float64(s.Columns)
+}
+
+// LabelHeight 标签高度
+func (s *Sheet) LabelHeight() float64 {
+	return (s.PageHeight - 2*s.MarginTop - float64(s.Rows-1)*s.GapY) / float64(s.Rows)
+}
+
+// PerPage 每页的标签数
+func (s *Sheet) PerPage() int {
+	return s.Columns * s.Rows
+}
+
+// parseSheet 解析 名称:纸张宽x高:列x行[:上边距x左边距[:列间距x行间距]]
+func parseSheet(v string) (*Sheet, error) {
+	parts := strings.Split(strings.TrimSpace(v), ":")
+	if len(parts) < 3 || len(parts) > 5 || parts[0] == "" {
+		return nil, fmt.Errorf("want name:WxH:COLSxROWS[:TOPxLEFT[:GAPXxGAPY]]")
+	}
+
+	s := &Sheet{Name: parts[0]}
+	var err error
+	if s.PageWidth, s.PageHeight, err = parsePair(parts[1]); err != nil {
+		return nil, err
+	}
+	cols, rows, err := parsePair(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	s.Columns, s.Rows = int(cols), int(rows)
+	if len(parts) > 3 {
+		if s.MarginTop, s.MarginLeft, err = parsePair(parts[3]); err != nil {
+			return nil, err
+		}
+	}
+	if len(parts) > 4 {
+		if s.GapX, s.GapY, err = parsePair(parts[4]); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.PageWidth <= 0 || s.PageHeight <= 0 || s.Columns < 1 || s.Rows < 1 ||
+		float64(s.Columns) != cols || float64(s.Rows) != rows ||
+		s.MarginTop < 0 || s.MarginLeft < 0 || s.GapX < 0 || s.GapY < 0 ||
+		s.LabelWidth() <= 0 || s.LabelHeight() <= 0 {
+		return nil, fmt.Errorf("invalid size")
+	}
+
+	return s, nil
+}
+
+func parsePair(v string) (float64, float64, error) {
+	pair := strings.SplitN(v, "x", 2)
+	if len(pair) != 2 {
+		return 0, 0, fmt.Errorf("want AxB, got %q", v)
+	}
+	a, err := strconv.ParseFloat(pair[0], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := strconv.ParseFloat(pair[1], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return a, b, nil
+}
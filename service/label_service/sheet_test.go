@@ -0,0 +1,76 @@
+package label_service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func TestParseSheet(t *testing.T) {
+	s, err := parseSheet(" a4-3x8:210x297:3x8:12.9x7.2:2.5x0 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Sheet{Name: "a4-3x8", PageWidth: 210, PageHeight: 297, Columns: 3, Rows: 8, MarginTop: 12.9, MarginLeft: 7.2, GapX: 2.5}
+	if *s != want {
+		t.Errorf("parseSheet = %+v, want %+v", *s, want)
+	}
+	// A4 不干胶 24 格为 63.5x33.9mm
+	if math.Abs(s.LabelWidth()-63.53) > 0.01 || math.Abs(s.LabelHeight()-33.90) > 0.01 || s.PerPage() != 24 {
+		t.Errorf("label = %.2fx%.2f, %d per page", s.LabelWidth(), s.LabelHeight(), s.PerPage())
+	}
+
+	s, err = parseSheet("thermal:50x30:1x1")
+	if err != nil || s.LabelWidth() != 50 || s.LabelHeight() != 30 {
+		t.Errorf("parseSheet(thermal) = %+v, %v", s, err)
+	}
+
+	for _, v := range []string{
+		"",
+		"a4",
+		"a4:210x297",
+		":210x297:3x8",
+		"a4:210x297:3x8:1x1:1x1:1x1",
+		"a4:210:3x8",
+		"a4:ax297:3x8",
+		"a4:210x297:3.5x8",
+		"a4:210x297:0x8",
+		"a4:0x297:3x8",
+		"a4:210x297:3x8:-1x0",
+		"a4:210x297:3x8:0x0:-1x0",
+		"a4:210x297:3x8:150x0",
+		"a4:210x297:3x8:0x0:110x0",
+	} {
+		if s, err := parseSheet(v); err == nil {
+			t.Errorf("parseSheet(%q) = %+v, want error", v, s)
+		}
+	}
+}
+
+func TestGetSheet(t *testing.T) {
+	saved, savedSheets := *setting.LabelSetting, sheets
+	t.Cleanup(func() { *setting.LabelSetting, sheets = saved, savedSheets })
+	setting.LabelSetting.Sheets = []string{"a4-3x8:210x297:3x8", "thermal:50x30:1x1"}
+	setting.LabelSetting.DefaultSheet = "thermal"
+	Setup()
+
+	if s := GetSheet(""); s == nil || s.Name != "thermal" {
+		t.Errorf("GetSheet(\"\") = %+v", s)
+	}
+	if s := GetSheet("a4-3x8"); s == nil || s.Columns != 3 {
+		t.Errorf("GetSheet(a4-3x8) = %+v", s)
+	}
+	if s := GetSheet("letter"); s != nil {
+		t.Errorf("GetSheet(letter) = %+v", s)
+	}
+}
+
+func TestConfSheets(t *testing.T) {
+	// conf/app.ini 中的标签纸
+	for _, v := range []string{"a4-3x8:210x297:3x8:12.9x7.2:2.5x0", "thermal-50x30:50x30:1x1", "thermal-40x30:40x30:1x1"} {
+		if _, err := parseSheet(v); err != nil {
+			t.Errorf("parseSheet(%q) = %v", v, err)
+		}
+	}
+}
@@ -1,9 +1,21 @@
 package order_service
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 )
 
+// ErrOrderExists 订单号已存在
+var ErrOrderExists = errors.New("order already exists")
+
+// addLockTTL 创建订单时按订单号加锁的过期时间
+const addLockTTL = 10 * time.Second
+
 // 公共订单字段结构体
 type BaseOrderForm struct {
 	OrderSn         string  `json:"order_sn" binding:"required,max=64"`
@@ -133,9 +145,52 @@ func toModelOrder(o *Order) *models.Order {
 	return model
 }
 
-// Add 创建订单
+// Add 创建订单，同一订单号只能写入一次，重复提交返回 ErrOrderExists
+//
+// 订单号的唯一索引保证不会重复写入，Redis 锁只让同一订单号的并发提交尽早返回；
+// Redis 不可用时记录日志后不加锁继续创建，并发的重复写入由唯一索引拒绝，不影响下单
 func (o *Order) Add() error {
-	return models.AddOrder(toModelOrder(o))
+	ctx := context.Background()
+	lock, err := gredis.Obtain(ctx, "ORDER_"+o.OrderSn, addLockTTL)
+	switch {
+	case err == gredis.ErrLockNotObtained:
+		return ErrOrderExists
+	case err != nil:
+		logging.Warn("order_service.Add obtain lock err, continue without lock:", o.OrderSn, err)
+	default:
+		defer func() {
+			if err := lock.Release(ctx); err != nil {
+				logging.Warn("order_service.Add release lock err:", o.OrderSn, err)
+			}
+		}()
+	}
+
+	exists, err := existOrderSn(o.OrderSn)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrOrderExists
+	}
+
+	if err := models.AddOrder(toModelOrder(o)); err != nil {
+		// 并发写入同一订单号时唯一索引冲突
+		if exists, _ := existOrderSn(o.OrderSn); exists {
+			return ErrOrderExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func existOrderSn(orderSn string) (bool, error) {
+	count, err := models.GetOrderTotal(map[string]interface{}{"order_sn": orderSn})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
 }
 
 // Edit 修改订单
@@ -0,0 +1,183 @@
+package poster_service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+var (
+	// ErrTemplateNotFound 模板不存在
+	ErrTemplateNotFound = errors.New("poster: template not found")
+	// ErrTemplateExists 模板名已被其他模板使用
+	ErrTemplateExists = errors.New("poster: template name already exists")
+)
+
+// DefaultName 内置模板的名称，数据库中有同名模板时使用数据库中的模板
+const DefaultName = "default"
+
+// Default 内置模板：bg.jpg 背景，标题、作者和二维码
+func Default() *poster.Template {
+	return &poster.Template{
+		Width:      550,
+		Height:     700,
+		Background: "bg.jpg",
+		Format:     poster.FormatJPEG,
+		Quality:    poster.DefaultQuality,
+		Layers: []poster.Layer{
+			{Type: poster.LayerText, Field: poster.FieldTitle, Text: "Golang Gin 系列文章", X: 80, Y: 160, Width: 440, Size: 42, MaxLines: 1, LineHeight: 1.3, Align: poster.AlignLeft},
+			{Type: poster.LayerText, Field: poster.FieldAuthor, Text: "---煎鱼", X: 320, Y: 220, Size: 36, LineHeight: 1.3, Align: poster.AlignLeft},
+			{Type: poster.LayerImage, Field: poster.FieldQrCode, X: 125, Y: 298, Fit: poster.FitCover},
+		},
+	}
+}
+
+type Template struct {
+	ID         int
+	Name       string
+	Definition *poster.Template
+	CreatedBy  string
+	ModifiedBy string
+}
+
+// View 接口返回的模板，带有解析后的定义
+type View struct {
+	*models.PosterTemplate
+	Definition *poster.Template `json:"definition"`
+}
+
+// Load 按名称获取模板定义，name 为空时使用 DefaultTemplate；
+// version 为模板的修改时间，模板修改后生成的海报文件名随之变化
+func Load(name string) (t *poster.Template, version int, err error) {
+	if name == "" {
+		name = setting.PosterSetting.DefaultTemplate
+	}
+
+	template, err := models.GetPosterTemplateByName(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if template == nil {
+		if name == DefaultName {
+			return Default(), 0, nil
+		}
+		return nil, 0, ErrTemplateNotFound
+	}
+
+	view, err := newView(template)
+	if err != nil {
+		return nil, 0, err
+	}
+	return view.Definition, template.ModifiedOn, nil
+}
+
+func (t *Template) Get() (*View, error) {
+	template, err := models.GetPosterTemplate(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	return newView(template)
+}
+
+func GetAll() ([]*View, error) {
+	templates, err := models.GetPosterTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*View, 0, len(templates))
+	for _, template := range templates {
+		view, err := newView(template)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// Add 保存前检查模板定义，返回 poster.ErrInvalidTemplate 或 ErrTemplateExists
+func (t *Template) Add() error {
+	definition, err := t.definition()
+	if err != nil {
+		return err
+	}
+
+	template := &models.PosterTemplate{
+		Name:       t.Name,
+		Definition: definition,
+		CreatedBy:  t.CreatedBy,
+	}
+	if err := models.AddPosterTemplate(template); err != nil {
+		return err
+	}
+
+	t.ID = template.ID
+	return nil
+}
+
+func (t *Template) Edit() error {
+	exists, err := models.GetPosterTemplate(t.ID)
+	if err != nil {
+		return err
+	}
+	if exists == nil {
+		return ErrTemplateNotFound
+	}
+
+	definition, err := t.definition()
+	if err != nil {
+		return err
+	}
+
+	return models.EditPosterTemplate(t.ID, map[string]interface{}{
+		"name":        t.Name,
+		"definition":  definition,
+		"modified_by": t.ModifiedBy,
+	})
+}
+
+func (t *Template) Delete() error {
+	return models.DeletePosterTemplate(t.ID)
+}
+
+// definition 检查名称和定义，返回定义的 JSON
+func (t *Template) definition() (string, error) {
+	if t.Definition == nil {
+		return "", poster.ErrInvalidTemplate
+	}
+	if err := t.Definition.Normalize(); err != nil {
+		return "", err
+	}
+
+	exists, err := models.ExistPosterTemplateByName(t.Name, t.ID)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", ErrTemplateExists
+	}
+
+	b, err := json.Marshal(t.Definition)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newView(template *models.PosterTemplate) (*View, error) {
+	var definition poster.Template
+	if err := json.Unmarshal([]byte(template.Definition), &definition); err != nil {
+		return nil, err
+	}
+
+	return &View{PosterTemplate: template, Definition: &definition}, nil
+}
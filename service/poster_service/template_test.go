@@ -0,0 +1,47 @@
+package poster_service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/poster"
+)
+
+func TestDefault(t *testing.T) {
+	// 内置模板已经是规范化后的形式
+	tpl := Default()
+	if err := tpl.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tpl, Default()) {
+		t.Errorf("Normalize changed the default template: %+v", tpl)
+	}
+}
+
+func TestNewView(t *testing.T) {
+	view, err := newView(&models.PosterTemplate{Name: "card", Definition: `{"width":300,"height":200,"format":"png","layers":[{"type":"text","field":"title","size":20}]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := view.Definition
+	if view.Name != "card" || d.Width != 300 || d.Format != poster.FormatPNG || len(d.Layers) != 1 || d.Layers[0].Field != poster.FieldTitle {
+		t.Errorf("newView = %+v", d)
+	}
+
+	if _, err := newView(&models.PosterTemplate{Definition: "{"}); err == nil {
+		t.Error("newView with invalid JSON succeeded")
+	}
+}
+
+func TestDefinitionInvalid(t *testing.T) {
+	// 定义不合法时在查询数据库之前返回
+	for _, tpl := range []*Template{
+		{Name: "a"},
+		{Name: "a", Definition: &poster.Template{Width: 0, Height: 100}},
+	} {
+		if _, err := tpl.definition(); err != poster.ErrInvalidTemplate {
+			t.Errorf("definition(%+v) = %v, want ErrInvalidTemplate", tpl.Definition, err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package product_service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/es"
+)
+
+// ProductIndex 商品索引（别名），定义见 conf/es/products.json
+const ProductIndex = "products"
+
+// 排序方式
+const (
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNewest    = "newest"
+)
+
+// 默认的价格直方图区间和分面桶数量
+const (
+	defaultPriceInterval = 500
+	facetSize            = 20
+)
+
+// Brand 商品品牌
+type Brand struct {
+	Name   string `json:"name"`
+	Origin string `json:"origin"`
+}
+
+// Attribute 商品属性，例如 颜色=黑色
+type Attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Product 商品索引文档
+type Product struct {
+	Sku        string                   `json:"sku"`
+	Title      string                   `json:"title"`
+	Category   string                   `json:"category"`
+	Price      float64                  `json:"price"`
+	Brand      Brand                    `json:"brand"`
+	Tags       []string                 `json:"tags"`
+	Comments   []map[string]interface{} `json:"comments"`
+	Attributes []Attribute              `json:"attributes"`
+	CreatedAt  time.Time                `json:"created_at"`
+}
+
+// ProductSearch 商品搜索条件，同一维度内多个值为“或”，不同维度之间为“与”
+type ProductSearch struct {
+	Keyword    string
+	Categories []string
+	Brands     []string
+	Origins    []string
+	Tags       []string
+	PriceMin   *float64
+	PriceMax   *float64
+	Attributes map[string][]string
+
+	PriceInterval float64
+	Sort          string
+	Page          int
+	PageSize      int
+}
+
+// FacetValue 分面中的一个取值
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket 价格直方图中的一个区间 [From, To)
+type PriceBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int64   `json:"count"`
+}
+
+// Facets 侧边栏过滤项
+type Facets struct {
+	Categories []*FacetValue            `json:"categories"`
+	Brands     []*FacetValue            `json:"brands"`
+	Origins    []*FacetValue            `json:"origins"`
+	Tags       []*FacetValue            `json:"tags"`
+	Attributes map[string][]*FacetValue `json:"attributes"`
+	Prices     []*PriceBucket           `json:"prices"`
+	PriceMin   *float64                 `json:"price_min,omitempty"`
+	PriceMax   *float64                 `json:"price_max,omitempty"`
+}
+
+// SearchResult 商品搜索结果
+type SearchResult struct {
+	Lists  []es.Hit[Product] `json:"lists"`
+	Total  int64             `json:"total"`
+	Facets *Facets           `json:"facets"`
+}
+
+// Search 执行分面搜索
+// 过滤条件放在 post_filter 中，每个分面的聚合只应用其他维度的过滤条件，
+// 这样勾选了某个品牌后，品牌分面里仍能看到其他品牌的数量
+func (s *ProductSearch) Search() (*SearchResult, error) {
+	query := es.Bool()
+	if s.Keyword != "" {
+		query.Must(es.MultiMatch(s.Keyword, "title^3", "title.std^2", "sku^2", "tags"))
+	} else {
+		query.Must(es.MatchAll())
+	}
+
+	filters := s.filters()
+
+	source := es.NewSearch().
+		Query(query).
+		PostFilter(combine(filters, "")).
+		Page(s.Page, s.PageSize).
+		Highlight(es.NewHighlight("title"))
+	switch s.Sort {
+	case SortPriceAsc:
+		source.Sort("price", true)
+	case SortPriceDesc:
+		source.Sort("price", false)
+	case SortNewest:
+		source.Sort("created_at", false)
+	}
+
+	interval := s.PriceInterval
+	if interval <= 0 {
+		interval = defaultPriceInterval
+	}
+
+	source.
+		Aggregation("categories", facetAgg(filters, "category", es.TermsAgg("category", facetSize))).
+		Aggregation("brands", facetAgg(filters, "brand", es.TermsAgg("brand.name", facetSize))).
+		Aggregation("origins", facetAgg(filters, "origin", es.TermsAgg("brand.origin", facetSize))).
+		Aggregation("tags", facetAgg(filters, "tags", es.TermsAgg("tags", facetSize))).
+		Aggregation("attributes", facetAgg(filters, "attributes", es.NestedAgg("attributes").
+			SubAgg("names", es.TermsAgg("attributes.name", facetSize).
+				SubAgg("values", es.TermsAgg("attributes.value", facetSize))))).
+		Aggregation("prices", es.FilterAgg(combine(filters, "price")).
+			SubAgg("facet", es.HistogramAgg("price", interval)).
+			SubAgg("min", es.MinAgg("price")).
+			SubAgg("max", es.MaxAgg("price")))
+
+	result, err := es.Search[Product](ProductIndex, source)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := decodeFacets(result.Aggregations, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchResult{
+		Lists:  result.Hits,
+		Total:  result.Total,
+		Facets: facets,
+	}, nil
+}
+
+// filters 按维度返回过滤条件，key 与 facetAgg 的 exclude 对应
+func (s *ProductSearch) filters() map[string]es.Query {
+	filters := map[string]es.Query{}
+	if len(s.Categories) > 0 {
+		filters["category"] = es.Terms("category", toInterfaces(s.Categories)...)
+	}
+	if len(s.Brands) > 0 {
+		filters["brand"] = es.Terms("brand.name", toInterfaces(s.Brands)...)
+	}
+	if len(s.Origins) > 0 {
+		filters["origin"] = es.Terms("brand.origin", toInterfaces(s.Origins)...)
+	}
+	if len(s.Tags) > 0 {
+		filters["tags"] = es.Terms("tags", toInterfaces(s.Tags)...)
+	}
+	if s.PriceMin != nil || s.PriceMax != nil {
+		r := es.Range("price")
+		if s.PriceMin != nil {
+			r.Gte(*s.PriceMin)
+		}
+		if s.PriceMax != nil {
+			r.Lte(*s.PriceMax)
+		}
+		filters["price"] = r
+	}
+	if len(s.Attributes) > 0 {
+		// 每个属性名各自一个 nested 条件，同一属性的多个取值为“或”
+		attrs := es.Bool()
+		for name, values := range s.Attributes {
+			attrs.Filter(es.Nested("attributes", es.Bool().Filter(
+				es.Term("attributes.name", name),
+				es.Terms("attributes.value", toInterfaces(values)...),
+			)))
+		}
+		filters["attributes"] = attrs
+	}
+
+	return filters
+}
+
+// combine 合并除 exclude 以外的全部过滤条件
+func combine(filters map[string]es.Query, exclude string) es.Query {
+	query := es.Bool()
+	for key, filter := range filters {
+		if key != exclude {
+			query.Filter(filter)
+		}
+	}
+	if query.IsEmpty() {
+		return es.MatchAll()
+	}
+
+	return query
+}
+
+// facetAgg 用其他维度的过滤条件包裹分面聚合
+func facetAgg(filters map[string]es.Query, exclude string, agg es.Aggregation) es.Aggregation {
+	return es.FilterAgg(combine(filters, exclude)).SubAgg("facet", agg)
+}
+
+func decodeFacets(aggs es.Aggregations, interval float64) (*Facets, error) {
+	facets := &Facets{Attributes: map[string][]*FacetValue{}}
+
+	terms := []struct {
+		name   string
+		target *[]*FacetValue
+	}{
+		{"categories", &facets.Categories},
+		{"brands", &facets.Brands},
+		{"origins", &facets.Origins},
+		{"tags", &facets.Tags},
+	}
+	for _, t := range terms {
+		facet, err := facetBuckets(aggs, t.name)
+		if err != nil {
+			return nil, err
+		}
+		*t.target = facetValues(facet)
+	}
+
+	wrapper, err := aggs.Single("attributes")
+	if err != nil {
+		return nil, err
+	}
+	nested, err := wrapper.Aggregations.Single("facet")
+	if err != nil {
+		return nil, err
+	}
+	names, err := nested.Aggregations.Buckets("names")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		values, err := name.Aggregations.Buckets("values")
+		if err != nil {
+			return nil, err
+		}
+		facets.Attributes[keyString(name.Key)] = facetValues(values)
+	}
+
+	wrapper, err = aggs.Single("prices")
+	if err != nil {
+		return nil, err
+	}
+	prices, err := wrapper.Aggregations.Buckets("facet")
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range prices {
+		from, _ := b.Key.(float64)
+		facets.Prices = append(facets.Prices, &PriceBucket{From: from, To: from + interval, Count: b.DocCount})
+	}
+	if v, ok := wrapper.Aggregations.Value("min"); ok {
+		facets.PriceMin = &v
+	}
+	if v, ok := wrapper.Aggregations.Value("max"); ok {
+		facets.PriceMax = &v
+	}
+
+	return facets, nil
+}
+
+func facetBuckets(aggs es.Aggregations, name string) ([]es.Bucket, error) {
+	wrapper, err := aggs.Single(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper.Aggregations.Buckets("facet")
+}
+
+func facetValues(buckets []es.Bucket) []*FacetValue {
+	values := make([]*FacetValue, 0, len(buckets))
+	for _, b := range buckets {
+		values = append(values, &FacetValue{Value: keyString(b.Key), Count: b.DocCount})
+	}
+
+	return values
+}
+
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(key)
+
+	return string(data)
+}
+
+func toInterfaces(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+
+	return list
+}
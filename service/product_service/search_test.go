@@ -0,0 +1,156 @@
+package product_service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/es"
+)
+
+func jsonString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// filterFields 返回 bool.filter 中各条件的 JSON，已排序
+func filterFields(q es.Query) []string {
+	var fields []string
+	filter, _ := q.Source()["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	for _, f := range filter {
+		fields = append(fields, jsonString(f))
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func TestFilters(t *testing.T) {
+	min, max := 100.0, 500.0
+	s := &ProductSearch{
+		Brands:     []string{"Apple", "Huawei"},
+		Tags:       []string{"5G"},
+		PriceMin:   &min,
+		PriceMax:   &max,
+		Attributes: map[string][]string{"颜色": {"黑色", "白色"}},
+	}
+	filters := s.filters()
+
+	want := map[string]string{
+		"brand": `{"terms":{"brand.name":["Apple","Huawei"]}}`,
+		"tags":  `{"terms":{"tags":["5G"]}}`,
+		"price": `{"range":{"price":{"gte":100,"lte":500}}}`,
+		"attributes": `{"bool":{"filter":[{"nested":{"path":"attributes","query":{"bool":{"filter":[` +
+			`{"term":{"attributes.name":"颜色"}},{"terms":{"attributes.value":["黑色","白色"]}}]}}}}]}}`,
+	}
+	if len(filters) != len(want) {
+		t.Errorf("filters = %v", filters)
+	}
+	for key, w := range want {
+		if got := jsonString(filters[key].Source()); got != w {
+			t.Errorf("filters[%s] = %s, want %s", key, got, w)
+		}
+	}
+
+	// 每个分面只应用其他维度的过滤条件
+	got := filterFields(combine(filters, "brand"))
+	if len(got) != 3 || strings.Contains(strings.Join(got, ""), "brand.name") {
+		t.Errorf("combine(exclude brand) = %v", got)
+	}
+	if len(filterFields(combine(filters, ""))) != 4 {
+		t.Errorf("combine() = %v", filterFields(combine(filters, "")))
+	}
+	if got := jsonString(combine(map[string]es.Query{"brand": filters["brand"]}, "brand").Source()); got != `{"match_all":{}}` {
+		t.Errorf("combine() without filters = %s", got)
+	}
+}
+
+const facetResponse = `{
+	"hits": {"total": {"value": 2}, "hits": [
+		{"_id": "1", "_score": 1, "_source": {"sku": "A1", "title": "iPhone", "price": 499, "brand": {"name": "Apple"}}}
+	]},
+	"aggregations": {
+		"categories": {"doc_count": 2, "facet": {"buckets": [{"key": "phone", "doc_count": 2}]}},
+		"brands": {"doc_count": 5, "facet": {"buckets": [{"key": "Apple", "doc_count": 2}, {"key": "Huawei", "doc_count": 3}]}},
+		"origins": {"doc_count": 2, "facet": {"buckets": []}},
+		"tags": {"doc_count": 2, "facet": {"buckets": [{"key": 5, "doc_count": 1}]}},
+		"attributes": {"doc_count": 2, "facet": {"doc_count": 4, "names": {"buckets": [
+			{"key": "颜色", "doc_count": 4, "values": {"buckets": [{"key": "黑色", "doc_count": 3}, {"key": "白色", "doc_count": 1}]}}
+		]}}},
+		"prices": {"doc_count": 2, "facet": {"buckets": [{"key": 0, "doc_count": 1}, {"key": 500, "doc_count": 1}]},
+			"min": {"value": 299}, "max": {"value": 899}}
+	}
+}`
+
+func TestSearch(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		if r.URL.Path != "/"+ProductIndex+"/_search" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, facetResponse)
+	}))
+	defer srv.Close()
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := es.ESClient
+	es.ESClient = client
+	defer func() { es.ESClient = saved }()
+
+	s := &ProductSearch{Keyword: "iphone", Brands: []string{"Apple"}, Sort: SortPriceAsc, Page: 2, PageSize: 10}
+	result, err := s.Search()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 过滤条件只放在 post_filter 中，不影响分面的统计
+	if got := jsonString(body["post_filter"]); got != `{"bool":{"filter":[{"terms":{"brand.name":["Apple"]}}]}}` {
+		t.Errorf("post_filter = %s", got)
+	}
+	if got := jsonString(body["sort"]); got != `[{"price":{"order":"asc"}}]` {
+		t.Errorf("sort = %s", got)
+	}
+	if body["from"] != float64(10) {
+		t.Errorf("from = %v", body["from"])
+	}
+	aggs := body["aggs"].(map[string]interface{})
+	if got := jsonString(aggs["brands"].(map[string]interface{})["filter"]); got != `{"match_all":{}}` {
+		t.Errorf("brands facet filter = %s, want match_all", got)
+	}
+	if got := jsonString(aggs["categories"].(map[string]interface{})["filter"]); !strings.Contains(got, "brand.name") {
+		t.Errorf("categories facet filter = %s, want the brand filter", got)
+	}
+
+	if result.Total != 2 || len(result.Lists) != 1 || result.Lists[0].Source.Sku != "A1" {
+		t.Errorf("result = %+v", result)
+	}
+	f := result.Facets
+	if jsonString(f.Brands) != `[{"value":"Apple","count":2},{"value":"Huawei","count":3}]` {
+		t.Errorf("brands = %s", jsonString(f.Brands))
+	}
+	if jsonString(f.Tags) != `[{"value":"5","count":1}]` || jsonString(f.Origins) != `[]` {
+		t.Errorf("tags = %s, origins = %s", jsonString(f.Tags), jsonString(f.Origins))
+	}
+	if jsonString(f.Attributes) != `{"颜色":[{"value":"黑色","count":3},{"value":"白色","count":1}]}` {
+		t.Errorf("attributes = %s", jsonString(f.Attributes))
+	}
+	if jsonString(f.Prices) != `[{"from":0,"to":500,"count":1},{"from":500,"to":1000,"count":1}]` {
+		t.Errorf("prices = %s", jsonString(f.Prices))
+	}
+	if f.PriceMin == nil || *f.PriceMin != 299 || f.PriceMax == nil || *f.PriceMax != 899 {
+		t.Errorf("price range = %v - %v", f.PriceMin, f.PriceMax)
+	}
+}
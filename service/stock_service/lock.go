@@ -0,0 +1,64 @@
+package stock_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+)
+
+const (
+	// lockTTL 锁的过期时间，应大于单个事务的最长执行时间
+	lockTTL = 10 * time.Second
+	// lockWait 等待其他请求释放锁的最长时间
+	lockWait = 3 * time.Second
+)
+
+// withLocks 持有全部锁后执行 fn，按名称排序加锁避免互相等待造成死锁；
+// fences 为每个锁名称对应的栅栏值，供 fn 写入时拒绝锁过期后的旧持有者
+func withLocks(names []string, fn func(fences map[string]int64) error) error {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockWait)
+	defer cancel()
+
+	var locks []*gredis.Lock
+	defer func() {
+		for _, lock := range locks {
+			if err := lock.Release(context.Background()); err != nil {
+				logging.Warn("stock_service release lock err:", err)
+			}
+		}
+	}()
+
+	fences := make(map[string]int64, len(sorted))
+	for _, name := range sorted {
+		if _, ok := fences[name]; ok {
+			continue
+		}
+
+		lock, err := gredis.ObtainWait(ctx, name, lockTTL, 50*time.Millisecond)
+		if err == gredis.ErrLockNotObtained {
+			return fmt.Errorf("资源正在被其他请求处理，请稍后重试")
+		}
+		if err != nil {
+			return fmt.Errorf("获取锁失败: %w", err)
+		}
+		locks = append(locks, lock)
+		fences[name] = lock.Fence()
+	}
+
+	return fn(fences)
+}
+
+func detailLockName(id int) string {
+	return fmt.Sprintf("STOCK_DETAIL_%d", id)
+}
+
+func orderLockName(orderSN string) string {
+	return "STOCK_ORDER_" + orderSN
+}
@@ -1,6 +1,7 @@
 package stock_service
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,12 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
+// ErrOrderExists 订单号已经扣减过库存
+var ErrOrderExists = errors.New("订单已创建，不能重复提交")
+
+// ErrStaleLock 锁已过期并被其他请求获得，本次写入被拒绝
+var ErrStaleLock = errors.New("锁已过期，请重试")
+
 // ===== 事务相关类型定义 =====
 
 type BatchProductDetail struct {
@@ -98,7 +105,16 @@ func BatchCreateProductWithDetails(productList []BatchProduct) error {
 }
 
 // TransferStock 库存转移（演示事务的 ACID 特性）
+// 先锁住两条库存明细，避免并发转移时“读-改-写”互相覆盖；写入时带上锁的栅栏值，
+// 锁过期后仍在执行的旧请求不会覆盖新持有者的写入
 func TransferStock(fromDetailID, toDetailID, quantity int) error {
+	fromLock, toLock := detailLockName(fromDetailID), detailLockName(toDetailID)
+	return withLocks([]string{fromLock, toLock}, func(fences map[string]int64) error {
+		return transferStock(fromDetailID, toDetailID, quantity, fences[fromLock], fences[toLock])
+	})
+}
+
+func transferStock(fromDetailID, toDetailID, quantity int, fromFence, toFence int64) error {
 	// 开始事务
 	tx := models.Db.Begin()
 	defer func() {
@@ -132,15 +148,15 @@ func TransferStock(fromDetailID, toDetailID, quantity int) error {
 	}
 
 	// 减少源库存
-	if err := tx.Model(&fromDetail).Update("num", fromDetail.Num-float64(quantity)).Error; err != nil {
+	if err := updateDetailNum(tx, fromDetail.ID, fromDetail.Num-float64(quantity), fromFence); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("减少源库存失败: %v", err)
+		return fmt.Errorf("减少源库存失败: %w", err)
 	}
 
 	// 增加目标库存
-	if err := tx.Model(&toDetail).Update("num", toDetail.Num+float64(quantity)).Error; err != nil {
+	if err := updateDetailNum(tx, toDetail.ID, toDetail.Num+float64(quantity), toFence); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("增加目标库存失败: %v", err)
+		return fmt.Errorf("增加目标库存失败: %w", err)
 	}
 
 	// 提交事务
@@ -151,6 +167,21 @@ func TransferStock(fromDetailID, toDetailID, quantity int) error {
 	return nil
 }
 
+// updateDetailNum 更新明细数量并记录栅栏值，明细已被栅栏值更大的持有者写入时返回 ErrStaleLock
+func updateDetailNum(tx *gorm.DB, id int, num float64, fence int64) error {
+	result := tx.Model(&stock.StockProductDetail{}).
+		Where("id = ? AND lock_fence <= ?", id, fence).
+		Updates(map[string]interface{}{"num": num, "lock_fence": fence})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleLock
+	}
+
+	return nil
+}
+
 // ===== Hooks 相关 =====
 
 // CreateProductWithHooks 创建产品（演示 GORM Hooks）
@@ -260,7 +291,15 @@ func GetProductsWithJoin(minQuantity int) ([]map[string]interface{}, error) {
 // ===== 事务嵌套 =====
 
 // CreateOrderWithNestedTransaction 创建订单（演示嵌套事务）
+// 订单号与扣减库存在同一个事务中写入，已处理过的订单号返回 ErrOrderExists，客户端重试不会重复扣减库存；
+// 锁只让同一订单号的并发请求排队，锁过期后的重试由事务内的检查和唯一索引拒绝
 func CreateOrderWithNestedTransaction(orderSN string, orderItems []OrderItem) error {
+	return withLocks([]string{orderLockName(orderSN)}, func(map[string]int64) error {
+		return createOrderWithNestedTransaction(orderSN, orderItems)
+	})
+}
+
+func createOrderWithNestedTransaction(orderSN string, orderItems []OrderItem) error {
 	// 外层事务：处理订单创建
 	tx := models.Db.Begin()
 	defer func() {
@@ -273,7 +312,21 @@ func CreateOrderWithNestedTransaction(orderSN string, orderItems []OrderItem) er
 		return err
 	}
 
-	// 创建订单（这里简化处理，实际应该有专门的订单表）
+	exists, err := stock.ExistStockOrderInTx(tx, orderSN)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("查询订单失败: %v", err)
+	}
+	if exists {
+		tx.Rollback()
+		return ErrOrderExists
+	}
+	if err := tx.Create(&stock.StockOrder{OrderSN: orderSN}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("创建订单失败: %v", err)
+	}
+
+	// 创建订单（这里简化处理，订单表只记录订单号）
 	orderData := map[string]interface{}{
 		"order_sn":   orderSN,
 		"status":     1,
@@ -0,0 +1,108 @@
+package tag_service
+
+import (
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// defaultSlug 名称中没有可用字符时使用
+const defaultSlug = "tag"
+
+// backfillBatch 每批补全 slug 的标签数
+const backfillBatch = 100
+
+// ValidSlug 指定的 slug 规范化后非空且不全为数字
+func ValidSlug(s string) bool {
+	s = slug.Make(s)
+	return s != "" && !slug.IsNumeric(s)
+}
+
+// ExistBySlug 指定的 slug 是否已被其他标签使用，没有指定 slug 时由名称生成，不会冲突
+func (t *Tag) ExistBySlug() (bool, error) {
+	if t.Slug == "" {
+		return false, nil
+	}
+
+	return models.ExistTagBySlug(slug.Make(t.Slug), t.ID)
+}
+
+// GetIDBySlug 按 slug 查找标签，不存在时返回 0
+func GetIDBySlug(s string) (int, error) {
+	tag, err := models.GetTagBySlug(slug.Make(s))
+	if err != nil || tag == nil {
+		return 0, err
+	}
+
+	return tag.ID, nil
+}
+
+// newSlug 指定了 want 时规范化后使用，否则由名称生成不重复的 slug
+func newSlug(id int, want, name string) (string, error) {
+	if want != "" {
+		return slug.Make(want), nil
+	}
+
+	return uniqueSlug(id, slug.Make(name))
+}
+
+// uniqueSlug 在 base 后依次加上 -2、-3 等后缀，直到没有被其他标签使用
+func uniqueSlug(id int, base string) (string, error) {
+	if base == "" {
+		base = defaultSlug
+	}
+
+	for i := 1; ; i++ {
+		s := base
+		if i > 1 {
+			s = base + "-" + strconv.Itoa(i)
+		}
+		if slug.IsNumeric(s) {
+			continue
+		}
+
+		exists, err := models.ExistTagBySlug(s, id)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return s, nil
+		}
+	}
+}
+
+// BackfillSlugs 为功能上线前没有 slug 的标签生成 slug
+func BackfillSlugs() error {
+	for {
+		tags, err := models.GetTagsWithoutSlug(backfillBatch)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		for _, tag := range tags {
+			s, err := uniqueSlug(tag.ID, slug.Make(tag.Name))
+			if err != nil {
+				return err
+			}
+			if err := models.SetTagSlug(tag.ID, s); err != nil {
+				return err
+			}
+		}
+		cache_service.BumpNamespace(cache_service.ArticleNamespace, cache_service.TagListNamespace, cache_service.ArticleListNamespace)
+	}
+}
+
+// StartSlugBackfill 在后台补全标签的 slug
+func StartSlugBackfill() {
+	go func() {
+		if err := BackfillSlugs(); err != nil {
+			logging.Warn("tag_service.BackfillSlugs err:", err)
+		}
+	}()
+}
@@ -0,0 +1,22 @@
+package tag_service
+
+import "testing"
+
+func TestValidSlug(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"golang", true},
+		{"Go 语言", true},
+		{"C++", true},
+		{"!!!", false},
+		{"2024", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidSlug(tt.s); got != tt.want {
+			t.Errorf("ValidSlug(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -1,25 +1,34 @@
 package tag_service
 
 import (
-	"encoding/json"
+	"bytes"
+	"fmt"
 	"io"
 	"strconv"
 	"time"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/tealeg/xlsx"
+	"github.com/unknwon/com"
 
 	"github.com/EDDYCJY/go-gin-example/models"
+	pkgcache "github.com/EDDYCJY/go-gin-example/pkg/cache"
 	"github.com/EDDYCJY/go-gin-example/pkg/export"
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
-	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
-	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/slug"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/service/cache_service"
 )
 
+// 导出文件中的工作表
+const (
+	tagSheet      = "标签信息"
+	relationSheet = "文章标签"
+)
+
 type Tag struct {
 	ID         int
 	Name       string
+	Slug       string // 为空时由名称生成，修改时名称不变则保持原 slug
 	CreatedBy  string
 	ModifiedBy string
 	State      int
@@ -36,23 +45,77 @@ func (t *Tag) ExistByID() (bool, error) {
 	return models.ExistTagByID(t.ID)
 }
 
+// ExistByIDs 标签是否全部存在
+func ExistByIDs(ids []int) (bool, error) {
+	return models.ExistTagsByIDs(ids)
+}
+
 func (t *Tag) Add() error {
-	return models.AddTag(t.Name, t.State, t.CreatedBy)
+	s, err := newSlug(0, t.Slug, t.Name)
+	if err != nil {
+		return err
+	}
+	t.Slug = s
+
+	if err := models.AddTag(t.Name, t.Slug, t.State, t.CreatedBy); err != nil {
+		return err
+	}
+
+	cache_service.BumpNamespace(cache_service.TagListNamespace)
+	return nil
 }
 
 func (t *Tag) Edit() error {
+	current, err := models.GetTag(t.ID)
+	if err != nil {
+		return err
+	}
+
 	data := make(map[string]interface{})
 	data["modified_by"] = t.ModifiedBy
 	data["name"] = t.Name
+	if t.Slug != "" || current == nil || current.Slug == "" || current.Name != t.Name {
+		if t.Slug, err = newSlug(t.ID, t.Slug, t.Name); err != nil {
+			return err
+		}
+		data["slug"] = t.Slug
+	}
 	if t.State >= 0 {
 		data["state"] = t.State
 	}
 
-	return models.EditTag(t.ID, data)
+	if err := models.EditTag(t.ID, data); err != nil {
+		return err
+	}
+
+	// 文章缓存中带有标签信息
+	cache_service.BumpNamespace(cache_service.ArticleNamespace, cache_service.TagListNamespace, cache_service.ArticleListNamespace)
+	return nil
 }
 
 func (t *Tag) Delete() error {
-	return models.DeleteTag(t.ID)
+	if err := models.DeleteTag(t.ID); err != nil {
+		return err
+	}
+
+	cache_service.BumpNamespace(cache_service.ArticleNamespace, cache_service.TagListNamespace, cache_service.ArticleListNamespace)
+	return nil
+}
+
+// Get 获取单个标签及其文章数，标签不存在时返回 nil
+func (t *Tag) Get() (*models.Tag, error) {
+	tag, err := models.GetTag(t.ID)
+	if err != nil || tag == nil {
+		return nil, err
+	}
+
+	counts, err := models.GetTagArticleCounts([]int{tag.ID})
+	if err != nil {
+		return nil, err
+	}
+	tag.ArticleCount = counts[tag.ID]
+
+	return tag, nil
 }
 
 func (t *Tag) Count() (int, error) {
@@ -60,33 +123,33 @@ func (t *Tag) Count() (int, error) {
 }
 
 func (t *Tag) GetAll() ([]models.Tag, error) {
-	var (
-		tags, cacheTags []models.Tag
-	)
-
 	cache := cache_service.Tag{
 		State: t.State,
 
 		PageNum:  t.PageNum,
 		PageSize: t.PageSize,
 	}
-	key := cache.GetTagsKey()
-	if gredis.Exists(key) {
-		data, err := gredis.Get(key)
-		if err != nil {
-			logging.Info(err)
-		} else {
-			json.Unmarshal(data, &cacheTags)
-			return cacheTags, nil
-		}
+
+	tags, err := pkgcache.Get(pkgcache.Default, cache.GetTagsKey(), func() ([]models.Tag, error) {
+		return models.GetTags(t.PageNum, t.PageSize, t.getMaps())
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	tags, err := models.GetTags(t.PageNum, t.PageSize, t.getMaps())
+	// 文章数随文章增删变化，不随标签列表缓存
+	ids := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	counts, err := models.GetTagArticleCounts(ids)
 	if err != nil {
 		return nil, err
 	}
+	for i := range tags {
+		tags[i].ArticleCount = counts[tags[i].ID]
+	}
 
-	gredis.Set(key, tags, 3600)
 	return tags, nil
 }
 
@@ -97,12 +160,12 @@ func (t *Tag) Export() (string, error) {
 	}
 
 	xlsFile := xlsx.NewFile()
-	sheet, err := xlsFile.AddSheet("标签信息")
+	sheet, err := xlsFile.AddSheet(tagSheet)
 	if err != nil {
 		return "", err
 	}
 
-	titles := []string{"ID", "名称", "创建人", "创建时间", "修改人", "修改时间"}
+	titles := []string{"ID", "名称", "创建人", "创建时间", "修改人", "修改时间", "文章数"}
 	row := sheet.AddRow()
 
 	var cell *xlsx.Cell
@@ -119,6 +182,7 @@ func (t *Tag) Export() (string, error) {
 			strconv.Itoa(v.CreatedOn),
 			v.ModifiedBy,
 			strconv.Itoa(v.ModifiedOn),
+			strconv.Itoa(v.ArticleCount),
 		}
 
 		row = sheet.AddRow()
@@ -128,16 +192,19 @@ func (t *Tag) Export() (string, error) {
 		}
 	}
 
+	if err := exportRelations(xlsFile, tags); err != nil {
+		return "", err
+	}
+
 	time := strconv.Itoa(int(time.Now().Unix()))
 	filename := "tags-" + time + export.EXT
 
-	dirFullPath := export.GetExcelFullPath()
-	err = file.IsNotExistMkDir(dirFullPath)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := xlsFile.Write(&buf); err != nil {
 		return "", err
 	}
 
-	err = xlsFile.Save(dirFullPath + filename)
+	err = storage.Default.Put(export.GetExcelKey(filename), &buf, export.CONTENT_TYPE)
 	if err != nil {
 		return "", err
 	}
@@ -145,21 +212,107 @@ func (t *Tag) Export() (string, error) {
 	return filename, nil
 }
 
+// Import 导入标签及文章标签关联；已存在的同名标签直接使用，关联按标签名称对应到本库的标签
 func (t *Tag) Import(r io.Reader) error {
 	xlsx, err := excelize.OpenReader(r)
 	if err != nil {
 		return err
 	}
 
-	rows := xlsx.GetRows("标签信息")
-	for irow, row := range rows {
-		if irow > 0 {
-			var data []string
-			for _, cell := range row {
-				data = append(data, cell)
-			}
+	tagIDs := make(map[string]int)
+	for irow, row := range xlsx.GetRows(tagSheet) {
+		if irow == 0 || len(row) < 3 || row[1] == "" {
+			continue
+		}
+
+		id, err := importTag(row[1], row[2])
+		if err != nil {
+			return err
+		}
+		tagIDs[row[1]] = id
+	}
+	cache_service.BumpNamespace(cache_service.TagListNamespace)
+
+	imported := 0
+	for irow, row := range xlsx.GetRows(relationSheet) {
+		if irow == 0 || len(row) < 4 {
+			continue
+		}
+
+		articleID := com.StrTo(row[0]).MustInt()
+		tagID, ok := tagIDs[row[3]]
+		if articleID <= 0 || !ok {
+			continue
+		}
+		exists, err := models.ExistArticleByID(articleID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		if err := models.AddArticleTag(articleID, tagID); err != nil {
+			return err
+		}
+		imported++
+	}
+
+	if imported > 0 {
+		cache_service.BumpNamespace(cache_service.ArticleNamespace, cache_service.ArticleListNamespace)
+	}
+	return nil
+}
+
+// importTag 返回同名标签的 ID，不存在时新建
+func importTag(name, createdBy string) (int, error) {
+	tag, err := models.GetTagByName(name)
+	if err != nil {
+		return 0, err
+	}
+	if tag == nil {
+		s, err := uniqueSlug(0, slug.Make(name))
+		if err != nil {
+			return 0, err
+		}
+		if err := models.AddTag(name, s, 1, createdBy); err != nil {
+			return 0, err
+		}
+		if tag, err = models.GetTagByName(name); err != nil {
+			return 0, err
+		}
+	}
+	if tag == nil {
+		return 0, fmt.Errorf("import tag %q: not found after insert", name)
+	}
+
+	return tag.ID, nil
+}
+
+// exportRelations 导出标签下的文章
+func exportRelations(xlsFile *xlsx.File, tags []models.Tag) error {
+	sheet, err := xlsFile.AddSheet(relationSheet)
+	if err != nil {
+		return err
+	}
+
+	row := sheet.AddRow()
+	for _, title := range []string{"文章ID", "文章标题", "标签ID", "标签名称"} {
+		row.AddCell().Value = title
+	}
 
-			models.AddTag(data[1], 1, data[2])
+	ids := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	relations, err := models.GetArticleTagRelations(ids)
+	if err != nil {
+		return err
+	}
+	for _, r := range relations {
+		row = sheet.AddRow()
+		for _, value := range []string{strconv.Itoa(r.ArticleID), r.ArticleTitle, strconv.Itoa(r.TagID), r.TagName} {
+			row.AddCell().Value = value
 		}
 	}
 